@@ -37,11 +37,16 @@ func main() {
 		fmt.Fprintf(os.Stderr, "  3. Generate a device registration token\n")
 		fmt.Fprintf(os.Stderr, "  4. Create a B-Deploy setup record\n")
 		fmt.Fprintf(os.Stderr, "  5. Output setup-id for use with player association\n\n")
+		fmt.Fprintf(os.Stderr, "Password fields may hold a secret reference instead of the secret, so the\n")
+		fmt.Fprintf(os.Stderr, "config file can be kept in git: \"passphrase\": \"env:WIFI_PASSPHRASE\", or file:,\n")
+		fmt.Fprintf(os.Stderr, "age:, sops: and (with BS_SECRET_EXEC=1) exec:. They are resolved when the record is sent.\n\n")
 		fmt.Fprintf(os.Stderr, "Options:\n")
 		flag.PrintDefaults()
 		fmt.Fprintf(os.Stderr, "\nEnvironment Variables:\n")
 		fmt.Fprintf(os.Stderr, "  BS_CLIENT_ID        BSN.cloud API client ID (required)\n")
-		fmt.Fprintf(os.Stderr, "  BS_SECRET          BSN.cloud API client secret (required)\n\n")
+		fmt.Fprintf(os.Stderr, "  BS_SECRET          BSN.cloud API client secret (required)\n")
+		fmt.Fprintf(os.Stderr, "  BS_SECRET_EXEC     Set to 1 to allow exec: secret references\n")
+		fmt.Fprintf(os.Stderr, "  BS_AGE_IDENTITY    age identity file for age: secret references\n\n")
 		fmt.Fprintf(os.Stderr, "Examples:\n")
 		fmt.Fprintf(os.Stderr, "  Create setup using default config:\n")
 		fmt.Fprintf(os.Stderr, "    %s config.json\n", os.Args[0])
@@ -99,7 +104,8 @@ func main() {
 	}
 
 	// Create client
-	var opts []gopurple.Option
+	// Setup files may hold secret references such as env:WIFI_PASSPHRASE
+	opts := []gopurple.Option{gopurple.WithSecretReferences(true)}
 	if timeout > 0 {
 		opts = append(opts, gopurple.WithTimeout(time.Duration(timeout)*time.Second))
 	}
@@ -302,6 +308,10 @@ func loadConfig(filename string) (*SetupConfig, error) {
 	if err := json.Unmarshal(data, &config); err != nil {
 		return nil, fmt.Errorf("failed to parse JSON: %w", err)
 	}
+	// Decode the record again so explicit false and 0 values are sent
+	if err := gopurple.UnmarshalSetupRecord(data, &config.BDeploySetupRecord); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
 
 	return &config, nil
 }
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/brightdevelopers/gopurple"
+)
+
+// listFlag collects a flag that may be given more than once.
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+func main() {
+	var (
+		helpFlag     = flag.Bool("help", false, "Display usage information")
+		timeoutFlag  = flag.Int("timeout", 30, "Request timeout in seconds")
+		templateFlag = flag.String("template", "", "Base setup record template (.json or .yaml)")
+		sitesFlag    = flag.String("sites", "", "Sites CSV file: one setup record per row")
+		dryRunFlag   = flag.Bool("dry-run", false, "Show what would be created or updated without writing")
+		jsonFlag     = flag.Bool("json", false, "Output results as JSON")
+		overlays     listFlag
+		networkFlag  *string
+		confirmFlag  *bool
+	)
+	flag.Var(&overlays, "overlay", "Overlay applied to every record (.json or .yaml, repeatable)")
+
+	// Set up network flags to point to the same variable
+	networkFlag = flag.String("network", "", "Network name to use (overrides BS_NETWORK)")
+	flag.StringVar(networkFlag, "n", "", "Network name to use (overrides BS_NETWORK) [alias for --network]")
+
+	// Set up confirm flags to point to the same variable
+	confirmFlag = flag.Bool("y", false, "Skip confirmation prompt")
+	flag.BoolVar(confirmFlag, "force", false, "Skip confirmation prompt [alias for -y]")
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s --template <file> --sites <sites.csv> [options]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Render a B-Deploy setup record for every site in a CSV file and create it, or\n")
+		fmt.Fprintf(os.Stderr, "update the existing record with the same package name. See bdeploy-render for the\n")
+		fmt.Fprintf(os.Stderr, "template and sites file format.\n\n")
+		fmt.Fprintf(os.Stderr, "Every site is rendered and validated first; if any site fails, nothing is written.\n")
+		fmt.Fprintf(os.Stderr, "The plan is shown and confirmed before any record is created or updated.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		flag.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nEnvironment Variables:\n")
+		fmt.Fprintf(os.Stderr, "  BS_CLIENT_ID        BSN.cloud API client ID (required)\n")
+		fmt.Fprintf(os.Stderr, "  BS_SECRET          BSN.cloud API client secret (required)\n")
+		fmt.Fprintf(os.Stderr, "  BS_NETWORK         BSN.cloud network name (optional)\n\n")
+		fmt.Fprintf(os.Stderr, "Examples:\n")
+		fmt.Fprintf(os.Stderr, "  Preview the rollout:\n")
+		fmt.Fprintf(os.Stderr, "    %s --template store-template.yaml --sites sites.csv --dry-run\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  Apply every store without prompting:\n")
+		fmt.Fprintf(os.Stderr, "    %s --template store-template.yaml --sites sites.csv --network Retail -y\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  Add an overlay to every store:\n")
+		fmt.Fprintf(os.Stderr, "    %s --template store-template.yaml --overlay proxy.yaml --sites sites.csv\n", os.Args[0])
+	}
+
+	flag.Parse()
+
+	if *helpFlag {
+		flag.Usage()
+		return
+	}
+
+	if *templateFlag == "" || *sitesFlag == "" {
+		fmt.Fprintf(os.Stderr, "Error: --template and --sites are required\n\n")
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	template, err := gopurple.LoadSetupTemplate(*templateFlag, overlays...)
+	if err != nil {
+		log.Fatalf("❌ Failed to load template: %v", err)
+	}
+	sites, err := gopurple.LoadSetupSites(*sitesFlag)
+	if err != nil {
+		log.Fatalf("❌ Failed to load sites: %v", err)
+	}
+
+	// Setup files may hold secret references such as env:WIFI_PASSPHRASE
+	opts := []gopurple.Option{gopurple.WithSecretReferences(true)}
+	if *timeoutFlag > 0 {
+		opts = append(opts, gopurple.WithTimeout(time.Duration(*timeoutFlag)*time.Second))
+	}
+	if *networkFlag != "" {
+		opts = append(opts, gopurple.WithNetwork(*networkFlag))
+	}
+
+	client, err := gopurple.New(opts...)
+	if err != nil {
+		if gopurple.IsConfigurationError(err) {
+			log.Fatalf("❌ Configuration error: %v", err)
+		}
+		log.Fatalf("❌ Failed to create client: %v", err)
+	}
+
+	ctx := context.Background()
+
+	if !*jsonFlag {
+		fmt.Fprintln(os.Stderr, "🔐 Authenticating with BSN.cloud...")
+	}
+	if err := client.Authenticate(ctx); err != nil {
+		log.Fatalf("❌ Authentication failed: %v", err)
+	}
+
+	if err := handleNetworkSelection(ctx, client, *networkFlag, *jsonFlag); err != nil {
+		log.Fatalf("❌ Network selection failed: %v", err)
+	}
+
+	current, err := client.GetCurrentNetwork(ctx)
+	if err != nil {
+		log.Fatalf("❌ Failed to get current network: %v", err)
+	}
+	if err := client.BDeploy.SetNetworkContext(ctx, current.Name); err != nil {
+		log.Fatalf("❌ Failed to set network context: %v", err)
+	}
+
+	// Plan first: render, validate and match every site against existing records
+	results, err := gopurple.ApplySetupSites(ctx, client.BDeploy, template, sites, gopurple.ApplySitesOptions{DryRun: true})
+	if err != nil {
+		printResults(results, *jsonFlag)
+		log.Fatalf("❌ %v", err)
+	}
+
+	if *dryRunFlag {
+		printResults(results, *jsonFlag)
+		return
+	}
+
+	if !*confirmFlag {
+		printResults(results, false)
+		creates, updates := countActions(results)
+		fmt.Printf("\nThis will create %d and update %d setup record(s) in network %s.\n", creates, updates, current.Name)
+		fmt.Print("Proceed? (yes/no): ")
+
+		scanner := bufio.NewScanner(os.Stdin)
+		if !scanner.Scan() {
+			log.Fatalf("Failed to read confirmation")
+		}
+
+		confirmation := strings.ToLower(strings.TrimSpace(scanner.Text()))
+		if confirmation != "yes" && confirmation != "y" {
+			fmt.Println("\nOperation cancelled.")
+			os.Exit(0)
+		}
+		fmt.Println()
+	}
+
+	results, err = gopurple.ApplySetupSites(ctx, client.BDeploy, template, sites, gopurple.ApplySitesOptions{})
+	printResults(results, *jsonFlag)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	for _, result := range results {
+		if result.Error != "" {
+			os.Exit(1)
+		}
+	}
+}
+
+// countActions returns how many sites will be created and updated.
+func countActions(results []gopurple.SetupSiteResult) (creates, updates int) {
+	for _, result := range results {
+		switch {
+		case result.Error != "":
+		case result.Action == gopurple.SetupActionCreate:
+			creates++
+		case result.Action == gopurple.SetupActionUpdate:
+			updates++
+		}
+	}
+	return creates, updates
+}
+
+// printResults prints one line per site, or the results as JSON.
+func printResults(results []gopurple.SetupSiteResult, jsonOutput bool) {
+	if jsonOutput {
+		data, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			log.Fatalf("❌ Failed to marshal JSON: %v", err)
+		}
+		fmt.Println(string(data))
+		return
+	}
+
+	fmt.Printf("%-12s %-8s %-28s %-26s %s\n", "SITE", "ACTION", "PACKAGE", "SETUP ID", "STATUS")
+	for _, result := range results {
+		status := "planned"
+		switch {
+		case result.Error != "":
+			status = "❌ " + result.Error
+		case result.Applied:
+			status = "✅ applied"
+		}
+		fmt.Printf("%-12s %-8s %-28s %-26s %s\n", result.Site, result.Action, result.PackageName, result.SetupID, status)
+	}
+}
+func handleNetworkSelection(ctx context.Context, client *gopurple.Client, requestedNetwork string, jsonMode bool) error {
+	if client.IsNetworkSet() {
+		if current, err := client.GetCurrentNetwork(ctx); err == nil {
+			if !jsonMode {
+				fmt.Fprintf(os.Stderr, "Using network: %s (ID: %d)\n", current.Name, current.ID)
+			}
+			return nil
+		}
+	}
+
+	// If no network flag was provided, check BS_NETWORK environment variable
+	if requestedNetwork == "" {
+		if envNetwork := os.Getenv("BS_NETWORK"); envNetwork != "" {
+			requestedNetwork = envNetwork
+			if !jsonMode {
+				fmt.Fprintf(os.Stderr, "Using network from BS_NETWORK environment variable\n")
+			}
+		}
+	}
+
+	networks, err := client.GetNetworks(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get networks: %w", err)
+	}
+
+	if len(networks) == 0 {
+		return fmt.Errorf("no networks available")
+	}
+
+	if requestedNetwork != "" {
+		for _, network := range networks {
+			if strings.EqualFold(network.Name, requestedNetwork) {
+				if !jsonMode {
+					fmt.Fprintf(os.Stderr, "Using network: %s (ID: %d)\n", network.Name, network.ID)
+				}
+				return client.SetNetworkByID(ctx, network.ID)
+			}
+		}
+	}
+
+	if len(networks) == 1 {
+		if !jsonMode {
+			fmt.Fprintf(os.Stderr, "Using network: %s (ID: %d)\n", networks[0].Name, networks[0].ID)
+		}
+		return client.SetNetworkByID(ctx, networks[0].ID)
+	}
+
+	fmt.Fprintf(os.Stderr, "Available networks:\n")
+	for i, network := range networks {
+		fmt.Fprintf(os.Stderr, "  %d. %s (ID: %d)\n", i+1, network.Name, network.ID)
+	}
+
+	fmt.Fprint(os.Stderr, "Select network (1-"+strconv.Itoa(len(networks))+"): ")
+	scanner := bufio.NewScanner(os.Stdin)
+	if !scanner.Scan() {
+		return fmt.Errorf("failed to read input")
+	}
+
+	selection, err := strconv.Atoi(strings.TrimSpace(scanner.Text()))
+	if err != nil || selection < 1 || selection > len(networks) {
+		return fmt.Errorf("invalid selection")
+	}
+
+	selectedNetwork := networks[selection-1]
+	if !jsonMode {
+		fmt.Fprintf(os.Stderr, "Selected network: %s (ID: %d)\n", selectedNetwork.Name, selectedNetwork.ID)
+	}
+	return client.SetNetworkByID(ctx, selectedNetwork.ID)
+}
@@ -355,7 +355,7 @@ func main() {
 
 	// Set setupID based on mode
 	if *dissociateFlag {
-		updateRequest.SetupID = "" // Empty string will be sent as null by the SDK
+		updateRequest.Dissociate = true // Sends a null setupId and removes the ledger entry
 	} else {
 		updateRequest.SetupID = setupID // This creates the association
 	}
@@ -0,0 +1,382 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/brightdevelopers/gopurple"
+)
+
+func main() {
+	var (
+		helpFlag    = flag.Bool("help", false, "Display usage information")
+		timeoutFlag = flag.Int("timeout", 30, "Request timeout in seconds")
+		specFlag    = flag.String("spec", "", "Wall spec file (.json or .yaml)")
+		outDirFlag  = flag.String("out-dir", "", "Write the setup records and a players.csv manifest for bdeploy-reconcile to this directory")
+		applyFlag   = flag.Bool("apply", false, "Create or update the setup records and associate the players")
+		planFlag    = flag.Bool("plan", false, "With --apply, show the plan without applying it")
+		jsonFlag    = flag.Bool("json", false, "Output as JSON")
+		networkFlag *string
+		confirmFlag *bool
+	)
+
+	// Set up network flags to point to the same variable
+	networkFlag = flag.String("network", "", "Network name to use (overrides BS_NETWORK)")
+	flag.StringVar(networkFlag, "n", "", "Network name to use (overrides BS_NETWORK) [alias for --network]")
+
+	// Set up confirm flags to point to the same variable
+	confirmFlag = flag.Bool("y", false, "Skip confirmation prompt")
+	flag.BoolVar(confirmFlag, "force", false, "Skip confirmation prompt [alias for -y]")
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s --spec <wall.yaml> [--out-dir <dir> | --apply] [options]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Generate the B-Deploy setup records for a BrightWall video wall: one record per\n")
+		fmt.Fprintf(os.Stderr, "screen, copied from a base record, with the wall name, screen number, package\n")
+		fmt.Fprintf(os.Stderr, "name, hostname and static IP address set, and each player's serial associated\n")
+		fmt.Fprintf(os.Stderr, "with its record. Screens are numbered from 1, left to right, then top to bottom.\n")
+		fmt.Fprintf(os.Stderr, "Every position must have exactly one player.\n\n")
+		fmt.Fprintf(os.Stderr, "Without --out-dir or --apply the wall layout is printed.\n\n")
+		fmt.Fprintf(os.Stderr, "Wall spec:\n")
+		fmt.Fprintf(os.Stderr, "  name: Lobby Wall\n")
+		fmt.Fprintf(os.Stderr, "  rows: 2\n")
+		fmt.Fprintf(os.Stderr, "  columns: 3\n")
+		fmt.Fprintf(os.Stderr, "  base: base.yaml             # or the base setup record inline\n")
+		fmt.Fprintf(os.Stderr, "  packagePrefix: lobby-wall   # optional, records are lobby-wall-1 ... lobby-wall-6\n")
+		fmt.Fprintf(os.Stderr, "  network:                    # optional, static addresses from startIP\n")
+		fmt.Fprintf(os.Stderr, "    startIP: 10.1.0.21\n")
+		fmt.Fprintf(os.Stderr, "    subnetMask: 255.255.255.0\n")
+		fmt.Fprintf(os.Stderr, "    gateway: 10.1.0.1\n")
+		fmt.Fprintf(os.Stderr, "    dns: [10.1.0.2]\n")
+		fmt.Fprintf(os.Stderr, "  screens:\n")
+		fmt.Fprintf(os.Stderr, "    - row: 1\n")
+		fmt.Fprintf(os.Stderr, "      column: 1\n")
+		fmt.Fprintf(os.Stderr, "      serial: XD001\n")
+		fmt.Fprintf(os.Stderr, "    ...\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		flag.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nEnvironment Variables:\n")
+		fmt.Fprintf(os.Stderr, "  BS_CLIENT_ID        BSN.cloud API client ID (required with --apply)\n")
+		fmt.Fprintf(os.Stderr, "  BS_SECRET          BSN.cloud API client secret (required with --apply)\n")
+		fmt.Fprintf(os.Stderr, "  BS_NETWORK         BSN.cloud network name (optional)\n\n")
+		fmt.Fprintf(os.Stderr, "Exit Status:\n")
+		fmt.Fprintf(os.Stderr, "  0  success\n")
+		fmt.Fprintf(os.Stderr, "  1  error, or a change failed\n")
+		fmt.Fprintf(os.Stderr, "  2  --plan found changes\n\n")
+		fmt.Fprintf(os.Stderr, "Examples:\n")
+		fmt.Fprintf(os.Stderr, "  Check the wall layout:\n")
+		fmt.Fprintf(os.Stderr, "    %s --spec wall.yaml\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  Write the records for review and bdeploy-reconcile:\n")
+		fmt.Fprintf(os.Stderr, "    %s --spec wall.yaml --out-dir lobby-wall\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  Create the records and associate the players:\n")
+		fmt.Fprintf(os.Stderr, "    %s --spec wall.yaml --apply --network Retail\n", os.Args[0])
+	}
+
+	flag.Parse()
+
+	if *helpFlag {
+		flag.Usage()
+		return
+	}
+
+	if *specFlag == "" {
+		fmt.Fprintf(os.Stderr, "Error: --spec is required\n\n")
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	spec, err := gopurple.LoadBrightWallSpec(*specFlag)
+	if err != nil {
+		log.Fatalf("❌ Failed to load wall spec: %v", err)
+	}
+	wall, err := gopurple.GenerateBrightWall(spec)
+	if err != nil {
+		log.Fatalf("❌ Invalid wall: %v", err)
+	}
+
+	if *outDirFlag != "" {
+		if err := writeWall(*outDirFlag, wall); err != nil {
+			log.Fatalf("❌ Failed to write wall: %v", err)
+		}
+		if !*jsonFlag {
+			fmt.Printf("✅ Wrote %d setup record(s) and players.csv to %s\n", len(wall.Records), *outDirFlag)
+		}
+	}
+
+	if !*applyFlag {
+		if *jsonFlag {
+			printWallJSON(wall)
+		} else if *outDirFlag == "" {
+			printWall(spec, wall)
+		}
+		return
+	}
+
+	// Setup files may hold secret references such as env:WIFI_PASSPHRASE
+	opts := []gopurple.Option{gopurple.WithSecretReferences(true)}
+	if *timeoutFlag > 0 {
+		opts = append(opts, gopurple.WithTimeout(time.Duration(*timeoutFlag)*time.Second))
+	}
+	if *networkFlag != "" {
+		opts = append(opts, gopurple.WithNetwork(*networkFlag))
+	}
+
+	client, err := gopurple.New(opts...)
+	if err != nil {
+		if gopurple.IsConfigurationError(err) {
+			log.Fatalf("❌ Configuration error: %v", err)
+		}
+		log.Fatalf("❌ Failed to create client: %v", err)
+	}
+
+	ctx := context.Background()
+
+	if !*jsonFlag {
+		fmt.Fprintln(os.Stderr, "🔐 Authenticating with BSN.cloud...")
+	}
+	if err := client.Authenticate(ctx); err != nil {
+		log.Fatalf("❌ Authentication failed: %v", err)
+	}
+
+	if err := handleNetworkSelection(ctx, client, *networkFlag, *jsonFlag); err != nil {
+		log.Fatalf("❌ Network selection failed: %v", err)
+	}
+
+	current, err := client.GetCurrentNetwork(ctx)
+	if err != nil {
+		log.Fatalf("❌ Failed to get current network: %v", err)
+	}
+	if err := client.BDeploy.SetNetworkContext(ctx, current.Name); err != nil {
+		log.Fatalf("❌ Failed to set network context: %v", err)
+	}
+
+	// The rest of the network is left alone: only the wall's records are planned
+	plan, err := gopurple.PlanSetupReconcile(ctx, client.BDeploy, wall, gopurple.ReconcileOptions{
+		NetworkName: current.Name,
+		Secrets:     client.SecretResolver(),
+	})
+	if err != nil {
+		log.Fatalf("❌ Failed to plan: %v", err)
+	}
+
+	if *planFlag || len(plan.Changes) == 0 {
+		printPlan(plan, *jsonFlag)
+		if len(plan.Changes) > 0 {
+			os.Exit(2)
+		}
+		return
+	}
+
+	if !*confirmFlag {
+		printPlan(plan, false)
+		fmt.Print("\nProceed? (yes/no): ")
+
+		scanner := bufio.NewScanner(os.Stdin)
+		if !scanner.Scan() {
+			log.Fatalf("Failed to read confirmation")
+		}
+
+		confirmation := strings.ToLower(strings.TrimSpace(scanner.Text()))
+		if confirmation != "yes" && confirmation != "y" {
+			fmt.Println("\nOperation cancelled.")
+			os.Exit(0)
+		}
+		fmt.Println()
+	}
+
+	if err := gopurple.ApplySetupPlan(ctx, client.BDeploy, plan); err != nil {
+		log.Fatalf("❌ Failed to apply plan: %v", err)
+	}
+
+	failed := 0
+	for _, change := range plan.Changes {
+		if change.Error != "" {
+			failed++
+		}
+	}
+
+	if *jsonFlag {
+		printPlan(plan, true)
+	} else {
+		for _, change := range plan.Changes {
+			target := change.PackageName
+			if change.Action == gopurple.SetupActionAssociate {
+				target = change.Serial + " -> " + change.PackageName
+			}
+			if change.Error != "" {
+				fmt.Printf("❌ %-9s %s: %s\n", change.Action, target, change.Error)
+			} else {
+				fmt.Printf("✅ %-9s %s\n", change.Action, target)
+			}
+		}
+		fmt.Printf("\n%d of %d change(s) applied\n", len(plan.Changes)-failed, len(plan.Changes))
+	}
+
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// printWall prints the wall as a grid of screen numbers and a table of screens.
+func printWall(spec *gopurple.BrightWallSpec, wall *gopurple.SetupDesiredState) {
+	fmt.Printf("BrightWall %s (%d rows x %d columns):\n\n", spec.Name, spec.Rows, spec.Columns)
+	for row := 1; row <= spec.Rows; row++ {
+		fmt.Print("  ")
+		for column := 1; column <= spec.Columns; column++ {
+			fmt.Printf("[%3d]", spec.ScreenNumber(row, column))
+		}
+		fmt.Println()
+	}
+	fmt.Println()
+
+	fmt.Printf("%-7s %-25s %-25s %-16s %s\n", "Screen", "Package", "Hostname", "IP Address", "Serial")
+	fmt.Printf("%-7s %-25s %-25s %-16s %s\n", "------", "-------", "--------", "----------", "------")
+	for i, record := range wall.Records {
+		address := record.StaticIPAddress
+		if address == "" {
+			address = "(base)"
+		}
+		fmt.Printf("%-7s %-25s %-25s %-16s %s\n", record.BrightWallScreenNumber, record.BDeploy.PackageName,
+			record.Hostname, address, wall.Associations[i].Serial)
+	}
+}
+
+// printWallJSON prints the generated records, with secrets masked, and associations.
+func printWallJSON(wall *gopurple.SetupDesiredState) {
+	redacted := &gopurple.SetupDesiredState{Associations: wall.Associations}
+	for _, record := range wall.Records {
+		redacted.Records = append(redacted.Records, record.Redacted())
+	}
+	data, err := json.MarshalIndent(redacted, "", "  ")
+	if err != nil {
+		log.Fatalf("❌ Failed to marshal JSON: %v", err)
+	}
+	fmt.Println(string(data))
+}
+
+// writeWall writes each record to <package>.json and the associations to
+// players.csv, the layout bdeploy-reconcile reads with --dir and --manifest.
+func writeWall(dir string, wall *gopurple.SetupDesiredState) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for _, record := range wall.Records {
+		data, err := json.MarshalIndent(record, "", "  ")
+		if err != nil {
+			return err
+		}
+		filename := filepath.Join(dir, record.BDeploy.PackageName+".json")
+		if err := os.WriteFile(filename, append(data, '\n'), 0600); err != nil {
+			return err
+		}
+	}
+
+	file, err := os.Create(filepath.Join(dir, "players.csv"))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	_ = writer.Write([]string{"serial", "package", "name", "description"})
+	for _, a := range wall.Associations {
+		_ = writer.Write([]string{a.Serial, a.PackageName, a.Name, a.Description})
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// printPlan prints the plan in terraform style, or as JSON.
+func printPlan(plan *gopurple.ReconcilePlan, jsonOutput bool) {
+	if jsonOutput {
+		data, err := json.MarshalIndent(plan, "", "  ")
+		if err != nil {
+			log.Fatalf("❌ Failed to marshal JSON: %v", err)
+		}
+		fmt.Println(string(data))
+		return
+	}
+	fmt.Printf("B-Deploy network %s:\n\n", plan.NetworkName)
+	fmt.Print(plan.String())
+}
+func handleNetworkSelection(ctx context.Context, client *gopurple.Client, requestedNetwork string, jsonMode bool) error {
+	if client.IsNetworkSet() {
+		if current, err := client.GetCurrentNetwork(ctx); err == nil {
+			if !jsonMode {
+				fmt.Fprintf(os.Stderr, "Using network: %s (ID: %d)\n", current.Name, current.ID)
+			}
+			return nil
+		}
+	}
+
+	// If no network flag was provided, check BS_NETWORK environment variable
+	if requestedNetwork == "" {
+		if envNetwork := os.Getenv("BS_NETWORK"); envNetwork != "" {
+			requestedNetwork = envNetwork
+			if !jsonMode {
+				fmt.Fprintf(os.Stderr, "Using network from BS_NETWORK environment variable\n")
+			}
+		}
+	}
+
+	networks, err := client.GetNetworks(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get networks: %w", err)
+	}
+
+	if len(networks) == 0 {
+		return fmt.Errorf("no networks available")
+	}
+
+	if requestedNetwork != "" {
+		for _, network := range networks {
+			if strings.EqualFold(network.Name, requestedNetwork) {
+				if !jsonMode {
+					fmt.Fprintf(os.Stderr, "Using network: %s (ID: %d)\n", network.Name, network.ID)
+				}
+				return client.SetNetworkByID(ctx, network.ID)
+			}
+		}
+	}
+
+	if len(networks) == 1 {
+		if !jsonMode {
+			fmt.Fprintf(os.Stderr, "Using network: %s (ID: %d)\n", networks[0].Name, networks[0].ID)
+		}
+		return client.SetNetworkByID(ctx, networks[0].ID)
+	}
+
+	fmt.Fprintf(os.Stderr, "Available networks:\n")
+	for i, network := range networks {
+		fmt.Fprintf(os.Stderr, "  %d. %s (ID: %d)\n", i+1, network.Name, network.ID)
+	}
+
+	fmt.Fprint(os.Stderr, "Select network (1-"+strconv.Itoa(len(networks))+"): ")
+	scanner := bufio.NewScanner(os.Stdin)
+	if !scanner.Scan() {
+		return fmt.Errorf("failed to read input")
+	}
+
+	selection, err := strconv.Atoi(strings.TrimSpace(scanner.Text()))
+	if err != nil || selection < 1 || selection > len(networks) {
+		return fmt.Errorf("invalid selection")
+	}
+
+	selectedNetwork := networks[selection-1]
+	if !jsonMode {
+		fmt.Fprintf(os.Stderr, "Selected network: %s (ID: %d)\n", selectedNetwork.Name, selectedNetwork.ID)
+	}
+	return client.SetNetworkByID(ctx, selectedNetwork.ID)
+}
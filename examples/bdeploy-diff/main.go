@@ -0,0 +1,269 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/brightdevelopers/gopurple"
+)
+
+func main() {
+	var (
+		helpFlag    = flag.Bool("help", false, "Display usage information")
+		timeoutFlag = flag.Int("timeout", 30, "Request timeout in seconds")
+		jsonFlag    = flag.Bool("json", false, "Output the differences as JSON")
+		networkFlag *string
+	)
+
+	// Set up network flags to point to the same variable
+	networkFlag = flag.String("network", "", "Network name to use (overrides BS_NETWORK)")
+	flag.StringVar(networkFlag, "n", "", "Network name to use (overrides BS_NETWORK) [alias for --network]")
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [options] <A> <B>\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Compare two B-Deploy setup records field by field. Passwords and tokens are\n")
+		fmt.Fprintf(os.Stderr, "masked. Each of A and B is one of:\n")
+		fmt.Fprintf(os.Stderr, "  <setup-id>             A setup record in B-Deploy\n")
+		fmt.Fprintf(os.Stderr, "  <setup-id>@<time>      The newest snapshot of the record in the setup history\n")
+		fmt.Fprintf(os.Stderr, "                         taken at or before <time> (see bdeploy-history)\n")
+		fmt.Fprintf(os.Stderr, "  <file>                 A setup record in a .json or .yaml file\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		flag.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nEnvironment Variables:\n")
+		fmt.Fprintf(os.Stderr, "  BS_CLIENT_ID        BSN.cloud API client ID (required)\n")
+		fmt.Fprintf(os.Stderr, "  BS_SECRET          BSN.cloud API client secret (required)\n")
+		fmt.Fprintf(os.Stderr, "  BS_NETWORK         BSN.cloud network name (optional)\n")
+		fmt.Fprintf(os.Stderr, "  BS_SETUP_HISTORY   Setup history directory, for <setup-id>@<time>\n\n")
+		fmt.Fprintf(os.Stderr, "Exit Status:\n")
+		fmt.Fprintf(os.Stderr, "  0  The records are the same\n")
+		fmt.Fprintf(os.Stderr, "  1  An error occurred\n")
+		fmt.Fprintf(os.Stderr, "  2  The records differ\n\n")
+		fmt.Fprintf(os.Stderr, "Examples:\n")
+		fmt.Fprintf(os.Stderr, "  Compare two setups:\n")
+		fmt.Fprintf(os.Stderr, "    %s 658f1dbef1d46c829f60a14f 658f1dbef1d46c829f60a150\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  Compare a setup with the file it was created from:\n")
+		fmt.Fprintf(os.Stderr, "    %s 658f1dbef1d46c829f60a14f desired/store-0001.yaml\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  See what an update changed:\n")
+		fmt.Fprintf(os.Stderr, "    %s 658f1dbef1d46c829f60a14f@2026-10-01T12:00:00Z 658f1dbef1d46c829f60a14f\n", os.Args[0])
+	}
+
+	flag.Parse()
+
+	if *helpFlag {
+		flag.Usage()
+		return
+	}
+
+	if flag.NArg() != 2 {
+		fmt.Fprintf(os.Stderr, "Error: two setup records are required\n\n")
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	var opts []gopurple.Option
+	if *timeoutFlag > 0 {
+		opts = append(opts, gopurple.WithTimeout(time.Duration(*timeoutFlag)*time.Second))
+	}
+	if *networkFlag != "" {
+		opts = append(opts, gopurple.WithNetwork(*networkFlag))
+	}
+
+	client, err := gopurple.New(opts...)
+	if err != nil {
+		if gopurple.IsConfigurationError(err) {
+			log.Fatalf("❌ Configuration error: %v", err)
+		}
+		log.Fatalf("❌ Failed to create client: %v", err)
+	}
+
+	ctx := context.Background()
+	loader := &recordLoader{client: client, jsonOutput: *jsonFlag, network: *networkFlag}
+
+	a, err := loader.load(ctx, flag.Arg(0))
+	if err != nil {
+		log.Fatalf("❌ %s: %v", flag.Arg(0), err)
+	}
+	b, err := loader.load(ctx, flag.Arg(1))
+	if err != nil {
+		log.Fatalf("❌ %s: %v", flag.Arg(1), err)
+	}
+
+	changes, err := gopurple.DiffSetupRecords(a, b)
+	if err != nil {
+		log.Fatalf("❌ Failed to compare setup records: %v", err)
+	}
+
+	if *jsonFlag {
+		data, err := json.MarshalIndent(changes, "", "  ")
+		if err != nil {
+			log.Fatalf("❌ Failed to marshal JSON: %v", err)
+		}
+		fmt.Println(string(data))
+	} else {
+		fmt.Printf("--- %s\n+++ %s\n", flag.Arg(0), flag.Arg(1))
+		for _, change := range changes {
+			fmt.Printf("  ~ %s\n", change)
+		}
+		if len(changes) == 0 {
+			fmt.Println("No differences.")
+		} else {
+			fmt.Printf("\n%d field(s) differ\n", len(changes))
+		}
+	}
+
+	if len(changes) > 0 {
+		os.Exit(2)
+	}
+}
+
+// recordLoader reads setup records from files, B-Deploy and the setup history,
+// authenticating the first time B-Deploy is needed.
+type recordLoader struct {
+	client        *gopurple.Client
+	jsonOutput    bool
+	network       string
+	authenticated bool
+}
+
+func (l *recordLoader) load(ctx context.Context, arg string) (*gopurple.BDeploySetupRecord, error) {
+	switch strings.ToLower(filepath.Ext(arg)) {
+	case ".json", ".yaml", ".yml":
+		tmpl, err := gopurple.LoadSetupTemplate(arg)
+		if err != nil {
+			return nil, err
+		}
+		return tmpl.Render(nil)
+	}
+
+	if setupID, at, ok := strings.Cut(arg, "@"); ok {
+		return loadSnapshot(ctx, l.client.SetupHistory(), setupID, at)
+	}
+
+	if err := l.authenticate(ctx); err != nil {
+		return nil, err
+	}
+	return l.client.BDeploy.GetSetupRecord(ctx, arg)
+}
+
+func (l *recordLoader) authenticate(ctx context.Context) error {
+	if l.authenticated {
+		return nil
+	}
+	if !l.jsonOutput {
+		fmt.Fprintln(os.Stderr, "🔐 Authenticating with BSN.cloud...")
+	}
+	if err := l.client.Authenticate(ctx); err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+	if err := handleNetworkSelection(ctx, l.client, l.network, l.jsonOutput); err != nil {
+		return fmt.Errorf("network selection failed: %w", err)
+	}
+	current, err := l.client.GetCurrentNetwork(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get current network: %w", err)
+	}
+	if err := l.client.BDeploy.SetNetworkContext(ctx, current.Name); err != nil {
+		return fmt.Errorf("failed to set network context: %w", err)
+	}
+	l.authenticated = true
+	return nil
+}
+
+// loadSnapshot returns the newest snapshot of a record taken at or before at.
+func loadSnapshot(ctx context.Context, store gopurple.SetupHistory, setupID, at string) (*gopurple.BDeploySetupRecord, error) {
+	if store == nil {
+		return nil, fmt.Errorf("setup history is off; set BS_SETUP_HISTORY")
+	}
+	when, err := time.Parse(time.RFC3339, at)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time %q, expected RFC 3339 such as 2026-10-01T12:00:00Z", at)
+	}
+
+	snapshots, err := store.List(ctx, setupID)
+	if err != nil {
+		return nil, err
+	}
+	found := gopurple.FindSetupSnapshot(snapshots, when)
+	if found == nil {
+		return nil, fmt.Errorf("no snapshot of %s at or before %s", setupID, at)
+	}
+	return found.Record, nil
+}
+func handleNetworkSelection(ctx context.Context, client *gopurple.Client, requestedNetwork string, jsonMode bool) error {
+	if client.IsNetworkSet() {
+		if current, err := client.GetCurrentNetwork(ctx); err == nil {
+			if !jsonMode {
+				fmt.Fprintf(os.Stderr, "Using network: %s (ID: %d)\n", current.Name, current.ID)
+			}
+			return nil
+		}
+	}
+
+	// If no network flag was provided, check BS_NETWORK environment variable
+	if requestedNetwork == "" {
+		if envNetwork := os.Getenv("BS_NETWORK"); envNetwork != "" {
+			requestedNetwork = envNetwork
+			if !jsonMode {
+				fmt.Fprintf(os.Stderr, "Using network from BS_NETWORK environment variable\n")
+			}
+		}
+	}
+
+	networks, err := client.GetNetworks(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get networks: %w", err)
+	}
+
+	if len(networks) == 0 {
+		return fmt.Errorf("no networks available")
+	}
+
+	if requestedNetwork != "" {
+		for _, network := range networks {
+			if strings.EqualFold(network.Name, requestedNetwork) {
+				if !jsonMode {
+					fmt.Fprintf(os.Stderr, "Using network: %s (ID: %d)\n", network.Name, network.ID)
+				}
+				return client.SetNetworkByID(ctx, network.ID)
+			}
+		}
+	}
+
+	if len(networks) == 1 {
+		if !jsonMode {
+			fmt.Fprintf(os.Stderr, "Using network: %s (ID: %d)\n", networks[0].Name, networks[0].ID)
+		}
+		return client.SetNetworkByID(ctx, networks[0].ID)
+	}
+
+	fmt.Fprintf(os.Stderr, "Available networks:\n")
+	for i, network := range networks {
+		fmt.Fprintf(os.Stderr, "  %d. %s (ID: %d)\n", i+1, network.Name, network.ID)
+	}
+
+	fmt.Fprint(os.Stderr, "Select network (1-"+strconv.Itoa(len(networks))+"): ")
+	scanner := bufio.NewScanner(os.Stdin)
+	if !scanner.Scan() {
+		return fmt.Errorf("failed to read input")
+	}
+
+	selection, err := strconv.Atoi(strings.TrimSpace(scanner.Text()))
+	if err != nil || selection < 1 || selection > len(networks) {
+		return fmt.Errorf("invalid selection")
+	}
+
+	selectedNetwork := networks[selection-1]
+	if !jsonMode {
+		fmt.Fprintf(os.Stderr, "Selected network: %s (ID: %d)\n", selectedNetwork.Name, selectedNetwork.ID)
+	}
+	return client.SetNetworkByID(ctx, selectedNetwork.ID)
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"flag"
@@ -19,6 +20,7 @@ func main() {
 		setupIDFlag   = flag.String("setup-id", "", "ID of the setup record to retrieve")
 		setupNameFlag = flag.String("setup-name", "", "Package name of the setup record to retrieve")
 		jsonFlag      = flag.Bool("json", false, "Output raw JSON (default shows formatted structure)")
+		secretsFlag   = flag.Bool("show-secrets", false, "Show passwords and tokens instead of ********")
 		networkFlag   *string
 	)
 
@@ -31,7 +33,8 @@ func main() {
 		fmt.Fprintf(os.Stderr, "Usage: %s [options]\n\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "A tool to retrieve a specific B-Deploy setup record from BSN.cloud.\n\n")
 		fmt.Fprintf(os.Stderr, "By default, displays the setup record structure in formatted JSON.\n")
-		fmt.Fprintf(os.Stderr, "Use --json flag to output raw JSON only (no status messages).\n\n")
+		fmt.Fprintf(os.Stderr, "Use --json flag to output raw JSON only (no status messages).\n")
+		fmt.Fprintf(os.Stderr, "Passwords and tokens are shown as ******** unless --show-secrets is given.\n\n")
 		fmt.Fprintf(os.Stderr, "Options:\n")
 		flag.PrintDefaults()
 		fmt.Fprintf(os.Stderr, "\nEnvironment Variables:\n")
@@ -45,6 +48,8 @@ func main() {
 		fmt.Fprintf(os.Stderr, "    %s --setup-name \"retail-display-v1\" --network \"My Network\"\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  Get raw JSON output only:\n")
 		fmt.Fprintf(os.Stderr, "    %s --setup-id \"658f1dbef1d46c829f60a14f\" --network \"My Network\" --json\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  Back up a record including its passwords:\n")
+		fmt.Fprintf(os.Stderr, "    %s --setup-id \"658f1dbef1d46c829f60a14f\" --json --show-secrets > backup.json\n", os.Args[0])
 	}
 
 	flag.Parse()
@@ -180,8 +185,17 @@ func main() {
 	}
 
 	// Display results
+	if !*secretsFlag {
+		record = record.Redacted()
+	}
 	if *jsonFlag {
-		jsonOutput, err := json.MarshalIndent(record, "", "  ")
+		// Encoded with the SDK so explicit false and 0 values are kept
+		jsonOutput, err := gopurple.MarshalSetupRecord(record)
+		if err == nil {
+			var indented bytes.Buffer
+			err = json.Indent(&indented, jsonOutput, "", "  ")
+			jsonOutput = indented.Bytes()
+		}
 		if err != nil {
 			log.Fatalf("❌ Failed to marshal JSON: %v", err)
 		}
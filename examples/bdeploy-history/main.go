@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/brightdevelopers/gopurple"
+)
+
+func main() {
+	var (
+		helpFlag    = flag.Bool("help", false, "Display usage information")
+		dirFlag     = flag.String("dir", "", "Setup history directory (default: BS_SETUP_HISTORY, then the user config directory)")
+		diffFlag    = flag.Bool("diff", false, "Show what changed between consecutive snapshots of the record")
+		jsonFlag    = flag.Bool("json", false, "Output snapshots as JSON, including the records")
+		secretsFlag = flag.Bool("show-secrets", false, "Show passwords and tokens in --json output instead of ********")
+	)
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [options] [setup-id]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "List the snapshots in the local B-Deploy setup history. When the history is on,\n")
+		fmt.Fprintf(os.Stderr, "the SDK saves a setup record just before every UpdateSetupRecord and\n")
+		fmt.Fprintf(os.Stderr, "DeleteSetupRecord call. Pass a time from this list to bdeploy-rollback --to to\n")
+		fmt.Fprintf(os.Stderr, "restore that snapshot. No BSN.cloud credentials are needed.\n\n")
+		fmt.Fprintf(os.Stderr, "The history is off by default. Turn it on for every program using the SDK by\n")
+		fmt.Fprintf(os.Stderr, "setting BS_SETUP_HISTORY to a directory, or in code with gopurple.WithSetupHistoryDir.\n")
+		fmt.Fprintf(os.Stderr, "Snapshots include passwords; the files are readable only by their owner, and\n")
+		fmt.Fprintf(os.Stderr, "--json shows them as ******** unless --show-secrets is given.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		flag.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nEnvironment Variables:\n")
+		fmt.Fprintf(os.Stderr, "  BS_SETUP_HISTORY   Setup history directory\n\n")
+		fmt.Fprintf(os.Stderr, "Examples:\n")
+		fmt.Fprintf(os.Stderr, "  List every snapshot:\n")
+		fmt.Fprintf(os.Stderr, "    %s\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  Show how one setup changed over time:\n")
+		fmt.Fprintf(os.Stderr, "    %s --diff 658f1dbef1d46c829f60a14f\n", os.Args[0])
+	}
+
+	flag.Parse()
+
+	if *helpFlag {
+		flag.Usage()
+		return
+	}
+
+	if flag.NArg() > 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+	setupID := flag.Arg(0)
+
+	dir := *dirFlag
+	if dir == "" {
+		dir = os.Getenv("BS_SETUP_HISTORY")
+	}
+	if dir == "" {
+		var err error
+		if dir, err = gopurple.DefaultSetupHistoryDir(); err != nil {
+			log.Fatalf("❌ No setup history directory: %v", err)
+		}
+	}
+
+	snapshots, err := gopurple.NewFileSetupHistory(dir).List(context.Background(), setupID)
+	if err != nil {
+		log.Fatalf("❌ Failed to read setup history: %v", err)
+	}
+
+	if *jsonFlag {
+		if !*secretsFlag {
+			for i := range snapshots {
+				snapshots[i].Record = snapshots[i].Record.Redacted()
+			}
+		}
+		data, err := json.MarshalIndent(snapshots, "", "  ")
+		if err != nil {
+			log.Fatalf("❌ Failed to marshal JSON: %v", err)
+		}
+		fmt.Println(string(data))
+		return
+	}
+
+	if len(snapshots) == 0 {
+		fmt.Printf("No snapshots in %s\n", dir)
+		return
+	}
+
+	fmt.Printf("%-22s %-26s %-28s %-10s %s\n", "TAKEN AT", "SETUP ID", "PACKAGE", "BEFORE", "NETWORK")
+	for i, snapshot := range snapshots {
+		fmt.Printf("%-22s %-26s %-28s %-10s %s\n", snapshot.TakenAt.Format(time.RFC3339), snapshot.SetupID,
+			snapshot.PackageName, snapshot.Operation, snapshot.NetworkName)
+
+		// Each snapshot is the state before a change, so the next snapshot shows its result
+		if *diffFlag && setupID != "" && i+1 < len(snapshots) {
+			changes, err := gopurple.DiffSetupRecords(snapshot.Record, snapshots[i+1].Record)
+			if err != nil {
+				log.Fatalf("❌ Failed to compare snapshots: %v", err)
+			}
+			for _, change := range changes {
+				fmt.Printf("    ~ %s\n", change)
+			}
+		}
+	}
+	fmt.Printf("\n%d snapshot(s) in %s\n", len(snapshots), dir)
+}
@@ -0,0 +1,303 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/brightdevelopers/gopurple"
+)
+
+func main() {
+	var (
+		helpFlag     = flag.Bool("help", false, "Display usage information")
+		timeoutFlag  = flag.Int("timeout", 30, "Request timeout in seconds")
+		usernameFlag = flag.String("username", "", "BSN.cloud username for new devices (default: the setup record's username)")
+		dryRunFlag   = flag.Bool("dry-run", false, "Show what each row would do without writing")
+		reportFlag   = flag.String("report", "", "Write a per-row CSV report to this file")
+		jsonFlag     = flag.Bool("json", false, "Output results as JSON")
+		networkFlag  *string
+		confirmFlag  *bool
+	)
+
+	// Set up network flags to point to the same variable
+	networkFlag = flag.String("network", "", "Network for rows without a network column (overrides BS_NETWORK)")
+	flag.StringVar(networkFlag, "n", "", "Network for rows without a network column (overrides BS_NETWORK) [alias for --network]")
+
+	// Set up confirm flags to point to the same variable
+	confirmFlag = flag.Bool("y", false, "Skip confirmation prompt")
+	flag.BoolVar(confirmFlag, "force", false, "Skip confirmation prompt [alias for -y]")
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [options] <devices.csv>\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Register, update or delete B-Deploy devices from a spreadsheet export. Save the\n")
+		fmt.Fprintf(os.Stderr, "sheet as CSV (comma, semicolon or tab separated); .xlsx files are not read directly.\n\n")
+		fmt.Fprintf(os.Stderr, "Columns (header names are case-insensitive):\n")
+		fmt.Fprintf(os.Stderr, "  serial       Serial number (required; also \"Serial Number\")\n")
+		fmt.Fprintf(os.Stderr, "  name         Device name (default: existing name, or the serial)\n")
+		fmt.Fprintf(os.Stderr, "  description  Device description\n")
+		fmt.Fprintf(os.Stderr, "  setup        Setup record package name to associate (also \"Setup Name\")\n")
+		fmt.Fprintf(os.Stderr, "  network      Network name (default: --network)\n")
+		fmt.Fprintf(os.Stderr, "  action       \"delete\" to remove the device\n\n")
+		fmt.Fprintf(os.Stderr, "Importing is idempotent: devices that already match their row are left alone, so a\n")
+		fmt.Fprintf(os.Stderr, "file can be imported again after fixing the rows that failed. Empty cells keep the\n")
+		fmt.Fprintf(os.Stderr, "device's existing value.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		flag.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nEnvironment Variables:\n")
+		fmt.Fprintf(os.Stderr, "  BS_CLIENT_ID        BSN.cloud API client ID (required)\n")
+		fmt.Fprintf(os.Stderr, "  BS_SECRET          BSN.cloud API client secret (required)\n")
+		fmt.Fprintf(os.Stderr, "  BS_NETWORK         BSN.cloud network name (optional)\n\n")
+		fmt.Fprintf(os.Stderr, "Examples:\n")
+		fmt.Fprintf(os.Stderr, "  Preview an import:\n")
+		fmt.Fprintf(os.Stderr, "    %s --dry-run --network Retail devices.csv\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  Import and keep a report:\n")
+		fmt.Fprintf(os.Stderr, "    %s --network Retail --report import-report.csv devices.csv\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  Import without prompting:\n")
+		fmt.Fprintf(os.Stderr, "    %s -y --json devices.csv\n", os.Args[0])
+	}
+
+	flag.Parse()
+
+	if *helpFlag {
+		flag.Usage()
+		return
+	}
+
+	if flag.NArg() != 1 {
+		fmt.Fprintf(os.Stderr, "Error: a device file is required\n\n")
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	rows, err := gopurple.LoadDeviceImportRows(flag.Arg(0))
+	if err != nil {
+		log.Fatalf("❌ Failed to read devices: %v", err)
+	}
+	if len(rows) == 0 {
+		log.Fatalf("❌ %s has no devices", flag.Arg(0))
+	}
+
+	var opts []gopurple.Option
+	if *timeoutFlag > 0 {
+		opts = append(opts, gopurple.WithTimeout(time.Duration(*timeoutFlag)*time.Second))
+	}
+	if *networkFlag != "" {
+		opts = append(opts, gopurple.WithNetwork(*networkFlag))
+	}
+
+	client, err := gopurple.New(opts...)
+	if err != nil {
+		if gopurple.IsConfigurationError(err) {
+			log.Fatalf("❌ Configuration error: %v", err)
+		}
+		log.Fatalf("❌ Failed to create client: %v", err)
+	}
+
+	ctx := context.Background()
+
+	if !*jsonFlag {
+		fmt.Fprintln(os.Stderr, "🔐 Authenticating with BSN.cloud...")
+	}
+	if err := client.Authenticate(ctx); err != nil {
+		log.Fatalf("❌ Authentication failed: %v", err)
+	}
+
+	if err := handleNetworkSelection(ctx, client, *networkFlag, *jsonFlag); err != nil {
+		log.Fatalf("❌ Network selection failed: %v", err)
+	}
+
+	current, err := client.GetCurrentNetwork(ctx)
+	if err != nil {
+		log.Fatalf("❌ Failed to get current network: %v", err)
+	}
+
+	importOpts := gopurple.DeviceImportOptions{
+		NetworkName: current.Name,
+		Username:    *usernameFlag,
+		DryRun:      true,
+	}
+
+	// Work out every row's action first
+	results, err := gopurple.ImportDevices(ctx, client.BDeploy, rows, importOpts)
+	if err != nil {
+		log.Fatalf("❌ Failed to plan import: %v", err)
+	}
+
+	changes := 0
+	for _, result := range results {
+		if result.Error == "" && result.Action != gopurple.SetupActionNone {
+			changes++
+		}
+	}
+
+	if !*dryRunFlag && changes > 0 {
+		if !*confirmFlag {
+			printResults(results, false)
+			fmt.Printf("\nThis will change %d device(s) in B-Deploy.\n", changes)
+			fmt.Print("Proceed? (yes/no): ")
+
+			scanner := bufio.NewScanner(os.Stdin)
+			if !scanner.Scan() {
+				log.Fatalf("Failed to read confirmation")
+			}
+
+			confirmation := strings.ToLower(strings.TrimSpace(scanner.Text()))
+			if confirmation != "yes" && confirmation != "y" {
+				fmt.Println("\nOperation cancelled.")
+				os.Exit(0)
+			}
+			fmt.Println()
+		}
+
+		importOpts.DryRun = false
+		results, err = gopurple.ImportDevices(ctx, client.BDeploy, rows, importOpts)
+		if err != nil {
+			log.Printf("❌ Import stopped: %v", err)
+		}
+	}
+
+	if *reportFlag != "" {
+		if err := writeReport(*reportFlag, results); err != nil {
+			log.Fatalf("❌ Failed to write report: %v", err)
+		}
+		if !*jsonFlag {
+			fmt.Fprintf(os.Stderr, "📝 Report written to %s\n", *reportFlag)
+		}
+	}
+
+	printResults(results, *jsonFlag)
+
+	for _, result := range results {
+		if result.Error != "" {
+			os.Exit(1)
+		}
+	}
+	if err != nil {
+		os.Exit(1)
+	}
+}
+
+// writeReport writes the per-row CSV report.
+func writeReport(path string, results []gopurple.DeviceImportResult) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := gopurple.WriteDeviceImportReport(f, results); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// printResults prints one line per row and a summary, or the results as JSON.
+func printResults(results []gopurple.DeviceImportResult, jsonOutput bool) {
+	if jsonOutput {
+		data, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			log.Fatalf("❌ Failed to marshal JSON: %v", err)
+		}
+		fmt.Println(string(data))
+		return
+	}
+
+	counts := map[gopurple.SetupAction]int{}
+	failed := 0
+	fmt.Printf("%-6s %-16s %-8s %-24s %s\n", "LINE", "SERIAL", "ACTION", "SETUP", "STATUS")
+	for _, result := range results {
+		status := "planned"
+		switch {
+		case result.Error != "":
+			status = "❌ " + result.Error
+			failed++
+		case result.Applied:
+			status = "✅ done"
+		case result.Action == gopurple.SetupActionNone:
+			status = "up to date"
+		}
+		if result.Error == "" {
+			counts[result.Action]++
+		}
+		fmt.Printf("%-6d %-16s %-8s %-24s %s\n", result.Line, result.Serial, result.Action, result.SetupName, status)
+	}
+	fmt.Printf("\n%d row(s): %d create, %d update, %d delete, %d unchanged, %d failed\n", len(results),
+		counts[gopurple.SetupActionCreate], counts[gopurple.SetupActionUpdate], counts[gopurple.SetupActionDelete],
+		counts[gopurple.SetupActionNone], failed)
+}
+func handleNetworkSelection(ctx context.Context, client *gopurple.Client, requestedNetwork string, jsonMode bool) error {
+	if client.IsNetworkSet() {
+		if current, err := client.GetCurrentNetwork(ctx); err == nil {
+			if !jsonMode {
+				fmt.Fprintf(os.Stderr, "Using network: %s (ID: %d)\n", current.Name, current.ID)
+			}
+			return nil
+		}
+	}
+
+	// If no network flag was provided, check BS_NETWORK environment variable
+	if requestedNetwork == "" {
+		if envNetwork := os.Getenv("BS_NETWORK"); envNetwork != "" {
+			requestedNetwork = envNetwork
+			if !jsonMode {
+				fmt.Fprintf(os.Stderr, "Using network from BS_NETWORK environment variable\n")
+			}
+		}
+	}
+
+	networks, err := client.GetNetworks(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get networks: %w", err)
+	}
+
+	if len(networks) == 0 {
+		return fmt.Errorf("no networks available")
+	}
+
+	if requestedNetwork != "" {
+		for _, network := range networks {
+			if strings.EqualFold(network.Name, requestedNetwork) {
+				if !jsonMode {
+					fmt.Fprintf(os.Stderr, "Using network: %s (ID: %d)\n", network.Name, network.ID)
+				}
+				return client.SetNetworkByID(ctx, network.ID)
+			}
+		}
+	}
+
+	if len(networks) == 1 {
+		if !jsonMode {
+			fmt.Fprintf(os.Stderr, "Using network: %s (ID: %d)\n", networks[0].Name, networks[0].ID)
+		}
+		return client.SetNetworkByID(ctx, networks[0].ID)
+	}
+
+	fmt.Fprintf(os.Stderr, "Available networks:\n")
+	for i, network := range networks {
+		fmt.Fprintf(os.Stderr, "  %d. %s (ID: %d)\n", i+1, network.Name, network.ID)
+	}
+
+	fmt.Fprint(os.Stderr, "Select network (1-"+strconv.Itoa(len(networks))+"): ")
+	scanner := bufio.NewScanner(os.Stdin)
+	if !scanner.Scan() {
+		return fmt.Errorf("failed to read input")
+	}
+
+	selection, err := strconv.Atoi(strings.TrimSpace(scanner.Text()))
+	if err != nil || selection < 1 || selection > len(networks) {
+		return fmt.Errorf("invalid selection")
+	}
+
+	selectedNetwork := networks[selection-1]
+	if !jsonMode {
+		fmt.Fprintf(os.Stderr, "Selected network: %s (ID: %d)\n", selectedNetwork.Name, selectedNetwork.ID)
+	}
+	return client.SetNetworkByID(ctx, selectedNetwork.ID)
+}
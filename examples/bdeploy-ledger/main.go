@@ -0,0 +1,275 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/brightdevelopers/gopurple"
+)
+
+func main() {
+	var (
+		helpFlag    = flag.Bool("help", false, "Display usage information")
+		timeoutFlag = flag.Int("timeout", 30, "Request timeout in seconds")
+		ledgerFlag  = flag.String("ledger", "", "Association ledger file (overrides BS_ASSOCIATION_LEDGER)")
+		verifyFlag  = flag.Bool("verify", false, "Check each player's setup in B-Deploy against the ledger")
+		allFlag     = flag.Bool("all", false, "With --verify, also show players that match")
+		jsonFlag    = flag.Bool("json", false, "Output as JSON")
+		networkFlag *string
+	)
+
+	// Set up network flags to point to the same variable
+	networkFlag = flag.String("network", "", "Network name to use (overrides BS_NETWORK)")
+	flag.StringVar(networkFlag, "n", "", "Network name to use (overrides BS_NETWORK) [alias for --network]")
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [options]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Show and audit the B-Deploy association ledger. B-Deploy never returns a device's\n")
+		fmt.Fprintf(os.Stderr, "setupId, so the SDK records every association made with UpdateDevice (including\n")
+		fmt.Fprintf(os.Stderr, "bdeploy-associate and bdeploy-reconcile) in a local ledger.\n\n")
+		fmt.Fprintf(os.Stderr, "Without --verify the ledger is listed and no credentials are needed. With --verify\n")
+		fmt.Fprintf(os.Stderr, "every device in the network is compared with the ledger using the setupName that\n")
+		fmt.Fprintf(os.Stderr, "B-Deploy does report:\n")
+		fmt.Fprintf(os.Stderr, "  mismatch        B-Deploy's setup differs from the ledger\n")
+		fmt.Fprintf(os.Stderr, "  unverified      B-Deploy reports no setup to compare\n")
+		fmt.Fprintf(os.Stderr, "  unrecorded      B-Deploy reports a setup the ledger does not know about\n")
+		fmt.Fprintf(os.Stderr, "  missing-device  the ledger has a device that B-Deploy does not\n")
+		fmt.Fprintf(os.Stderr, "  missing-setup   the ledger's setup record no longer exists\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		flag.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nEnvironment Variables:\n")
+		fmt.Fprintf(os.Stderr, "  BS_CLIENT_ID          BSN.cloud API client ID (required with --verify)\n")
+		fmt.Fprintf(os.Stderr, "  BS_SECRET            BSN.cloud API client secret (required with --verify)\n")
+		fmt.Fprintf(os.Stderr, "  BS_NETWORK           BSN.cloud network name (optional)\n")
+		fmt.Fprintf(os.Stderr, "  BS_ASSOCIATION_LEDGER  Association ledger file (optional)\n\n")
+		fmt.Fprintf(os.Stderr, "Exit Status:\n")
+		fmt.Fprintf(os.Stderr, "  0  success, and with --verify every player matches or is unverified\n")
+		fmt.Fprintf(os.Stderr, "  1  error, or --verify found a mismatch or missing device or setup\n\n")
+		fmt.Fprintf(os.Stderr, "Examples:\n")
+		fmt.Fprintf(os.Stderr, "  List every recorded association:\n")
+		fmt.Fprintf(os.Stderr, "    %s\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  Audit a network:\n")
+		fmt.Fprintf(os.Stderr, "    %s --verify --network Retail\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  Audit using a shared ledger file:\n")
+		fmt.Fprintf(os.Stderr, "    %s --verify --ledger /srv/bdeploy/associations.json --json\n", os.Args[0])
+	}
+
+	flag.Parse()
+
+	if *helpFlag {
+		flag.Usage()
+		return
+	}
+
+	if !*verifyFlag {
+		listLedger(ledgerPath(*ledgerFlag), *networkFlag, *jsonFlag)
+		return
+	}
+
+	var opts []gopurple.Option
+	if *timeoutFlag > 0 {
+		opts = append(opts, gopurple.WithTimeout(time.Duration(*timeoutFlag)*time.Second))
+	}
+	if *networkFlag != "" {
+		opts = append(opts, gopurple.WithNetwork(*networkFlag))
+	}
+	opts = append(opts, gopurple.WithAssociationLedgerFile(ledgerPath(*ledgerFlag)))
+
+	client, err := gopurple.New(opts...)
+	if err != nil {
+		if gopurple.IsConfigurationError(err) {
+			log.Fatalf("❌ Configuration error: %v", err)
+		}
+		log.Fatalf("❌ Failed to create client: %v", err)
+	}
+
+	ctx := context.Background()
+
+	if !*jsonFlag {
+		fmt.Fprintln(os.Stderr, "🔐 Authenticating with BSN.cloud...")
+	}
+	if err := client.Authenticate(ctx); err != nil {
+		log.Fatalf("❌ Authentication failed: %v", err)
+	}
+
+	if err := handleNetworkSelection(ctx, client, *networkFlag, *jsonFlag); err != nil {
+		log.Fatalf("❌ Network selection failed: %v", err)
+	}
+
+	current, err := client.GetCurrentNetwork(ctx)
+	if err != nil {
+		log.Fatalf("❌ Failed to get current network: %v", err)
+	}
+	if err := client.BDeploy.SetNetworkContext(ctx, current.Name); err != nil {
+		log.Fatalf("❌ Failed to set network context: %v", err)
+	}
+
+	if !*jsonFlag {
+		fmt.Fprintf(os.Stderr, "📋 Verifying associations in %s...\n\n", current.Name)
+	}
+	checks, err := gopurple.VerifyAssociations(ctx, client.BDeploy, client.AssociationLedger(), current.Name)
+	if err != nil {
+		log.Fatalf("❌ Failed to verify associations: %v", err)
+	}
+
+	problems := 0
+	counts := map[gopurple.AssociationStatus]int{}
+	for _, check := range checks {
+		counts[check.Status]++
+		switch check.Status {
+		case gopurple.AssociationMismatch, gopurple.AssociationMissingDevice, gopurple.AssociationMissingSetup:
+			problems++
+		}
+	}
+
+	if *jsonFlag {
+		if checks == nil {
+			checks = []gopurple.AssociationCheck{}
+		}
+		data, err := json.MarshalIndent(checks, "", "  ")
+		if err != nil {
+			log.Fatalf("❌ Failed to marshal JSON: %v", err)
+		}
+		fmt.Println(string(data))
+	} else {
+		fmt.Printf("%-16s %-15s %-28s %s\n", "SERIAL", "STATUS", "LEDGER SETUP", "B-DEPLOY SETUP")
+		for _, check := range checks {
+			if check.Status == gopurple.AssociationOK && !*allFlag {
+				continue
+			}
+			ledgerSetup := check.LedgerSetupName
+			if ledgerSetup == "" {
+				ledgerSetup = check.LedgerSetupID
+			}
+			fmt.Printf("%-16s %-15s %-28s %s\n", check.Serial, check.Status, ledgerSetup, check.SetupName)
+		}
+		fmt.Printf("\n%d ok, %d mismatch, %d unverified, %d unrecorded, %d missing device, %d missing setup\n",
+			counts[gopurple.AssociationOK], counts[gopurple.AssociationMismatch], counts[gopurple.AssociationUnverified],
+			counts[gopurple.AssociationUnrecorded], counts[gopurple.AssociationMissingDevice], counts[gopurple.AssociationMissingSetup])
+	}
+
+	if problems > 0 {
+		os.Exit(1)
+	}
+}
+
+// ledgerPath returns the ledger file from the flag, BS_ASSOCIATION_LEDGER or the default location.
+func ledgerPath(flagValue string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+	if envPath := os.Getenv("BS_ASSOCIATION_LEDGER"); envPath != "" {
+		return envPath
+	}
+	path, err := gopurple.DefaultAssociationLedgerPath()
+	if err != nil {
+		log.Fatalf("❌ No default ledger location, use --ledger: %v", err)
+	}
+	return path
+}
+
+// listLedger prints the ledger entries, optionally for one network.
+func listLedger(path, network string, jsonOutput bool) {
+	entries, err := gopurple.NewFileAssociationLedger(path).List(context.Background(), network)
+	if err != nil {
+		log.Fatalf("❌ Failed to read ledger: %v", err)
+	}
+
+	if jsonOutput {
+		data, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			log.Fatalf("❌ Failed to marshal JSON: %v", err)
+		}
+		fmt.Println(string(data))
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "Ledger: %s\n\n", path)
+	if len(entries) == 0 {
+		fmt.Println("No associations recorded")
+		return
+	}
+	fmt.Printf("%-20s %-16s %-26s %-24s %s\n", "NETWORK", "SERIAL", "SETUP ID", "SETUP NAME", "UPDATED")
+	for _, entry := range entries {
+		fmt.Printf("%-20s %-16s %-26s %-24s %s\n", entry.NetworkName, entry.Serial, entry.SetupID,
+			entry.SetupName, entry.UpdatedAt.Local().Format("2006-01-02 15:04"))
+	}
+	fmt.Printf("\n%d association(s)\n", len(entries))
+}
+func handleNetworkSelection(ctx context.Context, client *gopurple.Client, requestedNetwork string, jsonMode bool) error {
+	if client.IsNetworkSet() {
+		if current, err := client.GetCurrentNetwork(ctx); err == nil {
+			if !jsonMode {
+				fmt.Fprintf(os.Stderr, "Using network: %s (ID: %d)\n", current.Name, current.ID)
+			}
+			return nil
+		}
+	}
+
+	// If no network flag was provided, check BS_NETWORK environment variable
+	if requestedNetwork == "" {
+		if envNetwork := os.Getenv("BS_NETWORK"); envNetwork != "" {
+			requestedNetwork = envNetwork
+			if !jsonMode {
+				fmt.Fprintf(os.Stderr, "Using network from BS_NETWORK environment variable\n")
+			}
+		}
+	}
+
+	networks, err := client.GetNetworks(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get networks: %w", err)
+	}
+
+	if len(networks) == 0 {
+		return fmt.Errorf("no networks available")
+	}
+
+	if requestedNetwork != "" {
+		for _, network := range networks {
+			if strings.EqualFold(network.Name, requestedNetwork) {
+				if !jsonMode {
+					fmt.Fprintf(os.Stderr, "Using network: %s (ID: %d)\n", network.Name, network.ID)
+				}
+				return client.SetNetworkByID(ctx, network.ID)
+			}
+		}
+	}
+
+	if len(networks) == 1 {
+		if !jsonMode {
+			fmt.Fprintf(os.Stderr, "Using network: %s (ID: %d)\n", networks[0].Name, networks[0].ID)
+		}
+		return client.SetNetworkByID(ctx, networks[0].ID)
+	}
+
+	fmt.Fprintf(os.Stderr, "Available networks:\n")
+	for i, network := range networks {
+		fmt.Fprintf(os.Stderr, "  %d. %s (ID: %d)\n", i+1, network.Name, network.ID)
+	}
+
+	fmt.Fprint(os.Stderr, "Select network (1-"+strconv.Itoa(len(networks))+"): ")
+	scanner := bufio.NewScanner(os.Stdin)
+	if !scanner.Scan() {
+		return fmt.Errorf("failed to read input")
+	}
+
+	selection, err := strconv.Atoi(strings.TrimSpace(scanner.Text()))
+	if err != nil || selection < 1 || selection > len(networks) {
+		return fmt.Errorf("invalid selection")
+	}
+
+	selectedNetwork := networks[selection-1]
+	if !jsonMode {
+		fmt.Fprintf(os.Stderr, "Selected network: %s (ID: %d)\n", selectedNetwork.Name, selectedNetwork.ID)
+	}
+	return client.SetNetworkByID(ctx, selectedNetwork.ID)
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/brightdevelopers/gopurple"
+)
+
+func main() {
+	var (
+		helpFlag    = flag.Bool("help", false, "Display usage information")
+		timeoutFlag = flag.Int("timeout", 30, "Request timeout in seconds")
+		setupIDFlag = flag.String("setup-id", "", "Lint an existing setup record from B-Deploy instead of a file")
+		strictFlag  = flag.Bool("strict", false, "Exit with an error when there are warnings as well as errors")
+		jsonFlag    = flag.Bool("json", false, "Output issues as JSON")
+		networkFlag *string
+	)
+
+	// Set up network flags to point to the same variable
+	networkFlag = flag.String("network", "", "Network name to use with --setup-id (overrides BS_NETWORK)")
+	flag.StringVar(networkFlag, "n", "", "Network name to use with --setup-id (overrides BS_NETWORK) [alias for --network]")
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [options] [config.json]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Check a B-Deploy setup record for missing fields, out-of-range values and\n")
+		fmt.Fprintf(os.Stderr, "settings that contradict each other. Every issue is reported with its field path.\n\n")
+		fmt.Fprintf(os.Stderr, "Errors are problems that AddSetupRecord and UpdateSetupRecord refuse to send.\n")
+		fmt.Fprintf(os.Stderr, "Warnings are settings that have no effect as configured.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		flag.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nEnvironment Variables (only needed with --setup-id):\n")
+		fmt.Fprintf(os.Stderr, "  BS_CLIENT_ID        BSN.cloud API client ID\n")
+		fmt.Fprintf(os.Stderr, "  BS_SECRET          BSN.cloud API client secret\n")
+		fmt.Fprintf(os.Stderr, "  BS_NETWORK         BSN.cloud network name\n\n")
+		fmt.Fprintf(os.Stderr, "Exit Status:\n")
+		fmt.Fprintf(os.Stderr, "  0  no errors (and no warnings with --strict)\n")
+		fmt.Fprintf(os.Stderr, "  1  the record has errors, or could not be read\n\n")
+		fmt.Fprintf(os.Stderr, "Examples:\n")
+		fmt.Fprintf(os.Stderr, "  Lint a configuration file before creating it:\n")
+		fmt.Fprintf(os.Stderr, "    %s examples/bdeploy-add-setup/config.json\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  Lint an existing setup record:\n")
+		fmt.Fprintf(os.Stderr, "    %s --setup-id \"658f1dbef1d46c829f60a14f\" --network \"My Network\"\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  Fail a CI job on warnings too:\n")
+		fmt.Fprintf(os.Stderr, "    %s --strict --json config.json\n", os.Args[0])
+	}
+
+	flag.Parse()
+
+	if *helpFlag {
+		flag.Usage()
+		return
+	}
+
+	var record *gopurple.BDeploySetupRecord
+	var source string
+
+	switch {
+	case *setupIDFlag != "" && flag.NArg() > 0:
+		fmt.Fprintf(os.Stderr, "Error: cannot specify both --setup-id and a configuration file\n\n")
+		flag.Usage()
+		os.Exit(1)
+	case *setupIDFlag != "":
+		record = fetchSetupRecord(*setupIDFlag, *networkFlag, *timeoutFlag, *jsonFlag)
+		source = *setupIDFlag
+	case flag.NArg() == 1:
+		source = flag.Arg(0)
+		data, err := os.ReadFile(source)
+		if err != nil {
+			log.Fatalf("❌ Failed to read %s: %v", source, err)
+		}
+		record = &gopurple.BDeploySetupRecord{}
+		if err := gopurple.UnmarshalSetupRecord(data, record); err != nil {
+			log.Fatalf("❌ Failed to parse %s: %v", source, err)
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "Error: a configuration file or --setup-id is required\n\n")
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	issues := record.Lint()
+
+	errorCount, warningCount := 0, 0
+	for _, issue := range issues {
+		if issue.Severity == gopurple.SetupIssueError {
+			errorCount++
+		} else {
+			warningCount++
+		}
+	}
+
+	if *jsonFlag {
+		output := struct {
+			Source   string                `json:"source"`
+			Errors   int                   `json:"errors"`
+			Warnings int                   `json:"warnings"`
+			Issues   []gopurple.SetupIssue `json:"issues"`
+		}{source, errorCount, warningCount, issues}
+		if output.Issues == nil {
+			output.Issues = []gopurple.SetupIssue{}
+		}
+		jsonOutput, err := json.MarshalIndent(output, "", "  ")
+		if err != nil {
+			log.Fatalf("❌ Failed to marshal JSON: %v", err)
+		}
+		fmt.Println(string(jsonOutput))
+	} else {
+		for _, issue := range issues {
+			icon := "❌"
+			if issue.Severity == gopurple.SetupIssueWarning {
+				icon = "⚠️ "
+			}
+			fmt.Printf("%s %s: %s\n", icon, issue.Field, issue.Message)
+		}
+		if len(issues) == 0 {
+			fmt.Printf("✅ %s: no issues found\n", source)
+		} else {
+			fmt.Printf("\n%s: %d error(s), %d warning(s)\n", source, errorCount, warningCount)
+		}
+	}
+
+	if errorCount > 0 || (*strictFlag && warningCount > 0) {
+		os.Exit(1)
+	}
+}
+
+// fetchSetupRecord retrieves an existing setup record from B-Deploy.
+func fetchSetupRecord(setupID, network string, timeout int, jsonOutput bool) *gopurple.BDeploySetupRecord {
+	var opts []gopurple.Option
+	if timeout > 0 {
+		opts = append(opts, gopurple.WithTimeout(time.Duration(timeout)*time.Second))
+	}
+	if network != "" {
+		opts = append(opts, gopurple.WithNetwork(network))
+	}
+
+	client, err := gopurple.New(opts...)
+	if err != nil {
+		if gopurple.IsConfigurationError(err) {
+			log.Fatalf("❌ Configuration error: %v", err)
+		}
+		log.Fatalf("❌ Failed to create client: %v", err)
+	}
+
+	ctx := context.Background()
+
+	if !jsonOutput {
+		fmt.Fprintln(os.Stderr, "🔐 Authenticating with BSN.cloud...")
+	}
+	if err := client.Authenticate(ctx); err != nil {
+		log.Fatalf("❌ Authentication failed: %v", err)
+	}
+
+	if err := handleNetworkSelection(ctx, client, network, jsonOutput); err != nil {
+		log.Fatalf("❌ Network selection failed: %v", err)
+	}
+
+	current, err := client.GetCurrentNetwork(ctx)
+	if err != nil {
+		log.Fatalf("❌ Failed to get current network: %v", err)
+	}
+	if err := client.BDeploy.SetNetworkContext(ctx, current.Name); err != nil {
+		log.Fatalf("❌ Failed to set network context: %v", err)
+	}
+
+	if !jsonOutput {
+		fmt.Fprintf(os.Stderr, "📋 Fetching B-Deploy setup record: %s\n\n", setupID)
+	}
+	record, err := client.BDeploy.GetSetupRecord(ctx, setupID)
+	if err != nil {
+		log.Fatalf("❌ Failed to get B-Deploy setup record: %v", err)
+	}
+	return record
+}
+func handleNetworkSelection(ctx context.Context, client *gopurple.Client, requestedNetwork string, jsonMode bool) error {
+	if client.IsNetworkSet() {
+		if current, err := client.GetCurrentNetwork(ctx); err == nil {
+			if !jsonMode {
+				fmt.Fprintf(os.Stderr, "Using network: %s (ID: %d)\n", current.Name, current.ID)
+			}
+			return nil
+		}
+	}
+
+	// If no network flag was provided, check BS_NETWORK environment variable
+	if requestedNetwork == "" {
+		if envNetwork := os.Getenv("BS_NETWORK"); envNetwork != "" {
+			requestedNetwork = envNetwork
+			if !jsonMode {
+				fmt.Fprintf(os.Stderr, "Using network from BS_NETWORK environment variable\n")
+			}
+		}
+	}
+
+	networks, err := client.GetNetworks(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get networks: %w", err)
+	}
+
+	if len(networks) == 0 {
+		return fmt.Errorf("no networks available")
+	}
+
+	if requestedNetwork != "" {
+		for _, network := range networks {
+			if strings.EqualFold(network.Name, requestedNetwork) {
+				if !jsonMode {
+					fmt.Fprintf(os.Stderr, "Using network: %s (ID: %d)\n", network.Name, network.ID)
+				}
+				return client.SetNetworkByID(ctx, network.ID)
+			}
+		}
+	}
+
+	if len(networks) == 1 {
+		if !jsonMode {
+			fmt.Fprintf(os.Stderr, "Using network: %s (ID: %d)\n", networks[0].Name, networks[0].ID)
+		}
+		return client.SetNetworkByID(ctx, networks[0].ID)
+	}
+
+	fmt.Fprintf(os.Stderr, "Available networks:\n")
+	for i, network := range networks {
+		fmt.Fprintf(os.Stderr, "  %d. %s (ID: %d)\n", i+1, network.Name, network.ID)
+	}
+
+	fmt.Fprint(os.Stderr, "Select network (1-"+strconv.Itoa(len(networks))+"): ")
+	scanner := bufio.NewScanner(os.Stdin)
+	if !scanner.Scan() {
+		return fmt.Errorf("failed to read input")
+	}
+
+	selection, err := strconv.Atoi(strings.TrimSpace(scanner.Text()))
+	if err != nil || selection < 1 || selection > len(networks) {
+		return fmt.Errorf("invalid selection")
+	}
+
+	selectedNetwork := networks[selection-1]
+	if !jsonMode {
+		fmt.Fprintf(os.Stderr, "Selected network: %s (ID: %d)\n", selectedNetwork.Name, selectedNetwork.ID)
+	}
+	return client.SetNetworkByID(ctx, selectedNetwork.ID)
+}
@@ -0,0 +1,346 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/brightdevelopers/gopurple"
+)
+
+func main() {
+	var (
+		helpFlag      = flag.Bool("help", false, "Display usage information")
+		timeoutFlag   = flag.Int("timeout", 30, "Request timeout in seconds")
+		listFlag      = flag.Bool("list", false, "List the network's v2 setup records and exit")
+		showFlag      = flag.String("show", "", "Print a v2 setup record and its v3 conversion, by ID, and exit")
+		packageFlag   = flag.String("package", "", "Only migrate these packages (comma-separated)")
+		usernameFlag  = flag.String("username", "", "Only migrate records created by this BSN.cloud user")
+		overwriteFlag = flag.Bool("overwrite", false, "Update v3 records with the same package name instead of skipping them")
+		deleteFlag    = flag.Bool("delete-v2", false, "Delete each v2 record once its v3 copy is saved")
+		dryRunFlag    = flag.Bool("dry-run", false, "Convert and validate without writing")
+		jsonFlag      = flag.Bool("json", false, "Output results as JSON")
+		networkFlag   *string
+		confirmFlag   *bool
+	)
+
+	// Set up network flags to point to the same variable
+	networkFlag = flag.String("network", "", "Network name to use (overrides BS_NETWORK)")
+	flag.StringVar(networkFlag, "n", "", "Network name to use (overrides BS_NETWORK) [alias for --network]")
+
+	// Set up confirm flags to point to the same variable
+	confirmFlag = flag.Bool("y", false, "Skip confirmation prompt")
+	flag.BoolVar(confirmFlag, "force", false, "Skip confirmation prompt [alias for -y]")
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [options]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Move legacy B-Deploy v2 setup records to the v3 setup API. Each record is\n")
+		fmt.Fprintf(os.Stderr, "converted to the v3 layout (networking settings are mapped into the nested\n")
+		fmt.Fprintf(os.Stderr, "network object), validated, and saved as a v3 record with the same package name.\n")
+		fmt.Fprintf(os.Stderr, "v2 records are kept unless --delete-v2 is given.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		flag.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nEnvironment Variables:\n")
+		fmt.Fprintf(os.Stderr, "  BS_CLIENT_ID        BSN.cloud API client ID (required)\n")
+		fmt.Fprintf(os.Stderr, "  BS_SECRET          BSN.cloud API client secret (required)\n")
+		fmt.Fprintf(os.Stderr, "  BS_NETWORK         BSN.cloud network name (optional)\n\n")
+		fmt.Fprintf(os.Stderr, "Exit Status:\n")
+		fmt.Fprintf(os.Stderr, "  0  Every selected record was migrated, skipped, or would be (--dry-run)\n")
+		fmt.Fprintf(os.Stderr, "  1  At least one record could not be converted or saved\n\n")
+		fmt.Fprintf(os.Stderr, "Examples:\n")
+		fmt.Fprintf(os.Stderr, "  List v2 setups:\n")
+		fmt.Fprintf(os.Stderr, "    %s --list --network Retail\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  Inspect one conversion:\n")
+		fmt.Fprintf(os.Stderr, "    %s --show 5f1e2d3c4b5a --network Retail\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  Preview a migration:\n")
+		fmt.Fprintf(os.Stderr, "    %s --dry-run --network Retail\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  Migrate two packages and remove their v2 records:\n")
+		fmt.Fprintf(os.Stderr, "    %s --package store-0001,store-0002 --delete-v2 --network Retail\n", os.Args[0])
+	}
+
+	flag.Parse()
+
+	if *helpFlag {
+		flag.Usage()
+		return
+	}
+
+	var opts []gopurple.Option
+	if *timeoutFlag > 0 {
+		opts = append(opts, gopurple.WithTimeout(time.Duration(*timeoutFlag)*time.Second))
+	}
+	if *networkFlag != "" {
+		opts = append(opts, gopurple.WithNetwork(*networkFlag))
+	}
+
+	client, err := gopurple.New(opts...)
+	if err != nil {
+		if gopurple.IsConfigurationError(err) {
+			log.Fatalf("❌ Configuration error: %v", err)
+		}
+		log.Fatalf("❌ Failed to create client: %v", err)
+	}
+
+	ctx := context.Background()
+
+	if !*jsonFlag {
+		fmt.Fprintln(os.Stderr, "🔐 Authenticating with BSN.cloud...")
+	}
+	if err := client.Authenticate(ctx); err != nil {
+		log.Fatalf("❌ Authentication failed: %v", err)
+	}
+
+	if err := handleNetworkSelection(ctx, client, *networkFlag, *jsonFlag); err != nil {
+		log.Fatalf("❌ Network selection failed: %v", err)
+	}
+
+	current, err := client.GetCurrentNetwork(ctx)
+	if err != nil {
+		log.Fatalf("❌ Failed to get current network: %v", err)
+	}
+
+	if *showFlag != "" {
+		showConversion(ctx, client, *showFlag)
+		return
+	}
+
+	if *listFlag {
+		listV2Setups(ctx, client, current.Name, *usernameFlag, *jsonFlag)
+		return
+	}
+
+	migrateOpts := gopurple.SetupMigrateOptions{
+		NetworkName: current.Name,
+		Username:    *usernameFlag,
+		Overwrite:   *overwriteFlag,
+		DeleteV2:    *deleteFlag,
+		DryRun:      true,
+	}
+	for _, name := range strings.Split(*packageFlag, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			migrateOpts.PackageNames = append(migrateOpts.PackageNames, name)
+		}
+	}
+
+	// Convert and validate everything first
+	results, err := gopurple.MigrateSetups(ctx, client.BDeploy, migrateOpts)
+	if err != nil {
+		log.Fatalf("❌ Failed to plan migration: %v", err)
+	}
+
+	changes := 0
+	for _, result := range results {
+		if result.Error == "" && result.Action != gopurple.SetupActionSkip {
+			changes++
+		}
+	}
+
+	if !*dryRunFlag && changes > 0 {
+		if !*confirmFlag {
+			printResults(results, false)
+			fmt.Printf("\nThis will write %d v3 setup record(s)", changes)
+			if *deleteFlag {
+				fmt.Print(" and delete the v2 originals")
+			}
+			fmt.Println(".")
+			fmt.Print("Proceed? (yes/no): ")
+
+			scanner := bufio.NewScanner(os.Stdin)
+			if !scanner.Scan() {
+				log.Fatalf("Failed to read confirmation")
+			}
+
+			confirmation := strings.ToLower(strings.TrimSpace(scanner.Text()))
+			if confirmation != "yes" && confirmation != "y" {
+				fmt.Println("\nOperation cancelled.")
+				os.Exit(0)
+			}
+			fmt.Println()
+		}
+
+		migrateOpts.DryRun = false
+		results, err = gopurple.MigrateSetups(ctx, client.BDeploy, migrateOpts)
+		if err != nil {
+			log.Fatalf("❌ Migration failed: %v", err)
+		}
+	}
+
+	printResults(results, *jsonFlag)
+
+	for _, result := range results {
+		if result.Error != "" {
+			os.Exit(1)
+		}
+	}
+}
+
+// listV2Setups prints the network's v2 setup records.
+func listV2Setups(ctx context.Context, client *gopurple.Client, networkName, username string, jsonOutput bool) {
+	listOpts := []gopurple.BDeployListOption{gopurple.WithNetworkName(networkName)}
+	if username != "" {
+		listOpts = append(listOpts, gopurple.WithUsername(username))
+	}
+	records, err := client.BDeploy.GetSetupRecordsV2(ctx, listOpts...)
+	if err != nil {
+		log.Fatalf("❌ Failed to list v2 setup records: %v", err)
+	}
+
+	if jsonOutput {
+		data, err := json.MarshalIndent(records, "", "  ")
+		if err != nil {
+			log.Fatalf("❌ Failed to marshal JSON: %v", err)
+		}
+		fmt.Println(string(data))
+		return
+	}
+
+	if len(records.Items) == 0 {
+		fmt.Println("No v2 setup records found")
+		return
+	}
+	fmt.Printf("%-26s %-28s %-12s %s\n", "ID", "PACKAGE", "TYPE", "USERNAME")
+	for _, record := range records.Items {
+		fmt.Printf("%-26s %-28s %-12s %s\n", record.ID, record.PackageName, record.SetupType, record.Username)
+	}
+	fmt.Printf("\n%d v2 setup record(s)\n", records.TotalCount)
+}
+
+// showConversion prints a v2 record next to its v3 conversion as JSON, with
+// passwords and tokens masked.
+func showConversion(ctx context.Context, client *gopurple.Client, setupID string) {
+	record, err := client.BDeploy.GetSetupRecordV2(ctx, setupID)
+	if err != nil {
+		log.Fatalf("❌ Failed to get v2 setup record: %v", err)
+	}
+	upgraded, err := record.ToV3()
+	if err != nil {
+		log.Fatalf("❌ Failed to convert setup record: %v", err)
+	}
+
+	output := map[string]interface{}{"v2": record.Redacted(), "v3": upgraded.Redacted()}
+	if err := upgraded.Validate(); err != nil {
+		output["validationError"] = err.Error()
+	}
+	data, err := json.MarshalIndent(output, "", "  ")
+	if err != nil {
+		log.Fatalf("❌ Failed to marshal JSON: %v", err)
+	}
+	fmt.Println(string(data))
+}
+
+// printResults prints one line per record and a summary, or the results as JSON.
+func printResults(results []gopurple.SetupMigrationResult, jsonOutput bool) {
+	if jsonOutput {
+		data, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			log.Fatalf("❌ Failed to marshal JSON: %v", err)
+		}
+		fmt.Println(string(data))
+		return
+	}
+
+	if len(results) == 0 {
+		fmt.Println("No v2 setup records to migrate")
+		return
+	}
+
+	counts := map[gopurple.SetupAction]int{}
+	failed := 0
+	fmt.Printf("%-28s %-26s %-8s %s\n", "PACKAGE", "V2 ID", "ACTION", "STATUS")
+	for _, result := range results {
+		status := "planned"
+		switch {
+		case result.Error != "":
+			status = "❌ " + result.Error
+			failed++
+		case result.DeletedV2:
+			status = "✅ migrated, v2 deleted"
+		case result.Applied:
+			status = "✅ migrated"
+		case result.Note != "":
+			status = result.Note
+		}
+		if result.Error == "" {
+			counts[result.Action]++
+		}
+		fmt.Printf("%-28s %-26s %-8s %s\n", result.PackageName, result.V2ID, result.Action, status)
+	}
+	fmt.Printf("\n%d record(s): %d create, %d update, %d skipped, %d failed\n", len(results),
+		counts[gopurple.SetupActionCreate], counts[gopurple.SetupActionUpdate], counts[gopurple.SetupActionSkip], failed)
+}
+func handleNetworkSelection(ctx context.Context, client *gopurple.Client, requestedNetwork string, jsonMode bool) error {
+	if client.IsNetworkSet() {
+		if current, err := client.GetCurrentNetwork(ctx); err == nil {
+			if !jsonMode {
+				fmt.Fprintf(os.Stderr, "Using network: %s (ID: %d)\n", current.Name, current.ID)
+			}
+			return nil
+		}
+	}
+
+	// If no network flag was provided, check BS_NETWORK environment variable
+	if requestedNetwork == "" {
+		if envNetwork := os.Getenv("BS_NETWORK"); envNetwork != "" {
+			requestedNetwork = envNetwork
+			if !jsonMode {
+				fmt.Fprintf(os.Stderr, "Using network from BS_NETWORK environment variable\n")
+			}
+		}
+	}
+
+	networks, err := client.GetNetworks(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get networks: %w", err)
+	}
+
+	if len(networks) == 0 {
+		return fmt.Errorf("no networks available")
+	}
+
+	if requestedNetwork != "" {
+		for _, network := range networks {
+			if strings.EqualFold(network.Name, requestedNetwork) {
+				if !jsonMode {
+					fmt.Fprintf(os.Stderr, "Using network: %s (ID: %d)\n", network.Name, network.ID)
+				}
+				return client.SetNetworkByID(ctx, network.ID)
+			}
+		}
+	}
+
+	if len(networks) == 1 {
+		if !jsonMode {
+			fmt.Fprintf(os.Stderr, "Using network: %s (ID: %d)\n", networks[0].Name, networks[0].ID)
+		}
+		return client.SetNetworkByID(ctx, networks[0].ID)
+	}
+
+	fmt.Fprintf(os.Stderr, "Available networks:\n")
+	for i, network := range networks {
+		fmt.Fprintf(os.Stderr, "  %d. %s (ID: %d)\n", i+1, network.Name, network.ID)
+	}
+
+	fmt.Fprint(os.Stderr, "Select network (1-"+strconv.Itoa(len(networks))+"): ")
+	scanner := bufio.NewScanner(os.Stdin)
+	if !scanner.Scan() {
+		return fmt.Errorf("failed to read input")
+	}
+
+	selection, err := strconv.Atoi(strings.TrimSpace(scanner.Text()))
+	if err != nil || selection < 1 || selection > len(networks) {
+		return fmt.Errorf("invalid selection")
+	}
+
+	selectedNetwork := networks[selection-1]
+	if !jsonMode {
+		fmt.Fprintf(os.Stderr, "Selected network: %s (ID: %d)\n", selectedNetwork.Name, selectedNetwork.ID)
+	}
+	return client.SetNetworkByID(ctx, selectedNetwork.ID)
+}
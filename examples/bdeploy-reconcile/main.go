@@ -0,0 +1,279 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/brightdevelopers/gopurple"
+)
+
+func main() {
+	var (
+		helpFlag     = flag.Bool("help", false, "Display usage information")
+		timeoutFlag  = flag.Int("timeout", 30, "Request timeout in seconds")
+		dirFlag      = flag.String("dir", "", "Directory of desired setup records (.json or .yaml, one per file)")
+		manifestFlag = flag.String("manifest", "", "CSV of serial,package[,name,description] player associations")
+		pruneFlag    = flag.Bool("prune", false, "Delete setup records in the network that are not in --dir")
+		planFlag     = flag.Bool("plan", false, "Show the plan without applying it")
+		jsonFlag     = flag.Bool("json", false, "Output the plan and results as JSON")
+		networkFlag  *string
+		confirmFlag  *bool
+	)
+
+	// Set up network flags to point to the same variable
+	networkFlag = flag.String("network", "", "Network name to use (overrides BS_NETWORK)")
+	flag.StringVar(networkFlag, "n", "", "Network name to use (overrides BS_NETWORK) [alias for --network]")
+
+	// Set up confirm flags to point to the same variable
+	confirmFlag = flag.Bool("y", false, "Skip confirmation prompt")
+	flag.BoolVar(confirmFlag, "force", false, "Skip confirmation prompt [alias for -y]")
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s --dir <records-dir> [--manifest players.csv] [options]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Bring a network's B-Deploy setup records and player associations in line with\n")
+		fmt.Fprintf(os.Stderr, "files on disk. Records are matched by package name; the plan lists records to\n")
+		fmt.Fprintf(os.Stderr, "create, update (with each changed value), delete (--prune only) and players to\n")
+		fmt.Fprintf(os.Stderr, "associate, and is confirmed before anything is changed.\n\n")
+		fmt.Fprintf(os.Stderr, "Records may use secret references such as \"passphrase\": \"env:WIFI_PASSPHRASE\"\n")
+		fmt.Fprintf(os.Stderr, "instead of passwords. They are resolved to compare with the server and when sent.\n\n")
+		fmt.Fprintf(os.Stderr, "Plan symbols:\n")
+		fmt.Fprintf(os.Stderr, "  +  create     ~  update     -  delete\n")
+		fmt.Fprintf(os.Stderr, "  *  associate  ?  unmanaged record (kept without --prune)\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		flag.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nEnvironment Variables:\n")
+		fmt.Fprintf(os.Stderr, "  BS_CLIENT_ID        BSN.cloud API client ID (required)\n")
+		fmt.Fprintf(os.Stderr, "  BS_SECRET          BSN.cloud API client secret (required)\n")
+		fmt.Fprintf(os.Stderr, "  BS_NETWORK         BSN.cloud network name (optional)\n")
+		fmt.Fprintf(os.Stderr, "  BS_SECRET_EXEC     Set to 1 to allow exec: secret references\n")
+		fmt.Fprintf(os.Stderr, "  BS_AGE_IDENTITY    age identity file for age: secret references\n\n")
+		fmt.Fprintf(os.Stderr, "Exit Status:\n")
+		fmt.Fprintf(os.Stderr, "  0  no changes, or all changes applied\n")
+		fmt.Fprintf(os.Stderr, "  1  error, or a change failed\n")
+		fmt.Fprintf(os.Stderr, "  2  --plan found changes (drift)\n\n")
+		fmt.Fprintf(os.Stderr, "Examples:\n")
+		fmt.Fprintf(os.Stderr, "  Check for drift:\n")
+		fmt.Fprintf(os.Stderr, "    %s --dir desired --manifest players.csv --plan\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  Apply, deleting records that are not in the directory:\n")
+		fmt.Fprintf(os.Stderr, "    %s --dir desired --manifest players.csv --prune --network Retail\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  Apply from CI without prompting:\n")
+		fmt.Fprintf(os.Stderr, "    %s --dir desired --manifest players.csv -y --json\n", os.Args[0])
+	}
+
+	flag.Parse()
+
+	if *helpFlag {
+		flag.Usage()
+		return
+	}
+
+	if *dirFlag == "" {
+		fmt.Fprintf(os.Stderr, "Error: --dir is required\n\n")
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	desired, err := gopurple.LoadSetupDesiredState(*dirFlag, *manifestFlag)
+	if err != nil {
+		log.Fatalf("❌ Failed to load desired state: %v", err)
+	}
+
+	// Setup files may hold secret references such as env:WIFI_PASSPHRASE
+	opts := []gopurple.Option{gopurple.WithSecretReferences(true)}
+	if *timeoutFlag > 0 {
+		opts = append(opts, gopurple.WithTimeout(time.Duration(*timeoutFlag)*time.Second))
+	}
+	if *networkFlag != "" {
+		opts = append(opts, gopurple.WithNetwork(*networkFlag))
+	}
+
+	client, err := gopurple.New(opts...)
+	if err != nil {
+		if gopurple.IsConfigurationError(err) {
+			log.Fatalf("❌ Configuration error: %v", err)
+		}
+		log.Fatalf("❌ Failed to create client: %v", err)
+	}
+
+	ctx := context.Background()
+
+	if !*jsonFlag {
+		fmt.Fprintln(os.Stderr, "🔐 Authenticating with BSN.cloud...")
+	}
+	if err := client.Authenticate(ctx); err != nil {
+		log.Fatalf("❌ Authentication failed: %v", err)
+	}
+
+	if err := handleNetworkSelection(ctx, client, *networkFlag, *jsonFlag); err != nil {
+		log.Fatalf("❌ Network selection failed: %v", err)
+	}
+
+	current, err := client.GetCurrentNetwork(ctx)
+	if err != nil {
+		log.Fatalf("❌ Failed to get current network: %v", err)
+	}
+	if err := client.BDeploy.SetNetworkContext(ctx, current.Name); err != nil {
+		log.Fatalf("❌ Failed to set network context: %v", err)
+	}
+
+	if !*jsonFlag {
+		fmt.Fprintf(os.Stderr, "📋 Comparing %d setup record(s) and %d player(s) with %s...\n\n",
+			len(desired.Records), len(desired.Associations), current.Name)
+	}
+	plan, err := gopurple.PlanSetupReconcile(ctx, client.BDeploy, desired, gopurple.ReconcileOptions{
+		NetworkName: current.Name,
+		Prune:       *pruneFlag,
+		Secrets:     client.SecretResolver(),
+	})
+	if err != nil {
+		log.Fatalf("❌ Failed to plan: %v", err)
+	}
+
+	if *planFlag || len(plan.Changes) == 0 {
+		printPlan(plan, *jsonFlag)
+		if len(plan.Changes) > 0 {
+			os.Exit(2)
+		}
+		return
+	}
+
+	if !*confirmFlag {
+		printPlan(plan, false)
+		fmt.Print("\nProceed? (yes/no): ")
+
+		scanner := bufio.NewScanner(os.Stdin)
+		if !scanner.Scan() {
+			log.Fatalf("Failed to read confirmation")
+		}
+
+		confirmation := strings.ToLower(strings.TrimSpace(scanner.Text()))
+		if confirmation != "yes" && confirmation != "y" {
+			fmt.Println("\nOperation cancelled.")
+			os.Exit(0)
+		}
+		fmt.Println()
+	}
+
+	if err := gopurple.ApplySetupPlan(ctx, client.BDeploy, plan); err != nil {
+		log.Fatalf("❌ Failed to apply plan: %v", err)
+	}
+
+	failed := 0
+	for _, change := range plan.Changes {
+		if change.Error != "" {
+			failed++
+		}
+	}
+
+	if *jsonFlag {
+		printPlan(plan, true)
+	} else {
+		for _, change := range plan.Changes {
+			target := change.PackageName
+			if change.Action == gopurple.SetupActionAssociate {
+				target = change.Serial + " -> " + change.PackageName
+			}
+			if change.Error != "" {
+				fmt.Printf("❌ %-9s %s: %s\n", change.Action, target, change.Error)
+			} else {
+				fmt.Printf("✅ %-9s %s\n", change.Action, target)
+			}
+		}
+		fmt.Printf("\n%d of %d change(s) applied\n", len(plan.Changes)-failed, len(plan.Changes))
+	}
+
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// printPlan prints the plan in terraform style, or as JSON.
+func printPlan(plan *gopurple.ReconcilePlan, jsonOutput bool) {
+	if jsonOutput {
+		data, err := json.MarshalIndent(plan, "", "  ")
+		if err != nil {
+			log.Fatalf("❌ Failed to marshal JSON: %v", err)
+		}
+		fmt.Println(string(data))
+		return
+	}
+	fmt.Printf("B-Deploy network %s:\n\n", plan.NetworkName)
+	fmt.Print(plan.String())
+}
+func handleNetworkSelection(ctx context.Context, client *gopurple.Client, requestedNetwork string, jsonMode bool) error {
+	if client.IsNetworkSet() {
+		if current, err := client.GetCurrentNetwork(ctx); err == nil {
+			if !jsonMode {
+				fmt.Fprintf(os.Stderr, "Using network: %s (ID: %d)\n", current.Name, current.ID)
+			}
+			return nil
+		}
+	}
+
+	// If no network flag was provided, check BS_NETWORK environment variable
+	if requestedNetwork == "" {
+		if envNetwork := os.Getenv("BS_NETWORK"); envNetwork != "" {
+			requestedNetwork = envNetwork
+			if !jsonMode {
+				fmt.Fprintf(os.Stderr, "Using network from BS_NETWORK environment variable\n")
+			}
+		}
+	}
+
+	networks, err := client.GetNetworks(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get networks: %w", err)
+	}
+
+	if len(networks) == 0 {
+		return fmt.Errorf("no networks available")
+	}
+
+	if requestedNetwork != "" {
+		for _, network := range networks {
+			if strings.EqualFold(network.Name, requestedNetwork) {
+				if !jsonMode {
+					fmt.Fprintf(os.Stderr, "Using network: %s (ID: %d)\n", network.Name, network.ID)
+				}
+				return client.SetNetworkByID(ctx, network.ID)
+			}
+		}
+	}
+
+	if len(networks) == 1 {
+		if !jsonMode {
+			fmt.Fprintf(os.Stderr, "Using network: %s (ID: %d)\n", networks[0].Name, networks[0].ID)
+		}
+		return client.SetNetworkByID(ctx, networks[0].ID)
+	}
+
+	fmt.Fprintf(os.Stderr, "Available networks:\n")
+	for i, network := range networks {
+		fmt.Fprintf(os.Stderr, "  %d. %s (ID: %d)\n", i+1, network.Name, network.ID)
+	}
+
+	fmt.Fprint(os.Stderr, "Select network (1-"+strconv.Itoa(len(networks))+"): ")
+	scanner := bufio.NewScanner(os.Stdin)
+	if !scanner.Scan() {
+		return fmt.Errorf("failed to read input")
+	}
+
+	selection, err := strconv.Atoi(strings.TrimSpace(scanner.Text()))
+	if err != nil || selection < 1 || selection > len(networks) {
+		return fmt.Errorf("invalid selection")
+	}
+
+	selectedNetwork := networks[selection-1]
+	if !jsonMode {
+		fmt.Fprintf(os.Stderr, "Selected network: %s (ID: %d)\n", selectedNetwork.Name, selectedNetwork.ID)
+	}
+	return client.SetNetworkByID(ctx, selectedNetwork.ID)
+}
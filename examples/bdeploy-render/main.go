@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/brightdevelopers/gopurple"
+)
+
+// listFlag collects a flag that may be given more than once.
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+func main() {
+	var (
+		helpFlag     = flag.Bool("help", false, "Display usage information")
+		templateFlag = flag.String("template", "", "Base setup record template (.json or .yaml)")
+		sitesFlag    = flag.String("sites", "", "Sites CSV file: one record is rendered per row")
+		siteFlag     = flag.String("site", "", "Only render this site from --sites")
+		outDirFlag   = flag.String("out-dir", "", "Write each record to <out-dir>/<site>.json instead of stdout")
+		varsFlag     = flag.Bool("list-vars", false, "List the variables the template uses and exit")
+		overlays     listFlag
+		vars         listFlag
+	)
+	flag.Var(&overlays, "overlay", "Overlay applied to every record (.json or .yaml, repeatable)")
+	flag.Var(&vars, "var", "Template variable as name=value (repeatable, overrides CSV columns)")
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s --template <file> [options]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Render concrete B-Deploy setup records from a template. The template and overlays\n")
+		fmt.Fprintf(os.Stderr, "may reference variables as ${name} or ${name:-default} in any string value; values\n")
+		fmt.Fprintf(os.Stderr, "come from --var or from the columns of a sites CSV. Overlays are merged onto the\n")
+		fmt.Fprintf(os.Stderr, "template in order, then the site's own overlay from the CSV \"overlay\" column.\n\n")
+		fmt.Fprintf(os.Stderr, "Every rendered record is validated; nothing is written if any site fails.\n")
+		fmt.Fprintf(os.Stderr, "No BSN.cloud credentials are needed. Use bdeploy-apply to create the records.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		flag.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nExamples:\n")
+		fmt.Fprintf(os.Stderr, "  List the variables a template needs:\n")
+		fmt.Fprintf(os.Stderr, "    %s --template store-template.yaml --list-vars\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  Render one record:\n")
+		fmt.Fprintf(os.Stderr, "    %s --template store-template.yaml --var site=0042 --var bsn_user=admin@example.com \\\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "      --var ip=10.1.42.20 --var gateway=10.1.42.1\n")
+		fmt.Fprintf(os.Stderr, "  Render every store into a directory:\n")
+		fmt.Fprintf(os.Stderr, "    %s --template store-template.yaml --sites sites.csv --out-dir rendered\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  Render a single store with the Wi-Fi overlay:\n")
+		fmt.Fprintf(os.Stderr, "    %s --template store-template.yaml --overlay wifi-overlay.yaml --sites sites.csv --site 0001\n", os.Args[0])
+	}
+
+	flag.Parse()
+
+	if *helpFlag {
+		flag.Usage()
+		return
+	}
+
+	if *templateFlag == "" {
+		fmt.Fprintf(os.Stderr, "Error: --template is required\n\n")
+		flag.Usage()
+		os.Exit(1)
+	}
+	if *siteFlag != "" && *sitesFlag == "" {
+		fmt.Fprintf(os.Stderr, "Error: --site requires --sites\n\n")
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	template, err := gopurple.LoadSetupTemplate(*templateFlag, overlays...)
+	if err != nil {
+		log.Fatalf("❌ Failed to load template: %v", err)
+	}
+
+	if *varsFlag {
+		for _, name := range template.Variables() {
+			fmt.Println(name)
+		}
+		return
+	}
+
+	cliVars := map[string]string{}
+	for _, v := range vars {
+		name, value, ok := strings.Cut(v, "=")
+		if !ok || name == "" {
+			log.Fatalf("❌ Invalid --var %q: expected name=value", v)
+		}
+		cliVars[name] = value
+	}
+
+	sites := []gopurple.SetupSite{{Name: cliVars["site"], Vars: map[string]string{}}}
+	if *sitesFlag != "" {
+		sites, err = gopurple.LoadSetupSites(*sitesFlag)
+		if err != nil {
+			log.Fatalf("❌ Failed to load sites: %v", err)
+		}
+		if *siteFlag != "" {
+			sites = selectSite(sites, *siteFlag)
+		}
+	}
+
+	// Render and validate everything before writing anything
+	records := make([]*gopurple.BDeploySetupRecord, len(sites))
+	failed := 0
+	for i, site := range sites {
+		for name, value := range cliVars {
+			site.Vars[name] = value
+		}
+		record, err := template.RenderSite(site)
+		if err == nil {
+			err = record.Validate()
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %s: %v\n", siteLabel(site), err)
+			failed++
+			continue
+		}
+		records[i] = record
+	}
+	if failed > 0 {
+		log.Fatalf("❌ %d of %d site(s) failed to render", failed, len(sites))
+	}
+
+	if *outDirFlag != "" {
+		if err := os.MkdirAll(*outDirFlag, 0755); err != nil {
+			log.Fatalf("❌ Failed to create %s: %v", *outDirFlag, err)
+		}
+	}
+
+	for i, record := range records {
+		// Encoded with the SDK so explicit false and 0 values are kept
+		data, err := gopurple.MarshalSetupRecord(record)
+		if err == nil {
+			var indented bytes.Buffer
+			err = json.Indent(&indented, data, "", "  ")
+			data = indented.Bytes()
+		}
+		if err != nil {
+			log.Fatalf("❌ Failed to marshal JSON: %v", err)
+		}
+
+		if *outDirFlag == "" {
+			fmt.Println(string(data))
+			continue
+		}
+
+		name := sites[i].Name
+		if name == "" {
+			name = record.BDeploy.PackageName
+		}
+		path := filepath.Join(*outDirFlag, name+".json")
+		if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+			log.Fatalf("❌ Failed to write %s: %v", path, err)
+		}
+		fmt.Fprintf(os.Stderr, "✅ %s -> %s\n", siteLabel(sites[i]), path)
+	}
+}
+
+// selectSite returns the named site, exiting if the CSV does not contain it.
+func selectSite(sites []gopurple.SetupSite, name string) []gopurple.SetupSite {
+	for _, site := range sites {
+		if site.Name == name {
+			return []gopurple.SetupSite{site}
+		}
+	}
+	log.Fatalf("❌ Site %q not found in sites file", name)
+	return nil
+}
+
+func siteLabel(site gopurple.SetupSite) string {
+	if site.Name == "" {
+		return "record"
+	}
+	return "site " + site.Name
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/brightdevelopers/gopurple"
+)
+
+func main() {
+	var (
+		helpFlag    = flag.Bool("help", false, "Display usage information")
+		timeoutFlag = flag.Int("timeout", 30, "Request timeout in seconds")
+		toFlag      = flag.String("to", "", "Restore the newest snapshot taken at or before this time (RFC 3339, required)")
+		dirFlag     = flag.String("dir", "", "Setup history directory (default: BS_SETUP_HISTORY)")
+		dryRunFlag  = flag.Bool("dry-run", false, "Show what would change without writing")
+		jsonFlag    = flag.Bool("json", false, "Output the result as JSON")
+		networkFlag *string
+		confirmFlag *bool
+	)
+
+	// Set up network flags to point to the same variable
+	networkFlag = flag.String("network", "", "Network name to use (overrides BS_NETWORK)")
+	flag.StringVar(networkFlag, "n", "", "Network name to use (overrides BS_NETWORK) [alias for --network]")
+
+	// Set up confirm flags to point to the same variable
+	confirmFlag = flag.Bool("y", false, "Skip confirmation prompt")
+	flag.BoolVar(confirmFlag, "force", false, "Skip confirmation prompt [alias for -y]")
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [options] --to <time> <setup-id>\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Restore a B-Deploy setup record from the local setup history. Use a time listed\n")
+		fmt.Fprintf(os.Stderr, "by bdeploy-history to restore the record as it was just before the change made\n")
+		fmt.Fprintf(os.Stderr, "then. A deleted record is added again and gets a new ID. The version being\n")
+		fmt.Fprintf(os.Stderr, "replaced is saved to the history first, so a rollback can be rolled back.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		flag.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nEnvironment Variables:\n")
+		fmt.Fprintf(os.Stderr, "  BS_CLIENT_ID        BSN.cloud API client ID (required)\n")
+		fmt.Fprintf(os.Stderr, "  BS_SECRET          BSN.cloud API client secret (required)\n")
+		fmt.Fprintf(os.Stderr, "  BS_NETWORK         BSN.cloud network name (optional)\n")
+		fmt.Fprintf(os.Stderr, "  BS_SETUP_HISTORY   Setup history directory (required unless --dir is given)\n\n")
+		fmt.Fprintf(os.Stderr, "Examples:\n")
+		fmt.Fprintf(os.Stderr, "  Preview a rollback:\n")
+		fmt.Fprintf(os.Stderr, "    %s --dry-run --to 2026-10-01T12:00:00Z 658f1dbef1d46c829f60a14f\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  Roll back without prompting:\n")
+		fmt.Fprintf(os.Stderr, "    %s -y --to 2026-10-01T12:00:00Z 658f1dbef1d46c829f60a14f\n", os.Args[0])
+	}
+
+	flag.Parse()
+
+	if *helpFlag {
+		flag.Usage()
+		return
+	}
+
+	if flag.NArg() != 1 || *toFlag == "" {
+		fmt.Fprintf(os.Stderr, "Error: a setup ID and --to are required\n\n")
+		flag.Usage()
+		os.Exit(1)
+	}
+	setupID := flag.Arg(0)
+
+	to, err := time.Parse(time.RFC3339, *toFlag)
+	if err != nil {
+		log.Fatalf("❌ Invalid --to time %q, expected RFC 3339 such as 2026-10-01T12:00:00Z", *toFlag)
+	}
+
+	var opts []gopurple.Option
+	if *timeoutFlag > 0 {
+		opts = append(opts, gopurple.WithTimeout(time.Duration(*timeoutFlag)*time.Second))
+	}
+	if *networkFlag != "" {
+		opts = append(opts, gopurple.WithNetwork(*networkFlag))
+	}
+	if *dirFlag != "" {
+		opts = append(opts, gopurple.WithSetupHistoryDir(*dirFlag))
+	}
+
+	client, err := gopurple.New(opts...)
+	if err != nil {
+		if gopurple.IsConfigurationError(err) {
+			log.Fatalf("❌ Configuration error: %v", err)
+		}
+		log.Fatalf("❌ Failed to create client: %v", err)
+	}
+	if client.SetupHistory() == nil {
+		log.Fatalf("❌ Setup history is off; set BS_SETUP_HISTORY or use --dir")
+	}
+
+	ctx := context.Background()
+
+	if !*jsonFlag {
+		fmt.Fprintln(os.Stderr, "🔐 Authenticating with BSN.cloud...")
+	}
+	if err := client.Authenticate(ctx); err != nil {
+		log.Fatalf("❌ Authentication failed: %v", err)
+	}
+
+	if err := handleNetworkSelection(ctx, client, *networkFlag, *jsonFlag); err != nil {
+		log.Fatalf("❌ Network selection failed: %v", err)
+	}
+
+	current, err := client.GetCurrentNetwork(ctx)
+	if err != nil {
+		log.Fatalf("❌ Failed to get current network: %v", err)
+	}
+	if err := client.BDeploy.SetNetworkContext(ctx, current.Name); err != nil {
+		log.Fatalf("❌ Failed to set network context: %v", err)
+	}
+
+	rollbackOpts := gopurple.SetupRollbackOptions{To: to, DryRun: true}
+	result, err := gopurple.RollbackSetupRecord(ctx, client.BDeploy, client.SetupHistory(), setupID, rollbackOpts)
+	if err != nil {
+		log.Fatalf("❌ Failed to plan rollback: %v", err)
+	}
+
+	if !*dryRunFlag && result.Action != gopurple.SetupActionNone {
+		if !*confirmFlag {
+			printResult(result, false)
+			fmt.Print("\nProceed? (yes/no): ")
+
+			scanner := bufio.NewScanner(os.Stdin)
+			if !scanner.Scan() {
+				log.Fatalf("Failed to read confirmation")
+			}
+
+			confirmation := strings.ToLower(strings.TrimSpace(scanner.Text()))
+			if confirmation != "yes" && confirmation != "y" {
+				fmt.Println("\nOperation cancelled.")
+				os.Exit(0)
+			}
+			fmt.Println()
+		}
+
+		rollbackOpts.DryRun = false
+		result, err = gopurple.RollbackSetupRecord(ctx, client.BDeploy, client.SetupHistory(), setupID, rollbackOpts)
+		if err != nil {
+			log.Fatalf("❌ Rollback failed: %v", err)
+		}
+	}
+
+	printResult(result, *jsonFlag)
+}
+
+// printResult describes the rollback, or prints it as JSON.
+func printResult(result *gopurple.SetupRollbackResult, jsonOutput bool) {
+	if jsonOutput {
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			log.Fatalf("❌ Failed to marshal JSON: %v", err)
+		}
+		fmt.Println(string(data))
+		return
+	}
+
+	snapshot := result.Snapshot
+	fmt.Printf("Snapshot of %s (%s) taken %s, before %s\n", snapshot.PackageName, snapshot.SetupID,
+		snapshot.TakenAt.Format(time.RFC3339), snapshot.Operation)
+
+	switch result.Action {
+	case gopurple.SetupActionNone:
+		fmt.Println("The setup record already matches the snapshot. Nothing to do.")
+		return
+	case gopurple.SetupActionCreate:
+		fmt.Println("The setup record no longer exists and will be added again with a new ID.")
+	case gopurple.SetupActionUpdate:
+		fmt.Printf("%d field(s) will be restored:\n", len(result.Changes))
+		for _, change := range result.Changes {
+			fmt.Printf("  ~ %s\n", change)
+		}
+	}
+
+	if result.Applied {
+		fmt.Printf("\n✅ Restored setup record %s\n", result.SetupID)
+	}
+}
+func handleNetworkSelection(ctx context.Context, client *gopurple.Client, requestedNetwork string, jsonMode bool) error {
+	if client.IsNetworkSet() {
+		if current, err := client.GetCurrentNetwork(ctx); err == nil {
+			if !jsonMode {
+				fmt.Fprintf(os.Stderr, "Using network: %s (ID: %d)\n", current.Name, current.ID)
+			}
+			return nil
+		}
+	}
+
+	// If no network flag was provided, check BS_NETWORK environment variable
+	if requestedNetwork == "" {
+		if envNetwork := os.Getenv("BS_NETWORK"); envNetwork != "" {
+			requestedNetwork = envNetwork
+			if !jsonMode {
+				fmt.Fprintf(os.Stderr, "Using network from BS_NETWORK environment variable\n")
+			}
+		}
+	}
+
+	networks, err := client.GetNetworks(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get networks: %w", err)
+	}
+
+	if len(networks) == 0 {
+		return fmt.Errorf("no networks available")
+	}
+
+	if requestedNetwork != "" {
+		for _, network := range networks {
+			if strings.EqualFold(network.Name, requestedNetwork) {
+				if !jsonMode {
+					fmt.Fprintf(os.Stderr, "Using network: %s (ID: %d)\n", network.Name, network.ID)
+				}
+				return client.SetNetworkByID(ctx, network.ID)
+			}
+		}
+	}
+
+	if len(networks) == 1 {
+		if !jsonMode {
+			fmt.Fprintf(os.Stderr, "Using network: %s (ID: %d)\n", networks[0].Name, networks[0].ID)
+		}
+		return client.SetNetworkByID(ctx, networks[0].ID)
+	}
+
+	fmt.Fprintf(os.Stderr, "Available networks:\n")
+	for i, network := range networks {
+		fmt.Fprintf(os.Stderr, "  %d. %s (ID: %d)\n", i+1, network.Name, network.ID)
+	}
+
+	fmt.Fprint(os.Stderr, "Select network (1-"+strconv.Itoa(len(networks))+"): ")
+	scanner := bufio.NewScanner(os.Stdin)
+	if !scanner.Scan() {
+		return fmt.Errorf("failed to read input")
+	}
+
+	selection, err := strconv.Atoi(strings.TrimSpace(scanner.Text()))
+	if err != nil || selection < 1 || selection > len(networks) {
+		return fmt.Errorf("invalid selection")
+	}
+
+	selectedNetwork := networks[selection-1]
+	if !jsonMode {
+		fmt.Fprintf(os.Stderr, "Selected network: %s (ID: %d)\n", selectedNetwork.Name, selectedNetwork.ID)
+	}
+	return client.SetNetworkByID(ctx, selectedNetwork.ID)
+}
@@ -42,7 +42,44 @@ type NetworkInterface struct {
 	HealthReportingEnabled bool   `json:"healthReportingEnabled"`
 }
 
+// setFlags collects repeated --set field=value flags into a merge patch.
+type setFlags map[string]interface{}
+
+func (s setFlags) String() string {
+	return fmt.Sprintf("%v", map[string]interface{}(s))
+}
+
+// Set parses "field=value". Dotted fields address nested objects (bDeploy.packageName),
+// and values are read as JSON when they parse (false, 0, null) or as strings otherwise.
+func (s setFlags) Set(arg string) error {
+	field, raw, found := strings.Cut(arg, "=")
+	if !found || field == "" {
+		return fmt.Errorf("expected field=value, got %q", arg)
+	}
+
+	var value interface{}
+	if err := json.Unmarshal([]byte(raw), &value); err != nil {
+		value = raw
+	}
+
+	target := map[string]interface{}(s)
+	parts := strings.Split(field, ".")
+	for _, part := range parts[:len(parts)-1] {
+		nested, ok := target[part].(map[string]interface{})
+		if !ok {
+			nested = map[string]interface{}{}
+			target[part] = nested
+		}
+		target = nested
+	}
+	target[parts[len(parts)-1]] = value
+	return nil
+}
+
 func main() {
+	sets := setFlags{}
+	flag.Var(sets, "set", "Set a field by JSON name, e.g. dwsEnabled=false (repeatable)")
+
 	var (
 		helpFlag      = flag.Bool("help", false, "Display usage information")
 		jsonFlag      = flag.Bool("json", false, "Output as JSON")
@@ -54,15 +91,17 @@ func main() {
 
 	// Custom usage output
 	flag.Usage = func() {
-		fmt.Fprintf(os.Stderr, "Usage: %s [--setup-id <id> | --setup-name <name>] [options] <config.json>\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Usage: %s [--setup-id <id> | --setup-name <name>] [options] [--set field=value]... [config.json]\n\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "A tool to update an existing B-Deploy setup record using a JSON configuration file.\n\n")
 		fmt.Fprintf(os.Stderr, "This program performs the following workflow:\n")
 		fmt.Fprintf(os.Stderr, "  1. Authenticate with BSN.cloud\n")
 		fmt.Fprintf(os.Stderr, "  2. Set the network context\n")
 		fmt.Fprintf(os.Stderr, "  3. Fetch the existing setup record (by ID or name)\n")
-		fmt.Fprintf(os.Stderr, "  4. Apply updates from the config file\n")
+		fmt.Fprintf(os.Stderr, "  4. Apply updates from the config file and --set flags\n")
 		fmt.Fprintf(os.Stderr, "  5. Update the B-Deploy setup record\n")
 		fmt.Fprintf(os.Stderr, "  6. Display the updated setup details\n\n")
+		fmt.Fprintf(os.Stderr, "Fields changed with --set are sent even when false or 0, so they can turn\n")
+		fmt.Fprintf(os.Stderr, "settings off; use field=null to reset a field to unset.\n\n")
 		fmt.Fprintf(os.Stderr, "Options:\n")
 		flag.PrintDefaults()
 		fmt.Fprintf(os.Stderr, "\nEnvironment Variables:\n")
@@ -78,6 +117,8 @@ func main() {
 		fmt.Fprintf(os.Stderr, "    %s --setup-name \"production-setup\" --verbose config.json\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  Use custom timeout:\n")
 		fmt.Fprintf(os.Stderr, "    %s --setup-id 618fb7363a682fe7a40c73ca --timeout 60 config.json\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  Turn off local DWS and remote snapshots without a config file:\n")
+		fmt.Fprintf(os.Stderr, "    %s --setup-id 618fb7363a682fe7a40c73ca --set dwsEnabled=false --set enableRemoteSnapshot=false\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  Output as JSON:\n")
 		fmt.Fprintf(os.Stderr, "    %s --setup-id 618fb7363a682fe7a40c73ca config.json --json\n", os.Args[0])
 	}
@@ -104,21 +145,24 @@ func main() {
 	}
 
 	// Validate command line arguments
-	if flag.NArg() != 1 {
-		fmt.Fprintf(os.Stderr, "Error: config file is required\n\n")
+	if flag.NArg() > 1 || (flag.NArg() == 0 && len(sets) == 0) {
+		fmt.Fprintf(os.Stderr, "Error: a config file or at least one --set is required\n\n")
 		flag.Usage()
 		os.Exit(1)
 	}
 
-	configFile := flag.Arg(0)
-
 	// Load configuration
-	if !*jsonFlag {
-		fmt.Fprintf(os.Stderr, "📋 Loading configuration from: %s\n", configFile)
-	}
-	config, err := loadConfig(configFile)
-	if err != nil {
-		log.Fatalf("❌ Failed to load config: %v", err)
+	config := &Config{}
+	if flag.NArg() == 1 {
+		configFile := flag.Arg(0)
+		if !*jsonFlag {
+			fmt.Fprintf(os.Stderr, "📋 Loading configuration from: %s\n", configFile)
+		}
+		var err error
+		config, err = loadConfig(configFile)
+		if err != nil {
+			log.Fatalf("❌ Failed to load config: %v", err)
+		}
 	}
 
 	if *verboseFlag && !*jsonFlag {
@@ -255,6 +299,11 @@ func main() {
 		fmt.Fprintf(os.Stderr, "✏️  Applying updates from configuration...\n")
 	}
 	updatedSetup := applyUpdates(existingSetup, config)
+	if len(sets) > 0 {
+		if err := updatedSetup.ApplyPatch(sets); err != nil {
+			log.Fatalf("❌ Invalid --set: %v", err)
+		}
+	}
 
 	if *verboseFlag && !*jsonFlag {
 		fmt.Fprintf(os.Stderr, "\n📝 Updated Setup Details:\n")
@@ -280,8 +329,11 @@ func main() {
 		log.Fatalf("❌ Failed to update B-Deploy setup record: %v", err)
 	}
 
-	// Output as JSON if requested
+	// Output as JSON if requested, with passwords and tokens masked
 	if *jsonFlag {
+		if result != nil {
+			result = result.Redacted()
+		}
 		encoder := json.NewEncoder(os.Stdout)
 		encoder.SetIndent("", "  ")
 		if err := encoder.Encode(result); err != nil {
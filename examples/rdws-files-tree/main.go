@@ -0,0 +1,281 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/brightdevelopers/gopurple"
+)
+
+// treeEntry is a single walked entry for JSON output
+type treeEntry struct {
+	Path  string `json:"path"`
+	Type  string `json:"type"`
+	Size  int64  `json:"size,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+func main() {
+	var (
+		helpFlag    = flag.Bool("help", false, "Display usage information")
+		jsonFlag    = flag.Bool("json", false, "Output as JSON")
+		verboseFlag = flag.Bool("verbose", false, "Show file sizes")
+		timeoutFlag = flag.Int("timeout", 30, "Request timeout in seconds")
+		networkFlag *string
+		serialFlag  = flag.String("serial", "", "Device serial number (required)")
+		pathFlag    = flag.String("path", "sd", "Root path to walk (default: sd)")
+		globFlag    = flag.String("glob", "", "Only list paths matching this pattern (e.g. 'sd/autoplugins/*/manifest.json')")
+		depthFlag   = flag.Int("max-depth", 0, "Maximum directory depth to walk (0 for unlimited)")
+	)
+
+	networkFlag = flag.String("network", "", "Network name to use (overrides BS_NETWORK)")
+	flag.StringVar(networkFlag, "n", "", "Network name to use (overrides BS_NETWORK) [alias for --network]")
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [options]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Recursively list files on a player's storage via rDWS.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		flag.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nEnvironment Variables:\n")
+		fmt.Fprintf(os.Stderr, "  BS_CLIENT_ID        BSN.cloud API client ID (required)\n")
+		fmt.Fprintf(os.Stderr, "  BS_SECRET          BSN.cloud API client secret (required)\n")
+		fmt.Fprintf(os.Stderr, "  BS_NETWORK         BSN.cloud network name (optional)\n\n")
+		fmt.Fprintf(os.Stderr, "Examples:\n")
+		fmt.Fprintf(os.Stderr, "  Walk the SD card:\n")
+		fmt.Fprintf(os.Stderr, "    %s --serial UTD41X000009\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  Walk two levels of a folder with sizes:\n")
+		fmt.Fprintf(os.Stderr, "    %s --serial UTD41X000009 --path sd/autoplugins --max-depth 2 --verbose\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  Find plugin manifests:\n")
+		fmt.Fprintf(os.Stderr, "    %s --serial UTD41X000009 --glob 'sd/autoplugins/*/manifest.json'\n", os.Args[0])
+	}
+
+	flag.Parse()
+
+	if *helpFlag {
+		flag.Usage()
+		return
+	}
+
+	if *serialFlag == "" {
+		fmt.Fprintf(os.Stderr, "Error: Must specify --serial\n\n")
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	var opts []gopurple.Option
+	if *timeoutFlag > 0 {
+		opts = append(opts, gopurple.WithTimeout(time.Duration(*timeoutFlag)*time.Second))
+	}
+	if *networkFlag != "" {
+		opts = append(opts, gopurple.WithNetwork(*networkFlag))
+	}
+
+	client, err := gopurple.New(opts...)
+	if err != nil {
+		log.Fatalf("Failed to create client: %v", err)
+	}
+
+	ctx := context.Background()
+
+	if !*jsonFlag {
+		fmt.Fprintf(os.Stderr, "Authenticating with BSN.cloud...\n")
+	}
+	if err := client.Authenticate(ctx); err != nil {
+		log.Fatalf("Authentication failed: %v", err)
+	}
+
+	if err := handleNetworkSelection(ctx, client, *networkFlag, *jsonFlag); err != nil {
+		log.Fatalf("Network selection failed: %v", err)
+	}
+
+	if *globFlag != "" {
+		if !*jsonFlag {
+			fmt.Fprintf(os.Stderr, "Matching %s on device %s...\n", *globFlag, *serialFlag)
+		}
+
+		matches, err := client.RDWS.Glob(ctx, *serialFlag, *globFlag)
+		if err != nil {
+			log.Fatalf("Failed to match files: %v", err)
+		}
+
+		if *jsonFlag {
+			printJSON(matches)
+			return
+		}
+		for _, match := range matches {
+			fmt.Println(match)
+		}
+		fmt.Fprintf(os.Stderr, "\n%d match(es)\n", len(matches))
+		return
+	}
+
+	if !*jsonFlag {
+		fmt.Fprintf(os.Stderr, "Walking /%s on device %s...\n\n", strings.Trim(*pathFlag, "/"), *serialFlag)
+	}
+
+	root := strings.Trim(*pathFlag, "/")
+	rootDepth := strings.Count(root, "/")
+
+	var entries []treeEntry
+	var fileCount, dirCount int
+	err = client.RDWS.WalkFiles(ctx, *serialFlag, root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Report unreadable directories and keep walking
+			entries = append(entries, treeEntry{Path: path, Type: "error", Error: err.Error()})
+			if !*jsonFlag {
+				fmt.Fprintf(os.Stderr, "  ! %s: %v\n", path, err)
+			}
+			if d == nil {
+				return err
+			}
+			return nil
+		}
+
+		depth := strings.Count(path, "/") - rootDepth
+		entry := treeEntry{Path: path, Type: "file"}
+		if d.IsDir() {
+			entry.Type = "dir"
+			dirCount++
+		} else {
+			fileCount++
+			if info, err := d.Info(); err == nil {
+				entry.Size = info.Size()
+			}
+		}
+		entries = append(entries, entry)
+
+		if !*jsonFlag {
+			displayEntry(d, entry, depth, *verboseFlag)
+		}
+
+		if d.IsDir() && *depthFlag > 0 && depth >= *depthFlag {
+			return fs.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		log.Fatalf("Failed to walk files: %v", err)
+	}
+
+	if *jsonFlag {
+		printJSON(entries)
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "\n%d directories, %d files\n", dirCount, fileCount)
+}
+
+func displayEntry(d fs.DirEntry, entry treeEntry, depth int, verbose bool) {
+	prefix := strings.Repeat("  ", depth)
+
+	typeIcon := "📄"
+	if d.IsDir() {
+		typeIcon = "📁"
+	}
+
+	if verbose && !d.IsDir() {
+		fmt.Printf("%s%s %s (%s)\n", prefix, typeIcon, d.Name(), formatSize(entry.Size))
+		return
+	}
+	fmt.Printf("%s%s %s\n", prefix, typeIcon, d.Name())
+}
+
+func formatSize(size int64) string {
+	switch {
+	case size < 1024:
+		return fmt.Sprintf("%d B", size)
+	case size < 1024*1024:
+		return fmt.Sprintf("%.1f KB", float64(size)/1024)
+	case size < 1024*1024*1024:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1024*1024))
+	default:
+		return fmt.Sprintf("%.1f GB", float64(size)/(1024*1024*1024))
+	}
+}
+
+func printJSON(v interface{}) {
+	jsonData, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		log.Fatalf("Failed to marshal JSON: %v", err)
+	}
+	fmt.Println(string(jsonData))
+}
+
+func handleNetworkSelection(ctx context.Context, client *gopurple.Client, requestedNetwork string, jsonMode bool) error {
+	if client.IsNetworkSet() {
+		if current, err := client.GetCurrentNetwork(ctx); err == nil {
+			if !jsonMode {
+				fmt.Fprintf(os.Stderr, "Using network: %s (ID: %d)\n", current.Name, current.ID)
+			}
+			return nil
+		}
+	}
+
+	// If no network flag was provided, check BS_NETWORK environment variable
+	if requestedNetwork == "" {
+		if envNetwork := os.Getenv("BS_NETWORK"); envNetwork != "" {
+			requestedNetwork = envNetwork
+			if !jsonMode {
+				fmt.Fprintf(os.Stderr, "Using network from BS_NETWORK environment variable\n")
+			}
+		}
+	}
+
+	networks, err := client.GetNetworks(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get networks: %w", err)
+	}
+
+	if len(networks) == 0 {
+		return fmt.Errorf("no networks available")
+	}
+
+	if requestedNetwork != "" {
+		for _, network := range networks {
+			if strings.EqualFold(network.Name, requestedNetwork) {
+				if !jsonMode {
+					fmt.Fprintf(os.Stderr, "Using network: %s (ID: %d)\n", network.Name, network.ID)
+				}
+				return client.SetNetworkByID(ctx, network.ID)
+			}
+		}
+	}
+
+	if len(networks) == 1 {
+		if !jsonMode {
+			fmt.Fprintf(os.Stderr, "Using network: %s (ID: %d)\n", networks[0].Name, networks[0].ID)
+		}
+		return client.SetNetworkByID(ctx, networks[0].ID)
+	}
+
+	fmt.Fprintf(os.Stderr, "Available networks:\n")
+	for i, network := range networks {
+		fmt.Fprintf(os.Stderr, "  %d. %s (ID: %d)\n", i+1, network.Name, network.ID)
+	}
+
+	fmt.Fprint(os.Stderr, "Select network (1-"+strconv.Itoa(len(networks))+"): ")
+	scanner := bufio.NewScanner(os.Stdin)
+	if !scanner.Scan() {
+		return fmt.Errorf("failed to read input")
+	}
+
+	selection, err := strconv.Atoi(strings.TrimSpace(scanner.Text()))
+	if err != nil || selection < 1 || selection > len(networks) {
+		return fmt.Errorf("invalid selection")
+	}
+
+	selectedNetwork := networks[selection-1]
+	if !jsonMode {
+		fmt.Fprintf(os.Stderr, "Selected network: %s (ID: %d)\n", selectedNetwork.Name, selectedNetwork.ID)
+	}
+	return client.SetNetworkByID(ctx, selectedNetwork.ID)
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/brightdevelopers/gopurple"
+)
+
+func main() {
+	var (
+		helpFlag        = flag.Bool("help", false, "Display usage information")
+		jsonFlag        = flag.Bool("json", false, "Output the archive manifest as JSON")
+		timeoutFlag     = flag.Int("timeout", 60, "Request timeout in seconds")
+		networkFlag     *string
+		serialsFlag     = flag.String("serials", "", "Comma-separated device serial numbers")
+		serialsFileFlag = flag.String("serials-file", "", "File with one device serial per line ('#' starts a comment)")
+		outputFlag      = flag.String("output", "", "Archive path, or a directory to create a timestamped archive in (default: current directory)")
+		crashFlag       = flag.Bool("crashdumps", false, "Also collect crash dumps")
+		concurrencyFlag = flag.Int("concurrency", 4, "Number of players to fetch at once")
+	)
+
+	networkFlag = flag.String("network", "", "Network name to use (overrides BS_NETWORK)")
+	flag.StringVar(networkFlag, "n", "", "Network name to use (overrides BS_NETWORK) [alias for --network]")
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [options]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Collect logs from many players into a timestamped tar.gz archive with one folder per serial.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		flag.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nEnvironment Variables:\n")
+		fmt.Fprintf(os.Stderr, "  BS_CLIENT_ID        BSN.cloud API client ID (required)\n")
+		fmt.Fprintf(os.Stderr, "  BS_SECRET          BSN.cloud API client secret (required)\n")
+		fmt.Fprintf(os.Stderr, "  BS_NETWORK         BSN.cloud network name (optional)\n\n")
+		fmt.Fprintf(os.Stderr, "Examples:\n")
+		fmt.Fprintf(os.Stderr, "  Collect logs from two players:\n")
+		fmt.Fprintf(os.Stderr, "    %s --serials UTD41X000009,UTD41X000010\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  Collect logs and crash dumps for a support escalation:\n")
+		fmt.Fprintf(os.Stderr, "    %s --serials-file store-42.txt --crashdumps --output ./escalations/\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  Search the archive afterwards:\n")
+		fmt.Fprintf(os.Stderr, "    rdws-logs-search --archive bs-logs-20261018T101500Z.tar.gz --severity err\n")
+	}
+
+	flag.Parse()
+
+	if *helpFlag {
+		flag.Usage()
+		return
+	}
+
+	serials, err := readSerials(*serialsFlag, *serialsFileFlag)
+	if err != nil {
+		log.Fatalf("Failed to read serials: %v", err)
+	}
+	if len(serials) == 0 {
+		fmt.Fprintf(os.Stderr, "Error: Must specify --serials or --serials-file\n\n")
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	// Work out where the archive goes
+	archivePath := *outputFlag
+	if archivePath == "" {
+		archivePath = gopurple.LogArchiveName(time.Now())
+	} else if info, err := os.Stat(archivePath); (err == nil && info.IsDir()) || strings.HasSuffix(archivePath, string(os.PathSeparator)) {
+		if err := os.MkdirAll(archivePath, 0755); err != nil {
+			log.Fatalf("Failed to create output directory: %v", err)
+		}
+		archivePath = filepath.Join(archivePath, gopurple.LogArchiveName(time.Now()))
+	}
+
+	var opts []gopurple.Option
+	if *timeoutFlag > 0 {
+		opts = append(opts, gopurple.WithTimeout(time.Duration(*timeoutFlag)*time.Second))
+	}
+	if *networkFlag != "" {
+		opts = append(opts, gopurple.WithNetwork(*networkFlag))
+	}
+
+	client, err := gopurple.New(opts...)
+	if err != nil {
+		log.Fatalf("Failed to create client: %v", err)
+	}
+
+	ctx := context.Background()
+
+	if !*jsonFlag {
+		fmt.Fprintf(os.Stderr, "Authenticating with BSN.cloud...\n")
+	}
+	if err := client.Authenticate(ctx); err != nil {
+		log.Fatalf("Authentication failed: %v", err)
+	}
+
+	if err := handleNetworkSelection(ctx, client, *networkFlag, *jsonFlag); err != nil {
+		log.Fatalf("Network selection failed: %v", err)
+	}
+
+	file, err := os.Create(archivePath)
+	if err != nil {
+		log.Fatalf("Failed to create archive: %v", err)
+	}
+
+	if !*jsonFlag {
+		fmt.Fprintf(os.Stderr, "Collecting logs from %d player(s)...\n", len(serials))
+	}
+
+	manifest, err := gopurple.CollectLogs(ctx, client.RDWS, serials, file, gopurple.LogCollectOptions{
+		CrashDumps:  *crashFlag,
+		Concurrency: *concurrencyFlag,
+	})
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(archivePath)
+		log.Fatalf("Failed to collect logs: %v", err)
+	}
+
+	if *jsonFlag {
+		jsonData, err := json.MarshalIndent(map[string]interface{}{
+			"archive":  archivePath,
+			"manifest": manifest,
+		}, "", "  ")
+		if err != nil {
+			log.Fatalf("Failed to marshal JSON: %v", err)
+		}
+		fmt.Println(string(jsonData))
+		return
+	}
+
+	fmt.Println()
+	for _, player := range manifest.Players {
+		if player.Error != "" {
+			fmt.Printf("  ✗ %s: %s\n", player.Serial, player.Error)
+			continue
+		}
+		fmt.Printf("  ✓ %s: %d log file(s), %d crash dump(s)\n", player.Serial, len(player.Logs), len(player.CrashDumps))
+	}
+
+	failed := len(manifest.Failed())
+	fmt.Printf("\nSaved logs from %d of %d player(s) to %s\n", len(manifest.Players)-failed, len(manifest.Players), archivePath)
+	if failed > 0 {
+		os.Exit(2)
+	}
+}
+
+// readSerials combines serials from the comma-separated flag and the serials file.
+func readSerials(list string, filename string) ([]string, error) {
+	var serials []string
+	seen := make(map[string]bool)
+	add := func(serial string) {
+		serial = strings.TrimSpace(serial)
+		if serial != "" && !seen[serial] {
+			seen[serial] = true
+			serials = append(serials, serial)
+		}
+	}
+
+	for _, serial := range strings.Split(list, ",") {
+		add(serial)
+	}
+
+	if filename != "" {
+		f, err := os.Open(filename)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line, _, _ := strings.Cut(scanner.Text(), "#")
+			add(line)
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	return serials, nil
+}
+
+func handleNetworkSelection(ctx context.Context, client *gopurple.Client, requestedNetwork string, jsonMode bool) error {
+	if client.IsNetworkSet() {
+		if current, err := client.GetCurrentNetwork(ctx); err == nil {
+			if !jsonMode {
+				fmt.Fprintf(os.Stderr, "Using network: %s (ID: %d)\n", current.Name, current.ID)
+			}
+			return nil
+		}
+	}
+
+	// If no network flag was provided, check BS_NETWORK environment variable
+	if requestedNetwork == "" {
+		if envNetwork := os.Getenv("BS_NETWORK"); envNetwork != "" {
+			requestedNetwork = envNetwork
+			if !jsonMode {
+				fmt.Fprintf(os.Stderr, "Using network from BS_NETWORK environment variable\n")
+			}
+		}
+	}
+
+	networks, err := client.GetNetworks(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get networks: %w", err)
+	}
+
+	if len(networks) == 0 {
+		return fmt.Errorf("no networks available")
+	}
+
+	if requestedNetwork != "" {
+		for _, network := range networks {
+			if strings.EqualFold(network.Name, requestedNetwork) {
+				if !jsonMode {
+					fmt.Fprintf(os.Stderr, "Using network: %s (ID: %d)\n", network.Name, network.ID)
+				}
+				return client.SetNetworkByID(ctx, network.ID)
+			}
+		}
+	}
+
+	if len(networks) == 1 {
+		if !jsonMode {
+			fmt.Fprintf(os.Stderr, "Using network: %s (ID: %d)\n", networks[0].Name, networks[0].ID)
+		}
+		return client.SetNetworkByID(ctx, networks[0].ID)
+	}
+
+	fmt.Fprintf(os.Stderr, "Available networks:\n")
+	for i, network := range networks {
+		fmt.Fprintf(os.Stderr, "  %d. %s (ID: %d)\n", i+1, network.Name, network.ID)
+	}
+
+	fmt.Fprint(os.Stderr, "Select network (1-"+strconv.Itoa(len(networks))+"): ")
+	scanner := bufio.NewScanner(os.Stdin)
+	if !scanner.Scan() {
+		return fmt.Errorf("failed to read input")
+	}
+
+	selection, err := strconv.Atoi(strings.TrimSpace(scanner.Text()))
+	if err != nil || selection < 1 || selection > len(networks) {
+		return fmt.Errorf("invalid selection")
+	}
+
+	selectedNetwork := networks[selection-1]
+	if !jsonMode {
+		fmt.Fprintf(os.Stderr, "Selected network: %s (ID: %d)\n", selectedNetwork.Name, selectedNetwork.ID)
+	}
+	return client.SetNetworkByID(ctx, selectedNetwork.ID)
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/brightdevelopers/gopurple"
+)
+
+func main() {
+	var (
+		helpFlag     = flag.Bool("help", false, "Display usage information")
+		jsonFlag     = flag.Bool("json", false, "Output matching entries as JSON")
+		archiveFlag  = flag.String("archive", "", "Log archive created by rdws-logs-collect (required)")
+		grepFlag     = flag.String("grep", "", "Regular expression to match against the message")
+		ignoreFlag   = flag.Bool("i", false, "Case-insensitive --grep")
+		severityFlag = flag.String("severity", "", "Only entries at least this severe (emerg, alert, crit, err, warning, notice, info, debug)")
+		serialFlag   = flag.String("serial", "", "Only entries from this player")
+		sinceFlag    = flag.String("since", "", "Only entries at or after this time (RFC 3339, e.g. 2026-10-18T09:00:00Z)")
+	)
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [options]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Search a log archive created by rdws-logs-collect. Works offline; no credentials are needed.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		flag.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nExamples:\n")
+		fmt.Fprintf(os.Stderr, "  Show errors and worse from every player:\n")
+		fmt.Fprintf(os.Stderr, "    %s --archive bs-logs-20261018T101500Z.tar.gz --severity err\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  Find network drops on one player:\n")
+		fmt.Fprintf(os.Stderr, "    %s --archive bs-logs-20261018T101500Z.tar.gz --serial UTD41X000009 --grep 'link (down|up)'\n", os.Args[0])
+	}
+
+	flag.Parse()
+
+	if *helpFlag {
+		flag.Usage()
+		return
+	}
+
+	if *archiveFlag == "" {
+		fmt.Fprintf(os.Stderr, "Error: Must specify --archive\n\n")
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	query := gopurple.LogQuery{Serial: *serialFlag}
+
+	if *grepFlag != "" {
+		pattern := *grepFlag
+		if *ignoreFlag {
+			pattern = "(?i)" + pattern
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			log.Fatalf("Invalid --grep pattern: %v", err)
+		}
+		query.Pattern = re
+	}
+
+	if *severityFlag != "" {
+		severity, err := gopurple.ParseLogSeverity(*severityFlag)
+		if err != nil {
+			log.Fatalf("Invalid --severity: %v", err)
+		}
+		query.MaxSeverity = &severity
+	}
+
+	if *sinceFlag != "" {
+		since, err := time.Parse(time.RFC3339, *sinceFlag)
+		if err != nil {
+			log.Fatalf("Invalid --since: %v", err)
+		}
+		query.Since = since
+	}
+
+	file, err := os.Open(*archiveFlag)
+	if err != nil {
+		log.Fatalf("Failed to open archive: %v", err)
+	}
+	defer file.Close()
+
+	entries, err := gopurple.SearchLogs(file, query)
+	if err != nil {
+		log.Fatalf("Failed to search archive: %v", err)
+	}
+
+	if *jsonFlag {
+		jsonData, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			log.Fatalf("Failed to marshal JSON: %v", err)
+		}
+		fmt.Println(string(jsonData))
+		return
+	}
+
+	for _, entry := range entries {
+		displayEntry(entry)
+	}
+	fmt.Fprintf(os.Stderr, "\n%d matching entries\n", len(entries))
+}
+
+func displayEntry(entry gopurple.LogEntry) {
+	timestamp := "-"
+	if !entry.Time.IsZero() {
+		timestamp = entry.Time.Format("2006-01-02 15:04:05")
+	}
+
+	message := strings.ReplaceAll(entry.Message, "\n", "\n    ")
+	if entry.Tag != "" {
+		message = entry.Tag + ": " + message
+	}
+
+	fmt.Printf("%s %s %-7s %s\n", entry.Serial, timestamp, entry.Severity, message)
+}
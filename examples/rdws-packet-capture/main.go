@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
 	"strconv"
 	"strings"
 	"time"
@@ -25,6 +26,9 @@ func main() {
 		statusFlag   = flag.Bool("status", false, "Get packet capture status")
 		startFlag    = flag.Bool("start", false, "Start packet capture")
 		stopFlag     = flag.Bool("stop", false, "Stop packet capture")
+		captureFlag  = flag.Bool("capture", false, "Run a complete capture and download the pcap file")
+		outputFlag   = flag.String("output", "", "Local pcap file to write when using --capture (required with --capture)")
+		keepFlag     = flag.Bool("keep", false, "Keep the capture file on the player after downloading it")
 		ifaceFlag    = flag.String("interface", "eth0", "Network interface for capture (default: eth0)")
 		durationFlag = flag.Int("duration", 60, "Capture duration in seconds (default: 60)")
 		filterFlag   = flag.String("filter", "", "tcpdump filter expression (optional)")
@@ -51,6 +55,8 @@ func main() {
 		fmt.Fprintf(os.Stderr, "    %s --serial UTD41X000009 --start --filter \"port 80\"\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  Stop capture:\n")
 		fmt.Fprintf(os.Stderr, "    %s --serial UTD41X000009 --stop\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  Capture for 30 seconds and download the pcap (Ctrl-C stops early):\n")
+		fmt.Fprintf(os.Stderr, "    %s --serial UTD41X000009 --capture --duration 30 --filter \"port 53\" --output dns.pcap\n", os.Args[0])
 	}
 
 	flag.Parse()
@@ -60,8 +66,14 @@ func main() {
 		return
 	}
 
-	if *serialFlag == "" || (!*statusFlag && !*startFlag && !*stopFlag) {
-		fmt.Fprintf(os.Stderr, "Error: Must specify --serial and one of --status, --start, or --stop\n\n")
+	if *serialFlag == "" || (!*statusFlag && !*startFlag && !*stopFlag && !*captureFlag) {
+		fmt.Fprintf(os.Stderr, "Error: Must specify --serial and one of --status, --start, --stop, or --capture\n\n")
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	if *captureFlag && *outputFlag == "" {
+		fmt.Fprintf(os.Stderr, "Error: --capture requires --output\n\n")
 		flag.Usage()
 		os.Exit(1)
 	}
@@ -136,6 +148,10 @@ func main() {
 		}
 	}
 
+	if *captureFlag {
+		runCapture(ctx, client, *serialFlag, *ifaceFlag, *durationFlag, *filterFlag, *outputFlag, *keepFlag, *jsonFlag)
+	}
+
 	if *stopFlag {
 		if !*jsonFlag {
 			fmt.Println("Stopping packet capture...")
@@ -153,6 +169,57 @@ func main() {
 	}
 }
 
+// runCapture performs a complete capture, writing the pcap file to output.
+// Interrupting with Ctrl-C stops the capture early and still retrieves the file.
+func runCapture(ctx context.Context, client *gopurple.Client, serial, iface string, duration int, filter, output string, keep, jsonMode bool) {
+	file, err := os.Create(output)
+	if err != nil {
+		log.Fatalf("Failed to create output file: %v", err)
+	}
+	defer file.Close()
+
+	captureCtx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	if !jsonMode {
+		fmt.Printf("Capturing on %s for %d seconds (Ctrl-C to stop early)...\n", iface, duration)
+	}
+
+	result, err := client.RDWS.CapturePackets(captureCtx, serial, &gopurple.PacketCaptureRequest{
+		Interface:      iface,
+		Duration:       time.Duration(duration) * time.Second,
+		Filter:         filter,
+		Output:         file,
+		KeepRemoteFile: keep,
+	})
+	if err != nil {
+		file.Close()
+		os.Remove(output)
+		log.Fatalf("Packet capture failed: %v", err)
+	}
+
+	if jsonMode {
+		jsonData, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			log.Fatalf("Failed to marshal JSON: %v", err)
+		}
+		fmt.Println(string(jsonData))
+		return
+	}
+
+	if result.Interrupted {
+		fmt.Printf("✓ Packet capture stopped early after %s\n", result.Elapsed.Round(time.Second))
+	} else {
+		fmt.Printf("✓ Packet capture complete\n")
+	}
+	fmt.Printf("  Saved %d bytes to %s\n", result.Bytes, output)
+	if result.RemoteDeleted {
+		fmt.Printf("  Removed %s from the player\n", result.FilePath)
+	} else {
+		fmt.Printf("  Capture file kept on player: %s\n", result.FilePath)
+	}
+}
+
 func handleNetworkSelection(ctx context.Context, client *gopurple.Client, requestedNetwork string, jsonMode bool) error {
 	if client.IsNetworkSet() {
 		if current, err := client.GetCurrentNetwork(ctx); err == nil {
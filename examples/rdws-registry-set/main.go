@@ -29,6 +29,7 @@ func main() {
 		deleteFlag   = flag.Bool("delete", false, "Delete registry key")
 		flushFlag    = flag.Bool("flush", false, "Flush registry to disk")
 		recoveryFlag = flag.String("recovery-url", "", "Set recovery URL")
+		unknownFlag  = flag.Bool("allow-unknown", false, "Allow sections that are not well-known (skips section name checking)")
 		confirmFlag  *bool
 		jsonFlag     = flag.Bool("json", false, "Output as JSON")
 	)
@@ -133,6 +134,22 @@ func main() {
 		os.Exit(1)
 	}
 
+	// The player accepts any section name, so catch misspellings and invalid values here
+	if *sectionFlag != "" && !*unknownFlag {
+		if err := gopurple.ValidateRegistrySection(*sectionFlag); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\nUse --allow-unknown to write to a custom section\n\n", err)
+			os.Exit(1)
+		}
+	}
+	if *valueFlag != "" {
+		if key, ok := gopurple.LookupRegistryKey(*sectionFlag, *keyFlag); ok {
+			if err := key.Validate(*valueFlag); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n\n", err)
+				os.Exit(1)
+			}
+		}
+	}
+
 	// Create client
 	var opts []gopurple.Option
 	if *timeoutFlag > 0 {
@@ -0,0 +1,316 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/brightdevelopers/gopurple"
+)
+
+func main() {
+	var (
+		helpFlag       = flag.Bool("help", false, "Display usage information")
+		jsonFlag       = flag.Bool("json", false, "Output as JSON")
+		timeoutFlag    = flag.Int("timeout", 30, "Request timeout in seconds")
+		networkFlag    *string
+		serialFlag     = flag.String("serial", "", "Device serial number (required)")
+		saveFlag       = flag.String("save", "", "Save the player's registry to a .json or .yaml file")
+		diffFlag       = flag.String("diff", "", "Compare the player's registry with a snapshot or desired-state file")
+		diffSerialFlag = flag.String("diff-serial", "", "Compare the player's registry with another player's")
+		applyFlag      = flag.String("apply", "", "Apply a desired-state file to the player's registry")
+		dryRunFlag     = flag.Bool("dry-run", false, "Show the changes --apply would make without writing them")
+		protectedFlag  = flag.Bool("allow-protected", false, "Allow --apply to modify and delete protected (networking) keys")
+		confirmFlag    *bool
+	)
+
+	networkFlag = flag.String("network", "", "Network name to use (overrides BS_NETWORK)")
+	flag.StringVar(networkFlag, "n", "", "Network name to use (overrides BS_NETWORK) [alias for --network]")
+
+	confirmFlag = flag.Bool("y", false, "Skip confirmation prompt")
+	flag.BoolVar(confirmFlag, "force", false, "Skip confirmation prompt [alias for -y]")
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [options]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Snapshot, compare and declaratively apply a player's registry via rDWS.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		flag.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nEnvironment Variables:\n")
+		fmt.Fprintf(os.Stderr, "  BS_CLIENT_ID        BSN.cloud API client ID (required)\n")
+		fmt.Fprintf(os.Stderr, "  BS_SECRET          BSN.cloud API client secret (required)\n")
+		fmt.Fprintf(os.Stderr, "  BS_NETWORK         BSN.cloud network name (optional)\n\n")
+		fmt.Fprintf(os.Stderr, "Desired-state files only manage the sections they list; other sections are left alone.\n\n")
+		fmt.Fprintf(os.Stderr, "Examples:\n")
+		fmt.Fprintf(os.Stderr, "  Save a snapshot:\n")
+		fmt.Fprintf(os.Stderr, "    %s --serial UTD41X000009 --save lobby.yaml\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  Compare two players:\n")
+		fmt.Fprintf(os.Stderr, "    %s --serial UTD41X000009 --diff-serial UTD41X000010\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  Compare against a desired-state file:\n")
+		fmt.Fprintf(os.Stderr, "    %s --serial UTD41X000009 --diff desired.yaml\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  Preview and apply a desired-state file:\n")
+		fmt.Fprintf(os.Stderr, "    %s --serial UTD41X000009 --apply desired.yaml --dry-run\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "    %s --serial UTD41X000009 --apply desired.yaml\n", os.Args[0])
+	}
+
+	flag.Parse()
+
+	if *helpFlag {
+		flag.Usage()
+		return
+	}
+
+	modes := 0
+	for _, set := range []bool{*saveFlag != "", *diffFlag != "", *diffSerialFlag != "", *applyFlag != ""} {
+		if set {
+			modes++
+		}
+	}
+	if *serialFlag == "" || modes != 1 {
+		fmt.Fprintf(os.Stderr, "Error: Must specify --serial and exactly one of --save, --diff, --diff-serial, or --apply\n\n")
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	// Require -y flag when applying with --json (cannot prompt for confirmation)
+	if *applyFlag != "" && *jsonFlag && !*dryRunFlag && !*confirmFlag {
+		fmt.Fprintf(os.Stderr, "Error: -y flag is required when using --json with --apply (cannot prompt for confirmation)\n\n")
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	// Load local files before talking to the cloud
+	var desired *gopurple.RegistrySnapshot
+	if file := *diffFlag + *applyFlag; file != "" {
+		var err error
+		desired, err = gopurple.LoadRegistrySnapshot(file)
+		if err != nil {
+			log.Fatalf("Failed to load %s: %v", file, err)
+		}
+	}
+
+	var opts []gopurple.Option
+	if *timeoutFlag > 0 {
+		opts = append(opts, gopurple.WithTimeout(time.Duration(*timeoutFlag)*time.Second))
+	}
+	if *networkFlag != "" {
+		opts = append(opts, gopurple.WithNetwork(*networkFlag))
+	}
+
+	client, err := gopurple.New(opts...)
+	if err != nil {
+		log.Fatalf("Failed to create client: %v", err)
+	}
+
+	ctx := context.Background()
+
+	if !*jsonFlag {
+		fmt.Fprintf(os.Stderr, "Authenticating with BSN.cloud...\n")
+	}
+	if err := client.Authenticate(ctx); err != nil {
+		log.Fatalf("Authentication failed: %v", err)
+	}
+
+	if err := handleNetworkSelection(ctx, client, *networkFlag, *jsonFlag); err != nil {
+		log.Fatalf("Network selection failed: %v", err)
+	}
+
+	switch {
+	case *saveFlag != "":
+		snapshot, err := gopurple.SnapshotRegistry(ctx, client.RDWS, *serialFlag)
+		if err != nil {
+			log.Fatalf("Failed to read registry: %v", err)
+		}
+		if err := snapshot.SaveFile(*saveFlag); err != nil {
+			log.Fatalf("Failed to save snapshot: %v", err)
+		}
+		if *jsonFlag {
+			printJSON(map[string]interface{}{"serial": *serialFlag, "file": *saveFlag, "sections": len(snapshot.Sections)})
+			return
+		}
+		fmt.Printf("✓ Saved registry of %s (%d sections) to %s\n", *serialFlag, len(snapshot.Sections), *saveFlag)
+
+	case *diffFlag != "" || *diffSerialFlag != "":
+		current, err := gopurple.SnapshotRegistry(ctx, client.RDWS, *serialFlag)
+		if err != nil {
+			log.Fatalf("Failed to read registry: %v", err)
+		}
+
+		other := desired
+		otherName := *diffFlag
+		if *diffSerialFlag != "" {
+			other, err = gopurple.SnapshotRegistry(ctx, client.RDWS, *diffSerialFlag)
+			if err != nil {
+				log.Fatalf("Failed to read registry of %s: %v", *diffSerialFlag, err)
+			}
+			otherName = *diffSerialFlag
+		}
+
+		changes := gopurple.DiffRegistry(current, other)
+		if *jsonFlag {
+			printJSON(changes)
+			return
+		}
+		if len(changes) == 0 {
+			fmt.Printf("No differences between %s and %s\n", *serialFlag, otherName)
+			return
+		}
+		fmt.Printf("Changes from %s to %s:\n\n", *serialFlag, otherName)
+		for _, change := range changes {
+			fmt.Printf("  %s\n", change)
+		}
+		fmt.Printf("\n%d difference(s)\n", len(changes))
+
+	case *applyFlag != "":
+		applyOpts := gopurple.RegistryApplyOptions{DryRun: true, AllowProtected: *protectedFlag}
+
+		// Always plan first so the user can see what will change
+		result, err := gopurple.ApplyRegistry(ctx, client.RDWS, *serialFlag, desired, applyOpts)
+		if err != nil {
+			log.Fatalf("Failed to plan registry changes: %v", err)
+		}
+
+		if !*jsonFlag {
+			displayPlan(result)
+		}
+		if *dryRunFlag || len(result.Changes) == 0 {
+			if *jsonFlag {
+				printJSON(result)
+			}
+			return
+		}
+
+		if !*confirmFlag {
+			fmt.Printf("\nThis will make %d registry change(s) on device %s.\n", len(result.Changes), *serialFlag)
+			fmt.Print("Proceed? (yes/no): ")
+
+			scanner := bufio.NewScanner(os.Stdin)
+			if !scanner.Scan() {
+				log.Fatalf("Failed to read confirmation")
+			}
+
+			confirmation := strings.ToLower(strings.TrimSpace(scanner.Text()))
+			if confirmation != "yes" && confirmation != "y" {
+				fmt.Println("\nOperation cancelled.")
+				os.Exit(0)
+			}
+		}
+
+		applyOpts.DryRun = false
+		result, err = gopurple.ApplyRegistry(ctx, client.RDWS, *serialFlag, desired, applyOpts)
+		if err != nil {
+			if result != nil && !*jsonFlag {
+				fmt.Fprintf(os.Stderr, "Applied %d of %d change(s) before failing\n", result.Applied, len(result.Changes))
+			}
+			log.Fatalf("Failed to apply registry changes: %v", err)
+		}
+
+		if *jsonFlag {
+			printJSON(result)
+			return
+		}
+		fmt.Printf("\n✓ Applied %d change(s), flushed and verified the registry\n", result.Applied)
+	}
+}
+
+func displayPlan(result *gopurple.RegistryApplyResult) {
+	if len(result.Changes) == 0 {
+		fmt.Printf("Registry of %s already matches the desired state\n", result.Serial)
+	} else {
+		fmt.Printf("Planned changes for %s:\n\n", result.Serial)
+		for _, change := range result.Changes {
+			fmt.Printf("  %s\n", change)
+		}
+	}
+
+	if len(result.Skipped) > 0 {
+		fmt.Printf("\nProtected keys that will not be changed (use --allow-protected to override):\n")
+		for _, change := range result.Skipped {
+			fmt.Printf("  %s\n", change)
+		}
+	}
+}
+
+func printJSON(v interface{}) {
+	jsonData, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		log.Fatalf("Failed to marshal JSON: %v", err)
+	}
+	fmt.Println(string(jsonData))
+}
+
+func handleNetworkSelection(ctx context.Context, client *gopurple.Client, requestedNetwork string, jsonMode bool) error {
+	if client.IsNetworkSet() {
+		if current, err := client.GetCurrentNetwork(ctx); err == nil {
+			if !jsonMode {
+				fmt.Fprintf(os.Stderr, "Using network: %s (ID: %d)\n", current.Name, current.ID)
+			}
+			return nil
+		}
+	}
+
+	// If no network flag was provided, check BS_NETWORK environment variable
+	if requestedNetwork == "" {
+		if envNetwork := os.Getenv("BS_NETWORK"); envNetwork != "" {
+			requestedNetwork = envNetwork
+			if !jsonMode {
+				fmt.Fprintf(os.Stderr, "Using network from BS_NETWORK environment variable\n")
+			}
+		}
+	}
+
+	networks, err := client.GetNetworks(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get networks: %w", err)
+	}
+
+	if len(networks) == 0 {
+		return fmt.Errorf("no networks available")
+	}
+
+	if requestedNetwork != "" {
+		for _, network := range networks {
+			if strings.EqualFold(network.Name, requestedNetwork) {
+				if !jsonMode {
+					fmt.Fprintf(os.Stderr, "Using network: %s (ID: %d)\n", network.Name, network.ID)
+				}
+				return client.SetNetworkByID(ctx, network.ID)
+			}
+		}
+	}
+
+	if len(networks) == 1 {
+		if !jsonMode {
+			fmt.Fprintf(os.Stderr, "Using network: %s (ID: %d)\n", networks[0].Name, networks[0].ID)
+		}
+		return client.SetNetworkByID(ctx, networks[0].ID)
+	}
+
+	fmt.Fprintf(os.Stderr, "Available networks:\n")
+	for i, network := range networks {
+		fmt.Fprintf(os.Stderr, "  %d. %s (ID: %d)\n", i+1, network.Name, network.ID)
+	}
+
+	fmt.Fprint(os.Stderr, "Select network (1-"+strconv.Itoa(len(networks))+"): ")
+	scanner := bufio.NewScanner(os.Stdin)
+	if !scanner.Scan() {
+		return fmt.Errorf("failed to read input")
+	}
+
+	selection, err := strconv.Atoi(strings.TrimSpace(scanner.Text()))
+	if err != nil || selection < 1 || selection > len(networks) {
+		return fmt.Errorf("invalid selection")
+	}
+
+	selectedNetwork := networks[selection-1]
+	if !jsonMode {
+		fmt.Fprintf(os.Stderr, "Selected network: %s (ID: %d)\n", selectedNetwork.Name, selectedNetwork.ID)
+	}
+	return client.SetNetworkByID(ctx, selectedNetwork.ID)
+}
@@ -32,11 +32,20 @@ package gopurple
 
 import (
 	"context"
+	"sync"
+	"time"
 
+	"github.com/brightdevelopers/gopurple/internal/audit"
 	"github.com/brightdevelopers/gopurple/internal/auth"
+	"github.com/brightdevelopers/gopurple/internal/bdeploy"
 	"github.com/brightdevelopers/gopurple/internal/config"
 	"github.com/brightdevelopers/gopurple/internal/errors"
+	"github.com/brightdevelopers/gopurple/internal/history"
 	"github.com/brightdevelopers/gopurple/internal/http"
+	"github.com/brightdevelopers/gopurple/internal/ledger"
+	"github.com/brightdevelopers/gopurple/internal/logs"
+	"github.com/brightdevelopers/gopurple/internal/registry"
+	"github.com/brightdevelopers/gopurple/internal/secrets"
 	"github.com/brightdevelopers/gopurple/internal/services"
 	"github.com/brightdevelopers/gopurple/internal/types"
 )
@@ -148,6 +157,15 @@ type (
 	// BDeployInfo represents the B-Deploy section of a setup record.
 	BDeployInfo = types.BDeployInfo
 
+	// SetupIssue is a single problem found when linting a setup record.
+	SetupIssue = types.SetupIssue
+
+	// SetupIssueSeverity distinguishes setup record errors from warnings.
+	SetupIssueSeverity = types.SetupIssueSeverity
+
+	// SetupValidationError lists every error found when validating a setup record.
+	SetupValidationError = types.SetupValidationError
+
 	// IdleScreenColor represents RGBA color for idle screen configuration.
 	IdleScreenColor = types.IdleScreenColor
 
@@ -327,6 +345,56 @@ type (
 
 	// DeviceWebPageList represents a paginated list of device web pages
 	DeviceWebPageList = types.DeviceWebPageList
+
+	// TokenRefresh describes a new access token, as passed to the token refresh hook.
+	TokenRefresh = types.TokenRefresh
+
+	// TokenMetrics reports on the access token and how it has been refreshed.
+	TokenMetrics = auth.TokenMetrics
+
+	// Doer sends an HTTP request; middleware wraps one.
+	Doer = types.Doer
+
+	// DoerFunc adapts a function to a Doer.
+	DoerFunc = types.DoerFunc
+
+	// Middleware wraps the Doer that sends every request attempt.
+	Middleware = types.Middleware
+
+	// RecorderMode selects whether WithRecorder records or replays a cassette.
+	RecorderMode = types.RecorderMode
+
+	// BreakerState is the state of a player's circuit breaker.
+	BreakerState = types.BreakerState
+
+	// PlayerBreakerEvent reports that a player's circuit breaker changed state.
+	PlayerBreakerEvent = types.PlayerBreakerEvent
+)
+
+// HTTP recorder modes
+const (
+	// RecorderRecord sends requests and writes every exchange to the cassette.
+	RecorderRecord = types.RecorderRecord
+	// RecorderReplay answers requests from the cassette without sending them.
+	RecorderReplay = types.RecorderReplay
+)
+
+// Audit record outcomes
+const (
+	// AuditOutcomeSuccess records a call that succeeded.
+	AuditOutcomeSuccess = audit.OutcomeSuccess
+	// AuditOutcomeFailure records a call that failed or that the player declined.
+	AuditOutcomeFailure = audit.OutcomeFailure
+)
+
+// Player circuit breaker states
+const (
+	// BreakerClosed lets rDWS calls to the player through.
+	BreakerClosed = types.BreakerClosed
+	// BreakerOpen fails rDWS calls to the player at once.
+	BreakerOpen = types.BreakerOpen
+	// BreakerHalfOpen lets one trial call through after the cooldown.
+	BreakerHalfOpen = types.BreakerHalfOpen
 )
 
 // Re-export configuration options
@@ -348,6 +416,38 @@ var (
 	// WithDebug enables debug logging of all HTTP requests and responses.
 	WithDebug = config.WithDebug
 
+	// WithLogger sets a log/slog logger for structured, redacted records of every
+	// HTTP request: failures at warn level, successes and bodies at debug level.
+	WithLogger = config.WithLogger
+
+	// WithHTTPClient sends requests with a caller-supplied *http.Client, e.g. one
+	// with a proxy or custom TLS roots.
+	WithHTTPClient = config.WithHTTPClient
+
+	// WithMiddleware adds middleware around every request attempt.
+	WithMiddleware = config.WithMiddleware
+
+	// WithRecorder records every HTTP exchange to a scrubbed YAML cassette, or
+	// replays one without touching the network.
+	WithRecorder = config.WithRecorder
+
+	// WithPlayerCircuitBreaker fails rDWS calls to a player at once with
+	// ErrPlayerOffline after threshold consecutive unreachable results, trying
+	// again after the cooldown.
+	WithPlayerCircuitBreaker = config.WithPlayerCircuitBreaker
+
+	// WithPlayerBreakerHook sets a function called whenever a player's circuit
+	// breaker changes state.
+	WithPlayerBreakerHook = config.WithPlayerBreakerHook
+
+	// WithTracerProvider sets the OpenTelemetry tracer provider: a span per SDK
+	// operation, with a child span for each HTTP attempt.
+	WithTracerProvider = config.WithTracerProvider
+
+	// WithMeterProvider sets the OpenTelemetry meter provider for request, latency,
+	// retry, rate limit and token refresh metrics.
+	WithMeterProvider = config.WithMeterProvider
+
 	// WithEndpoints sets custom API endpoints for BSN.cloud and RDWS.
 	WithEndpoints = config.WithEndpoints
 
@@ -360,10 +460,58 @@ var (
 	// WithDeviceSerial sets a default device serial number for single-device operations.
 	WithDeviceSerial = config.WithDeviceSerial
 
+	// WithSetupValidation enables or disables validation of B-Deploy setup records
+	// before they are created or updated. Validation is enabled by default.
+	WithSetupValidation = config.WithSetupValidation
+
+	// WithAssociationLedger sets the store that records B-Deploy device associations;
+	// the ledger is off by default and nil turns it off.
+	WithAssociationLedger = config.WithAssociationLedger
+
+	// WithAssociationLedgerFile keeps the association ledger in the given JSON file
+	// (see DefaultAssociationLedgerPath).
+	WithAssociationLedgerFile = config.WithAssociationLedgerFile
+
+	// WithSetupHistory sets the store that keeps snapshots of B-Deploy setup records
+	// before they are updated or deleted; nil turns it off.
+	WithSetupHistory = config.WithSetupHistory
+
+	// WithSetupHistoryDir keeps setup record snapshots in the given directory.
+	WithSetupHistoryDir = config.WithSetupHistoryDir
+
+	// WithAuditSink sends an audit record of every state-changing call to the
+	// sink; nil turns the audit log off.
+	WithAuditSink = config.WithAuditSink
+
+	// WithAuditLogFile appends audit records to the given file as JSON lines.
+	WithAuditLogFile = config.WithAuditLogFile
+
+	// WithAuditUser names the person or system acting through the client in
+	// audit records.
+	WithAuditUser = config.WithAuditUser
+
+	// WithSecretResolver sets the resolver for secret references in setup records;
+	// nil sends references as they are, which is the default.
+	WithSecretResolver = config.WithSecretResolver
+
+	// WithSecretReferences enables resolving env:, file:, age: and sops: secret
+	// references in setup records.
+	WithSecretReferences = config.WithSecretReferences
+
+	// WithSecretExec enables or disables exec: secret references.
+	WithSecretExec = config.WithSecretExec
+
 	// WithAccessToken sets a pre-loaded access token for session reuse.
 	// This allows CLI tools to cache the bearer token between invocations,
 	// skipping the OAuth round-trip when the token is still valid.
 	WithAccessToken = config.WithAccessToken
+
+	// WithTokenRefresh renews the access token in the background once the given
+	// fraction of its lifetime has passed (0.8 renews at 80%); 0 turns it off.
+	WithTokenRefresh = config.WithTokenRefresh
+
+	// WithTokenRefreshHook sets a function called whenever a new access token is obtained.
+	WithTokenRefreshHook = config.WithTokenRefreshHook
 )
 
 // Re-export device list options
@@ -387,6 +535,10 @@ var (
 	// WithNetworkName sets the network name filter for B-Deploy record listing.
 	WithNetworkName = services.WithNetworkName
 
+	// WithoutSecretResolution returns a context in which setup records are sent
+	// without resolving secret references, for records fetched from B-Deploy.
+	WithoutSecretResolution = services.WithoutSecretResolution
+
 	// WithUsername sets the username filter for B-Deploy record listing.
 	WithUsername = services.WithUsername
 
@@ -404,6 +556,408 @@ var (
 	WithSetupName = services.WithSetupName
 )
 
+// Re-export remote filesystem access
+type PlayerFS = services.PlayerFS
+
+var (
+	// NewPlayerFS returns a read-only io/fs.FS over a storage device (e.g. "sd") on a player,
+	// backed by rDWS file requests.
+	NewPlayerFS = services.NewPlayerFS
+)
+
+// Re-export packet capture workflow types
+type (
+	PacketCaptureRequest = services.PacketCaptureRequest
+	PacketCaptureResult  = services.PacketCaptureResult
+)
+
+// Re-export registry snapshot, diff and apply support
+type (
+	// RegistrySnapshot is a versioned copy of a player's registry.
+	RegistrySnapshot = registry.Snapshot
+
+	// RegistryChange is a single registry key difference.
+	RegistryChange = registry.Change
+
+	// RegistryApplyOptions controls how ApplyRegistry changes a player's registry.
+	RegistryApplyOptions = registry.ApplyOptions
+
+	// RegistryApplyResult describes the outcome of ApplyRegistry.
+	RegistryApplyResult = registry.ApplyResult
+)
+
+// Registry change types
+const (
+	RegistryChangeAdd    = registry.ChangeAdd
+	RegistryChangeModify = registry.ChangeModify
+	RegistryChangeDelete = registry.ChangeDelete
+)
+
+var (
+	// SnapshotRegistry reads a player's complete registry into a snapshot.
+	SnapshotRegistry = registry.Take
+
+	// LoadRegistrySnapshot reads a snapshot or desired-state document from a .json, .yaml or .yml file.
+	LoadRegistrySnapshot = registry.LoadFile
+
+	// DiffRegistry returns the changes needed to turn one registry snapshot into another.
+	DiffRegistry = registry.Diff
+
+	// ApplyRegistry sets and deletes keys to bring a player's registry in line with a
+	// desired state, flushes it and re-reads it to verify the result.
+	ApplyRegistry = registry.Apply
+
+	// DefaultProtectedRegistryKeys lists "section/key" patterns that ApplyRegistry never modifies or deletes
+	// unless RegistryApplyOptions.AllowProtected is set.
+	DefaultProtectedRegistryKeys = registry.DefaultProtectedKeys
+)
+
+// Re-export typed registry keys
+type (
+	// RegistryKey describes a registry key, its value type and the values it accepts.
+	RegistryKey = registry.Key
+
+	// RegistryStringKey, RegistryBoolKey, RegistryIntKey, RegistryDurationKey and
+	// RegistryURLKey read and write registry values as Go types.
+	RegistryStringKey   = registry.StringKey
+	RegistryBoolKey     = registry.BoolKey
+	RegistryIntKey      = registry.IntKey
+	RegistryDurationKey = registry.DurationKey
+	RegistryURLKey      = registry.URLKey
+)
+
+// Well-known registry sections
+const (
+	RegistrySectionNetworking   = registry.SectionNetworking
+	RegistrySectionHTML         = registry.SectionHTML
+	RegistrySectionBrightScript = registry.SectionBrightScript
+	RegistrySectionAutorun      = registry.SectionAutorun
+	RegistrySectionRDWS         = registry.SectionRDWS
+)
+
+// Well-known registry keys
+var (
+	RegistryNetworkingHostname         = registry.NetworkingHostname
+	RegistryNetworkingLocalDWS         = registry.NetworkingLocalDWS
+	RegistryNetworkingLocalDWSPassword = registry.NetworkingLocalDWSPassword
+	RegistryNetworkingSSHPort          = registry.NetworkingSSHPort
+	RegistryNetworkingTelnetPort       = registry.NetworkingTelnetPort
+	RegistryNetworkingRecoveryURL      = registry.NetworkingRecoveryURL
+	RegistryNetworkingCurlDebug        = registry.NetworkingCurlDebug
+	RegistryHTMLWebInspector           = registry.HTMLWebInspector
+	RegistryBrightScriptDebug          = registry.BrightScriptDebug
+	RegistryAutorunTimeout             = registry.AutorunTimeout
+	RegistryRDWSEnabled                = registry.RDWSEnabled
+	RegistryRDWSServerURL              = registry.RDWSServerURL
+
+	// LookupRegistryKey returns the well-known definition of a section/key pair.
+	LookupRegistryKey = registry.LookupKey
+
+	// ValidateRegistrySection rejects unknown section names, suggesting the closest known one.
+	ValidateRegistrySection = registry.ValidateSection
+
+	// ValidateRegistryValue checks a raw value against the well-known definition of its key.
+	ValidateRegistryValue = registry.ValidateValue
+)
+
+// Re-export log collection, parsing and search
+type (
+	// LogCollectOptions controls what CollectLogs fetches from each player.
+	LogCollectOptions = logs.CollectOptions
+
+	// LogManifest describes the players in a log archive and any that could not be reached.
+	LogManifest = logs.Manifest
+
+	// LogEntry is a single parsed syslog line.
+	LogEntry = logs.LogEntry
+
+	// LogSeverity is a syslog severity level. Lower values are more severe.
+	LogSeverity = logs.Severity
+
+	// LogQuery selects log entries when searching an archive.
+	LogQuery = logs.Query
+)
+
+// Syslog severity levels
+const (
+	LogSeverityUnknown   = logs.SeverityUnknown
+	LogSeverityEmergency = logs.SeverityEmergency
+	LogSeverityAlert     = logs.SeverityAlert
+	LogSeverityCritical  = logs.SeverityCritical
+	LogSeverityError     = logs.SeverityError
+	LogSeverityWarning   = logs.SeverityWarning
+	LogSeverityNotice    = logs.SeverityNotice
+	LogSeverityInfo      = logs.SeverityInfo
+	LogSeverityDebug     = logs.SeverityDebug
+)
+
+var (
+	// CollectLogs fetches logs from many players into a per-serial tar.gz archive.
+	CollectLogs = logs.Collect
+
+	// LogArchiveName returns the conventional timestamped file name for a log archive.
+	LogArchiveName = logs.ArchiveName
+
+	// ParseLogLine parses a single BrightSign syslog line.
+	ParseLogLine = logs.ParseLine
+
+	// ParseLogs parses syslog lines, joining continuation lines to the previous entry.
+	ParseLogs = logs.Parse
+
+	// ParseLogSeverity converts a severity name or number.
+	ParseLogSeverity = logs.ParseSeverity
+
+	// SearchLogs returns the entries in a log archive that match a query.
+	SearchLogs = logs.Search
+)
+
+// Re-export B-Deploy setup templates
+type (
+	// SetupDocument is a decoded JSON or YAML setup record document.
+	SetupDocument = bdeploy.Document
+
+	// SetupTemplate is a base setup record plus overlays with ${var} references.
+	SetupTemplate = bdeploy.Template
+
+	// SetupSite is one row of a sites CSV file.
+	SetupSite = bdeploy.Site
+
+	// SetupSiteResult describes what ApplySetupSites did for one site.
+	SetupSiteResult = bdeploy.SiteResult
+
+	// SetupAction is what applying a rendered setup record does on the server.
+	SetupAction = bdeploy.Action
+
+	// ApplySitesOptions controls ApplySetupSites.
+	ApplySitesOptions = bdeploy.ApplySitesOptions
+
+	// SetupDesiredState is the setup records and player associations a network should have.
+	SetupDesiredState = bdeploy.DesiredState
+
+	// SetupAssociation says which setup record a player should use.
+	SetupAssociation = bdeploy.Association
+
+	// ReconcileOptions controls PlanSetupReconcile.
+	ReconcileOptions = bdeploy.ReconcileOptions
+
+	// ReconcilePlan is the set of changes that brings a network to the desired state.
+	ReconcilePlan = bdeploy.Plan
+
+	// ReconcileChange is one action in a reconciliation plan.
+	ReconcileChange = bdeploy.PlanChange
+
+	// SetupFieldChange is one setup record value that an update changes.
+	SetupFieldChange = bdeploy.FieldChange
+
+	// AssociationLedger persists which setup record each player was associated with.
+	AssociationLedger = ledger.Store
+
+	// AssociationEntry is the setup record a player was last associated with.
+	AssociationEntry = ledger.Entry
+
+	// SetupHistory persists snapshots of setup records taken before they change.
+	SetupHistory = history.Store
+
+	// SetupSnapshot is a setup record as it was just before the SDK changed or deleted it.
+	SetupSnapshot = history.Snapshot
+
+	// AuditSink receives an audit record for every state-changing call.
+	AuditSink = audit.Sink
+
+	// AuditRecord is one state-changing call: who made it, on what, and the outcome.
+	AuditRecord = audit.Record
+
+	// SecretResolver turns a secret reference such as "env:WIFI_PASSPHRASE" into the secret.
+	SecretResolver = secrets.Resolver
+
+	// SecretSources resolves env:, file:, exec:, age: and sops: secret references.
+	SecretSources = secrets.Sources
+
+	// SetupRollbackOptions controls RollbackSetupRecord.
+	SetupRollbackOptions = bdeploy.RollbackOptions
+
+	// SetupRollbackResult describes what RollbackSetupRecord did, or would do.
+	SetupRollbackResult = bdeploy.RollbackResult
+
+	// AssociationCheck compares a player's ledger entry with what B-Deploy reports.
+	AssociationCheck = bdeploy.AssociationCheck
+
+	// AssociationStatus is the outcome of checking one player against the ledger.
+	AssociationStatus = bdeploy.AssociationStatus
+
+	// DeviceImportRow is one row of a B-Deploy device import file.
+	DeviceImportRow = bdeploy.DeviceRow
+
+	// DeviceImportOptions controls ImportDevices.
+	DeviceImportOptions = bdeploy.ImportOptions
+
+	// DeviceImportResult is what ImportDevices did, or would do, for one row.
+	DeviceImportResult = bdeploy.DeviceImportResult
+
+	// SetupMigrateOptions controls MigrateSetups.
+	SetupMigrateOptions = bdeploy.MigrateOptions
+
+	// SetupMigrationResult is what MigrateSetups did, or would do, for one v2 record.
+	SetupMigrationResult = bdeploy.MigrationResult
+
+	// BrightWallSpec describes a BrightWall video wall for GenerateBrightWall.
+	BrightWallSpec = bdeploy.WallSpec
+
+	// BrightWallNetwork gives a wall's players consecutive static addresses.
+	BrightWallNetwork = bdeploy.WallNetwork
+
+	// BrightWallScreen places a player in a wall by row and column.
+	BrightWallScreen = bdeploy.WallScreen
+)
+
+// Association check results
+const (
+	AssociationOK            = bdeploy.AssociationOK
+	AssociationMismatch      = bdeploy.AssociationMismatch
+	AssociationUnverified    = bdeploy.AssociationUnverified
+	AssociationUnrecorded    = bdeploy.AssociationUnrecorded
+	AssociationMissingDevice = bdeploy.AssociationMissingDevice
+	AssociationMissingSetup  = bdeploy.AssociationMissingSetup
+)
+
+// Setup record actions
+const (
+	SetupActionCreate    = bdeploy.ActionCreate
+	SetupActionUpdate    = bdeploy.ActionUpdate
+	SetupActionDelete    = bdeploy.ActionDelete
+	SetupActionAssociate = bdeploy.ActionAssociate
+	SetupActionNone      = bdeploy.ActionNone
+	SetupActionSkip      = bdeploy.ActionSkip
+)
+
+// RedactedSecret replaces passwords and tokens in redacted output.
+const RedactedSecret = types.RedactedSecret
+
+// Setup record layout versions
+const (
+	SetupVersion2 = types.SetupVersion2
+	SetupVersion3 = types.SetupVersion3
+)
+
+var (
+	// LoadSetupTemplate reads a base setup document and overlays from .json or .yaml files.
+	LoadSetupTemplate = bdeploy.LoadTemplate
+
+	// LoadSetupDocument reads a .json or .yaml setup document.
+	LoadSetupDocument = bdeploy.LoadDocument
+
+	// MergeSetupDocuments merges overlays onto a base document as JSON merge patches.
+	MergeSetupDocuments = bdeploy.Merge
+
+	// SubstituteSetupVariables replaces ${var} references in a document.
+	SubstituteSetupVariables = bdeploy.Substitute
+
+	// LoadSetupSites reads a sites CSV file.
+	LoadSetupSites = bdeploy.LoadSites
+
+	// ReadSetupSites reads sites CSV data.
+	ReadSetupSites = bdeploy.ReadSites
+
+	// ApplySetupSites renders a setup record per site and creates or updates it.
+	ApplySetupSites = bdeploy.ApplySites
+
+	// LoadSetupDesiredState reads a directory of setup records and an association manifest.
+	LoadSetupDesiredState = bdeploy.LoadDesiredState
+
+	// LoadSetupAssociations reads a serial-to-package association manifest.
+	LoadSetupAssociations = bdeploy.LoadAssociations
+
+	// PlanSetupReconcile compares the desired state with a network and returns the changes needed.
+	PlanSetupReconcile = bdeploy.PlanReconcile
+
+	// ApplySetupPlan makes the changes in a reconciliation plan.
+	ApplySetupPlan = bdeploy.ApplyPlan
+
+	// VerifyAssociations checks B-Deploy's reported setup for each player against the ledger.
+	VerifyAssociations = bdeploy.VerifyAssociations
+
+	// NewFileAssociationLedger returns an association ledger kept in a JSON file.
+	NewFileAssociationLedger = ledger.NewFileStore
+
+	// NewMemoryAssociationLedger returns an association ledger kept in memory.
+	NewMemoryAssociationLedger = ledger.NewMemoryStore
+
+	// DefaultAssociationLedgerPath returns the default association ledger file.
+	DefaultAssociationLedgerPath = ledger.DefaultPath
+
+	// DiffSetupRecords compares two setup records field by field, masking secrets.
+	DiffSetupRecords = bdeploy.DiffSetupRecords
+
+	// RollbackSetupRecord restores a setup record from the setup history.
+	RollbackSetupRecord = bdeploy.RollbackSetupRecord
+
+	// NewFileSetupHistory returns a setup history kept in a directory.
+	NewFileSetupHistory = history.NewFileStore
+
+	// NewMemorySetupHistory returns a setup history kept in memory.
+	NewMemorySetupHistory = history.NewMemoryStore
+
+	// DefaultSetupHistoryDir returns the usual setup history directory.
+	DefaultSetupHistoryDir = history.DefaultDir
+
+	// FindSetupSnapshot returns the newest snapshot taken at or before a time.
+	FindSetupSnapshot = history.At
+
+	// NewFileAuditSink returns an audit sink appending JSON lines to a file.
+	NewFileAuditSink = audit.NewFileSink
+
+	// NewSyslogAuditSink returns an audit sink sending records to syslog.
+	NewSyslogAuditSink = audit.NewSyslogSink
+
+	// NewMemoryAuditSink returns an audit sink keeping records in memory.
+	NewMemoryAuditSink = audit.NewMemorySink
+
+	// NewSecretSources returns a resolver for secret references; exec: references
+	// are only resolved with allowExec.
+	NewSecretSources = secrets.NewSources
+
+	// IsSecretReference reports whether a value is a secret reference rather than a secret.
+	IsSecretReference = types.IsSecretReference
+
+	// MarshalSetupRecord encodes a setup record, including the zero-valued fields
+	// listed in its ForceSendFields.
+	MarshalSetupRecord = types.MarshalSetupRecord
+
+	// UnmarshalSetupRecord decodes a setup record, adding the zero-valued fields
+	// present in the JSON to its ForceSendFields.
+	UnmarshalSetupRecord = types.UnmarshalSetupRecord
+
+	// RedactSecrets masks passwords and tokens in a JSON or form-encoded body.
+	RedactSecrets = secrets.Redact
+
+	// LoadDeviceImportRows reads a CSV, TSV or semicolon-separated device import file.
+	LoadDeviceImportRows = bdeploy.LoadDeviceRows
+
+	// ReadDeviceImportRows reads device import data.
+	ReadDeviceImportRows = bdeploy.ReadDeviceRows
+
+	// ImportDevices creates, updates or deletes B-Deploy devices to match import rows.
+	ImportDevices = bdeploy.ImportDevices
+
+	// WriteDeviceImportReport writes import results as CSV.
+	WriteDeviceImportReport = bdeploy.WriteImportReport
+
+	// MigrateSetups converts a network's v2 setup records to v3 and saves them with the v3 API.
+	MigrateSetups = bdeploy.MigrateSetups
+
+	// LoadBrightWallSpec reads a BrightWall spec from a .json or .yaml file.
+	LoadBrightWallSpec = bdeploy.LoadWallSpec
+
+	// GenerateBrightWall returns a setup record per screen of a wall and the player associations.
+	GenerateBrightWall = bdeploy.GenerateWall
+)
+
+// Setup record issue severities
+const (
+	SetupIssueError   = types.SetupIssueError
+	SetupIssueWarning = types.SetupIssueWarning
+)
+
 // Re-export reboot type constants
 const (
 	// RebootTypeNormal performs a standard reboot
@@ -416,6 +970,38 @@ const (
 	RebootTypeDisableAutorun = types.RebootTypeDisableAutorun
 )
 
+// Re-export error types
+type (
+	// APIError is a BSN.cloud API error, or a failed operation wrapping its cause
+	// with the status code, operation, player serial and request ID.
+	APIError = errors.APIError
+
+	// AuthenticationError indicates authentication-related errors.
+	AuthenticationError = errors.AuthenticationError
+
+	// NetworkError indicates network-related errors.
+	NetworkError = errors.NetworkError
+
+	// PlayerError is the message an rDWS call got from an offline player, or one
+	// that rejected the command, in place of a result.
+	PlayerError = errors.PlayerError
+)
+
+// Re-export sentinel errors, for use with errors.Is
+var (
+	// ErrNotFound matches a 404 Not Found response.
+	ErrNotFound = errors.ErrNotFound
+
+	// ErrForbidden matches a 403 Forbidden response.
+	ErrForbidden = errors.ErrForbidden
+
+	// ErrRateLimited matches a 429 Too Many Requests response.
+	ErrRateLimited = errors.ErrRateLimited
+
+	// ErrPlayerOffline matches a failed call on a player that is not connected to BSN.cloud.
+	ErrPlayerOffline = errors.ErrPlayerOffline
+)
+
 // Re-export error checking functions
 var (
 	// IsAuthenticationError checks if an error is authentication-related.
@@ -427,6 +1013,9 @@ var (
 	// IsConfigurationError checks if an error is configuration-related.
 	IsConfigurationError = errors.IsConfigurationError
 
+	// IsPlayerError checks if an error is a player's rDWS error result.
+	IsPlayerError = errors.IsPlayerError
+
 	// IsRetryableError checks if an error might succeed on retry.
 	IsRetryableError = errors.IsRetryableError
 )
@@ -440,6 +1029,9 @@ type Client struct {
 	httpClient  *http.HTTPClient
 	authManager *auth.AuthManager
 
+	networksMu sync.Mutex
+	networks   map[string]*Client // Handles returned by Network, by network name
+
 	// Services
 	Devices        services.DeviceService
 	BDeploy        services.BDeployService
@@ -487,25 +1079,88 @@ func New(opts ...Option) (*Client, error) {
 		return nil, err
 	}
 
+	// Resolve the stores once so the services, the client and its network handles
+	// share them; two stores on the same file would lose each other's updates
+	if cfg.AssociationLedger == nil {
+		cfg.AssociationLedger = cfg.AssociationStore()
+	}
+	if cfg.SetupHistory == nil {
+		cfg.SetupHistory = cfg.SetupHistoryStore()
+	}
+	if cfg.SecretResolver == nil {
+		cfg.SecretResolver = cfg.SecretsResolver()
+	}
+	if cfg.AuditSink == nil {
+		cfg.AuditSink = cfg.AuditLog()
+	}
+
 	// Create HTTP client
 	httpClient := http.NewHTTPClient(cfg)
 
-	// Create authentication manager
+	return newClient(cfg, httpClient, nil), nil
+}
+
+// newClient creates a client with its own authentication manager, and so its own
+// session, on a shared HTTP client. If parent is not nil the client is a network
+// handle of parent and shares its players' circuit breakers.
+func newClient(cfg *config.Config, httpClient *http.HTTPClient, parent *Client) *Client {
 	authManager := auth.NewAuthManager(cfg, httpClient)
+	if cfg.TokenRefreshFraction > 0 {
+		authManager.StartRefresher(cfg.TokenRefreshFraction)
+	}
 
-	client := &Client{
+	rdws := services.NewRDWSService(cfg, httpClient, authManager)
+	if parent != nil {
+		rdws = services.NewNetworkRDWSService(parent.RDWS, cfg, httpClient, authManager)
+	}
+
+	return &Client{
 		config:         cfg,
 		httpClient:     httpClient,
 		authManager:    authManager,
 		Devices:        services.NewDeviceService(cfg, httpClient, authManager),
 		BDeploy:        services.NewBDeployService(cfg, httpClient, authManager),
 		Provisioning:   services.NewProvisioningService(cfg, httpClient, authManager),
-		RDWS:           services.NewRDWSService(cfg, httpClient, authManager),
+		RDWS:           rdws,
 		Subscriptions:  services.NewSubscriptionService(cfg, httpClient, authManager),
 		DeviceWebPages: services.NewDeviceWebPageService(cfg, httpClient, authManager),
 	}
+}
+
+// Network returns a client for one network, with its own BSN.cloud session.
+//
+// BSN.cloud keeps the selected network in the session, so a client works on one
+// network at a time. Each client Network returns has its own token and session,
+// so goroutines can work on different networks at once:
+//
+//	east := client.Network("Store-East")
+//	devices, err := east.Devices.List(ctx)
+//
+// The network is selected on the first call and again whenever the token is
+// refreshed. The handle shares the client's configuration, HTTP client, stores
+// (association ledger, setup history, audit log) and player circuit breakers, and
+// is cached: calling Network with the same name returns the same client. Handles
+// authenticate with the client credentials, not a pre-loaded access token, since
+// selecting a network would change that token's session.
+func (c *Client) Network(name string) *Client {
+	c.networksMu.Lock()
+	defer c.networksMu.Unlock()
+
+	if handle, ok := c.networks[name]; ok {
+		return handle
+	}
+
+	cfg := *c.config
+	cfg.NetworkName = name
+	cfg.AccessToken = ""
+	cfg.ExpiresAt = time.Time{}
 
-	return client, nil
+	handle := newClient(&cfg, c.httpClient, c)
+	if c.networks == nil {
+		c.networks = make(map[string]*Client)
+	}
+	c.networks[name] = handle
+	return handle
 }
 
 // Authenticate performs OAuth2 authentication with BSN.cloud using the configured credentials.
@@ -559,6 +1214,44 @@ func (c *Client) GetAccessToken() (string, error) {
 	return c.authManager.GetToken()
 }
 
+// TokenMetrics returns when the access token expires and how often it has been
+// refreshed.
+func (c *Client) TokenMetrics() TokenMetrics {
+	return c.authManager.Metrics()
+}
+
+// Close stops the background token refresher of the client and of the clients
+// Network returned. The client can still be used afterwards, with tokens
+// refreshed on demand.
+func (c *Client) Close() error {
+	c.networksMu.Lock()
+	defer c.networksMu.Unlock()
+
+	c.authManager.StopRefresher()
+	for _, handle := range c.networks {
+		handle.authManager.StopRefresher()
+	}
+	return nil
+}
+
+// AssociationLedger returns the store that records B-Deploy device associations,
+// or nil if the ledger is disabled.
+func (c *Client) AssociationLedger() AssociationLedger {
+	return c.config.AssociationLedger
+}
+
+// SetupHistory returns the store that keeps snapshots of B-Deploy setup records,
+// or nil if the setup history is off.
+func (c *Client) SetupHistory() SetupHistory {
+	return c.config.SetupHistory
+}
+
+// SecretResolver returns the resolver for secret references in B-Deploy setup
+// records, or nil if references are sent as they are.
+func (c *Client) SecretResolver() SecretResolver {
+	return c.config.SecretsResolver()
+}
+
 // Config returns a copy of the client configuration.
 func (c *Client) Config() config.Config {
 	return *c.config
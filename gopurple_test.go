@@ -2,6 +2,9 @@ package gopurple
 
 import (
 	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 )
@@ -324,3 +327,72 @@ func TestPublicTypeExports(t *testing.T) {
 		}
 	})
 }
+
+func TestClientNetwork(t *testing.T) {
+	client, err := New(
+		WithCredentials("test-id", "test-secret"),
+		WithNetwork("Default"),
+		WithAccessToken("preloaded", time.Now().Add(time.Hour)),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	east := client.Network("Store-East")
+	if east == client || client.Network("Store-East") != east {
+		t.Error("Expected Network to return one cached handle per network")
+	}
+	if client.Network("Store-West") == east {
+		t.Error("Expected each network to get its own handle")
+	}
+
+	config := east.Config()
+	if config.NetworkName != "Store-East" || config.AccessToken != "" {
+		t.Errorf("Expected the handle to select Store-East with its own token, got %q and %q", config.NetworkName, config.AccessToken)
+	}
+	if client.Config().NetworkName != "Default" || !client.IsAuthenticated() {
+		t.Error("Expected the client itself to be unchanged")
+	}
+	if east.IsAuthenticated() || east.IsNetworkSet() {
+		t.Error("Expected the handle to start without a session")
+	}
+}
+
+func TestClientNetwork_SharedLedger(t *testing.T) {
+	client, err := New(
+		WithCredentials("test-id", "test-secret"),
+		WithAssociationLedgerFile(filepath.Join(t.TempDir(), "ledger.json")),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	east, west := client.Network("Store-East"), client.Network("Store-West")
+	if east.AssociationLedger() != client.AssociationLedger() || west.AssociationLedger() != client.AssociationLedger() {
+		t.Fatal("Expected the handles to share the client's association ledger")
+	}
+
+	// Both handles record associations at once; none may be lost
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		for _, handle := range []*Client{east, west} {
+			wg.Add(1)
+			go func(handle *Client, i int) {
+				defer wg.Done()
+				entry := AssociationEntry{NetworkName: handle.Config().NetworkName, Serial: fmt.Sprintf("XD%03d", i), SetupID: "setup-1"}
+				if err := handle.AssociationLedger().Put(ctx, entry); err != nil {
+					t.Errorf("Put failed: %v", err)
+				}
+			}(handle, i)
+		}
+	}
+	wg.Wait()
+
+	entries, err := client.AssociationLedger().List(ctx, "")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(entries) != 40 {
+		t.Errorf("Expected 40 associations, got %d", len(entries))
+	}
+}
@@ -0,0 +1,116 @@
+// Package audit records who changed what through the SDK: every state-changing
+// call, such as a reboot, a storage reformat or a setup record deletion, produces
+// a record with the caller, the target, the redacted parameters and the outcome.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Outcomes of an audited operation.
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+// Record is one state-changing call.
+type Record struct {
+	Time      time.Time         `json:"time"` // When the call was made, UTC
+	ClientID  string            `json:"clientId"`
+	User      string            `json:"user,omitempty"` // Person or system acting through the client
+	Operation string            `json:"operation"`      // Service and method, e.g. "Devices.RebootBySerial"
+	Target    string            `json:"target,omitempty"`
+	Network   string            `json:"network,omitempty"`
+	Params    map[string]string `json:"params,omitempty"` // Secrets are redacted
+	Outcome   string            `json:"outcome"`          // OutcomeSuccess or OutcomeFailure
+	Error     string            `json:"error,omitempty"`
+}
+
+// Sink receives audit records. Implementations must be safe for concurrent use.
+type Sink interface {
+	// Write records a call.
+	Write(ctx context.Context, record Record) error
+}
+
+// FileSink appends records to a file as JSON lines. Records hold no secrets, but
+// the file is readable only by its owner, as it tells who did what to which
+// player.
+type FileSink struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileSink returns a sink appending to the file at path. The file and its
+// directory are created on the first record.
+func NewFileSink(path string) *FileSink {
+	return &FileSink{path: path}
+}
+
+// Path returns the file the sink appends to.
+func (s *FileSink) Path() string {
+	return s.path
+}
+
+// Write appends a record to the file.
+func (s *FileSink) Write(ctx context.Context, record Record) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// MemorySink keeps records in memory, e.g. for tests.
+type MemorySink struct {
+	mu      sync.Mutex
+	records []Record
+}
+
+// NewMemorySink returns an empty in-memory sink.
+func NewMemorySink() *MemorySink {
+	return &MemorySink{}
+}
+
+// Write adds a record.
+func (s *MemorySink) Write(ctx context.Context, record Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, record)
+	return nil
+}
+
+// Records returns the records written so far, oldest first.
+func (s *MemorySink) Records() []Record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Record(nil), s.records...)
+}
+
+// message returns the text of a record for a line-oriented log such as syslog.
+func message(record Record) string {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Sprintf("%s %s %s", record.Operation, record.Target, record.Outcome)
+	}
+	return string(data)
+}
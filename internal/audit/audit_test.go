@@ -0,0 +1,64 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "audit.jsonl")
+	sink := NewFileSink(path)
+
+	records := []Record{
+		{Time: time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC), ClientID: "id", User: "jdoe", Operation: "Devices.RebootBySerial",
+			Target: "XD001", Network: "Production", Params: map[string]string{"rebootType": "normal"}, Outcome: OutcomeSuccess},
+		{Time: time.Date(2026, 10, 18, 9, 1, 0, 0, time.UTC), ClientID: "id", Operation: "RDWS.ReformatStorage",
+			Target: "XD001", Outcome: OutcomeFailure, Error: "player offline"},
+	}
+	for _, record := range records {
+		if err := sink.Write(context.Background(), record); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Expected the file to be created: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected the file to be readable only by its owner, got %v", info.Mode().Perm())
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var read []Record
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("Expected a JSON record per line, got %q: %v", scanner.Text(), err)
+		}
+		read = append(read, record)
+	}
+	if len(read) != 2 || read[0].Params["rebootType"] != "normal" || read[1].Error != "player offline" || !read[1].Time.Equal(records[1].Time) {
+		t.Errorf("Expected the records back, got %+v", read)
+	}
+}
+
+func TestMemorySink(t *testing.T) {
+	sink := NewMemorySink()
+	sink.Write(context.Background(), Record{Operation: "RDWS.FlushRegistry", Outcome: OutcomeSuccess})
+	records := sink.Records()
+	records[0].Operation = "changed"
+	if got := sink.Records(); len(got) != 1 || got[0].Operation != "RDWS.FlushRegistry" {
+		t.Errorf("Expected the records to be a copy, got %+v", got)
+	}
+}
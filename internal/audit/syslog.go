@@ -0,0 +1,38 @@
+//go:build !windows && !plan9
+
+package audit
+
+import (
+	"context"
+	"log/syslog"
+)
+
+// SyslogSink sends records to syslog with the auth facility, as JSON messages:
+// successes at notice level, failures at warning level.
+type SyslogSink struct {
+	writer *syslog.Writer
+}
+
+// NewSyslogSink connects to the local syslog daemon, or to a remote one if
+// network ("udp" or "tcp") and addr are set. The tag names the program in the
+// log; an empty tag uses the program name.
+func NewSyslogSink(network, addr, tag string) (*SyslogSink, error) {
+	writer, err := syslog.Dial(network, addr, syslog.LOG_AUTH|syslog.LOG_NOTICE, tag)
+	if err != nil {
+		return nil, err
+	}
+	return &SyslogSink{writer: writer}, nil
+}
+
+// Write sends a record to syslog.
+func (s *SyslogSink) Write(ctx context.Context, record Record) error {
+	if record.Outcome == OutcomeFailure {
+		return s.writer.Warning(message(record))
+	}
+	return s.writer.Notice(message(record))
+}
+
+// Close closes the connection to syslog.
+func (s *SyslogSink) Close() error {
+	return s.writer.Close()
+}
@@ -0,0 +1,26 @@
+//go:build windows || plan9
+
+package audit
+
+import (
+	"context"
+	"errors"
+)
+
+// SyslogSink is not available on this platform; use a FileSink instead.
+type SyslogSink struct{}
+
+// NewSyslogSink reports that syslog is not available on this platform.
+func NewSyslogSink(network, addr, tag string) (*SyslogSink, error) {
+	return nil, errors.New("syslog is not available on this platform")
+}
+
+// Write reports that syslog is not available on this platform.
+func (s *SyslogSink) Write(ctx context.Context, record Record) error {
+	return errors.New("syslog is not available on this platform")
+}
+
+// Close does nothing.
+func (s *SyslogSink) Close() error {
+	return nil
+}
@@ -10,6 +10,8 @@ import (
 	"github.com/brightdevelopers/gopurple/internal/errors"
 	"github.com/brightdevelopers/gopurple/internal/http"
 	"github.com/brightdevelopers/gopurple/internal/types"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
 )
 
 // AuthManager handles OAuth2 authentication and network selection for BSN.cloud.
@@ -18,9 +20,17 @@ type AuthManager struct {
 	httpClient     *http.HTTPClient
 	mu             sync.RWMutex
 	accessToken    string
+	issuedAt       time.Time
 	expiresAt      time.Time
 	networkSet     bool
 	currentNetwork *types.Network
+
+	inflight *flight       // Token request that concurrent refreshes wait on
+	metrics  TokenMetrics  // Token refresh counters
+	issued   chan struct{} // Signals the background refresher that a token was obtained
+	stop     chan struct{} // Closed to stop the background refresher (nil if not running)
+
+	refreshCounter metric.Int64Counter // Nil without a meter provider
 }
 
 // NewAuthManager creates a new authentication manager.
@@ -28,27 +38,45 @@ func NewAuthManager(cfg *config.Config, httpClient *http.HTTPClient) *AuthManage
 	am := &AuthManager{
 		config:     cfg,
 		httpClient: httpClient,
+		issued:     make(chan struct{}, 1),
+	}
+
+	if cfg.MeterProvider != nil {
+		// A counter that fails to register comes back as a no-op one
+		am.refreshCounter, _ = cfg.MeterProvider.Meter(http.InstrumentationName).Int64Counter("gopurple.token.refreshes",
+			metric.WithDescription("Access tokens obtained"),
+			metric.WithUnit("{token}"))
 	}
 
-	// If a pre-loaded access token was provided, use it
+	// If a pre-loaded access token was provided, use it. When it was issued is
+	// unknown, so its lifetime is counted from now
 	if cfg.AccessToken != "" && !cfg.ExpiresAt.IsZero() {
 		am.accessToken = cfg.AccessToken
+		am.issuedAt = time.Now()
 		am.expiresAt = cfg.ExpiresAt
+		am.metrics.ExpiresAt = cfg.ExpiresAt
 	}
 
 	return am
 }
 
-// Authenticate performs OAuth2 client credentials authentication.
+// Authenticate performs OAuth2 client credentials authentication. Concurrent
+// calls share one token request.
 func (a *AuthManager) Authenticate(ctx context.Context) error {
-	a.mu.Lock()
-	defer a.mu.Unlock()
-
 	// Check if we already have a valid token
-	if a.accessToken != "" && time.Until(a.expiresAt) > 30*time.Second {
+	a.mu.RLock()
+	valid := a.accessToken != "" && time.Until(a.expiresAt) > 30*time.Second
+	a.mu.RUnlock()
+	if valid {
 		return nil
 	}
 
+	return a.refresh(ctx, false)
+}
+
+// requestToken gets a new token and swaps it in, selecting the session's network
+// on the new token first so that callers never see a token without it.
+func (a *AuthManager) requestToken(ctx context.Context, background bool) error {
 	// Prepare token request
 	formData := map[string]string{
 		"grant_type": "client_credentials",
@@ -56,7 +84,7 @@ func (a *AuthManager) Authenticate(ctx context.Context) error {
 
 	var tokenResp types.TokenResponse
 	err := a.httpClient.PostFormWithAuth(
-		ctx,
+		http.WithOperation(ctx, http.Operation{Name: "Auth.Token"}),
 		a.config.ClientID,
 		a.config.ClientSecret,
 		a.config.TokenEndpoint,
@@ -66,18 +94,82 @@ func (a *AuthManager) Authenticate(ctx context.Context) error {
 	if err != nil {
 		return errors.NewAuthError("failed to get access token", err)
 	}
+	issuedAt := time.Now()
+
+	// The network is part of the session, so a new token starts without one.
+	// Select the network the old session had again, so a refresh is transparent.
+	// SetNetwork may select another network on the old session while this runs,
+	// so the token is only swapped in once its session has the current network.
+	var selected *types.Network
+	for {
+		a.mu.RLock()
+		var network *types.Network
+		if a.networkSet && a.currentNetwork != nil {
+			current := *a.currentNetwork
+			network = &current
+		}
+		a.mu.RUnlock()
+
+		if network != nil && (selected == nil || *selected != *network) {
+			if err := a.putNetwork(ctx, tokenResp.AccessToken, *network); err != nil {
+				return errors.NewAuthError(fmt.Sprintf("failed to select network '%s' again after refreshing the token", networkLabel(*network)), err)
+			}
+			selected = network
+		}
+
+		a.mu.Lock()
+		if !a.networkSet || a.currentNetwork == nil || (selected != nil && *a.currentNetwork == *selected) {
+			break
+		}
+		a.mu.Unlock()
+	}
 
-	// Update token information
+	// Update token information; the lock is still held from the loop above
 	a.accessToken = tokenResp.AccessToken
-	a.expiresAt = time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
+	a.issuedAt = issuedAt
+	a.expiresAt = issuedAt.Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
+
+	a.metrics.Refreshes++
+	if background {
+		a.metrics.BackgroundRefreshes++
+	}
+	a.metrics.LastRefresh = issuedAt
+	a.metrics.ExpiresAt = a.expiresAt
+	refreshed := types.TokenRefresh{ExpiresAt: a.expiresAt, Background: background}
+	if a.networkSet && a.currentNetwork != nil {
+		refreshed.NetworkName = a.currentNetwork.Name
+	}
+	a.mu.Unlock()
 
-	// Reset network state since we have a new token
-	a.networkSet = false
-	a.currentNetwork = nil
+	select {
+	case a.issued <- struct{}{}:
+	default:
+	}
+	if a.refreshCounter != nil {
+		a.refreshCounter.Add(ctx, 1, metric.WithAttributes(attribute.Bool("background", background)))
+	}
+	if a.config.OnTokenRefreshed != nil {
+		a.config.OnTokenRefreshed(refreshed)
+	}
 
 	return nil
 }
 
+// putNetwork selects the network of the session a token belongs to.
+func (a *AuthManager) putNetwork(ctx context.Context, token string, network types.Network) error {
+	url := fmt.Sprintf("%s/%s/Self/Session/Network", a.config.BSNBaseURL, a.config.APIVersion)
+	ctx = http.WithOperation(ctx, http.Operation{Name: "Auth.SetNetwork", Network: networkLabel(network)})
+	return a.httpClient.PutWithAuth(ctx, token, url, types.NetworkRequest{Name: network.Name, ID: network.ID}, nil)
+}
+
+// networkLabel names a network by name, or by ID when it was selected by ID.
+func networkLabel(network types.Network) string {
+	if network.Name != "" {
+		return network.Name
+	}
+	return fmt.Sprintf("ID %d", network.ID)
+}
+
 // SetNetwork sets the active network for API operations.
 func (a *AuthManager) SetNetwork(ctx context.Context, networkName string) error {
 	if networkName == "" {
@@ -98,12 +190,7 @@ func (a *AuthManager) SetNetwork(ctx context.Context, networkName string) error
 	}
 
 	// Set the network via API
-	networkReq := types.NetworkRequest{
-		Name: networkName,
-	}
-
-	url := fmt.Sprintf("%s/%s/Self/Session/Network", a.config.BSNBaseURL, a.config.APIVersion)
-	err := a.httpClient.PutWithAuth(ctx, a.accessToken, url, networkReq, nil)
+	err := a.putNetwork(ctx, a.accessToken, types.Network{Name: networkName})
 	if err != nil {
 		return errors.NewAuthError(fmt.Sprintf("failed to set network '%s'", networkName), err)
 	}
@@ -135,12 +222,7 @@ func (a *AuthManager) SetNetworkByID(ctx context.Context, networkID int) error {
 	}
 
 	// Set the network via API
-	networkReq := types.NetworkRequest{
-		ID: networkID,
-	}
-
-	url := fmt.Sprintf("%s/%s/Self/Session/Network", a.config.BSNBaseURL, a.config.APIVersion)
-	err := a.httpClient.PutWithAuth(ctx, a.accessToken, url, networkReq, nil)
+	err := a.putNetwork(ctx, a.accessToken, types.Network{ID: networkID})
 	if err != nil {
 		return errors.NewAuthError(fmt.Sprintf("failed to set network ID %d", networkID), err)
 	}
@@ -165,6 +247,7 @@ func (a *AuthManager) GetNetworks(ctx context.Context) ([]types.Network, error)
 
 	url := fmt.Sprintf("%s/%s/Self/Networks", a.config.BSNBaseURL, a.config.APIVersion)
 	var networks []types.Network
+	ctx = http.WithOperation(ctx, http.Operation{Name: "Auth.GetNetworks"})
 	err := a.httpClient.GetWithAuth(ctx, token, url, &networks)
 	if err != nil {
 		return nil, errors.NewAuthError("failed to get networks", err)
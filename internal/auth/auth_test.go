@@ -0,0 +1,321 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	nethttp "net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/brightdevelopers/gopurple/internal/config"
+	"github.com/brightdevelopers/gopurple/internal/http"
+	"github.com/brightdevelopers/gopurple/internal/types"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// testServer is a BSN.cloud stand-in that issues numbered tokens and records the
+// network each token's session selects.
+type testServer struct {
+	mu        sync.Mutex
+	tokens    int
+	sessions  map[string]string
+	expiresIn int           // Token lifetime in seconds
+	delay     time.Duration // How long a token request takes
+	failures  int           // Token requests still to fail with 503
+}
+
+func newTestServer(t *testing.T) (*testServer, *httptest.Server) {
+	ts := &testServer{sessions: map[string]string{}, expiresIn: 3600}
+	server := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		if r.URL.Path == "/token" {
+			ts.mu.Lock()
+			delay := ts.delay
+			ts.mu.Unlock()
+			time.Sleep(delay)
+		}
+
+		ts.mu.Lock()
+		defer ts.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/token":
+			if ts.failures > 0 {
+				ts.failures--
+				w.WriteHeader(nethttp.StatusServiceUnavailable)
+				return
+			}
+			ts.tokens++
+			json.NewEncoder(w).Encode(types.TokenResponse{AccessToken: fmt.Sprintf("token-%d", ts.tokens), ExpiresIn: ts.expiresIn})
+		case "/2022/06/REST/Self/Session/Network":
+			var req types.NetworkRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			ts.sessions[r.Header.Get("Authorization")] = req.Name
+			w.WriteHeader(nethttp.StatusNoContent)
+		default:
+			w.WriteHeader(nethttp.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return ts, server
+}
+
+func (ts *testServer) tokenCount() int {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	return ts.tokens
+}
+
+func (ts *testServer) session(token string) string {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	return ts.sessions["Bearer "+token]
+}
+
+func newTestAuthManager(server *httptest.Server) *AuthManager {
+	cfg := config.DefaultConfig()
+	cfg.ClientID = "test-id"
+	cfg.ClientSecret = "test-secret"
+	cfg.TokenEndpoint = server.URL + "/token"
+	cfg.BSNBaseURL = server.URL
+	cfg.RetryCount = 0
+	return NewAuthManager(cfg, http.NewHTTPClient(cfg))
+}
+
+func TestAuthManager_RefreshKeepsNetwork(t *testing.T) {
+	ts, server := newTestServer(t)
+	a := newTestAuthManager(server)
+	ctx := context.Background()
+
+	if err := a.SetNetwork(ctx, "Store-East"); err != nil {
+		t.Fatalf("SetNetwork failed: %v", err)
+	}
+
+	// Expire the token so the next call refreshes it
+	a.mu.Lock()
+	a.expiresAt = time.Now()
+	a.mu.Unlock()
+	if err := a.EnsureValid(ctx); err != nil {
+		t.Fatalf("EnsureValid failed: %v", err)
+	}
+
+	token, err := a.GetToken()
+	if err != nil || token != "token-2" {
+		t.Fatalf("Expected a refreshed token, got %q (%v)", token, err)
+	}
+	if network := ts.session(token); network != "Store-East" {
+		t.Errorf("Expected the new session to select Store-East again, got %q", network)
+	}
+	if current, err := a.GetCurrentNetwork(); err != nil || current.Name != "Store-East" {
+		t.Errorf("Expected the network to stay selected, got %+v (%v)", current, err)
+	}
+}
+
+func TestAuthManager_SeparateSessions(t *testing.T) {
+	ts, server := newTestServer(t)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	managers := map[string]*AuthManager{"Store-East": newTestAuthManager(server), "Store-West": newTestAuthManager(server)}
+	for network, a := range managers {
+		wg.Add(1)
+		go func(network string, a *AuthManager) {
+			defer wg.Done()
+			if err := a.SetNetwork(ctx, network); err != nil {
+				t.Errorf("SetNetwork(%s) failed: %v", network, err)
+			}
+		}(network, a)
+	}
+	wg.Wait()
+
+	for network, a := range managers {
+		token, _ := a.GetToken()
+		if got := ts.session(token); got != network {
+			t.Errorf("Expected the session of %s to select it, got %q", network, got)
+		}
+	}
+}
+
+func TestAuthManager_SingleFlight(t *testing.T) {
+	ts, server := newTestServer(t)
+	ts.delay = 100 * time.Millisecond
+	a := newTestAuthManager(server)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := a.Authenticate(context.Background()); err != nil {
+				t.Errorf("Authenticate failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := ts.tokenCount(); n != 1 {
+		t.Errorf("Expected concurrent calls to share 1 token request, got %d", n)
+	}
+	metrics := a.Metrics()
+	if metrics.Refreshes != 1 || metrics.SharedRefreshes == 0 {
+		t.Errorf("Expected 1 refresh shared by the other calls, got %+v", metrics)
+	}
+	if in := metrics.ExpiresIn(); in <= 0 || in > time.Hour {
+		t.Errorf("Expected the token to expire within the hour, got %v", in)
+	}
+}
+
+func TestAuthManager_RefreshOutlivesCaller(t *testing.T) {
+	ts, server := newTestServer(t)
+	ts.delay = 100 * time.Millisecond
+	a := newTestAuthManager(server)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := a.Authenticate(ctx); err == nil {
+		t.Fatal("Expected the caller whose context ended to stop waiting")
+	}
+	if err := a.Authenticate(context.Background()); err != nil {
+		t.Fatalf("Authenticate failed: %v", err)
+	}
+	if n := ts.tokenCount(); n != 1 {
+		t.Errorf("Expected the second caller to share the first token request, got %d", n)
+	}
+}
+
+func TestAuthManager_SetNetworkDuringRefresh(t *testing.T) {
+	ts, server := newTestServer(t)
+	a := newTestAuthManager(server)
+	ctx := context.Background()
+
+	if err := a.SetNetwork(ctx, "Store-East"); err != nil {
+		t.Fatalf("SetNetwork failed: %v", err)
+	}
+
+	// Refresh while the old token is still valid, and select another network on
+	// its session while the token request is in flight
+	ts.mu.Lock()
+	ts.delay = 100 * time.Millisecond
+	ts.mu.Unlock()
+	refreshed := make(chan error, 1)
+	go func() { refreshed <- a.refresh(ctx, false) }()
+	time.Sleep(20 * time.Millisecond)
+	if err := a.SetNetwork(ctx, "Store-West"); err != nil {
+		t.Fatalf("SetNetwork failed: %v", err)
+	}
+	if err := <-refreshed; err != nil {
+		t.Fatalf("refresh failed: %v", err)
+	}
+
+	token, _ := a.GetToken()
+	if token != "token-2" {
+		t.Fatalf("Expected a refreshed token, got %q", token)
+	}
+	if network := ts.session(token); network != "Store-West" {
+		t.Errorf("Expected the new session to select Store-West, got %q", network)
+	}
+	if current, err := a.GetCurrentNetwork(); err != nil || current.Name != "Store-West" {
+		t.Errorf("Expected Store-West to stay selected, got %+v (%v)", current, err)
+	}
+}
+
+func TestAuthManager_BackgroundRefresh(t *testing.T) {
+	ts, server := newTestServer(t)
+	ts.expiresIn = 2
+
+	refreshed := make(chan types.TokenRefresh, 10)
+	a := newTestAuthManager(server)
+	a.config.OnTokenRefreshed = func(r types.TokenRefresh) { refreshed <- r }
+	a.StartRefresher(0.5)
+	defer a.StopRefresher()
+
+	if err := a.SetNetwork(context.Background(), "Store-East"); err != nil {
+		t.Fatalf("SetNetwork failed: %v", err)
+	}
+	if r := <-refreshed; r.Background {
+		t.Errorf("Expected the first token to be obtained on demand, got %+v", r)
+	}
+
+	select {
+	case r := <-refreshed:
+		if !r.Background || r.NetworkName != "Store-East" {
+			t.Errorf("Expected a background refresh keeping Store-East, got %+v", r)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("Expected the token to be refreshed in the background")
+	}
+
+	// The token is too short-lived for GetToken, which keeps a margin before expiry
+	a.mu.RLock()
+	token := a.accessToken
+	a.mu.RUnlock()
+	if network := ts.session(token); network != "Store-East" {
+		t.Errorf("Expected the new session to select Store-East again, got %q", network)
+	}
+}
+
+func TestAuthManager_BackgroundRefreshRetries(t *testing.T) {
+	wait := refreshRetryWait
+	refreshRetryWait = 10 * time.Millisecond
+	defer func() { refreshRetryWait = wait }()
+
+	ts, server := newTestServer(t)
+	ts.expiresIn = 1
+	a := newTestAuthManager(server)
+	a.StartRefresher(0.1)
+	defer a.StopRefresher()
+
+	if err := a.Authenticate(context.Background()); err != nil {
+		t.Fatalf("Authenticate failed: %v", err)
+	}
+	ts.mu.Lock()
+	ts.failures = 2
+	ts.mu.Unlock()
+
+	deadline := time.Now().Add(3 * time.Second)
+	for a.Metrics().BackgroundRefreshes == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the refresher to retry until it got a token, got %+v", a.Metrics())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if metrics := a.Metrics(); metrics.Failures != 2 || metrics.LastError == "" {
+		t.Errorf("Expected 2 failed token requests, got %+v", metrics)
+	}
+}
+
+func TestAuthManager_RefreshMetric(t *testing.T) {
+	_, server := newTestServer(t)
+	reader := sdkmetric.NewManualReader()
+	a := newTestAuthManager(server)
+	a.config.MeterProvider = sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	a = NewAuthManager(a.config, a.httpClient)
+
+	if err := a.Authenticate(context.Background()); err != nil {
+		t.Fatalf("Authenticate failed: %v", err)
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("Collect failed: %v", err)
+	}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != "gopurple.token.refreshes" {
+				continue
+			}
+			points := m.Data.(metricdata.Sum[int64]).DataPoints
+			if len(points) != 1 || points[0].Value != 1 {
+				t.Fatalf("Expected 1 token refresh, got %+v", points)
+			}
+			if background, _ := points[0].Attributes.Value("background"); background.AsBool() {
+				t.Errorf("Expected an on-demand refresh, got %v", points[0].Attributes)
+			}
+			return
+		}
+	}
+	t.Fatal("Expected a gopurple.token.refreshes metric")
+}
@@ -0,0 +1,169 @@
+package auth
+
+import (
+	"context"
+	"time"
+
+	"github.com/brightdevelopers/gopurple/internal/errors"
+)
+
+// Wait before the background refresher retries a failed refresh; it doubles up
+// to refreshRetryMaxWait.
+var (
+	refreshRetryWait    = time.Second
+	refreshRetryMaxWait = time.Minute
+)
+
+// flight is a token request in progress, which concurrent refreshes wait on.
+type flight struct {
+	done chan struct{}
+	err  error
+}
+
+// TokenMetrics reports on the access token and how it has been refreshed.
+type TokenMetrics struct {
+	ExpiresAt           time.Time // When the current token expires (zero before authenticating)
+	LastRefresh         time.Time // When the current token was obtained
+	Refreshes           int       // Tokens obtained
+	BackgroundRefreshes int       // Tokens the background refresher obtained
+	SharedRefreshes     int       // Refreshes that waited on a token request already in flight
+	Failures            int       // Failed token requests
+	LastError           string    // Error of the last failed token request
+}
+
+// ExpiresIn returns the time left before the current token expires.
+func (m TokenMetrics) ExpiresIn() time.Duration {
+	if m.ExpiresAt.IsZero() {
+		return 0
+	}
+	return time.Until(m.ExpiresAt)
+}
+
+// Metrics returns the token metrics.
+func (a *AuthManager) Metrics() TokenMetrics {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	return a.metrics
+}
+
+// refresh gets a new token. If a token request is already in flight it waits for
+// that one instead of sending another. The request is shared, so it runs without
+// the cancellation of the caller that started it; a caller whose context ends
+// stops waiting but leaves the request to finish for the others.
+func (a *AuthManager) refresh(ctx context.Context, background bool) error {
+	a.mu.Lock()
+	f := a.inflight
+	if f != nil {
+		a.metrics.SharedRefreshes++
+	} else {
+		f = &flight{done: make(chan struct{})}
+		a.inflight = f
+		go a.fly(context.WithoutCancel(ctx), f, background)
+	}
+	a.mu.Unlock()
+
+	select {
+	case <-f.done:
+		return f.err
+	case <-ctx.Done():
+		return errors.NewAuthError("waiting for token refresh", ctx.Err())
+	}
+}
+
+// fly sends the token request of a flight and lands it.
+func (a *AuthManager) fly(ctx context.Context, f *flight, background bool) {
+	f.err = a.requestToken(ctx, background)
+
+	a.mu.Lock()
+	a.inflight = nil
+	if f.err != nil {
+		a.metrics.Failures++
+		a.metrics.LastError = f.err.Error()
+	}
+	a.mu.Unlock()
+	close(f.done)
+}
+
+// StartRefresher renews the token in the background once fraction of its
+// lifetime has passed. A failed renewal is retried with backoff while the error
+// is transient; otherwise the refresher waits for the next token obtained on
+// demand. It does nothing if the refresher is already running.
+func (a *AuthManager) StartRefresher(fraction float64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.stop != nil || fraction <= 0 || fraction >= 1 {
+		return
+	}
+	a.stop = make(chan struct{})
+	go a.runRefresher(fraction, a.stop)
+}
+
+// StopRefresher stops the background refresher.
+func (a *AuthManager) StopRefresher() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.stop != nil {
+		close(a.stop)
+		a.stop = nil
+	}
+}
+
+func (a *AuthManager) runRefresher(fraction float64, stop chan struct{}) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-stop
+		cancel()
+	}()
+
+	var retryWait time.Duration
+	idle := false // After a permanent failure, wait for a token obtained on demand
+	for {
+		a.mu.RLock()
+		hasToken := a.accessToken != ""
+		due := a.issuedAt.Add(time.Duration(fraction * float64(a.expiresAt.Sub(a.issuedAt))))
+		a.mu.RUnlock()
+
+		var timer <-chan time.Time
+		switch {
+		case retryWait > 0:
+			timer = time.After(retryWait)
+		case hasToken && !idle:
+			timer = time.After(time.Until(due))
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-a.issued:
+			retryWait, idle = 0, false
+			continue
+		case <-timer:
+		}
+
+		err := a.refresh(ctx, true)
+		switch {
+		case err == nil:
+			retryWait = 0
+		case ctx.Err() != nil:
+			return
+		case errors.IsRetryableError(err):
+			retryWait = nextRetryWait(retryWait)
+		default:
+			retryWait, idle = 0, true
+		}
+	}
+}
+
+func nextRetryWait(wait time.Duration) time.Duration {
+	if wait == 0 {
+		return refreshRetryWait
+	}
+	if wait *= 2; wait > refreshRetryMaxWait {
+		return refreshRetryMaxWait
+	}
+	return wait
+}
@@ -0,0 +1,130 @@
+package bdeploy
+
+import (
+	"context"
+	"sort"
+
+	"github.com/brightdevelopers/gopurple/internal/errors"
+	"github.com/brightdevelopers/gopurple/internal/ledger"
+	"github.com/brightdevelopers/gopurple/internal/services"
+	"github.com/brightdevelopers/gopurple/internal/types"
+)
+
+// AssociationStatus is the outcome of checking one player against the ledger.
+type AssociationStatus string
+
+const (
+	AssociationOK            AssociationStatus = "ok"             // B-Deploy's setupName matches the ledger
+	AssociationMismatch      AssociationStatus = "mismatch"       // B-Deploy's setupName differs from the ledger
+	AssociationUnverified    AssociationStatus = "unverified"     // B-Deploy reports no setupName to compare
+	AssociationUnrecorded    AssociationStatus = "unrecorded"     // B-Deploy reports a setupName the ledger has no entry for
+	AssociationMissingDevice AssociationStatus = "missing-device" // The ledger has an entry for a device B-Deploy does not have
+	AssociationMissingSetup  AssociationStatus = "missing-setup"  // The ledger's setup record no longer exists
+)
+
+// AssociationCheck compares what the ledger says a player uses with what B-Deploy reports.
+type AssociationCheck struct {
+	Serial          string            `json:"serial"`
+	DeviceID        string            `json:"deviceId,omitempty"`
+	LedgerSetupID   string            `json:"ledgerSetupId,omitempty"`
+	LedgerSetupName string            `json:"ledgerSetupName,omitempty"`
+	SetupName       string            `json:"setupName,omitempty"` // As reported by B-Deploy
+	Status          AssociationStatus `json:"status"`
+}
+
+// AssociationClient is the subset of the B-Deploy service used to verify associations.
+type AssociationClient interface {
+	GetSetupRecords(ctx context.Context, opts ...services.BDeployListOption) (*types.BDeployRecordList, error)
+	GetAllDevices(ctx context.Context, opts ...services.BDeployDeviceListOption) (*types.BDeployDeviceListResponse, error)
+}
+
+// VerifyAssociations checks every device in the network and every ledger entry
+// for it. B-Deploy does not return a device's setupId but does return the name of
+// its setup, so the ledger's setup ID is resolved to the record's current package
+// name and compared with that. Results are sorted by serial.
+func VerifyAssociations(ctx context.Context, client AssociationClient, store ledger.Store, networkName string) ([]AssociationCheck, error) {
+	if store == nil {
+		return nil, errors.NewValidationError("store", nil, "association ledger is disabled")
+	}
+	if networkName == "" {
+		return nil, errors.NewValidationError("networkName", networkName, "network name is required")
+	}
+
+	entries, err := store.List(ctx, networkName)
+	if err != nil {
+		return nil, err
+	}
+	records, err := client.GetSetupRecords(ctx, services.WithNetworkName(networkName))
+	if err != nil {
+		return nil, err
+	}
+	devices, err := client.GetAllDevices(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	packages := map[string]string{}
+	for _, record := range records.Items {
+		packages[record.ID] = record.PackageName
+	}
+	bySerial := map[string]ledger.Entry{}
+	for _, entry := range entries {
+		bySerial[entry.Serial] = entry
+	}
+
+	var checks []AssociationCheck
+	seen := map[string]bool{}
+	for _, device := range devices.Players {
+		if seen[device.Serial] {
+			continue
+		}
+		seen[device.Serial] = true
+
+		check := AssociationCheck{Serial: device.Serial, DeviceID: device.ID, SetupName: device.SetupName}
+		entry, recorded := bySerial[device.Serial]
+		if !recorded {
+			if device.SetupName == "" {
+				continue // Neither side says the device is associated
+			}
+			check.Status = AssociationUnrecorded
+			checks = append(checks, check)
+			continue
+		}
+
+		check.LedgerSetupID = entry.SetupID
+		check.LedgerSetupName = entry.SetupName
+		check.Status = compareAssociation(entry, device.SetupName, packages)
+		if name, ok := packages[entry.SetupID]; ok {
+			check.LedgerSetupName = name
+		}
+		checks = append(checks, check)
+	}
+
+	for _, entry := range entries {
+		if !seen[entry.Serial] {
+			checks = append(checks, AssociationCheck{
+				Serial:          entry.Serial,
+				DeviceID:        entry.DeviceID,
+				LedgerSetupID:   entry.SetupID,
+				LedgerSetupName: entry.SetupName,
+				Status:          AssociationMissingDevice,
+			})
+		}
+	}
+
+	sort.Slice(checks, func(i, j int) bool { return checks[i].Serial < checks[j].Serial })
+	return checks, nil
+}
+
+func compareAssociation(entry ledger.Entry, setupName string, packages map[string]string) AssociationStatus {
+	name, exists := packages[entry.SetupID]
+	switch {
+	case !exists:
+		return AssociationMissingSetup
+	case setupName == "":
+		return AssociationUnverified
+	case setupName != name:
+		return AssociationMismatch
+	}
+	return AssociationOK
+}
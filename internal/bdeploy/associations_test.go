@@ -0,0 +1,61 @@
+package bdeploy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/brightdevelopers/gopurple/internal/ledger"
+	"github.com/brightdevelopers/gopurple/internal/types"
+)
+
+func TestVerifyAssociations(t *testing.T) {
+	ctx := context.Background()
+	client := &fakeReconcileClient{fakeSetupClient: newFakeSetupClient()}
+	client.records["setup-a"] = testRecord("store-a", "a")
+	client.records["setup-b"] = testRecord("store-b", "b")
+	client.devices = []types.BDeployDevice{
+		{ID: "dev-1", Serial: "XD001", SetupName: "store-a"},
+		{ID: "dev-2", Serial: "XD002", SetupName: "store-a"},
+		{ID: "dev-3", Serial: "XD003"},
+		{ID: "dev-4", Serial: "XD004", SetupName: "store-b"},
+		{ID: "dev-5", Serial: "XD005", SetupName: "store-b"},
+		{ID: "dev-6", Serial: "XD006"},
+	}
+
+	store := ledger.NewMemoryStore()
+	store.Put(ctx, ledger.Entry{NetworkName: "Retail", Serial: "XD001", SetupID: "setup-a"})
+	store.Put(ctx, ledger.Entry{NetworkName: "Retail", Serial: "XD002", SetupID: "setup-b"})
+	store.Put(ctx, ledger.Entry{NetworkName: "Retail", Serial: "XD003", SetupID: "setup-a"})
+	store.Put(ctx, ledger.Entry{NetworkName: "Retail", Serial: "XD005", SetupID: "setup-gone"})
+	store.Put(ctx, ledger.Entry{NetworkName: "Retail", Serial: "XD007", SetupID: "setup-a"})
+	store.Put(ctx, ledger.Entry{NetworkName: "Lab", Serial: "XD006", SetupID: "setup-a"})
+
+	checks, err := VerifyAssociations(ctx, client, store, "Retail")
+	if err != nil {
+		t.Fatalf("VerifyAssociations failed: %v", err)
+	}
+
+	expected := map[string]AssociationStatus{
+		"XD001": AssociationOK,
+		"XD002": AssociationMismatch,
+		"XD003": AssociationUnverified,
+		"XD004": AssociationUnrecorded,
+		"XD005": AssociationMissingSetup,
+		"XD007": AssociationMissingDevice,
+	}
+	if len(checks) != len(expected) {
+		t.Fatalf("Expected %d checks, got %+v", len(expected), checks)
+	}
+	for _, check := range checks {
+		if check.Status != expected[check.Serial] {
+			t.Errorf("%s: expected %s, got %s", check.Serial, expected[check.Serial], check.Status)
+		}
+	}
+	if checks[1].LedgerSetupName != "store-b" || checks[1].SetupName != "store-a" {
+		t.Errorf("Expected mismatch to show both setup names, got %+v", checks[1])
+	}
+
+	if _, err := VerifyAssociations(ctx, client, nil, "Retail"); err == nil {
+		t.Error("Expected error when the ledger is disabled")
+	}
+}
@@ -0,0 +1,306 @@
+package bdeploy
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/brightdevelopers/gopurple/internal/errors"
+	"github.com/brightdevelopers/gopurple/internal/types"
+)
+
+// hostnameInvalid matches runs of characters that cannot appear in a hostname.
+var hostnameInvalid = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// WallSpec describes a BrightWall video wall: a grid of players that each need
+// their own setup record with the wall name and their screen number.
+type WallSpec struct {
+	Name    string `json:"name"`    // BrightWall name, shared by every screen
+	Rows    int    `json:"rows"`    // Screens from top to bottom
+	Columns int    `json:"columns"` // Screens from left to right
+
+	// Base holds the settings every screen shares, including bDeploy.networkName
+	// and bDeploy.username.
+	Base *types.BDeploySetupRecord `json:"-"`
+
+	// PackagePrefix names the setup records <prefix>-<screen>. It defaults to the
+	// wall name made lowercase, with anything but letters, digits and "-" replaced.
+	PackagePrefix string `json:"packagePrefix,omitempty"`
+
+	// HostnamePrefix names the players <prefix>-<screen>; it defaults to PackagePrefix.
+	HostnamePrefix string `json:"hostnamePrefix,omitempty"`
+
+	Network WallNetwork  `json:"network"`
+	Screens []WallScreen `json:"screens"` // The player at each position
+}
+
+// WallNetwork gives the wall's players consecutive static addresses on the wired
+// interface. With StartIP empty the base record's addressing is kept; Gateway and
+// DNS default to the base record's.
+type WallNetwork struct {
+	StartIP    string   `json:"startIP,omitempty"` // Address of screen 1
+	EndIP      string   `json:"endIP,omitempty"`   // Last usable address, if the range is limited
+	SubnetMask string   `json:"subnetMask,omitempty"`
+	Gateway    string   `json:"gateway,omitempty"`
+	DNS        []string `json:"dns,omitempty"` // Up to three servers
+}
+
+// WallScreen places a player in the wall. Rows and columns start at 1 in the top
+// left corner.
+type WallScreen struct {
+	Row    int    `json:"row"`
+	Column int    `json:"column"`
+	Serial string `json:"serial"`
+}
+
+// ScreenNumber returns the BrightWall screen number of a position: screens are
+// numbered from 1 left to right, then top to bottom.
+func (s *WallSpec) ScreenNumber(row, column int) int {
+	return (row-1)*s.Columns + column
+}
+
+// LoadWallSpec reads a wall spec from a .json or .yaml file. The "base" key is
+// either the base setup record itself or the name of a .json or .yaml file holding
+// it, relative to the spec file.
+func LoadWallSpec(filename string) (*WallSpec, error) {
+	doc, err := LoadDocument(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var base Document
+	switch value := doc["base"].(type) {
+	case map[string]interface{}:
+		base = value
+	case string:
+		path := value
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(filepath.Dir(filename), path)
+		}
+		if base, err = LoadDocument(path); err != nil {
+			return nil, err
+		}
+	case nil:
+		return nil, errors.NewValidationError("base", nil, filename+": a base setup record is required")
+	default:
+		return nil, errors.NewValidationError("base", value, filename+": base must be a setup record or a file name")
+	}
+	delete(doc, "base")
+
+	// Render coerces YAML strings where the record expects numbers or booleans
+	record, err := (&Template{Base: base}).Render(nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: base: %w", filename, err)
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	spec := &WallSpec{Base: record}
+	if err := json.Unmarshal(data, spec); err != nil {
+		return nil, fmt.Errorf("%s: not a wall spec: %w", filename, err)
+	}
+	return spec, nil
+}
+
+// GenerateWall returns a setup record for every screen of the wall and the
+// association of each player with its record, ready for PlanReconcile. Each record
+// is a copy of the base with BrightWallName, BrightWallScreenNumber, the package
+// name, the hostname and, with a network range, the static address set. Every
+// position must be covered by exactly one player; all problems with the spec are
+// reported together, and every record is validated.
+func GenerateWall(spec *WallSpec) (*DesiredState, error) {
+	if err := checkWall(spec); err != nil {
+		return nil, err
+	}
+
+	packagePrefix := spec.PackagePrefix
+	if packagePrefix == "" {
+		packagePrefix = hostnameLabel(spec.Name)
+	}
+	hostnamePrefix := spec.HostnamePrefix
+	if hostnamePrefix == "" {
+		hostnamePrefix = hostnameLabel(packagePrefix)
+	}
+
+	screens := append([]WallScreen{}, spec.Screens...)
+	sort.Slice(screens, func(i, j int) bool {
+		return spec.ScreenNumber(screens[i].Row, screens[i].Column) < spec.ScreenNumber(screens[j].Row, screens[j].Column)
+	})
+
+	state := &DesiredState{}
+	for _, screen := range screens {
+		number := spec.ScreenNumber(screen.Row, screen.Column)
+		record, err := copyRecord(spec.Base)
+		if err != nil {
+			return nil, err
+		}
+		record.ID = ""
+		record.BrightWallName = spec.Name
+		record.BrightWallScreenNumber = strconv.Itoa(number)
+		record.BDeploy.PackageName = fmt.Sprintf("%s-%d", packagePrefix, number)
+		record.SpecifyHostname = true
+		record.Hostname = fmt.Sprintf("%s-%d", hostnamePrefix, number)
+		if spec.Network.StartIP != "" {
+			setStaticAddress(record, spec.Network, number)
+		}
+
+		if err := record.Validate(); err != nil {
+			return nil, fmt.Errorf("screen %d (row %d, column %d): %w", number, screen.Row, screen.Column, err)
+		}
+		state.Records = append(state.Records, record)
+		state.Associations = append(state.Associations, Association{
+			Serial:      screen.Serial,
+			PackageName: record.BDeploy.PackageName,
+			Name:        record.Hostname,
+			Description: fmt.Sprintf("%s screen %d (row %d, column %d)", spec.Name, number, screen.Row, screen.Column),
+		})
+	}
+	return state, nil
+}
+
+// checkWall reports every problem with a spec in one validation error.
+func checkWall(spec *WallSpec) error {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if spec.Name == "" {
+		add("name is required")
+	}
+	if spec.Base == nil {
+		add("a base setup record is required")
+	}
+	if spec.Rows < 1 || spec.Columns < 1 {
+		add("rows and columns must be at least 1, got %dx%d", spec.Rows, spec.Columns)
+		return errors.NewValidationError("wall", spec.Name, strings.Join(problems, "; "))
+	}
+
+	positions := map[[2]int]string{}
+	serials := map[string]string{}
+	for _, screen := range spec.Screens {
+		position := fmt.Sprintf("row %d, column %d", screen.Row, screen.Column)
+		switch {
+		case screen.Row < 1 || screen.Row > spec.Rows || screen.Column < 1 || screen.Column > spec.Columns:
+			add("%s is outside the %dx%d wall", position, spec.Rows, spec.Columns)
+			continue
+		case positions[[2]int{screen.Row, screen.Column}] != "":
+			add("%s has both %s and %s", position, positions[[2]int{screen.Row, screen.Column}], screen.Serial)
+			continue
+		case screen.Serial == "":
+			add("%s has no serial", position)
+			positions[[2]int{screen.Row, screen.Column}] = "(no serial)"
+			continue
+		case serials[screen.Serial] != "":
+			add("serial %s is at both %s and %s", screen.Serial, serials[screen.Serial], position)
+		}
+		positions[[2]int{screen.Row, screen.Column}] = screen.Serial
+		serials[screen.Serial] = position
+	}
+	for row := 1; row <= spec.Rows; row++ {
+		for column := 1; column <= spec.Columns; column++ {
+			if _, ok := positions[[2]int{row, column}]; !ok {
+				add("row %d, column %d has no player", row, column)
+			}
+		}
+	}
+
+	if spec.Network.StartIP != "" {
+		if err := checkWallNetwork(spec.Network, spec.Rows*spec.Columns); err != nil {
+			add("%v", err)
+		}
+	}
+
+	if len(problems) > 0 {
+		return errors.NewValidationError("wall", spec.Name, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// checkWallNetwork checks that the address range holds count addresses on one subnet.
+func checkWallNetwork(network WallNetwork, count int) error {
+	start := net.ParseIP(network.StartIP).To4()
+	if start == nil {
+		return fmt.Errorf("network.startIP %q is not an IPv4 address", network.StartIP)
+	}
+	mask := net.ParseIP(network.SubnetMask).To4()
+	if mask == nil {
+		return fmt.Errorf("network.subnetMask is required with network.startIP")
+	}
+	if len(network.DNS) > 3 {
+		return fmt.Errorf("network.dns has %d servers, at most 3 are used", len(network.DNS))
+	}
+
+	last := addIPv4(start, count-1)
+	if network.EndIP != "" {
+		end := net.ParseIP(network.EndIP).To4()
+		if end == nil {
+			return fmt.Errorf("network.endIP %q is not an IPv4 address", network.EndIP)
+		}
+		if binary.BigEndian.Uint32(last) > binary.BigEndian.Uint32(end) {
+			return fmt.Errorf("%d screens need %s to %s, beyond network.endIP %s", count, start, last, end)
+		}
+	}
+
+	subnet := net.IPNet{IP: start.Mask(net.IPMask(mask)), Mask: net.IPMask(mask)}
+	broadcast := addIPv4(subnet.IP, int(^binary.BigEndian.Uint32(mask)))
+	if !subnet.Contains(last) || last.Equal(broadcast) || start.Equal(subnet.IP) {
+		return fmt.Errorf("%d screens need %s to %s, which does not fit in %s", count, start, last, subnet.String())
+	}
+	return nil
+}
+
+// setStaticAddress gives a screen's wired interface its address from the range.
+func setStaticAddress(record *types.BDeploySetupRecord, network WallNetwork, number int) {
+	record.UseDHCP = false
+	record.ForceSend("useDHCP")
+	record.StaticIPAddress = addIPv4(net.ParseIP(network.StartIP).To4(), number-1).String()
+	record.SubnetMask = network.SubnetMask
+	if network.Gateway != "" {
+		record.Gateway = network.Gateway
+	}
+	if len(network.DNS) > 0 {
+		dns := append(append([]string{}, network.DNS...), "", "")
+		record.DNS1, record.DNS2, record.DNS3 = dns[0], dns[1], dns[2]
+	}
+
+	if record.Network != nil {
+		for i := range record.Network.Interfaces {
+			if iface := &record.Network.Interfaces[i]; iface.Name == "eth0" || iface.Type == "Ethernet" {
+				iface.Proto = "Static"
+			}
+		}
+	}
+}
+
+func addIPv4(ip net.IP, n int) net.IP {
+	result := make(net.IP, 4)
+	binary.BigEndian.PutUint32(result, binary.BigEndian.Uint32(ip.To4())+uint32(n))
+	return result
+}
+
+// hostnameLabel turns a name into something usable in a hostname.
+func hostnameLabel(name string) string {
+	return strings.Trim(hostnameInvalid.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
+
+// copyRecord returns a deep copy of a record, including the fields it sends explicitly.
+func copyRecord(record *types.BDeploySetupRecord) (*types.BDeploySetupRecord, error) {
+	data, err := types.MarshalSetupRecord(record)
+	if err != nil {
+		return nil, err
+	}
+	var copied types.BDeploySetupRecord
+	if err := types.UnmarshalSetupRecord(data, &copied); err != nil {
+		return nil, err
+	}
+	return &copied, nil
+}
@@ -0,0 +1,128 @@
+package bdeploy
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func testWall() *WallSpec {
+	spec := &WallSpec{
+		Name:    "Lobby Wall",
+		Rows:    2,
+		Columns: 3,
+		Base:    testRecord("", ""),
+		Network: WallNetwork{StartIP: "10.1.0.21", SubnetMask: "255.255.255.0", Gateway: "10.1.0.1", DNS: []string{"10.1.0.2"}},
+	}
+	serial := 1
+	for row := 2; row >= 1; row-- {
+		for column := 1; column <= 3; column++ {
+			spec.Screens = append(spec.Screens, WallScreen{Row: row, Column: column, Serial: fmt.Sprintf("XD%03d", serial)})
+			serial++
+		}
+	}
+	return spec
+}
+
+func TestGenerateWall(t *testing.T) {
+	state, err := GenerateWall(testWall())
+	if err != nil {
+		t.Fatalf("GenerateWall failed: %v", err)
+	}
+	if len(state.Records) != 6 || len(state.Associations) != 6 {
+		t.Fatalf("Expected 6 records and associations, got %d and %d", len(state.Records), len(state.Associations))
+	}
+
+	// Screens are numbered left to right, top to bottom; XD004 is row 1, column 1
+	first, last := state.Records[0], state.Records[5]
+	if first.BrightWallName != "Lobby Wall" || first.BrightWallScreenNumber != "1" || last.BrightWallScreenNumber != "6" {
+		t.Errorf("Unexpected screen numbers %q and %q", first.BrightWallScreenNumber, last.BrightWallScreenNumber)
+	}
+	if first.BDeploy.PackageName != "lobby-wall-1" || first.Hostname != "lobby-wall-1" || !first.SpecifyHostname {
+		t.Errorf("Unexpected package name %q or hostname %q", first.BDeploy.PackageName, first.Hostname)
+	}
+	if first.StaticIPAddress != "10.1.0.21" || last.StaticIPAddress != "10.1.0.26" || first.UseDHCP || !first.IsForceSent("useDHCP") {
+		t.Errorf("Unexpected addresses %q and %q", first.StaticIPAddress, last.StaticIPAddress)
+	}
+	if first.DNS1 != "10.1.0.2" || first.Gateway != "10.1.0.1" {
+		t.Errorf("Expected the network settings to be applied, got %+v", first)
+	}
+	if a := state.Associations[0]; a.Serial != "XD004" || a.PackageName != "lobby-wall-1" {
+		t.Errorf("Expected XD004 at screen 1, got %+v", a)
+	}
+}
+
+func TestGenerateWall_Coverage(t *testing.T) {
+	spec := testWall()
+	spec.Screens[0].Row, spec.Screens[0].Column = 1, 1 // XD001 joins XD004, leaving row 2, column 1 empty
+	spec.Screens[1].Serial = ""
+	spec.Screens[2].Column = 4
+	spec.Screens[5].Serial = "XD005"
+	spec.Network.EndIP = "10.1.0.24"
+
+	_, err := GenerateWall(spec)
+	if err == nil {
+		t.Fatal("Expected an error for an incomplete wall")
+	}
+	for _, expected := range []string{
+		"row 1, column 1 has both XD001 and XD004",
+		"row 2, column 2 has no serial",
+		"row 2, column 4 is outside the 2x3 wall",
+		"serial XD005 is at both row 1, column 2 and row 1, column 3",
+		"row 2, column 1 has no player",
+		"row 2, column 3 has no player",
+		"beyond network.endIP 10.1.0.24",
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected %q in %v", expected, err)
+		}
+	}
+}
+
+func TestGenerateWall_SubnetTooSmall(t *testing.T) {
+	spec := testWall()
+	spec.Network.StartIP = "10.1.0.250"
+	if _, err := GenerateWall(spec); err == nil || !strings.Contains(err.Error(), "does not fit") {
+		t.Errorf("Expected the range to overflow the subnet, got %v", err)
+	}
+}
+
+func TestLoadWallSpec(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "base.yaml", "version: \"3.0.0\"\nsetupType: lfn\nbDeploy:\n  username: admin@example.com\n  networkName: Retail\n")
+	filename := writeFile(t, dir, "wall.yaml", `name: Lobby
+rows: 1
+columns: 2
+base: base.yaml
+network:
+  startIP: 10.1.0.21
+  subnetMask: 255.255.255.0
+screens:
+  - row: 1
+    column: 1
+    serial: XD001
+  - row: 1
+    column: 2
+    serial: XD002
+`)
+
+	spec, err := LoadWallSpec(filename)
+	if err != nil {
+		t.Fatalf("LoadWallSpec failed: %v", err)
+	}
+	if spec.Base == nil || spec.Base.BDeploy.NetworkName != "Retail" || len(spec.Screens) != 2 || spec.Network.StartIP != "10.1.0.21" {
+		t.Fatalf("Unexpected spec %+v", spec)
+	}
+	state, err := GenerateWall(spec)
+	if err != nil {
+		t.Fatalf("GenerateWall failed: %v", err)
+	}
+	if state.Records[1].BDeploy.PackageName != "lobby-2" || state.Records[1].StaticIPAddress != "10.1.0.22" {
+		t.Errorf("Unexpected record %+v", state.Records[1])
+	}
+
+	missing := writeFile(t, dir, "nobase.yaml", "name: Lobby\nrows: 1\ncolumns: 1\n")
+	if _, err := LoadWallSpec(missing); err == nil {
+		t.Error("Expected an error for a spec without a base")
+	}
+}
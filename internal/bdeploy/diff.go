@@ -0,0 +1,27 @@
+package bdeploy
+
+import (
+	"github.com/brightdevelopers/gopurple/internal/types"
+)
+
+// DiffSetupRecords compares two setup records field by field and returns the
+// values that differ, sorted by their dotted JSON path. Record IDs are ignored,
+// values that are unset or zero in both records are treated as equal, and
+// passwords and tokens (see types.IsSecretSetupField) are masked, so a changed
+// secret shows as "********" on both sides. Secret references such as
+// "env:WIFI_PASSPHRASE" are shown as they are.
+func DiffSetupRecords(a, b *types.BDeploySetupRecord) ([]FieldChange, error) {
+	return diffRecords(a, b)
+}
+
+// maskSecret hides a secret value, keeping unset values and secret references
+// visible so an added or removed secret can still be seen.
+func maskSecret(v interface{}) interface{} {
+	if s, ok := v.(string); ok && types.IsSecretReference(s) {
+		return v
+	}
+	if isZeroValue(v) {
+		return v
+	}
+	return types.RedactedSecret
+}
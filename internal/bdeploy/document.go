@@ -0,0 +1,164 @@
+// Package bdeploy provides workflows built on the B-Deploy setup and device APIs:
+// rendering setup records from templates and applying them to many sites.
+package bdeploy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/brightdevelopers/gopurple/internal/types"
+	"gopkg.in/yaml.v3"
+)
+
+// Document is a setup record, or part of one, in generic form: keys are JSON field
+// names and values are strings, float64s, bools, nil, []interface{} or nested
+// map[string]interface{}, as produced by encoding/json.
+type Document map[string]interface{}
+
+// LoadDocument reads a document from a .json, .yaml or .yml file.
+func LoadDocument(filename string) (Document, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc Document
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".yaml", ".yml":
+		doc, err = DecodeYAML(bytes.NewReader(data))
+	default:
+		doc, err = DecodeJSON(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return doc, nil
+}
+
+// DecodeJSON reads a JSON object.
+func DecodeJSON(r io.Reader) (Document, error) {
+	var doc Document
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to decode document: %w", err)
+	}
+	if doc == nil {
+		doc = Document{}
+	}
+	return doc, nil
+}
+
+// Merge returns a copy of base with each overlay applied in order as a JSON merge
+// patch (RFC 7386): nested objects are merged, other values replace the base value
+// and null removes it. base and the overlays are not modified.
+func Merge(base Document, overlays ...Document) Document {
+	result := types.CloneJSON(map[string]interface{}(base)).(map[string]interface{})
+	for _, overlay := range overlays {
+		result = types.MergePatch(result, types.CloneJSON(map[string]interface{}(overlay)).(map[string]interface{}))
+	}
+	return result
+}
+
+// DecodeYAML reads a YAML mapping. Values take the types encoding/json produces,
+// so YAML and JSON documents merge alike: numbers become float64s and timestamps
+// stay strings. Numbers written with a leading zero, such as 0042, are kept as
+// strings, as they are usually identifiers.
+func DecodeYAML(r io.Reader) (Document, error) {
+	var root yaml.Node
+	if err := yaml.NewDecoder(r).Decode(&root); err != nil {
+		if err == io.EOF {
+			return Document{}, nil
+		}
+		return nil, fmt.Errorf("failed to decode document: %w", err)
+	}
+
+	value, err := yamlValue(&root)
+	if err != nil {
+		return nil, err
+	}
+	if value == nil {
+		return Document{}, nil
+	}
+	doc, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("document must be a mapping")
+	}
+	return doc, nil
+}
+
+// yamlValue converts a YAML node to a generic JSON value.
+func yamlValue(node *yaml.Node) (interface{}, error) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return nil, nil
+		}
+		return yamlValue(node.Content[0])
+	case yaml.AliasNode:
+		return yamlValue(node.Alias)
+	case yaml.MappingNode:
+		result := make(map[string]interface{}, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			if key.Kind != yaml.ScalarNode || key.ShortTag() == "!!merge" {
+				return nil, fmt.Errorf("line %d: mapping keys must be plain scalars", key.Line)
+			}
+			if _, exists := result[key.Value]; exists {
+				return nil, fmt.Errorf("line %d: duplicate key %q", key.Line, key.Value)
+			}
+			value, err := yamlValue(node.Content[i+1])
+			if err != nil {
+				return nil, err
+			}
+			result[key.Value] = value
+		}
+		return result, nil
+	case yaml.SequenceNode:
+		result := make([]interface{}, 0, len(node.Content))
+		for _, item := range node.Content {
+			value, err := yamlValue(item)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, value)
+		}
+		return result, nil
+	default:
+		return yamlScalar(node)
+	}
+}
+
+// yamlScalar converts a scalar to a string, bool, float64 or nil.
+func yamlScalar(node *yaml.Node) (interface{}, error) {
+	switch node.ShortTag() {
+	case "!!null":
+		return nil, nil
+	case "!!bool":
+		var b bool
+		if err := node.Decode(&b); err != nil {
+			return nil, fmt.Errorf("line %d: %w", node.Line, err)
+		}
+		return b, nil
+	case "!!int", "!!float":
+		if hasLeadingZero(node.Value) {
+			return node.Value, nil
+		}
+		var f float64
+		if err := node.Decode(&f); err != nil {
+			return nil, fmt.Errorf("line %d: %w", node.Line, err)
+		}
+		return f, nil
+	default:
+		return node.Value, nil
+	}
+}
+
+// hasLeadingZero reports whether a number is written with a leading zero, like 0042.
+func hasLeadingZero(text string) bool {
+	digits := strings.TrimLeft(text, "+-")
+	return len(digits) > 1 && digits[0] == '0' && digits[1] != '.'
+}
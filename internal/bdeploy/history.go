@@ -0,0 +1,119 @@
+package bdeploy
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/brightdevelopers/gopurple/internal/errors"
+	"github.com/brightdevelopers/gopurple/internal/history"
+	"github.com/brightdevelopers/gopurple/internal/services"
+	"github.com/brightdevelopers/gopurple/internal/types"
+)
+
+// HistoryClient is the subset of the B-Deploy service used to roll back setup records.
+type HistoryClient interface {
+	GetSetupRecord(ctx context.Context, setupID string) (*types.BDeploySetupRecord, error)
+	AddSetupRecord(ctx context.Context, record *types.BDeploySetupRecord) (*types.BDeployCreateResponse, error)
+	UpdateSetupRecord(ctx context.Context, setupID string, record *types.BDeploySetupRecord) (*types.BDeploySetupRecord, error)
+}
+
+// RollbackOptions controls RollbackSetupRecord.
+type RollbackOptions struct {
+	To     time.Time // Restore the newest snapshot taken at or before this time
+	DryRun bool      // Work out the changes without writing
+}
+
+// RollbackResult describes what RollbackSetupRecord did, or would do.
+type RollbackResult struct {
+	SetupID  string           `json:"setupId"` // The restored record; new if the record had been deleted
+	Snapshot history.Snapshot `json:"-"`
+	TakenAt  time.Time        `json:"takenAt"` // When the restored snapshot was taken
+	Action   Action           `json:"action"`  // ActionUpdate, ActionCreate (deleted record) or ActionNone
+	Changes  []FieldChange    `json:"changes,omitempty"`
+	Applied  bool             `json:"applied"`
+}
+
+// RollbackSetupRecord restores a setup record from the setup history. It picks the
+// newest snapshot of the record taken at or before opts.To, so passing a time
+// listed by the history restores that snapshot: the record as it was just before
+// the change made at that time.
+//
+// The record is updated in place if it still exists. If it was deleted, the
+// snapshot is added as a new record, which gets a new ID. When the client keeps a
+// setup history, the version being replaced is itself saved first, so a rollback
+// can be rolled back. The snapshot is sent as it was stored, without resolving
+// secret references. Snapshots of records changed through the v2 setup API are
+// refused, since they cannot be restored with the v3 API.
+func RollbackSetupRecord(ctx context.Context, client HistoryClient, store history.Store, setupID string, opts RollbackOptions) (*RollbackResult, error) {
+	if store == nil {
+		return nil, errors.NewValidationError("store", nil, "setup history is off")
+	}
+	if setupID == "" {
+		return nil, errors.NewValidationError("setupID", setupID, "setup ID cannot be empty")
+	}
+	if opts.To.IsZero() {
+		return nil, errors.NewValidationError("to", opts.To, "a time to roll back to is required")
+	}
+
+	snapshots, err := store.List(ctx, setupID)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := history.At(snapshots, opts.To)
+	if snapshot == nil {
+		return nil, errors.NewValidationError("to", opts.To.UTC().Format(time.RFC3339),
+			fmt.Sprintf("no snapshot of setup %s was taken at or before this time (%d in history)", setupID, len(snapshots)))
+	}
+
+	if snapshot.API == history.APIV2 {
+		return nil, errors.NewValidationError("to", snapshot.TakenAt.Format(time.RFC3339),
+			fmt.Sprintf("the snapshot of setup %s was taken through the v2 setup API and cannot be rolled back; restore it with UpdateSetupRecordV2", setupID))
+	}
+
+	result := &RollbackResult{SetupID: setupID, Snapshot: *snapshot, TakenAt: snapshot.TakenAt}
+	restored := *snapshot.Record
+
+	current, err := client.GetSetupRecord(ctx, setupID)
+	var apiErr *errors.APIError
+	switch {
+	case err == nil:
+		result.Changes, err = diffRecords(current, &restored)
+		if err != nil {
+			return nil, err
+		}
+		result.Action = ActionUpdate
+		if len(result.Changes) == 0 {
+			result.Action = ActionNone
+		}
+	case stderrors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound:
+		result.Action = ActionCreate
+	default:
+		return nil, err
+	}
+
+	if opts.DryRun || result.Action == ActionNone {
+		return result, nil
+	}
+
+	// The snapshot holds the values B-Deploy stored, so nothing is resolved
+	ctx = services.WithoutSecretResolution(ctx)
+
+	switch result.Action {
+	case ActionUpdate:
+		if _, err := client.UpdateSetupRecord(ctx, setupID, &restored); err != nil {
+			return result, err
+		}
+	case ActionCreate:
+		restored.ID = ""
+		response, err := client.AddSetupRecord(ctx, &restored)
+		if err != nil {
+			return result, err
+		}
+		result.SetupID = response.ID
+	}
+	result.Applied = true
+	return result, nil
+}
@@ -0,0 +1,118 @@
+package bdeploy
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/brightdevelopers/gopurple/internal/errors"
+	"github.com/brightdevelopers/gopurple/internal/history"
+	"github.com/brightdevelopers/gopurple/internal/types"
+)
+
+func TestDiffSetupRecords(t *testing.T) {
+	a := testRecord("store-0001", "store-a")
+	a.ID = "setup-1"
+	a.LWSPassword = "old-secret"
+	a.BSNDeviceRegistrationTokenEntity = &types.BSNTokenEntity{Token: "token-a", Scope: "cert"}
+
+	b := testRecord("store-0001", "store-b")
+	b.ID = "setup-2"
+	b.LWSPassword = "new-secret"
+	b.SFNPassword = "added"
+	b.BSNDeviceRegistrationTokenEntity = &types.BSNTokenEntity{Token: "token-b", Scope: "cert"}
+
+	changes, err := DiffSetupRecords(a, b)
+	if err != nil {
+		t.Fatalf("DiffSetupRecords failed: %v", err)
+	}
+
+	expected := []string{
+		`bsnDeviceRegistrationTokenEntity.token: "********" -> "********"`,
+		`hostname: "store-a" -> "store-b"`,
+		`lwsPassword: "********" -> "********"`,
+		`sfnPassword: (unset) -> "********"`,
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("Expected %d changes, got %v", len(expected), changes)
+	}
+	for i, change := range changes {
+		if change.String() != expected[i] {
+			t.Errorf("Change %d: expected %s, got %s", i, expected[i], change)
+		}
+	}
+}
+
+// deletedSetupClient reports every setup record as missing, as B-Deploy does after a delete.
+type deletedSetupClient struct {
+	*fakeSetupClient
+}
+
+func (f deletedSetupClient) GetSetupRecord(ctx context.Context, setupID string) (*types.BDeploySetupRecord, error) {
+	return nil, errors.NewAPIError(404, "bdeploy_not_found", "Setup record not found", setupID)
+}
+
+func TestRollbackSetupRecord(t *testing.T) {
+	ctx := context.Background()
+	base := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	store := history.NewMemoryStore()
+	for i, hostname := range []string{"first", "second"} {
+		record := testRecord("store-0001", hostname)
+		record.ID = "setup-1"
+		snapshot := history.NewSnapshot(record, history.OperationUpdate)
+		snapshot.TakenAt = base.Add(time.Duration(i) * time.Hour)
+		store.Save(ctx, snapshot)
+	}
+
+	client := newFakeSetupClient()
+	client.records["setup-1"] = testRecord("store-0001", "third")
+
+	if _, err := RollbackSetupRecord(ctx, client, store, "setup-1", RollbackOptions{To: base.Add(-time.Minute)}); err == nil {
+		t.Error("Expected an error when no snapshot is old enough")
+	}
+	if _, err := RollbackSetupRecord(ctx, client, nil, "setup-1", RollbackOptions{To: base}); err == nil {
+		t.Error("Expected an error without a setup history")
+	}
+
+	// A time between the snapshots restores the older one
+	result, err := RollbackSetupRecord(ctx, client, store, "setup-1", RollbackOptions{To: base.Add(30 * time.Minute), DryRun: true})
+	if err != nil {
+		t.Fatalf("RollbackSetupRecord dry run failed: %v", err)
+	}
+	if result.Action != ActionUpdate || !result.TakenAt.Equal(base) || len(result.Changes) != 1 || client.writes != 0 {
+		t.Errorf("Expected a planned update to the first snapshot, got %+v", result)
+	}
+
+	result, err = RollbackSetupRecord(ctx, client, store, "setup-1", RollbackOptions{To: base.Add(time.Hour)})
+	if err != nil {
+		t.Fatalf("RollbackSetupRecord failed: %v", err)
+	}
+	if !result.Applied || client.records["setup-1"].Hostname != "second" {
+		t.Errorf("Expected the second snapshot to be restored, got %+v", client.records["setup-1"])
+	}
+
+	result, _ = RollbackSetupRecord(ctx, client, store, "setup-1", RollbackOptions{To: base.Add(time.Hour)})
+	if result.Action != ActionNone || result.Applied {
+		t.Errorf("Expected no change when the record already matches, got %+v", result)
+	}
+
+	// A deleted record is added again with a new ID
+	deleted := deletedSetupClient{newFakeSetupClient()}
+	result, err = RollbackSetupRecord(ctx, deleted, store, "setup-1", RollbackOptions{To: base})
+	if err != nil {
+		t.Fatalf("RollbackSetupRecord of a deleted record failed: %v", err)
+	}
+	if result.Action != ActionCreate || !result.Applied || deleted.records[result.SetupID].Hostname != "first" {
+		t.Errorf("Expected the deleted record to be re-created, got %+v", result)
+	}
+
+	// A v2 snapshot is not restored through the v3 API
+	v2 := history.NewSnapshot(testRecord("store-0002", "v2"), history.OperationDelete)
+	v2.SetupID, v2.API = "setup-2", history.APIV2
+	store.Save(ctx, v2)
+	writes := deleted.writes
+	if _, err := RollbackSetupRecord(ctx, deleted, store, "setup-2", RollbackOptions{To: v2.TakenAt}); err == nil || deleted.writes != writes {
+		t.Errorf("Expected a v2 snapshot to be refused without writing, got %v", err)
+	}
+}
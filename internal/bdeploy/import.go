@@ -0,0 +1,402 @@
+package bdeploy
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/brightdevelopers/gopurple/internal/errors"
+	"github.com/brightdevelopers/gopurple/internal/services"
+	"github.com/brightdevelopers/gopurple/internal/types"
+)
+
+// ActionNone means a device already matches its row and nothing is sent.
+const ActionNone Action = "none"
+
+// deviceColumns maps the header names accepted in a device import file, after
+// lower-casing and removing spaces, dashes and underscores, to a column.
+var deviceColumns = map[string]string{
+	"serial":       "serial",
+	"serialnumber": "serial",
+	"name":         "name",
+	"devicename":   "name",
+	"description":  "description",
+	"desc":         "description",
+	"setup":        "setup",
+	"setupname":    "setup",
+	"package":      "setup",
+	"packagename":  "setup",
+	"network":      "network",
+	"networkname":  "network",
+	"action":       "action",
+}
+
+// DeviceRow is one row of a device import file.
+type DeviceRow struct {
+	Line        int    // Line in the file, for reports
+	Serial      string // Required
+	Name        string // Defaults to the existing name, or the serial for new devices
+	Description string
+	SetupName   string // Package name of the setup record to associate, if any
+	NetworkName string // Defaults to ImportOptions.NetworkName
+	Delete      bool   // The action column says "delete"
+}
+
+// ReadDeviceRows reads a device import file exported from a spreadsheet as CSV,
+// semicolon-separated or tab-separated text. The header row must have a serial
+// column; name, description, setup, network and action columns are optional and
+// a few common spellings ("Serial Number", "Setup Name", ...) are accepted.
+// Rows are returned as found; problems with a single row are reported by
+// ImportDevices rather than here.
+func ReadDeviceRows(r io.Reader) ([]DeviceRow, error) {
+	buffered := bufio.NewReader(r)
+	firstLine, err := buffered.Peek(4096)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, err
+	}
+
+	reader := csv.NewReader(buffered)
+	reader.Comma = detectDelimiter(string(firstLine))
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.NewValidationError("devices", "", "device file is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read device header: %w", err)
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		key := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		key = strings.NewReplacer(" ", "", "-", "", "_", "").Replace(key)
+		if column, ok := deviceColumns[key]; ok {
+			if _, duplicate := columns[column]; !duplicate {
+				columns[column] = i
+			}
+		}
+	}
+	if _, ok := columns["serial"]; !ok {
+		return nil, errors.NewValidationError("devices", header, "device file needs a serial column")
+	}
+	column := func(row []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	var rows []DeviceRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read devices: %w", err)
+		}
+		if isBlankRow(record) {
+			continue
+		}
+
+		line, _ := reader.FieldPos(0)
+		rows = append(rows, DeviceRow{
+			Line:        line,
+			Serial:      strings.ToUpper(column(record, "serial")),
+			Name:        column(record, "name"),
+			Description: column(record, "description"),
+			SetupName:   column(record, "setup"),
+			NetworkName: column(record, "network"),
+			Delete:      strings.EqualFold(column(record, "action"), "delete"),
+		})
+	}
+	return rows, nil
+}
+
+// LoadDeviceRows reads a device import file.
+func LoadDeviceRows(filename string) ([]DeviceRow, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	rows, err := ReadDeviceRows(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return rows, nil
+}
+
+// detectDelimiter picks the separator that appears most often in the first line.
+func detectDelimiter(text string) rune {
+	line, _, _ := strings.Cut(text, "\n")
+	best, bestCount := ',', strings.Count(line, ",")
+	for _, delimiter := range []rune{';', '\t'} {
+		if n := strings.Count(line, string(delimiter)); n > bestCount {
+			best, bestCount = delimiter, n
+		}
+	}
+	return best
+}
+
+// isBlankRow reports whether a row has no values, as spreadsheets export for empty lines.
+func isBlankRow(record []string) bool {
+	for _, value := range record {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// DeviceImportClient is the subset of the B-Deploy service used to import devices.
+type DeviceImportClient interface {
+	SetNetworkContext(ctx context.Context, networkName string) error
+	GetSetupRecords(ctx context.Context, opts ...services.BDeployListOption) (*types.BDeployRecordList, error)
+	GetAllDevices(ctx context.Context, opts ...services.BDeployDeviceListOption) (*types.BDeployDeviceListResponse, error)
+	CreateDevice(ctx context.Context, request *types.BDeployDeviceRequest) (string, error)
+	UpdateDevice(ctx context.Context, deviceID string, request *types.BDeployDeviceRequest) (*types.BDeployDevice, error)
+	DeleteDevice(ctx context.Context, deviceID string, serial string) error
+}
+
+// ImportOptions controls ImportDevices.
+type ImportOptions struct {
+	NetworkName string // Network for rows without a network column
+	Username    string // BSN.cloud username for new devices (default: the setup record's username)
+	DryRun      bool   // Work out every row's action without writing
+}
+
+// DeviceImportResult is what ImportDevices did, or would do, for one row.
+type DeviceImportResult struct {
+	Line        int    `json:"line"`
+	Serial      string `json:"serial"`
+	NetworkName string `json:"networkName,omitempty"`
+	SetupName   string `json:"setupName,omitempty"`
+	SetupID     string `json:"setupId,omitempty"`
+	DeviceID    string `json:"deviceId,omitempty"`
+	Action      Action `json:"action,omitempty"`
+	Applied     bool   `json:"applied"`
+	Error       string `json:"error,omitempty"`
+}
+
+// ImportDevices registers, updates or deletes B-Deploy devices so they match the
+// rows. It is idempotent: a device that already has the row's name, description
+// and setup gets ActionNone, so a file can be imported again after fixing the rows
+// that failed.
+//
+// Rows are processed one network at a time. For each network the network context
+// is set, setup names are resolved to IDs with GetSetupRecords and existing devices
+// are found with GetAllDevices. Empty cells keep the device's existing name,
+// description and association. B-Deploy does not return a device's setupId, so an
+// association the ledger does not know is found by the setup name B-Deploy
+// reports, and the row is refused if that name is not exactly one record.
+// Problems with a row, such as a missing serial, an unknown setup or a failed
+// request, are recorded in that row's result and do not stop the others; the
+// returned error is only set when a network cannot be read or the context is done.
+func ImportDevices(ctx context.Context, client DeviceImportClient, rows []DeviceRow, opts ImportOptions) ([]DeviceImportResult, error) {
+	results := make([]DeviceImportResult, len(rows))
+	byNetwork := map[string][]int{}
+	seen := map[string]int{}
+
+	for i, row := range rows {
+		result := &results[i]
+		result.Line = row.Line
+		result.Serial = row.Serial
+		result.SetupName = row.SetupName
+		result.NetworkName = row.NetworkName
+		if result.NetworkName == "" {
+			result.NetworkName = opts.NetworkName
+		}
+
+		key := result.NetworkName + "/" + row.Serial
+		switch {
+		case row.Serial == "":
+			result.Error = "serial is empty"
+		case result.NetworkName == "":
+			result.Error = "no network: add a network column or set a default network"
+		case seen[key] != 0:
+			result.Error = fmt.Sprintf("serial already imported on line %d", seen[key])
+		default:
+			seen[key] = row.Line
+			byNetwork[result.NetworkName] = append(byNetwork[result.NetworkName], i)
+		}
+	}
+
+	networks := make([]string, 0, len(byNetwork))
+	for network := range byNetwork {
+		networks = append(networks, network)
+	}
+	sort.Strings(networks)
+
+	for _, network := range networks {
+		if err := importNetwork(ctx, client, network, rows, results, byNetwork[network], opts); err != nil {
+			return results, err
+		}
+	}
+	return results, nil
+}
+
+// importNetwork imports the rows at indexes, which all belong to one network.
+func importNetwork(ctx context.Context, client DeviceImportClient, network string, rows []DeviceRow, results []DeviceImportResult, indexes []int, opts ImportOptions) error {
+	if err := client.SetNetworkContext(ctx, network); err != nil {
+		return fmt.Errorf("network %s: %w", network, err)
+	}
+	records, err := client.GetSetupRecords(ctx, services.WithNetworkName(network))
+	if err != nil {
+		return fmt.Errorf("network %s: %w", network, err)
+	}
+	devices, err := client.GetAllDevices(ctx)
+	if err != nil {
+		return fmt.Errorf("network %s: %w", network, err)
+	}
+
+	setups := map[string][]types.BDeployRecord{}
+	for _, record := range records.Items {
+		setups[record.PackageName] = append(setups[record.PackageName], record)
+	}
+	existing := map[string]types.BDeployDevice{}
+	for _, device := range devices.Players {
+		if _, ok := existing[device.Serial]; !ok {
+			existing[device.Serial] = device
+		}
+	}
+
+	for _, i := range indexes {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		row, result := rows[i], &results[i]
+		device, registered := existing[row.Serial]
+		result.DeviceID = device.ID
+
+		if row.Delete {
+			result.Action = ActionNone
+			if registered {
+				result.Action = ActionDelete
+			}
+			if result.Action == ActionDelete && !opts.DryRun {
+				if err := client.DeleteDevice(ctx, device.ID, row.Serial); err != nil {
+					result.Error = err.Error()
+					continue
+				}
+				result.Applied = true
+			}
+			continue
+		}
+
+		// Empty cells keep what the device already has
+		request := &types.BDeployDeviceRequest{
+			Username:    opts.Username,
+			Serial:      row.Serial,
+			Name:        firstNonEmpty(row.Name, device.Name, row.Serial),
+			NetworkName: network,
+			Desc:        firstNonEmpty(row.Description, device.Desc),
+			SetupID:     device.SetupID,
+		}
+
+		setupName := row.SetupName
+		switch {
+		case row.SetupName != "":
+			matches := setups[row.SetupName]
+			switch len(matches) {
+			case 0:
+				result.Error = fmt.Sprintf("setup %q not found in %s", row.SetupName, network)
+				continue
+			case 1:
+			default:
+				result.Error = fmt.Sprintf("%d setup records in %s are named %q", len(matches), network, row.SetupName)
+				continue
+			}
+			request.SetupID = matches[0].ID
+			result.SetupID = matches[0].ID
+			if request.Username == "" {
+				request.Username = matches[0].Username
+			}
+
+		case registered && device.SetupID == "" && device.SetupName != "":
+			// The ledger does not know the device's setup, so find it by the
+			// name B-Deploy reports; an update without it would clear it
+			matches := setups[device.SetupName]
+			if len(matches) != 1 {
+				result.Error = fmt.Sprintf("cannot keep the device's setup: %d setup records in %s are named %q; add a setup column",
+					len(matches), network, device.SetupName)
+				continue
+			}
+			request.SetupID = matches[0].ID
+			setupName = device.SetupName
+		}
+		if request.Username == "" {
+			request.Username = device.Username
+		}
+
+		switch {
+		case !registered:
+			result.Action = ActionCreate
+			if request.Username == "" {
+				result.Error = "no username: set a setup or the import username"
+				continue
+			}
+		case deviceMatches(device, request, setupName):
+			result.Action = ActionNone
+			continue
+		default:
+			result.Action = ActionUpdate
+		}
+		if opts.DryRun {
+			continue
+		}
+
+		if result.Action == ActionCreate {
+			result.DeviceID, err = client.CreateDevice(ctx, request)
+		} else {
+			_, err = client.UpdateDevice(ctx, device.ID, request)
+		}
+		if err != nil {
+			result.Error = err.Error()
+			continue
+		}
+		result.Applied = true
+	}
+	return nil
+}
+
+// deviceMatches reports whether an existing device already has what the request
+// would set. B-Deploy does not return setupId, so the setup is also considered
+// the same when the device's setupName is the row's setup.
+func deviceMatches(device types.BDeployDevice, request *types.BDeployDeviceRequest, setupName string) bool {
+	sameSetup := request.SetupID == device.SetupID ||
+		(device.SetupID == "" && setupName != "" && device.SetupName == setupName)
+	return sameSetup && device.Name == request.Name && device.Desc == request.Desc
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// WriteImportReport writes the results as CSV with one row per imported row.
+func WriteImportReport(w io.Writer, results []DeviceImportResult) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"line", "serial", "network", "setup", "setupId", "deviceId", "action", "applied", "error"})
+	for _, r := range results {
+		writer.Write([]string{
+			strconv.Itoa(r.Line), r.Serial, r.NetworkName, r.SetupName, r.SetupID, r.DeviceID,
+			string(r.Action), strconv.FormatBool(r.Applied), r.Error,
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,171 @@
+package bdeploy
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/brightdevelopers/gopurple/internal/types"
+)
+
+// fakeImportClient adds network context and device deletion to fakeReconcileClient.
+type fakeImportClient struct {
+	*fakeReconcileClient
+	networks []string
+}
+
+func (f *fakeImportClient) SetNetworkContext(ctx context.Context, networkName string) error {
+	f.networks = append(f.networks, networkName)
+	return nil
+}
+
+func (f *fakeImportClient) DeleteDevice(ctx context.Context, deviceID string, serial string) error {
+	f.writes++
+	for i, device := range f.devices {
+		if device.ID == deviceID {
+			f.devices = append(f.devices[:i], f.devices[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func TestReadDeviceRows(t *testing.T) {
+	input := "\ufeffSerial Number;Device Name;Setup Name;Network\r\nxd001;Front;store-a;\r\n;;;\r\nXD002;;store-b;Lab\r\n"
+	rows, err := ReadDeviceRows(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadDeviceRows failed: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("Expected blank row to be skipped, got %+v", rows)
+	}
+	if rows[0].Serial != "XD001" || rows[0].Name != "Front" || rows[0].SetupName != "store-a" || rows[0].Line != 2 {
+		t.Errorf("Unexpected first row %+v", rows[0])
+	}
+	if rows[1].NetworkName != "Lab" || rows[1].Line != 4 {
+		t.Errorf("Unexpected second row %+v", rows[1])
+	}
+
+	rows, err = ReadDeviceRows(strings.NewReader("serial\tdescription\taction\nXD003\tSpare\tdelete\n"))
+	if err != nil || len(rows) != 1 || !rows[0].Delete || rows[0].Description != "Spare" {
+		t.Errorf("Expected tab-separated row with delete action, got %+v (%v)", rows, err)
+	}
+
+	if _, err := ReadDeviceRows(strings.NewReader("name,setup\nfront,store-a\n")); err == nil {
+		t.Error("Expected error for missing serial column")
+	}
+}
+
+func TestImportDevices(t *testing.T) {
+	client := &fakeImportClient{fakeReconcileClient: &fakeReconcileClient{fakeSetupClient: newFakeSetupClient()}}
+	client.records["setup-a"] = testRecord("store-a", "a")
+	client.records["setup-a"].BDeploy.Username = "owner@example.com"
+	client.records["setup-b1"] = testRecord("store-b", "b")
+	client.records["setup-b2"] = testRecord("store-b", "b")
+	client.devices = []types.BDeployDevice{
+		{ID: "dev-1", Serial: "XD001", Name: "Front", SetupName: "store-a"},
+		{ID: "dev-2", Serial: "XD002", Name: "Old name", Username: "owner@example.com"},
+		{ID: "dev-3", Serial: "XD003", Name: "XD003"},
+	}
+
+	rows := []DeviceRow{
+		{Line: 2, Serial: "XD001", Name: "Front", SetupName: "store-a"}, // unchanged
+		{Line: 3, Serial: "XD002", Name: "Counter"},                     // renamed, keeps association
+		{Line: 4, Serial: "XD003", Delete: true},
+		{Line: 5, Serial: "XD004", SetupName: "store-a"}, // new
+		{Line: 6, Serial: "XD005", SetupName: "store-x"},
+		{Line: 7, Serial: "XD006", SetupName: "store-b"},
+		{Line: 8, Serial: "XD004"},
+		{Line: 9, Serial: ""},
+		{Line: 10, Serial: "XD007", NetworkName: "Other"},
+	}
+
+	results, err := ImportDevices(context.Background(), client, rows, ImportOptions{NetworkName: "Retail", DryRun: true})
+	if err != nil {
+		t.Fatalf("ImportDevices dry run failed: %v", err)
+	}
+	if client.writes != 0 {
+		t.Errorf("Expected no writes on a dry run, got %d", client.writes)
+	}
+
+	expected := []struct {
+		action Action
+		failed bool
+	}{
+		{ActionNone, false}, {ActionUpdate, false}, {ActionDelete, false}, {ActionCreate, false},
+		{"", true}, {"", true}, {"", true}, {"", true}, {ActionCreate, true},
+	}
+	for i, want := range expected {
+		if results[i].Action != want.action || (results[i].Error != "") != want.failed {
+			t.Errorf("Line %d: expected %q (failed=%v), got %+v", rows[i].Line, want.action, want.failed, results[i])
+		}
+	}
+	if len(client.networks) != 2 || client.networks[0] != "Other" {
+		t.Errorf("Expected each network to be selected in order, got %v", client.networks)
+	}
+
+	results, err = ImportDevices(context.Background(), client, rows[:4], ImportOptions{NetworkName: "Retail"})
+	if err != nil {
+		t.Fatalf("ImportDevices failed: %v", err)
+	}
+	if results[0].Applied || !results[1].Applied || !results[2].Applied || !results[3].Applied {
+		t.Errorf("Unexpected results %+v", results)
+	}
+	if results[3].SetupID != "setup-a" || results[3].DeviceID == "" {
+		t.Errorf("Expected new device to be associated with setup-a, got %+v", results[3])
+	}
+	for _, device := range client.devices {
+		if device.Serial == "XD003" {
+			t.Error("Expected XD003 to be deleted")
+		}
+	}
+
+	var report bytes.Buffer
+	if err := WriteImportReport(&report, results); err != nil {
+		t.Fatalf("WriteImportReport failed: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(report.String()), "\n"); len(lines) != 5 || !strings.HasPrefix(lines[4], "5,XD004,Retail,store-a,setup-a,") {
+		t.Errorf("Unexpected report:\n%s", report.String())
+	}
+}
+
+func TestImportDevices_KeepsSetupByName(t *testing.T) {
+	client := &fakeImportClient{fakeReconcileClient: &fakeReconcileClient{fakeSetupClient: newFakeSetupClient()}}
+	client.records["setup-a"] = testRecord("store-a", "a")
+	client.records["setup-b1"] = testRecord("store-b", "b")
+	client.records["setup-b2"] = testRecord("store-b", "b")
+	client.devices = []types.BDeployDevice{
+		{ID: "dev-1", Serial: "XD001", Name: "Front", SetupName: "store-a", Username: "owner@example.com"},
+		{ID: "dev-2", Serial: "XD002", Name: "Back", SetupName: "store-a", Username: "owner@example.com"},
+		{ID: "dev-3", Serial: "XD003", Name: "Side", SetupName: "store-b", Username: "owner@example.com"},
+		{ID: "dev-4", Serial: "XD004", Name: "Door", SetupName: "store-gone", Username: "owner@example.com"},
+	}
+
+	// Only the names change, and the ledger has no entries
+	rows := []DeviceRow{
+		{Line: 2, Serial: "XD001", Name: "Front counter"},
+		{Line: 3, Serial: "XD002", Name: "Back"},
+		{Line: 4, Serial: "XD003", Name: "Side door"},
+		{Line: 5, Serial: "XD004", Name: "Front door"},
+	}
+	results, err := ImportDevices(context.Background(), client, rows, ImportOptions{NetworkName: "Retail"})
+	if err != nil {
+		t.Fatalf("ImportDevices failed: %v", err)
+	}
+
+	if !results[0].Applied || client.devices[0].SetupID != "setup-a" {
+		t.Errorf("Expected the renamed device to keep setup-a, got %+v (device %+v)", results[0], client.devices[0])
+	}
+	if results[1].Action != ActionNone || results[1].Error != "" {
+		t.Errorf("Expected an unchanged device to need nothing, got %+v", results[1])
+	}
+	for _, i := range []int{2, 3} {
+		if results[i].Error == "" || results[i].Applied {
+			t.Errorf("Line %d: expected a device whose setup cannot be resolved to be refused, got %+v", rows[i].Line, results[i])
+		}
+	}
+	if client.writes != 1 {
+		t.Errorf("Expected only the resolvable row to be written, got %d writes", client.writes)
+	}
+}
@@ -0,0 +1,161 @@
+package bdeploy
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/brightdevelopers/gopurple/internal/errors"
+	"github.com/brightdevelopers/gopurple/internal/services"
+	"github.com/brightdevelopers/gopurple/internal/types"
+)
+
+// ActionSkip means a record is left alone, e.g. because it was already migrated.
+const ActionSkip Action = "skip"
+
+// MigrationClient is the subset of the B-Deploy service used to move setup
+// records from the v2 setup API to v3.
+type MigrationClient interface {
+	GetSetupRecordsV2(ctx context.Context, opts ...services.BDeployListOption) (*types.BDeployRecordList, error)
+	GetSetupRecordV2(ctx context.Context, setupID string) (*types.BDeploySetupRecord, error)
+	DeleteSetupRecordV2(ctx context.Context, setupID string) (*types.BDeployDeleteResponse, error)
+	SetupClient
+}
+
+// MigrateOptions controls MigrateSetups.
+type MigrateOptions struct {
+	NetworkName  string   // Required
+	Username     string   // Only migrate records created by this user, if set
+	PackageNames []string // Only migrate these packages, if set
+	Overwrite    bool     // Update v3 records with the same package name instead of skipping them
+	DeleteV2     bool     // Delete each v2 record once its v3 copy is saved
+	DryRun       bool     // Fetch, convert and validate without writing
+}
+
+// MigrationResult describes what MigrateSetups did, or would do, for one v2 record.
+type MigrationResult struct {
+	V2ID        string                    `json:"v2Id"`
+	PackageName string                    `json:"packageName"`
+	SetupID     string                    `json:"setupId,omitempty"` // The v3 record
+	Action      Action                    `json:"action,omitempty"`
+	Applied     bool                      `json:"applied"`
+	DeletedV2   bool                      `json:"deletedV2,omitempty"`
+	Note        string                    `json:"note,omitempty"`
+	Error       string                    `json:"error,omitempty"`
+	Record      *types.BDeploySetupRecord `json:"-"` // The converted v3 record
+}
+
+// MigrateSetups converts every v2 setup record in a network to the v3 layout
+// (see BDeploySetupRecord.ToV3) and saves it with the v3 setup API. A v3 record
+// with the same package name is updated only if Overwrite is set; otherwise the
+// v2 record is skipped. The v2 records are left in place unless DeleteV2 is set,
+// and are then deleted only after their v3 copy is saved.
+//
+// Problems with a single record are reported in its result and do not stop the
+// others. Results are sorted by package name.
+func MigrateSetups(ctx context.Context, client MigrationClient, opts MigrateOptions) ([]MigrationResult, error) {
+	if opts.NetworkName == "" {
+		return nil, errors.NewValidationError("networkName", opts.NetworkName, "network name is required")
+	}
+
+	listOpts := []services.BDeployListOption{services.WithNetworkName(opts.NetworkName)}
+	if opts.Username != "" {
+		listOpts = append(listOpts, services.WithUsername(opts.Username))
+	}
+	legacy, err := client.GetSetupRecordsV2(ctx, listOpts...)
+	if err != nil {
+		return nil, err
+	}
+	current, err := client.GetSetupRecords(ctx, services.WithNetworkName(opts.NetworkName))
+	if err != nil {
+		return nil, err
+	}
+
+	existing := map[string][]string{}
+	for _, record := range current.Items {
+		existing[record.PackageName] = append(existing[record.PackageName], record.ID)
+	}
+	wanted := map[string]bool{}
+	for _, name := range opts.PackageNames {
+		wanted[name] = true
+	}
+
+	var results []MigrationResult
+	for _, summary := range legacy.Items {
+		if len(wanted) > 0 && !wanted[summary.PackageName] {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		results = append(results, migrateSetup(ctx, client, summary, existing[summary.PackageName], opts))
+	}
+
+	sort.SliceStable(results, func(i, j int) bool { return results[i].PackageName < results[j].PackageName })
+	return results, nil
+}
+
+// migrateSetup converts and saves one v2 record.
+func migrateSetup(ctx context.Context, client MigrationClient, summary types.BDeployRecord, existingIDs []string, opts MigrateOptions) MigrationResult {
+	result := MigrationResult{V2ID: summary.ID, PackageName: summary.PackageName}
+
+	legacy, err := client.GetSetupRecordV2(ctx, summary.ID)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	record, err := legacy.ToV3()
+	if err == nil {
+		err = client.ValidateSetupRecord(record)
+	}
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Record = record
+
+	switch {
+	case len(existingIDs) == 0:
+		result.Action = ActionCreate
+	case len(existingIDs) > 1:
+		result.Error = fmt.Sprintf("%d v3 setup records already use package name %q", len(existingIDs), summary.PackageName)
+		return result
+	case opts.Overwrite:
+		result.Action = ActionUpdate
+		result.SetupID = existingIDs[0]
+	default:
+		result.Action = ActionSkip
+		result.SetupID = existingIDs[0]
+		result.Note = "a v3 record with this package name already exists"
+		return result
+	}
+
+	if opts.DryRun {
+		return result
+	}
+
+	switch result.Action {
+	case ActionCreate:
+		response, err := client.AddSetupRecord(ctx, record)
+		if err != nil {
+			result.Error = err.Error()
+			return result
+		}
+		result.SetupID = response.ID
+	case ActionUpdate:
+		if _, err := client.UpdateSetupRecord(ctx, result.SetupID, record); err != nil {
+			result.Error = err.Error()
+			return result
+		}
+	}
+	result.Applied = true
+
+	if opts.DeleteV2 {
+		if _, err := client.DeleteSetupRecordV2(ctx, summary.ID); err != nil {
+			result.Error = fmt.Sprintf("migrated, but the v2 record was not deleted: %v", err)
+			return result
+		}
+		result.DeletedV2 = true
+	}
+	return result
+}
@@ -0,0 +1,105 @@
+package bdeploy
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+
+	"github.com/brightdevelopers/gopurple/internal/services"
+	"github.com/brightdevelopers/gopurple/internal/types"
+)
+
+// fakeMigrationClient keeps v2 records alongside the v3 records of fakeSetupClient.
+type fakeMigrationClient struct {
+	*fakeSetupClient
+	v2 map[string]*types.BDeploySetupRecord
+}
+
+func (f *fakeMigrationClient) GetSetupRecordsV2(ctx context.Context, opts ...services.BDeployListOption) (*types.BDeployRecordList, error) {
+	list := &types.BDeployRecordList{}
+	for id, record := range f.v2 {
+		list.Items = append(list.Items, types.BDeployRecord{ID: id, PackageName: record.BDeploy.PackageName})
+	}
+	list.TotalCount = len(list.Items)
+	return list, nil
+}
+
+func (f *fakeMigrationClient) GetSetupRecordV2(ctx context.Context, setupID string) (*types.BDeploySetupRecord, error) {
+	record, ok := f.v2[setupID]
+	if !ok {
+		return nil, stderrors.New("not found")
+	}
+	copied := *record
+	return &copied, nil
+}
+
+func (f *fakeMigrationClient) DeleteSetupRecordV2(ctx context.Context, setupID string) (*types.BDeployDeleteResponse, error) {
+	delete(f.v2, setupID)
+	return &types.BDeployDeleteResponse{Success: true}, nil
+}
+
+func v2Record(pkg string) *types.BDeploySetupRecord {
+	return &types.BDeploySetupRecord{
+		Version:   types.SetupVersion2,
+		BDeploy:   types.BDeployInfo{Username: "ops@example.com", NetworkName: "Retail", PackageName: pkg},
+		SetupType: "bsn",
+	}
+}
+
+func TestMigrateSetups(t *testing.T) {
+	ctx := context.Background()
+	client := &fakeMigrationClient{
+		fakeSetupClient: newFakeSetupClient(),
+		v2: map[string]*types.BDeploySetupRecord{
+			"v2-a": v2Record("store-0001"),
+			"v2-b": v2Record("store-0002"),
+			"v2-c": {Version: types.SetupVersion2, BDeploy: types.BDeployInfo{PackageName: "broken"}},
+		},
+	}
+	client.records["existing"] = testRecord("store-0002", "")
+
+	if _, err := MigrateSetups(ctx, client, MigrateOptions{}); err == nil {
+		t.Error("Expected an error without a network name")
+	}
+
+	results, err := MigrateSetups(ctx, client, MigrateOptions{NetworkName: "Retail", DryRun: true})
+	if err != nil {
+		t.Fatalf("MigrateSetups dry run failed: %v", err)
+	}
+	if len(results) != 3 || client.writes != 0 {
+		t.Fatalf("Expected 3 results and no writes, got %d results and %d writes", len(results), client.writes)
+	}
+	if results[0].PackageName != "broken" || results[0].Error == "" {
+		t.Errorf("Expected the invalid record to fail validation, got %+v", results[0])
+	}
+	if results[1].Action != ActionCreate || results[1].Record.Version != types.SetupVersion3 || results[1].Record.Network == nil {
+		t.Errorf("Expected store-0001 to be created as a v3 record, got %+v", results[1])
+	}
+	if results[2].Action != ActionSkip || results[2].SetupID != "existing" {
+		t.Errorf("Expected store-0002 to be skipped, got %+v", results[2])
+	}
+
+	results, err = MigrateSetups(ctx, client, MigrateOptions{
+		NetworkName:  "Retail",
+		PackageNames: []string{"store-0001", "store-0002"},
+		Overwrite:    true,
+		DeleteV2:     true,
+	})
+	if err != nil {
+		t.Fatalf("MigrateSetups failed: %v", err)
+	}
+	if len(results) != 2 || results[1].Action != ActionUpdate {
+		t.Fatalf("Expected store-0002 to be updated with Overwrite, got %+v", results)
+	}
+	for _, result := range results {
+		if !result.Applied || !result.DeletedV2 || result.Error != "" {
+			t.Errorf("Expected %s to be migrated and its v2 record deleted, got %+v", result.PackageName, result)
+		}
+	}
+	if _, ok := client.v2["v2-c"]; !ok || len(client.v2) != 1 {
+		t.Errorf("Expected only the unselected v2 record to remain, got %v", client.v2)
+	}
+	if record := client.records["existing"]; record.Version != types.SetupVersion3 || record.Network == nil {
+		t.Errorf("Expected the existing v3 record to be overwritten, got %+v", record)
+	}
+}
@@ -0,0 +1,612 @@
+package bdeploy
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/brightdevelopers/gopurple/internal/errors"
+	"github.com/brightdevelopers/gopurple/internal/secrets"
+	"github.com/brightdevelopers/gopurple/internal/services"
+	"github.com/brightdevelopers/gopurple/internal/types"
+)
+
+// Reconciliation actions, in addition to ActionCreate and ActionUpdate.
+const (
+	ActionDelete    Action = "delete"
+	ActionAssociate Action = "associate"
+)
+
+// Association says which setup record, by package name, a player should use.
+type Association struct {
+	Serial      string `json:"serial"`
+	PackageName string `json:"packageName"`
+	Name        string `json:"name,omitempty"`        // Device name used when the device is registered
+	Description string `json:"description,omitempty"` // Device description used when the device is registered
+}
+
+// ReadAssociations reads an association manifest: a CSV with "serial" and
+// "package" columns and optional "name" and "description" columns.
+func ReadAssociations(r io.Reader) ([]Association, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest header: %w", err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))] = i
+	}
+	for _, required := range []string{"serial", "package"} {
+		if _, ok := columns[required]; !ok {
+			return nil, errors.NewValidationError("manifest", header, fmt.Sprintf("manifest needs a %q column", required))
+		}
+	}
+	column := func(row []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	var associations []Association
+	seen := map[string]bool{}
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read manifest: %w", err)
+		}
+
+		line, _ := reader.FieldPos(0)
+		a := Association{
+			Serial:      column(row, "serial"),
+			PackageName: column(row, "package"),
+			Name:        column(row, "name"),
+			Description: column(row, "description"),
+		}
+		if a.Serial == "" || a.PackageName == "" {
+			return nil, errors.NewValidationError("manifest", row, fmt.Sprintf("line %d: serial and package are required", line))
+		}
+		if seen[a.Serial] {
+			return nil, errors.NewValidationError("serial", a.Serial, fmt.Sprintf("line %d: duplicate serial", line))
+		}
+		seen[a.Serial] = true
+		associations = append(associations, a)
+	}
+	return associations, nil
+}
+
+// LoadAssociations reads an association manifest file.
+func LoadAssociations(filename string) ([]Association, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	associations, err := ReadAssociations(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return associations, nil
+}
+
+// DesiredState is the B-Deploy state a network should have: its setup records and
+// which setup record each player uses.
+type DesiredState struct {
+	Records      []*types.BDeploySetupRecord
+	Associations []Association
+}
+
+// LoadDesiredState reads every .json, .yaml and .yml file in dir as a setup record
+// and, if manifest is not empty, the association manifest. Records are validated,
+// package names must be unique and every association must name a desired record.
+func LoadDesiredState(dir, manifest string) (*DesiredState, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &DesiredState{}
+	files := map[string]string{}
+	for _, entry := range entries {
+		switch strings.ToLower(filepath.Ext(entry.Name())) {
+		case ".json", ".yaml", ".yml":
+		default:
+			continue
+		}
+		if entry.IsDir() {
+			continue
+		}
+
+		filename := filepath.Join(dir, entry.Name())
+		doc, err := LoadDocument(filename)
+		if err != nil {
+			return nil, err
+		}
+		record, err := (&Template{Base: doc}).Render(nil)
+		if err == nil {
+			err = record.Validate()
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filename, err)
+		}
+
+		if other, ok := files[record.BDeploy.PackageName]; ok {
+			return nil, errors.NewValidationError("packageName", record.BDeploy.PackageName,
+				fmt.Sprintf("used by both %s and %s", other, filename))
+		}
+		files[record.BDeploy.PackageName] = filename
+		state.Records = append(state.Records, record)
+	}
+
+	if manifest != "" {
+		state.Associations, err = LoadAssociations(manifest)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range state.Associations {
+			if _, ok := files[a.PackageName]; !ok {
+				return nil, errors.NewValidationError("package", a.PackageName,
+					fmt.Sprintf("%s: player %s uses a package that is not in %s", manifest, a.Serial, dir))
+			}
+		}
+	}
+
+	return state, nil
+}
+
+// ReconcileClient is the subset of the B-Deploy service used to reconcile a network.
+type ReconcileClient interface {
+	SetupClient
+	GetSetupRecord(ctx context.Context, setupID string) (*types.BDeploySetupRecord, error)
+	DeleteSetupRecord(ctx context.Context, setupID string) (*types.BDeployDeleteResponse, error)
+	GetAllDevices(ctx context.Context, opts ...services.BDeployDeviceListOption) (*types.BDeployDeviceListResponse, error)
+	CreateDevice(ctx context.Context, request *types.BDeployDeviceRequest) (string, error)
+	UpdateDevice(ctx context.Context, deviceID string, request *types.BDeployDeviceRequest) (*types.BDeployDevice, error)
+}
+
+// ReconcileOptions controls PlanReconcile.
+type ReconcileOptions struct {
+	NetworkName string // Network to reconcile; every desired record must use it
+	Prune       bool   // Delete setup records in the network that are not desired
+
+	// Secrets resolves secret references in desired records before they are
+	// compared with the server's and sent. Without it, a desired record with
+	// references is refused. The plan keeps the unresolved records.
+	Secrets secrets.Resolver
+}
+
+// FieldChange is one setup record value that an update changes. Nested fields are
+// named by their dotted JSON path, e.g. "bDeploy.bsnGroupName". Passwords and
+// tokens are masked.
+type FieldChange struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+// String formats the change as "field: old -> new".
+func (c FieldChange) String() string {
+	return fmt.Sprintf("%s: %s -> %s", c.Field, formatValue(c.Old), formatValue(c.New))
+}
+
+// PlanChange is one action in a reconciliation plan. After ApplyPlan, Applied or
+// Error says what happened.
+type PlanChange struct {
+	Action      Action        `json:"action"`
+	PackageName string        `json:"packageName"`
+	SetupID     string        `json:"setupId,omitempty"`
+	Serial      string        `json:"serial,omitempty"`   // Player, for ActionAssociate
+	DeviceID    string        `json:"deviceId,omitempty"` // Existing device record, for ActionAssociate
+	Fields      []FieldChange `json:"fields,omitempty"`   // Changed values, for ActionUpdate
+	Applied     bool          `json:"applied"`
+	Error       string        `json:"error,omitempty"`
+
+	Record      *types.BDeploySetupRecord `json:"-"` // Desired record, for ActionCreate and ActionUpdate
+	Association *Association              `json:"-"` // Desired association, for ActionAssociate
+
+	send *types.BDeploySetupRecord // Record with its secret references resolved, if it has any
+}
+
+// sendRecord returns the record to send for a create or update.
+func (c *PlanChange) sendRecord() *types.BDeploySetupRecord {
+	if c.send != nil {
+		return c.send
+	}
+	return c.Record
+}
+
+// Plan is the set of changes that brings a network to the desired state.
+type Plan struct {
+	NetworkName string       `json:"networkName"`
+	Changes     []PlanChange `json:"changes"`
+	Unmanaged   []string     `json:"unmanaged,omitempty"` // Packages not desired and kept because Prune is off
+	Warnings    []string     `json:"warnings,omitempty"`
+}
+
+// Count returns how many changes in the plan have the given action.
+func (p *Plan) Count(action Action) int {
+	n := 0
+	for _, change := range p.Changes {
+		if change.Action == action {
+			n++
+		}
+	}
+	return n
+}
+
+// Summary returns a one-line summary such as
+// "Plan: 1 to create, 2 to update, 0 to delete, 3 to associate."
+func (p *Plan) Summary() string {
+	if len(p.Changes) == 0 {
+		return "No changes. B-Deploy matches the desired state."
+	}
+	return fmt.Sprintf("Plan: %d to create, %d to update, %d to delete, %d to associate.",
+		p.Count(ActionCreate), p.Count(ActionUpdate), p.Count(ActionDelete), p.Count(ActionAssociate))
+}
+
+// String formats the plan in the style of terraform plan: "+" creates, "~" updates
+// with one line per changed value, "-" deletes and "*" associations.
+func (p *Plan) String() string {
+	var b strings.Builder
+	for _, change := range p.Changes {
+		switch change.Action {
+		case ActionCreate:
+			fmt.Fprintf(&b, "  + create    %s\n", change.PackageName)
+		case ActionUpdate:
+			fmt.Fprintf(&b, "  ~ update    %s (%s)\n", change.PackageName, change.SetupID)
+			for _, field := range change.Fields {
+				fmt.Fprintf(&b, "      %s\n", field)
+			}
+		case ActionDelete:
+			fmt.Fprintf(&b, "  - delete    %s (%s)\n", change.PackageName, change.SetupID)
+		case ActionAssociate:
+			fmt.Fprintf(&b, "  * associate %s -> %s\n", change.Serial, change.PackageName)
+		}
+		if change.Error != "" {
+			fmt.Fprintf(&b, "      error: %s\n", change.Error)
+		}
+	}
+	for _, name := range p.Unmanaged {
+		fmt.Fprintf(&b, "  ? unmanaged %s (kept, use prune to delete)\n", name)
+	}
+	for _, warning := range p.Warnings {
+		fmt.Fprintf(&b, "  ! %s\n", warning)
+	}
+	if b.Len() > 0 {
+		b.WriteString("\n")
+	}
+	b.WriteString(p.Summary())
+	b.WriteString("\n")
+	return b.String()
+}
+
+func formatValue(v interface{}) string {
+	if v == nil {
+		return "(unset)"
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// PlanReconcile compares the desired state with the setup records and devices in
+// the network and returns the changes needed. Nothing is written.
+//
+// Records are matched by package name. A record is updated when any value differs;
+// values the desired record leaves out are only reported when the server has a
+// non-zero value for them, since an update replaces the whole record. Players are
+// associated when they are not registered or use a different setup record. B-Deploy
+// does not return a device's setupId, so a player's current setup comes from the
+// association ledger (filled in by GetAllDevices) or from the setupName B-Deploy reports.
+func PlanReconcile(ctx context.Context, client ReconcileClient, desired *DesiredState, opts ReconcileOptions) (*Plan, error) {
+	if opts.NetworkName == "" {
+		return nil, errors.NewValidationError("networkName", opts.NetworkName, "network name is required")
+	}
+	for _, record := range desired.Records {
+		if record.BDeploy.NetworkName != opts.NetworkName {
+			return nil, errors.NewValidationError("bDeploy.networkName", record.BDeploy.NetworkName,
+				fmt.Sprintf("package %s is not in network %s", record.BDeploy.PackageName, opts.NetworkName))
+		}
+	}
+
+	list, err := client.GetSetupRecords(ctx, services.WithNetworkName(opts.NetworkName))
+	if err != nil {
+		return nil, err
+	}
+	existing := map[string][]types.BDeployRecord{}
+	for _, record := range list.Items {
+		existing[record.PackageName] = append(existing[record.PackageName], record)
+	}
+
+	plan := &Plan{NetworkName: opts.NetworkName}
+	setupIDs := map[string]string{} // package name to setup ID, for associations
+	desiredPackages := map[string]bool{}
+
+	for _, record := range desired.Records {
+		name := record.BDeploy.PackageName
+		desiredPackages[name] = true
+
+		send := record
+		if opts.Secrets != nil {
+			send, err = record.ResolveSecrets(ctx, opts.Secrets.Resolve)
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve secrets of %s: %w", name, err)
+			}
+		} else if err := record.CheckSecretReferences(); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		matches := existing[name]
+		switch len(matches) {
+		case 0:
+			plan.Changes = append(plan.Changes, PlanChange{Action: ActionCreate, PackageName: name, Record: record, send: send})
+			continue
+		case 1:
+		default:
+			return nil, errors.NewValidationError("packageName", name,
+				fmt.Sprintf("%d setup records in %s use this package name", len(matches), opts.NetworkName))
+		}
+
+		setupID := matches[0].ID
+		setupIDs[name] = setupID
+		current, err := client.GetSetupRecord(ctx, setupID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get setup record %s (%s): %w", name, setupID, err)
+		}
+		fields, err := diffRecords(current, send)
+		if err != nil {
+			return nil, err
+		}
+		if len(fields) > 0 {
+			plan.Changes = append(plan.Changes, PlanChange{
+				Action: ActionUpdate, PackageName: name, SetupID: setupID, Fields: fields, Record: record, send: send,
+			})
+		}
+	}
+
+	// Records nobody asked for are deleted with Prune, or listed as unmanaged
+	deleted := map[string]string{}
+	for _, record := range list.Items {
+		if desiredPackages[record.PackageName] {
+			continue
+		}
+		if opts.Prune {
+			plan.Changes = append(plan.Changes, PlanChange{Action: ActionDelete, PackageName: record.PackageName, SetupID: record.ID})
+			deleted[record.ID] = record.PackageName
+		} else {
+			plan.Unmanaged = append(plan.Unmanaged, record.PackageName)
+		}
+	}
+
+	if len(desired.Associations) > 0 || len(deleted) > 0 {
+		devices, err := client.GetAllDevices(ctx)
+		if err != nil {
+			return nil, err
+		}
+		bySerial := map[string]types.BDeployDevice{}
+		for _, device := range devices.Players {
+			if _, ok := bySerial[device.Serial]; !ok {
+				bySerial[device.Serial] = device
+			}
+		}
+
+		associated := map[string]bool{}
+		for i := range desired.Associations {
+			a := &desired.Associations[i]
+			device, registered := bySerial[a.Serial]
+			setupID, exists := setupIDs[a.PackageName]
+			if registered && exists && (device.SetupID == setupID || (device.SetupID == "" && device.SetupName == a.PackageName)) {
+				continue
+			}
+			associated[a.Serial] = true
+			plan.Changes = append(plan.Changes, PlanChange{
+				Action: ActionAssociate, PackageName: a.PackageName, SetupID: setupID,
+				Serial: a.Serial, DeviceID: device.ID, Association: a,
+			})
+		}
+
+		for _, device := range devices.Players {
+			if name, ok := deleted[device.SetupID]; ok && !associated[device.Serial] {
+				plan.Warnings = append(plan.Warnings,
+					fmt.Sprintf("player %s uses %s, which will be deleted", device.Serial, name))
+			}
+		}
+	}
+
+	sort.Strings(plan.Unmanaged)
+	return plan, nil
+}
+
+// ApplyPlan makes the changes in a plan: creates first, then updates, associations
+// and finally deletes, so players are moved before their old record disappears.
+// Each change records whether it was applied or the error it failed with; a
+// failed change does not stop the others, except that players are not associated
+// with a record that failed to be created. The returned error is only set when
+// the context is done.
+//
+// Records are sent as PlanReconcile resolved them with ReconcileOptions.Secrets;
+// the client does not resolve secret references again.
+func ApplyPlan(ctx context.Context, client ReconcileClient, plan *Plan) error {
+	ctx = services.WithoutSecretResolution(ctx)
+	setupIDs := map[string]string{}
+	usernames := map[string]string{}
+	for _, change := range plan.Changes {
+		if change.Record != nil {
+			usernames[change.PackageName] = change.Record.BDeploy.Username
+		}
+	}
+
+	for _, action := range []Action{ActionCreate, ActionUpdate, ActionAssociate, ActionDelete} {
+		for i := range plan.Changes {
+			change := &plan.Changes[i]
+			if change.Action != action || change.Applied {
+				continue
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			var err error
+			switch action {
+			case ActionCreate:
+				var response *types.BDeployCreateResponse
+				response, err = client.AddSetupRecord(ctx, change.sendRecord())
+				if err == nil {
+					change.SetupID = response.ID
+					setupIDs[change.PackageName] = response.ID
+				}
+			case ActionUpdate:
+				_, err = client.UpdateSetupRecord(ctx, change.SetupID, change.sendRecord())
+			case ActionAssociate:
+				if change.SetupID == "" {
+					change.SetupID = setupIDs[change.PackageName]
+				}
+				if change.SetupID == "" {
+					err = fmt.Errorf("setup record %s was not created", change.PackageName)
+					break
+				}
+				err = associate(ctx, client, plan.NetworkName, usernames[change.PackageName], change)
+			case ActionDelete:
+				_, err = client.DeleteSetupRecord(ctx, change.SetupID)
+			}
+
+			if err != nil {
+				change.Error = err.Error()
+				continue
+			}
+			change.Applied = true
+		}
+	}
+	return nil
+}
+
+// associate registers the player if needed and points it at the change's setup record.
+func associate(ctx context.Context, client ReconcileClient, network, username string, change *PlanChange) error {
+	a := change.Association
+	request := &types.BDeployDeviceRequest{
+		Username:    username,
+		Serial:      change.Serial,
+		Name:        change.Serial,
+		NetworkName: network,
+		Desc:        fmt.Sprintf("Device %s", change.Serial),
+	}
+	if a != nil && a.Name != "" {
+		request.Name = a.Name
+	}
+	if a != nil && a.Description != "" {
+		request.Desc = a.Description
+	}
+
+	if change.DeviceID == "" {
+		deviceID, err := client.CreateDevice(ctx, request)
+		if err != nil {
+			return err
+		}
+		change.DeviceID = deviceID
+	}
+
+	request.SetupID = change.SetupID
+	_, err := client.UpdateDevice(ctx, change.DeviceID, request)
+	return err
+}
+
+// diffRecords returns the values that differ between the current and desired
+// records, with secrets masked.
+func diffRecords(current, desired *types.BDeploySetupRecord) ([]FieldChange, error) {
+	currentMap, err := recordMap(current)
+	if err != nil {
+		return nil, err
+	}
+	desiredMap, err := recordMap(desired)
+	if err != nil {
+		return nil, err
+	}
+	delete(currentMap, "_id")
+	delete(desiredMap, "_id")
+
+	var changes []FieldChange
+	diffValues("", currentMap, desiredMap, &changes)
+	for i := range changes {
+		if types.IsSecretSetupField(changes[i].Field) {
+			changes[i].Old, changes[i].New = maskSecret(changes[i].Old), maskSecret(changes[i].New)
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes, nil
+}
+
+func recordMap(record *types.BDeploySetupRecord) (map[string]interface{}, error) {
+	data, err := types.MarshalSetupRecord(record)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func diffValues(path string, current, desired interface{}, changes *[]FieldChange) {
+	currentObject, currentIsObject := current.(map[string]interface{})
+	desiredObject, desiredIsObject := desired.(map[string]interface{})
+	if currentIsObject && desiredIsObject {
+		keys := map[string]bool{}
+		for key := range currentObject {
+			keys[key] = true
+		}
+		for key := range desiredObject {
+			keys[key] = true
+		}
+		for key := range keys {
+			diffValues(joinPath(path, key), currentObject[key], desiredObject[key], changes)
+		}
+		return
+	}
+
+	if isZeroValue(current) && isZeroValue(desired) {
+		return
+	}
+	if !reflect.DeepEqual(current, desired) {
+		*changes = append(*changes, FieldChange{Field: path, Old: current, New: desired})
+	}
+}
+
+// isZeroValue reports whether a decoded JSON value is absent or empty.
+func isZeroValue(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case bool:
+		return !v
+	case float64:
+		return v == 0
+	case string:
+		return v == ""
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	}
+	return false
+}
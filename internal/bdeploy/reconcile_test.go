@@ -0,0 +1,201 @@
+package bdeploy
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/brightdevelopers/gopurple/internal/secrets"
+	"github.com/brightdevelopers/gopurple/internal/services"
+	"github.com/brightdevelopers/gopurple/internal/types"
+)
+
+// fakeReconcileClient adds B-Deploy device records to fakeSetupClient.
+type fakeReconcileClient struct {
+	*fakeSetupClient
+	devices []types.BDeployDevice
+}
+
+func (f *fakeReconcileClient) GetAllDevices(ctx context.Context, opts ...services.BDeployDeviceListOption) (*types.BDeployDeviceListResponse, error) {
+	return &types.BDeployDeviceListResponse{Players: f.devices, Total: len(f.devices)}, nil
+}
+
+func (f *fakeReconcileClient) CreateDevice(ctx context.Context, request *types.BDeployDeviceRequest) (string, error) {
+	f.writes++
+	id := fmt.Sprintf("device-%d", len(f.devices)+1)
+	f.devices = append(f.devices, types.BDeployDevice{ID: id, Serial: request.Serial, Name: request.Name})
+	return id, nil
+}
+
+func (f *fakeReconcileClient) UpdateDevice(ctx context.Context, deviceID string, request *types.BDeployDeviceRequest) (*types.BDeployDevice, error) {
+	f.writes++
+	for i := range f.devices {
+		if f.devices[i].ID == deviceID {
+			f.devices[i].SetupID = request.SetupID
+			return &f.devices[i], nil
+		}
+	}
+	return nil, fmt.Errorf("device %s not found", deviceID)
+}
+
+func testRecord(packageName, hostname string) *types.BDeploySetupRecord {
+	return &types.BDeploySetupRecord{
+		Version:   "3.0.0",
+		BDeploy:   types.BDeployInfo{Username: "admin@example.com", NetworkName: "Retail", PackageName: packageName},
+		SetupType: "lfn",
+		Hostname:  hostname,
+	}
+}
+
+func TestLoadDesiredState(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "a.yaml", "version: \"3.0.0\"\nsetupType: lfn\nbDeploy:\n  username: admin@example.com\n  networkName: Retail\n  packageName: store-a\n")
+	writeFile(t, dir, "b.json", `{"version": "3.0.0", "setupType": "lfn", "bDeploy": {"username": "admin@example.com", "networkName": "Retail", "packageName": "store-b"}}`)
+	writeFile(t, dir, "notes.txt", "ignored")
+	manifest := writeFile(t, t.TempDir(), "players.csv", "serial,package,name\nXD001,store-a,Front\nXD002,store-b,\n")
+
+	state, err := LoadDesiredState(dir, manifest)
+	if err != nil {
+		t.Fatalf("LoadDesiredState failed: %v", err)
+	}
+	if len(state.Records) != 2 || len(state.Associations) != 2 || state.Associations[0].Name != "Front" {
+		t.Errorf("Unexpected state %+v", state)
+	}
+
+	bad := writeFile(t, t.TempDir(), "players.csv", "serial,package\nXD003,store-c\n")
+	if _, err := LoadDesiredState(dir, bad); err == nil {
+		t.Error("Expected error for association with unknown package")
+	}
+
+	writeFile(t, dir, "c.json", `{"version": "3.0.0", "setupType": "lfn", "bDeploy": {"username": "admin@example.com", "networkName": "Retail", "packageName": "store-a"}}`)
+	if _, err := LoadDesiredState(dir, ""); err == nil {
+		t.Error("Expected error for duplicate package name")
+	}
+	os.Remove(filepath.Join(dir, "c.json"))
+
+	writeFile(t, dir, "d.json", `{"version": "3.0.0", "bDeploy": {"networkName": "Retail", "packageName": "store-d"}}`)
+	if _, err := LoadDesiredState(dir, ""); err == nil {
+		t.Error("Expected error for invalid record")
+	}
+}
+
+func TestReadAssociations(t *testing.T) {
+	if _, err := ReadAssociations(strings.NewReader("serial\nXD001\n")); err == nil {
+		t.Error("Expected error for missing package column")
+	}
+	if _, err := ReadAssociations(strings.NewReader("serial,package\nXD001,a\nXD001,b\n")); err == nil {
+		t.Error("Expected error for duplicate serial")
+	}
+}
+
+func TestPlanAndApplyReconcile(t *testing.T) {
+	client := &fakeReconcileClient{fakeSetupClient: newFakeSetupClient()}
+	client.records["id-same"] = testRecord("store-same", "same")
+	client.records["id-drift"] = testRecord("store-drift", "edited-by-hand")
+	client.records["id-drift"].DWSEnabled = true
+	client.records["id-old"] = testRecord("store-old", "old")
+	client.devices = []types.BDeployDevice{
+		{ID: "dev-1", Serial: "XD001", SetupID: "id-same"},
+		{ID: "dev-2", Serial: "XD002", SetupID: "id-old"},
+		{ID: "dev-3", Serial: "XD003", SetupID: "id-old"},
+	}
+
+	desired := &DesiredState{
+		Records: []*types.BDeploySetupRecord{
+			testRecord("store-same", "same"),
+			testRecord("store-drift", "drift"),
+			testRecord("store-new", "new"),
+		},
+		Associations: []Association{
+			{Serial: "XD001", PackageName: "store-same"},
+			{Serial: "XD002", PackageName: "store-drift"},
+			{Serial: "XD004", PackageName: "store-new", Name: "Lobby"},
+		},
+	}
+
+	plan, err := PlanReconcile(context.Background(), client, desired, ReconcileOptions{NetworkName: "Retail"})
+	if err != nil {
+		t.Fatalf("PlanReconcile failed: %v", err)
+	}
+	if plan.Count(ActionCreate) != 1 || plan.Count(ActionUpdate) != 1 || plan.Count(ActionDelete) != 0 || plan.Count(ActionAssociate) != 2 {
+		t.Fatalf("Unexpected plan:\n%s", plan)
+	}
+	if len(plan.Unmanaged) != 1 || plan.Unmanaged[0] != "store-old" {
+		t.Errorf("Expected store-old to be unmanaged, got %v", plan.Unmanaged)
+	}
+	for _, change := range plan.Changes {
+		if change.Action == ActionUpdate {
+			if len(change.Fields) != 2 || change.Fields[0].Field != "dwsEnabled" || change.Fields[1].Field != "hostname" {
+				t.Errorf("Unexpected field changes %+v", change.Fields)
+			}
+		}
+	}
+	if !strings.Contains(plan.String(), `hostname: "edited-by-hand" -> "drift"`) {
+		t.Errorf("Expected value change in plan output:\n%s", plan)
+	}
+
+	plan, err = PlanReconcile(context.Background(), client, desired, ReconcileOptions{NetworkName: "Retail", Prune: true})
+	if err != nil {
+		t.Fatalf("PlanReconcile with prune failed: %v", err)
+	}
+	if plan.Count(ActionDelete) != 1 || len(plan.Warnings) != 1 || !strings.Contains(plan.Warnings[0], "XD003") {
+		t.Fatalf("Expected store-old to be deleted with a warning for XD003:\n%s", plan)
+	}
+
+	if err := ApplyPlan(context.Background(), client, plan); err != nil {
+		t.Fatalf("ApplyPlan failed: %v", err)
+	}
+	for _, change := range plan.Changes {
+		if !change.Applied {
+			t.Errorf("Change not applied: %+v", change)
+		}
+	}
+	if _, ok := client.records["id-old"]; ok {
+		t.Error("Expected store-old to be deleted")
+	}
+
+	// A second plan finds nothing left to do
+	plan, err = PlanReconcile(context.Background(), client, desired, ReconcileOptions{NetworkName: "Retail", Prune: true})
+	if err != nil {
+		t.Fatalf("PlanReconcile failed: %v", err)
+	}
+	if len(plan.Changes) != 0 {
+		t.Errorf("Expected no changes after apply, got:\n%s", plan)
+	}
+}
+
+func TestPlanReconcile_WrongNetwork(t *testing.T) {
+	client := &fakeReconcileClient{fakeSetupClient: newFakeSetupClient()}
+	desired := &DesiredState{Records: []*types.BDeploySetupRecord{testRecord("store-a", "a")}}
+	if _, err := PlanReconcile(context.Background(), client, desired, ReconcileOptions{NetworkName: "Other"}); err == nil {
+		t.Error("Expected error for record in another network")
+	}
+}
+
+func TestPlanReconcile_SecretReferences(t *testing.T) {
+	t.Setenv("GOPURPLE_TEST_LWS", "lws-secret")
+	client := &fakeReconcileClient{fakeSetupClient: newFakeSetupClient()}
+	current := testRecord("store-a", "a")
+	current.LWSPassword = "lws-secret"
+	client.records["setup-1"] = current
+
+	record := testRecord("store-a", "a")
+	record.LWSPassword = "env:GOPURPLE_TEST_LWS"
+	desired := &DesiredState{Records: []*types.BDeploySetupRecord{record}}
+
+	// Without a resolver the reference would be stored as the password
+	if _, err := PlanReconcile(context.Background(), client, desired, ReconcileOptions{NetworkName: "Retail"}); err == nil || !strings.Contains(err.Error(), "WithSecretReferences") {
+		t.Errorf("Expected a reference without a resolver to be refused, got %v", err)
+	}
+
+	plan, err := PlanReconcile(context.Background(), client, desired, ReconcileOptions{NetworkName: "Retail", Secrets: secrets.NewSources(false)})
+	if err != nil {
+		t.Fatalf("PlanReconcile with secrets failed: %v", err)
+	}
+	if len(plan.Changes) != 0 {
+		t.Errorf("Expected no changes once the reference is resolved, got:\n%s", plan)
+	}
+}
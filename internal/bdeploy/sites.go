@@ -0,0 +1,273 @@
+package bdeploy
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/brightdevelopers/gopurple/internal/errors"
+	"github.com/brightdevelopers/gopurple/internal/services"
+	"github.com/brightdevelopers/gopurple/internal/types"
+)
+
+const (
+	// siteColumn names the required sites CSV column holding each site's name.
+	siteColumn = "site"
+
+	// overlayColumn names the optional sites CSV column holding a per-site overlay file.
+	overlayColumn = "overlay"
+)
+
+// Site is one row of a sites CSV file: a name, the template variables for the
+// site and an optional overlay document with settings only that site needs.
+type Site struct {
+	Name    string            // Value of the "site" column
+	Vars    map[string]string // Every column by header name, including "site"
+	Overlay string            // Overlay file from the "overlay" column, if any
+}
+
+// ReadSites reads a sites CSV. The header row names the variables; a "site" column
+// is required and an "overlay" column may name a .json or .yaml overlay per site.
+// Blank lines are skipped and site names must be unique.
+func ReadSites(r io.Reader) ([]Site, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.NewValidationError("sites", "", "sites file is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sites header: %w", err)
+	}
+	for i := range header {
+		header[i] = strings.TrimSpace(strings.TrimPrefix(header[i], "\ufeff"))
+	}
+
+	hasSite := false
+	for _, name := range header {
+		hasSite = hasSite || name == siteColumn
+	}
+	if !hasSite {
+		return nil, errors.NewValidationError("sites", header, "sites file needs a \"site\" column")
+	}
+
+	var sites []Site
+	seen := map[string]bool{}
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read sites: %w", err)
+		}
+
+		line, _ := reader.FieldPos(0)
+		site := Site{Vars: map[string]string{}}
+		for i, value := range row {
+			site.Vars[header[i]] = strings.TrimSpace(value)
+		}
+		site.Name = site.Vars[siteColumn]
+		site.Overlay = site.Vars[overlayColumn]
+
+		if site.Name == "" {
+			return nil, errors.NewValidationError("site", "", fmt.Sprintf("line %d: site name is empty", line))
+		}
+		if seen[site.Name] {
+			return nil, errors.NewValidationError("site", site.Name, fmt.Sprintf("line %d: duplicate site", line))
+		}
+		seen[site.Name] = true
+		sites = append(sites, site)
+	}
+
+	return sites, nil
+}
+
+// LoadSites reads a sites CSV file. Overlay paths are resolved relative to the file.
+func LoadSites(filename string) ([]Site, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	sites, err := ReadSites(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	for i := range sites {
+		if sites[i].Overlay != "" && !filepath.IsAbs(sites[i].Overlay) {
+			sites[i].Overlay = filepath.Join(filepath.Dir(filename), sites[i].Overlay)
+		}
+	}
+	return sites, nil
+}
+
+// RenderSite renders the template with the site's variables and overlay.
+func (t *Template) RenderSite(site Site) (*types.BDeploySetupRecord, error) {
+	var extra []Document
+	if site.Overlay != "" {
+		overlay, err := LoadDocument(site.Overlay)
+		if err != nil {
+			return nil, err
+		}
+		extra = append(extra, overlay)
+	}
+	return t.Render(site.Vars, extra...)
+}
+
+// SetupClient is the subset of the B-Deploy service used to apply setup records.
+type SetupClient interface {
+	GetSetupRecords(ctx context.Context, opts ...services.BDeployListOption) (*types.BDeployRecordList, error)
+	AddSetupRecord(ctx context.Context, record *types.BDeploySetupRecord) (*types.BDeployCreateResponse, error)
+	UpdateSetupRecord(ctx context.Context, setupID string, record *types.BDeploySetupRecord) (*types.BDeploySetupRecord, error)
+	ValidateSetupRecord(record *types.BDeploySetupRecord) error
+}
+
+// Action is what applying a record does on the server.
+type Action string
+
+const (
+	ActionCreate Action = "create"
+	ActionUpdate Action = "update"
+)
+
+// SiteResult describes what ApplySites did, or would do, for one site.
+type SiteResult struct {
+	Site        string                    `json:"site"`
+	PackageName string                    `json:"packageName,omitempty"`
+	SetupID     string                    `json:"setupId,omitempty"`
+	Action      Action                    `json:"action,omitempty"`
+	Applied     bool                      `json:"applied"`
+	Error       string                    `json:"error,omitempty"`
+	Record      *types.BDeploySetupRecord `json:"-"` // The rendered record
+}
+
+// ApplySitesOptions controls ApplySites.
+type ApplySitesOptions struct {
+	DryRun bool // Render, validate and look up existing records without writing
+}
+
+// ApplySites renders a setup record for every site and creates it, or updates the
+// existing record with the same package name in the same network.
+//
+// Every site is rendered and validated before anything is written, unless the
+// client has setup validation disabled; if any fails, nothing is applied and the
+// error lists the failing sites, whose results carry the details. Failures while
+// writing are recorded per site and do not stop the remaining sites.
+func ApplySites(ctx context.Context, client SetupClient, t *Template, sites []Site, opts ApplySitesOptions) ([]SiteResult, error) {
+	if len(sites) == 0 {
+		return nil, errors.NewValidationError("sites", sites, "at least one site is required")
+	}
+
+	results := make([]SiteResult, len(sites))
+	var failed []string
+	packages := map[string]string{}
+	for i, site := range sites {
+		results[i].Site = site.Name
+
+		record, err := t.RenderSite(site)
+		if err == nil {
+			err = client.ValidateSetupRecord(record)
+		}
+		if err != nil {
+			results[i].Error = err.Error()
+			failed = append(failed, site.Name)
+			continue
+		}
+
+		results[i].Record = record
+		results[i].PackageName = record.BDeploy.PackageName
+
+		key := record.BDeploy.NetworkName + "/" + record.BDeploy.PackageName
+		if other, ok := packages[key]; ok {
+			results[i].Error = fmt.Sprintf("package name %q is also used by site %s", record.BDeploy.PackageName, other)
+			failed = append(failed, site.Name)
+			continue
+		}
+		packages[key] = site.Name
+	}
+	if len(failed) > 0 {
+		return results, errors.NewValidationError("sites", failed,
+			fmt.Sprintf("%d site(s) could not be rendered: %s", len(failed), strings.Join(failed, ", ")))
+	}
+
+	existing, err := existingSetupIDs(ctx, client, results)
+	if err != nil {
+		return results, err
+	}
+
+	for i := range results {
+		result := &results[i]
+		ids := existing[result.Record.BDeploy.NetworkName][result.PackageName]
+		switch len(ids) {
+		case 0:
+			result.Action = ActionCreate
+		case 1:
+			result.Action = ActionUpdate
+			result.SetupID = ids[0]
+		default:
+			result.Error = fmt.Sprintf("%d setup records already use package name %q", len(ids), result.PackageName)
+			continue
+		}
+
+		if opts.DryRun {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+
+		switch result.Action {
+		case ActionCreate:
+			response, err := client.AddSetupRecord(ctx, result.Record)
+			if err != nil {
+				result.Error = err.Error()
+				continue
+			}
+			result.SetupID = response.ID
+		case ActionUpdate:
+			if _, err := client.UpdateSetupRecord(ctx, result.SetupID, result.Record); err != nil {
+				result.Error = err.Error()
+				continue
+			}
+		}
+		result.Applied = true
+	}
+
+	return results, nil
+}
+
+// existingSetupIDs lists the setup records in every network the results use,
+// returning their IDs by network and package name.
+func existingSetupIDs(ctx context.Context, client SetupClient, results []SiteResult) (map[string]map[string][]string, error) {
+	networks := map[string]bool{}
+	for _, result := range results {
+		networks[result.Record.BDeploy.NetworkName] = true
+	}
+	names := make([]string, 0, len(networks))
+	for name := range networks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	existing := map[string]map[string][]string{}
+	for _, network := range names {
+		records, err := client.GetSetupRecords(ctx, services.WithNetworkName(network))
+		if err != nil {
+			return nil, err
+		}
+		existing[network] = map[string][]string{}
+		for _, record := range records.Items {
+			existing[network][record.PackageName] = append(existing[network][record.PackageName], record.ID)
+		}
+	}
+	return existing, nil
+}
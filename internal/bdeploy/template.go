@@ -0,0 +1,254 @@
+package bdeploy
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/brightdevelopers/gopurple/internal/errors"
+	"github.com/brightdevelopers/gopurple/internal/types"
+)
+
+// variablePattern matches ${name} and ${name:-default}. "$${" is an escaped "${".
+var variablePattern = regexp.MustCompile(`\$\$\{|\$\{([A-Za-z_][A-Za-z0-9_]*)(?::-([^}]*))?\}`)
+
+// Template is a base setup record plus overlays that apply to every record rendered
+// from it. Both may reference variables as ${name} or ${name:-default} in any string
+// value, for example "hostname": "store-${store}" or "staticIPAddress": "${ip}".
+type Template struct {
+	Base     Document
+	Overlays []Document
+}
+
+// LoadTemplate reads a base document and any overlays from .json or .yaml files.
+func LoadTemplate(base string, overlays ...string) (*Template, error) {
+	doc, err := LoadDocument(base)
+	if err != nil {
+		return nil, err
+	}
+	t := &Template{Base: doc}
+	for _, filename := range overlays {
+		overlay, err := LoadDocument(filename)
+		if err != nil {
+			return nil, err
+		}
+		t.Overlays = append(t.Overlays, overlay)
+	}
+	return t, nil
+}
+
+// Variables returns the names of the variables the template references, sorted.
+// Variables with a default value are included.
+func (t *Template) Variables() []string {
+	return Variables(append([]Document{t.Base}, t.Overlays...)...)
+}
+
+// Render merges the template's overlays and then extra onto the base, substitutes
+// vars and decodes the result into a setup record. String values are converted
+// where the record expects numbers or booleans, so "proxyPort": "${port}" works.
+// Keys that are not setup record fields, such as a misspelled "hostName", are an
+// error rather than being dropped.
+func (t *Template) Render(vars map[string]string, extra ...Document) (*types.BDeploySetupRecord, error) {
+	overlays := append(append([]Document{}, t.Overlays...), extra...)
+	doc, err := Substitute(Merge(t.Base, overlays...), vars)
+	if err != nil {
+		return nil, err
+	}
+
+	var unknown []string
+	coerced, err := coerce(map[string]interface{}(doc), reflect.TypeOf(types.BDeploySetupRecord{}), "", &unknown)
+	if err != nil {
+		return nil, err
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, errors.NewValidationError(strings.Join(unknown, ", "), nil, "unknown setup record field")
+	}
+	data, err := json.Marshal(coerced)
+	if err != nil {
+		return nil, err
+	}
+
+	var record types.BDeploySetupRecord
+	if err := types.UnmarshalSetupRecord(data, &record); err != nil {
+		return nil, fmt.Errorf("rendered document is not a setup record: %w", err)
+	}
+	return &record, nil
+}
+
+// Variables returns the names of the variables referenced in the documents, sorted.
+func Variables(docs ...Document) []string {
+	seen := map[string]bool{}
+	for _, doc := range docs {
+		walkStrings(map[string]interface{}(doc), func(s string) {
+			for _, m := range variablePattern.FindAllStringSubmatch(s, -1) {
+				if m[1] != "" {
+					seen[m[1]] = true
+				}
+			}
+		})
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Substitute returns a copy of doc with variable references in string values
+// replaced. Every variable without a value or default is reported in one error.
+func Substitute(doc Document, vars map[string]string) (Document, error) {
+	missing := map[string]bool{}
+	result := substitute(types.CloneJSON(map[string]interface{}(doc)), vars, missing)
+
+	if len(missing) > 0 {
+		names := make([]string, 0, len(missing))
+		for name := range missing {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, errors.NewValidationError("vars", names, "undefined template variables: "+strings.Join(names, ", "))
+	}
+	return result.(map[string]interface{}), nil
+}
+
+func substitute(value interface{}, vars map[string]string, missing map[string]bool) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = substitute(item, vars, missing)
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = substitute(item, vars, missing)
+		}
+		return v
+	case string:
+		return variablePattern.ReplaceAllStringFunc(v, func(ref string) string {
+			if ref == "$${" {
+				return "${"
+			}
+			m := variablePattern.FindStringSubmatch(ref)
+			if value, ok := vars[m[1]]; ok {
+				return value
+			}
+			if strings.Contains(ref, ":-") {
+				return m[2]
+			}
+			missing[m[1]] = true
+			return ref
+		})
+	default:
+		return v
+	}
+}
+
+func walkStrings(value interface{}, fn func(string)) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, item := range v {
+			walkStrings(item, fn)
+		}
+	case []interface{}:
+		for _, item := range v {
+			walkStrings(item, fn)
+		}
+	case string:
+		fn(v)
+	}
+}
+
+// coerce converts strings to numbers or booleans, and scalars to strings, where the
+// Go type the value decodes into expects them. path names the value in errors, and
+// the paths of keys that are not fields of the struct they are in are added to
+// unknown.
+func coerce(value interface{}, t reflect.Type, path string, unknown *[]string) (interface{}, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if t.Kind() != reflect.Struct {
+			return v, nil
+		}
+		for key, item := range v {
+			field, ok := fieldByJSONName(t, key)
+			if !ok {
+				*unknown = append(*unknown, joinPath(path, key))
+				continue
+			}
+			converted, err := coerce(item, field.Type, joinPath(path, key), unknown)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = converted
+		}
+		return v, nil
+
+	case []interface{}:
+		if t.Kind() != reflect.Slice {
+			return v, nil
+		}
+		for i, item := range v {
+			converted, err := coerce(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i), unknown)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = converted
+		}
+		return v, nil
+
+	case string:
+		switch t.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			n, err := strconv.Atoi(strings.TrimSpace(v))
+			if err != nil {
+				return nil, errors.NewValidationError(path, v, "must be a whole number")
+			}
+			return n, nil
+		case reflect.Bool:
+			b, err := strconv.ParseBool(strings.TrimSpace(v))
+			if err != nil {
+				return nil, errors.NewValidationError(path, v, "must be true or false")
+			}
+			return b, nil
+		}
+
+	case float64:
+		if t.Kind() == reflect.String {
+			return strconv.FormatFloat(v, 'f', -1, 64), nil
+		}
+
+	case bool:
+		if t.Kind() == reflect.String {
+			return strconv.FormatBool(v), nil
+		}
+	}
+	return value, nil
+}
+
+// fieldByJSONName finds the struct field encoded under the given JSON name.
+func fieldByJSONName(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if tag == name || (tag == "" && field.Name == name) {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,297 @@
+package bdeploy
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/brightdevelopers/gopurple/internal/services"
+	"github.com/brightdevelopers/gopurple/internal/types"
+)
+
+const testBaseYAML = `# Store template
+version: "3.0.0"
+bDeploy:
+  username: admin@example.com
+  networkName: Retail
+  packageName: store-${store}
+setupType: bsn
+bsnGroupName: ${group:-Stores}
+hostname: store-${store}   # player hostname
+specifyHostname: true
+useDHCP: false
+staticIPAddress: ${ip}
+subnetMask: 255.255.255.0
+gateway: ${gateway}
+proxyPort: ${proxy_port:-0}
+network:
+  timeServers: ["http://time.brightsignnetwork.com"]
+  interfaces:
+    - id: eth0
+      name: eth0
+      type: Ethernet
+      proto: Static
+      contentDownloadEnabled: true
+`
+
+func writeFile(t *testing.T, dir, name, contents string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestDecodeYAML(t *testing.T) {
+	doc, err := DecodeYAML(strings.NewReader(testBaseYAML))
+	if err != nil {
+		t.Fatalf("DecodeYAML failed: %v", err)
+	}
+
+	if doc["version"] != "3.0.0" || doc["specifyHostname"] != true || doc["hostname"] != "store-${store}" {
+		t.Errorf("Unexpected scalars: %v", doc)
+	}
+	network := doc["network"].(map[string]interface{})
+	if servers := network["timeServers"].([]interface{}); len(servers) != 1 || servers[0] != "http://time.brightsignnetwork.com" {
+		t.Errorf("Unexpected flow sequence: %v", servers)
+	}
+	iface := network["interfaces"].([]interface{})[0].(map[string]interface{})
+	if iface["proto"] != "Static" || iface["contentDownloadEnabled"] != true {
+		t.Errorf("Unexpected sequence of mappings: %v", iface)
+	}
+
+	if _, err := DecodeYAML(strings.NewReader("a: 1\n  b: 2\n")); err == nil {
+		t.Error("Expected error for unexpected indentation")
+	}
+	if _, err := DecodeYAML(strings.NewReader("a: 1\na: 2\n")); err == nil {
+		t.Error("Expected error for duplicate key")
+	}
+	if _, err := DecodeYAML(strings.NewReader("- a\n- b\n")); err == nil {
+		t.Error("Expected error for a document that is not a mapping")
+	}
+}
+
+func TestDecodeYAML_Scalars(t *testing.T) {
+	doc, err := DecodeYAML(strings.NewReader("n: 42\nf: 1.5\nzero: 0042\nq: \"a # b\"\ns: 'it''s'\nnothing: ~\nlist:\n- a\n- 2\n" +
+		"day: 2024-01-02\nanswer: yes\nscript: |\n  line 1\n  line 2\nbase: &base {x: 1}\ncopy: *base\n"))
+	if err != nil {
+		t.Fatalf("DecodeYAML failed: %v", err)
+	}
+	expected := Document{"n": 42.0, "f": 1.5, "zero": "0042", "q": "a # b", "s": "it's", "nothing": nil, "list": []interface{}{"a", 2.0},
+		"day": "2024-01-02", "answer": "yes", "script": "line 1\nline 2\n",
+		"base": map[string]interface{}{"x": 1.0}, "copy": map[string]interface{}{"x": 1.0}}
+	if !reflect.DeepEqual(doc, expected) {
+		t.Errorf("DecodeYAML:\n got  %v\n want %v", doc, expected)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	base := Document{"a": 1.0, "nested": map[string]interface{}{"x": "1", "y": "2"}, "gone": true}
+	merged := Merge(base, Document{"nested": map[string]interface{}{"y": "3"}, "gone": nil, "b": "new"})
+
+	expected := Document{"a": 1.0, "nested": map[string]interface{}{"x": "1", "y": "3"}, "b": "new"}
+	if !reflect.DeepEqual(merged, expected) {
+		t.Errorf("Merge:\n got  %v\n want %v", merged, expected)
+	}
+	if base["nested"].(map[string]interface{})["y"] != "2" || base["gone"] != true {
+		t.Error("Expected Merge not to modify the base document")
+	}
+}
+
+func TestTemplateRender(t *testing.T) {
+	base, err := DecodeYAML(strings.NewReader(testBaseYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &Template{Base: base}
+
+	if vars := tmpl.Variables(); !reflect.DeepEqual(vars, []string{"gateway", "group", "ip", "proxy_port", "store"}) {
+		t.Errorf("Unexpected variables %v", vars)
+	}
+
+	record, err := tmpl.Render(map[string]string{"store": "0042", "ip": "10.0.42.10", "gateway": "10.0.42.1", "proxy_port": "3128"},
+		Document{"useProxy": true, "proxyAddress": "proxy.example.com"})
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if record.BDeploy.PackageName != "store-0042" || record.Hostname != "store-0042" || record.StaticIPAddress != "10.0.42.10" {
+		t.Errorf("Variables not substituted: %+v", record)
+	}
+	if record.BSNGroupName != "Stores" || record.ProxyPort != 3128 || !record.UseProxy {
+		t.Errorf("Defaults, coercion or overlay not applied: %+v", record)
+	}
+	if !record.IsForceSent("useDHCP") {
+		t.Error("Expected explicit useDHCP: false to be kept")
+	}
+	if err := record.Validate(); err != nil {
+		t.Errorf("Expected rendered record to be valid, got %v", err)
+	}
+
+	_, err = tmpl.Render(map[string]string{"store": "1"})
+	if err == nil || !strings.Contains(err.Error(), "gateway, ip") {
+		t.Errorf("Expected every missing variable to be reported, got %v", err)
+	}
+
+	_, err = tmpl.Render(map[string]string{"store": "1", "ip": "x", "gateway": "y", "proxy_port": "many"})
+	if err == nil || !strings.Contains(err.Error(), "proxyPort") {
+		t.Errorf("Expected error naming proxyPort, got %v", err)
+	}
+
+	vars := map[string]string{"store": "1", "ip": "x", "gateway": "y"}
+	_, err = tmpl.Render(vars, Document{"hostName": "typo", "network": map[string]interface{}{"interfaces": []interface{}{
+		map[string]interface{}{"id": "eth0", "dnss": "8.8.8.8"}}}})
+	if err == nil || !strings.Contains(err.Error(), "hostName, network.interfaces[0].dnss") {
+		t.Errorf("Expected error naming every unknown key, got %v", err)
+	}
+}
+
+func TestSubstitute_Escape(t *testing.T) {
+	doc, err := Substitute(Document{"s": "$${literal} ${x}"}, map[string]string{"x": "y"})
+	if err != nil || doc["s"] != "${literal} y" {
+		t.Errorf("Expected escaped reference to be kept, got %v (%v)", doc, err)
+	}
+}
+
+func TestLoadSites(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "wifi.yaml", "useWireless: true\nssid: ${ssid}\npassphrase: \"${psk}\"\n")
+	path := writeFile(t, dir, "sites.csv", "site,ip,gateway,ssid,psk,overlay\n0001,10.0.1.10,10.0.1.1,,,\n\n0002, 10.0.2.10,10.0.2.1,Store2,secret-psk,wifi.yaml\n")
+
+	sites, err := LoadSites(path)
+	if err != nil {
+		t.Fatalf("LoadSites failed: %v", err)
+	}
+	if len(sites) != 2 || sites[1].Vars["ip"] != "10.0.2.10" || sites[1].Overlay != filepath.Join(dir, "wifi.yaml") {
+		t.Fatalf("Unexpected sites %+v", sites)
+	}
+
+	if _, err := ReadSites(strings.NewReader("site,ip\n1,a\n1,b\n")); err == nil {
+		t.Error("Expected error for duplicate site")
+	}
+	if _, err := ReadSites(strings.NewReader("name,ip\n1,a\n")); err == nil {
+		t.Error("Expected error for missing site column")
+	}
+}
+
+// fakeSetupClient keeps setup records in memory.
+type fakeSetupClient struct {
+	records        map[string]*types.BDeploySetupRecord
+	nextID         int
+	writes         int
+	skipValidation bool // As with validation disabled in the client configuration
+}
+
+func newFakeSetupClient() *fakeSetupClient {
+	return &fakeSetupClient{records: map[string]*types.BDeploySetupRecord{}}
+}
+
+func (f *fakeSetupClient) GetSetupRecords(ctx context.Context, opts ...services.BDeployListOption) (*types.BDeployRecordList, error) {
+	list := &types.BDeployRecordList{}
+	for id, record := range f.records {
+		list.Items = append(list.Items, types.BDeployRecord{ID: id, PackageName: record.BDeploy.PackageName, NetworkName: record.BDeploy.NetworkName, Username: record.BDeploy.Username})
+	}
+	list.TotalCount = len(list.Items)
+	return list, nil
+}
+
+func (f *fakeSetupClient) GetSetupRecord(ctx context.Context, setupID string) (*types.BDeploySetupRecord, error) {
+	record, ok := f.records[setupID]
+	if !ok {
+		return nil, stderrors.New("not found")
+	}
+	copied := *record
+	return &copied, nil
+}
+
+func (f *fakeSetupClient) AddSetupRecord(ctx context.Context, record *types.BDeploySetupRecord) (*types.BDeployCreateResponse, error) {
+	f.writes++
+	f.nextID++
+	id := fmt.Sprintf("setup-%d", f.nextID)
+	copied := *record
+	copied.ID = id
+	f.records[id] = &copied
+	return &types.BDeployCreateResponse{ID: id, Success: true}, nil
+}
+
+func (f *fakeSetupClient) UpdateSetupRecord(ctx context.Context, setupID string, record *types.BDeploySetupRecord) (*types.BDeploySetupRecord, error) {
+	f.writes++
+	if _, ok := f.records[setupID]; !ok {
+		return nil, stderrors.New("not found")
+	}
+	copied := *record
+	copied.ID = setupID
+	f.records[setupID] = &copied
+	return &copied, nil
+}
+
+func (f *fakeSetupClient) ValidateSetupRecord(record *types.BDeploySetupRecord) error {
+	if f.skipValidation {
+		return nil
+	}
+	return record.Validate()
+}
+
+func (f *fakeSetupClient) DeleteSetupRecord(ctx context.Context, setupID string) (*types.BDeployDeleteResponse, error) {
+	f.writes++
+	delete(f.records, setupID)
+	return &types.BDeployDeleteResponse{Success: true}, nil
+}
+
+func TestApplySites(t *testing.T) {
+	base, _ := DecodeYAML(strings.NewReader(testBaseYAML))
+	tmpl := &Template{Base: base}
+	client := newFakeSetupClient()
+	client.records["existing"] = &types.BDeploySetupRecord{BDeploy: types.BDeployInfo{NetworkName: "Retail", PackageName: "store-0002"}}
+
+	sites := []Site{
+		{Name: "0001", Vars: map[string]string{"store": "0001", "ip": "10.0.1.10", "gateway": "10.0.1.1"}},
+		{Name: "0002", Vars: map[string]string{"store": "0002", "ip": "10.0.2.10", "gateway": "10.0.2.1"}},
+	}
+
+	results, err := ApplySites(context.Background(), client, tmpl, sites, ApplySitesOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("ApplySites dry run failed: %v", err)
+	}
+	if results[0].Action != ActionCreate || results[1].Action != ActionUpdate || results[1].SetupID != "existing" || client.writes != 0 {
+		t.Errorf("Unexpected dry run results %+v (%d writes)", results, client.writes)
+	}
+
+	results, err = ApplySites(context.Background(), client, tmpl, sites, ApplySitesOptions{})
+	if err != nil {
+		t.Fatalf("ApplySites failed: %v", err)
+	}
+	if !results[0].Applied || !results[1].Applied || client.records["existing"].StaticIPAddress != "10.0.2.10" || len(client.records) != 2 {
+		t.Errorf("Unexpected results %+v", results)
+	}
+
+	// One bad site stops everything before any write
+	client.writes = 0
+	sites = append(sites, Site{Name: "0003", Vars: map[string]string{"store": "0003"}})
+	results, err = ApplySites(context.Background(), client, tmpl, sites, ApplySitesOptions{})
+	if err == nil || client.writes != 0 || results[2].Error == "" {
+		t.Errorf("Expected no writes when a site fails to render, got %v, %d writes", err, client.writes)
+	}
+}
+
+func TestApplySites_SkipValidation(t *testing.T) {
+	base, _ := DecodeYAML(strings.NewReader(testBaseYAML))
+	tmpl := &Template{Base: base}
+	client := newFakeSetupClient()
+	sites := []Site{{Name: "0001", Vars: map[string]string{"store": "0001", "ip": "10.0.1.300", "gateway": "10.0.1.1"}}}
+
+	if _, err := ApplySites(context.Background(), client, tmpl, sites, ApplySitesOptions{}); err == nil || client.writes != 0 {
+		t.Fatalf("Expected an invalid address to fail validation, got %v, %d writes", err, client.writes)
+	}
+
+	client.skipValidation = true
+	results, err := ApplySites(context.Background(), client, tmpl, sites, ApplySitesOptions{})
+	if err != nil || !results[0].Applied {
+		t.Errorf("Expected the record to be applied with validation disabled, got %v, %+v", err, results)
+	}
+}
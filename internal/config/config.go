@@ -2,10 +2,20 @@ package config
 
 import (
 	"fmt"
+	"log/slog"
+	"net/http"
 	"os"
+	"strconv"
 	"time"
 
+	"github.com/brightdevelopers/gopurple/internal/audit"
 	"github.com/brightdevelopers/gopurple/internal/errors"
+	"github.com/brightdevelopers/gopurple/internal/history"
+	"github.com/brightdevelopers/gopurple/internal/ledger"
+	"github.com/brightdevelopers/gopurple/internal/secrets"
+	"github.com/brightdevelopers/gopurple/internal/types"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Config holds all configuration for the BSN.cloud SDK client.
@@ -28,9 +38,71 @@ type Config struct {
 	RetryCount int           `json:"retry_count"`
 	Debug      bool          `json:"debug"` // Enable debug logging of HTTP requests/responses
 
+	// HTTPClient sends the requests, e.g. through a proxy or with custom TLS roots.
+	// Nil uses a default client. Middleware wraps every request attempt, the
+	// first in the list outermost
+	HTTPClient *http.Client       `json:"-"`
+	Middleware []types.Middleware `json:"-"`
+
+	// Cassette the HTTP recorder records exchanges to or replays them from, off
+	// unless RecorderPath is set
+	RecorderPath string             `json:"recorder_path,omitempty"`
+	RecorderMode types.RecorderMode `json:"recorder_mode,omitempty"`
+
+	// Logger receives a structured record of every HTTP request, with credentials
+	// and passwords redacted. Nil turns it off
+	Logger *slog.Logger `json:"-"`
+
+	// OpenTelemetry providers for spans and metrics. Nil turns each off
+	TracerProvider trace.TracerProvider `json:"-"`
+	MeterProvider  metric.MeterProvider `json:"-"`
+
 	// Optional device settings
 	DeviceSerial string `json:"device_serial,omitempty"`
 
+	// SkipSetupValidation disables the local check of B-Deploy setup records
+	// before they are created or updated
+	SkipSetupValidation bool `json:"skip_setup_validation,omitempty"`
+
+	// Association ledger for B-Deploy device associations, off unless
+	// AssociationLedger or AssociationLedgerPath is set. AssociationLedger takes precedence
+	AssociationLedger     ledger.Store `json:"-"`
+	AssociationLedgerPath string       `json:"association_ledger_path,omitempty"`
+
+	// Setup history for B-Deploy setup records, off unless SetupHistory or
+	// SetupHistoryDir is set. SetupHistory takes precedence
+	SetupHistory    history.Store `json:"-"`
+	SetupHistoryDir string        `json:"setup_history_dir,omitempty"`
+
+	// Secret references in B-Deploy setup records (see types.SecretSchemes), sent
+	// as they are unless SecretResolver or ResolveSecretReferences is set.
+	// SecretResolver takes precedence; ResolveSecretReferences resolves env:,
+	// file:, age: and sops: references, and exec: references only with
+	// AllowSecretExec
+	SecretResolver          secrets.Resolver `json:"-"`
+	ResolveSecretReferences bool             `json:"resolve_secret_references,omitempty"`
+	AllowSecretExec         bool             `json:"allow_secret_exec,omitempty"`
+
+	// Audit log of state-changing calls, off unless AuditSink or AuditLogPath is
+	// set. AuditSink takes precedence. AuditUser names who acts through the client,
+	// by default the user running the program
+	AuditSink    audit.Sink `json:"-"`
+	AuditLogPath string     `json:"audit_log_path,omitempty"`
+	AuditUser    string     `json:"audit_user,omitempty"`
+
+	// Per-player circuit breaker for rDWS calls: after PlayerBreakerThreshold
+	// consecutive unreachable results calls to the player fail at once, until a
+	// trial call after PlayerBreakerCooldown. A threshold of 0 turns it off
+	PlayerBreakerThreshold int                            `json:"player_breaker_threshold,omitempty"`
+	PlayerBreakerCooldown  time.Duration                  `json:"player_breaker_cooldown,omitempty"`
+	OnPlayerBreaker        func(types.PlayerBreakerEvent) `json:"-"`
+
+	// Background token refresh: with TokenRefreshFraction above 0 the token is
+	// renewed once that fraction of its lifetime has passed. OnTokenRefreshed is
+	// called whenever a new token is obtained
+	TokenRefreshFraction float64                  `json:"token_refresh_fraction,omitempty"`
+	OnTokenRefreshed     func(types.TokenRefresh) `json:"-"`
+
 	// Pre-loaded access token (for session reuse across CLI invocations)
 	AccessToken string `json:"-"`
 	ExpiresAt   time.Time `json:"-"`
@@ -60,6 +132,29 @@ func (c *Config) LoadFromEnv() {
 	if networkName := os.Getenv("BS_NETWORK"); networkName != "" {
 		c.NetworkName = networkName
 	}
+	if ledgerPath := os.Getenv("BS_ASSOCIATION_LEDGER"); ledgerPath != "" {
+		c.AssociationLedgerPath = ledgerPath
+	}
+	if historyDir := os.Getenv("BS_SETUP_HISTORY"); historyDir != "" {
+		c.SetupHistoryDir = historyDir
+	}
+	if auditLog := os.Getenv("BS_AUDIT_LOG"); auditLog != "" {
+		c.AuditLogPath = auditLog
+	}
+	if auditUser := os.Getenv("BS_AUDIT_USER"); auditUser != "" {
+		c.AuditUser = auditUser
+	}
+	if resolve := os.Getenv("BS_SECRET_REFERENCES"); resolve == "1" || resolve == "true" {
+		c.ResolveSecretReferences = true
+	}
+	if allowExec := os.Getenv("BS_SECRET_EXEC"); allowExec == "1" || allowExec == "true" {
+		c.AllowSecretExec = true
+	}
+	if refresh := os.Getenv("BS_TOKEN_REFRESH"); refresh != "" {
+		if fraction, err := strconv.ParseFloat(refresh, 64); err == nil {
+			c.TokenRefreshFraction = fraction
+		}
+	}
 }
 
 // Validate checks that the configuration contains all required fields and valid values.
@@ -92,6 +187,18 @@ func (c *Config) Validate() error {
 		return errors.NewConfigError("RetryCount", "cannot be negative", "")
 	}
 
+	if c.PlayerBreakerThreshold < 0 {
+		return errors.NewConfigError("PlayerBreakerThreshold", "cannot be negative", "")
+	}
+
+	if c.PlayerBreakerCooldown < 0 {
+		return errors.NewConfigError("PlayerBreakerCooldown", "cannot be negative", "")
+	}
+
+	if c.TokenRefreshFraction < 0 || c.TokenRefreshFraction >= 1 {
+		return errors.NewConfigError("TokenRefreshFraction", "must be at least 0 and less than 1", "e.g. 0.75 renews the token when three quarters of its lifetime have passed")
+	}
+
 	return nil
 }
 
@@ -151,8 +258,9 @@ func WithRetryCount(count int) Option {
 // WithDebug enables debug logging of all HTTP requests and responses.
 //
 // When enabled, the SDK will print detailed information about every API call
-// including request/response headers and bodies. This is useful for debugging
-// but should not be enabled in production as it may expose sensitive data.
+// including request/response headers and bodies. Credentials, passwords and
+// tokens are masked, but the log still holds device and network details, so it
+// should not be enabled in production.
 func WithDebug(debug bool) Option {
 	return func(c *Config) error {
 		c.Debug = debug
@@ -201,6 +309,342 @@ func WithDeviceSerial(serial string) Option {
 	}
 }
 
+// WithSetupValidation enables or disables validation of B-Deploy setup records.
+//
+// When enabled (the default), AddSetupRecord and UpdateSetupRecord check the
+// record with BDeploySetupRecord.Validate and refuse to send it if any errors are
+// found. Disable it to send records the local checks do not understand.
+func WithSetupValidation(enabled bool) Option {
+	return func(c *Config) error {
+		c.SkipSetupValidation = !enabled
+		return nil
+	}
+}
+
+// WithAssociationLedger sets the store that records B-Deploy device associations.
+//
+// B-Deploy never returns a device's setupId, so with a ledger UpdateDevice records
+// every association in it and GetDeviceBySerial and GetAllDevices fill in setupId
+// from it. The ledger is off by default. Passing nil turns it off.
+func WithAssociationLedger(store ledger.Store) Option {
+	return func(c *Config) error {
+		c.AssociationLedger = store
+		if store == nil {
+			c.AssociationLedgerPath = ""
+		}
+		return nil
+	}
+}
+
+// WithAssociationLedgerFile keeps the association ledger in the given JSON file
+// (see ledger.DefaultPath for the usual location). It can also be set with
+// BS_ASSOCIATION_LEDGER.
+func WithAssociationLedgerFile(path string) Option {
+	return func(c *Config) error {
+		if path == "" {
+			return errors.NewConfigError("AssociationLedgerPath", "cannot be empty", "")
+		}
+		c.AssociationLedgerPath = path
+		return nil
+	}
+}
+
+// AssociationStore returns the association ledger the configuration selects, or
+// nil if it is off.
+func (c *Config) AssociationStore() ledger.Store {
+	switch {
+	case c.AssociationLedger != nil:
+		return c.AssociationLedger
+	case c.AssociationLedgerPath != "":
+		return ledger.NewFileStore(c.AssociationLedgerPath)
+	}
+	return nil
+}
+
+// WithSetupHistory sets the store that keeps snapshots of B-Deploy setup records.
+//
+// When set, UpdateSetupRecord and DeleteSetupRecord fetch the record and save it
+// to the store before changing it, and refuse to change it if the snapshot cannot
+// be taken. Passing nil turns the history off.
+func WithSetupHistory(store history.Store) Option {
+	return func(c *Config) error {
+		c.SetupHistory = store
+		if store == nil {
+			c.SetupHistoryDir = ""
+		}
+		return nil
+	}
+}
+
+// WithSetupHistoryDir keeps setup record snapshots in the given directory (see
+// history.DefaultDir for the usual location). It can also be set with BS_SETUP_HISTORY.
+func WithSetupHistoryDir(dir string) Option {
+	return func(c *Config) error {
+		if dir == "" {
+			return errors.NewConfigError("SetupHistoryDir", "cannot be empty", "")
+		}
+		c.SetupHistoryDir = dir
+		return nil
+	}
+}
+
+// SetupHistoryStore returns the setup history the configuration selects, or nil
+// if it is off.
+func (c *Config) SetupHistoryStore() history.Store {
+	switch {
+	case c.SetupHistory != nil:
+		return c.SetupHistory
+	case c.SetupHistoryDir != "":
+		return history.NewFileStore(c.SetupHistoryDir)
+	}
+	return nil
+}
+
+// WithAuditSink sends an audit record of every state-changing call, such as a
+// reboot, a storage reformat or a setup record deletion, to the sink: who made
+// it, the target, the network, the parameters with secrets redacted, and the
+// outcome. Passing nil turns the audit log off.
+func WithAuditSink(sink audit.Sink) Option {
+	return func(c *Config) error {
+		c.AuditSink = sink
+		if sink == nil {
+			c.AuditLogPath = ""
+		}
+		return nil
+	}
+}
+
+// WithAuditLogFile appends audit records to the given file as JSON lines. It can
+// also be set with BS_AUDIT_LOG.
+func WithAuditLogFile(path string) Option {
+	return func(c *Config) error {
+		if path == "" {
+			return errors.NewConfigError("AuditLogPath", "cannot be empty", "")
+		}
+		c.AuditLogPath = path
+		return nil
+	}
+}
+
+// WithAuditUser names the person or system acting through the client in audit
+// records, e.g. the operator of a shared tool. By default it is the user running
+// the program. It can also be set with BS_AUDIT_USER.
+func WithAuditUser(user string) Option {
+	return func(c *Config) error {
+		if user == "" {
+			return errors.NewConfigError("AuditUser", "cannot be empty", "")
+		}
+		c.AuditUser = user
+		return nil
+	}
+}
+
+// AuditLog returns the audit sink the configuration selects, or nil if the audit
+// log is off.
+func (c *Config) AuditLog() audit.Sink {
+	switch {
+	case c.AuditSink != nil:
+		return c.AuditSink
+	case c.AuditLogPath != "":
+		return audit.NewFileSink(c.AuditLogPath)
+	}
+	return nil
+}
+
+// WithSecretResolver sets the resolver for secret references in B-Deploy setup
+// records, such as "env:WIFI_PASSPHRASE" in the passphrase field.
+//
+// AddSetupRecord and UpdateSetupRecord send a copy of the record with every
+// reference resolved; the caller's record keeps its references. Records that hold
+// values fetched from B-Deploy, as in PatchSetupRecord and rollbacks, are never
+// resolved. Passing nil sends references as they are, which is the default.
+func WithSecretResolver(resolver secrets.Resolver) Option {
+	return func(c *Config) error {
+		c.SecretResolver = resolver
+		if resolver == nil {
+			c.ResolveSecretReferences = false
+		}
+		return nil
+	}
+}
+
+// WithSecretReferences enables or disables resolving env:, file:, age: and sops:
+// secret references in B-Deploy setup records with a secrets.Sources resolver, as
+// WithSecretResolver(secrets.NewSources(false)) does. It is off by default and
+// can also be enabled with BS_SECRET_REFERENCES=1.
+func WithSecretReferences(enabled bool) Option {
+	return func(c *Config) error {
+		c.ResolveSecretReferences = enabled
+		return nil
+	}
+}
+
+// WithSecretExec enables or disables exec: secret references, which run a command
+// and use its output, when secret references are resolved with
+// WithSecretReferences. They are disabled by default, since a record with a
+// command in a password field would run it. It can also be enabled with
+// BS_SECRET_EXEC=1.
+func WithSecretExec(enabled bool) Option {
+	return func(c *Config) error {
+		c.AllowSecretExec = enabled
+		return nil
+	}
+}
+
+// WithLogger sets the logger that records every HTTP request the SDK makes, with
+// the service, operation, player serial, network, method, host, status, duration
+// and attempt. Successful requests are logged at debug level and failed ones at
+// warn level, so a logger at info level only reports failures; at debug level the
+// request and response bodies are included too. Authorization headers, client
+// secrets and password fields are redacted. Nil turns logging off.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Config) error {
+		c.Logger = logger
+		return nil
+	}
+}
+
+// WithHTTPClient sends the SDK's requests with the given client, for an
+// authenticated proxy or a private CA, for example. The client's transport is
+// used as it is; its timeout is replaced by the configured Timeout. The SDK
+// works on a copy, so the client is not changed.
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *Config) error {
+		if client == nil {
+			return errors.NewConfigError("HTTPClient", "cannot be nil", "")
+		}
+		c.HTTPClient = client
+		return nil
+	}
+}
+
+// WithMiddleware adds middleware around every request attempt, to inject
+// headers, sign requests, audit, cache or fail fast without touching the
+// underlying client. Middleware added first runs outermost. Retries go through
+// the chain again.
+func WithMiddleware(middleware ...types.Middleware) Option {
+	return func(c *Config) error {
+		for _, m := range middleware {
+			if m == nil {
+				return errors.NewConfigError("Middleware", "cannot be nil", "")
+			}
+		}
+		c.Middleware = append(c.Middleware, middleware...)
+		return nil
+	}
+}
+
+// WithRecorder records every HTTP exchange to a YAML cassette at path, or
+// replays a cassette recorded before without touching the network. Cassettes
+// hold no credentials: Authorization headers are not recorded, and tokens,
+// passwords and player serials are scrubbed from URLs and bodies. In replay mode
+// the cassette must exist.
+func WithRecorder(path string, mode types.RecorderMode) Option {
+	return func(c *Config) error {
+		if path == "" {
+			return errors.NewConfigError("RecorderPath", "cannot be empty", "")
+		}
+		switch mode {
+		case types.RecorderRecord:
+		case types.RecorderReplay:
+			if _, err := os.Stat(path); err != nil {
+				return errors.NewConfigError("RecorderPath", fmt.Sprintf("cannot read cassette: %v", err), "record it first in record mode")
+			}
+		default:
+			return errors.NewConfigError("RecorderMode", fmt.Sprintf("unknown mode %q", mode), "use record or replay")
+		}
+		c.RecorderPath = path
+		c.RecorderMode = mode
+		return nil
+	}
+}
+
+// WithTracerProvider sets the OpenTelemetry tracer provider. Each SDK operation
+// (e.g. "RDWS.GetHealth") gets a span, with a child span for each HTTP attempt.
+// Nil, the default, records no spans.
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(c *Config) error {
+		c.TracerProvider = provider
+		return nil
+	}
+}
+
+// WithMeterProvider sets the OpenTelemetry meter provider for request counts,
+// latency, retries, rate-limited responses and token refreshes, labelled by
+// service and operation. Nil, the default, records no metrics.
+func WithMeterProvider(provider metric.MeterProvider) Option {
+	return func(c *Config) error {
+		c.MeterProvider = provider
+		return nil
+	}
+}
+
+// WithPlayerCircuitBreaker makes rDWS calls to a player fail at once with an
+// error matching ErrPlayerOffline after threshold consecutive calls found it
+// unreachable, instead of each waiting out the timeout and retries. After the
+// cooldown one trial call goes through: if it reaches the player the breaker
+// closes, otherwise it opens for another cooldown. A threshold of 0 turns the
+// breaker off, which is the default.
+func WithPlayerCircuitBreaker(threshold int, cooldown time.Duration) Option {
+	return func(c *Config) error {
+		if threshold < 0 {
+			return errors.NewConfigError("PlayerBreakerThreshold", "cannot be negative", "")
+		}
+		if threshold > 0 && cooldown <= 0 {
+			return errors.NewConfigError("PlayerBreakerCooldown", "must be positive", "")
+		}
+		c.PlayerBreakerThreshold = threshold
+		c.PlayerBreakerCooldown = cooldown
+		return nil
+	}
+}
+
+// WithPlayerBreakerHook sets a function called whenever a player's circuit
+// breaker opens, half-opens or closes.
+func WithPlayerBreakerHook(fn func(types.PlayerBreakerEvent)) Option {
+	return func(c *Config) error {
+		c.OnPlayerBreaker = fn
+		return nil
+	}
+}
+
+// WithTokenRefresh renews the access token in the background once the given
+// fraction of its lifetime has passed, e.g. 0.75 for a token that lasts an hour
+// renews it after 45 minutes, so calls never wait for a token and a long operation
+// never sees one expire. Failed renewals are retried while the error is
+// transient. 0 turns it off, which is the default; BS_TOKEN_REFRESH also sets it.
+// The refresher stops when the client is closed.
+func WithTokenRefresh(fraction float64) Option {
+	return func(c *Config) error {
+		if fraction < 0 || fraction >= 1 {
+			return errors.NewConfigError("TokenRefreshFraction", "must be at least 0 and less than 1", "")
+		}
+		c.TokenRefreshFraction = fraction
+		return nil
+	}
+}
+
+// WithTokenRefreshHook sets a function called whenever a new access token is
+// obtained, on demand or in the background.
+func WithTokenRefreshHook(fn func(types.TokenRefresh)) Option {
+	return func(c *Config) error {
+		c.OnTokenRefreshed = fn
+		return nil
+	}
+}
+
+// SecretsResolver returns the resolver for secret references the configuration
+// selects, or nil if references are sent as they are.
+func (c *Config) SecretsResolver() secrets.Resolver {
+	switch {
+	case c.SecretResolver != nil:
+		return c.SecretResolver
+	case c.ResolveSecretReferences:
+		return secrets.NewSources(c.AllowSecretExec)
+	}
+	return nil
+}
+
 // WithAccessToken sets a pre-loaded access token for session reuse.
 //
 // This allows CLI tools to cache the bearer token between invocations,
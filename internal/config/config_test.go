@@ -2,8 +2,15 @@ package config
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
+
+	"github.com/brightdevelopers/gopurple/internal/audit"
+	"github.com/brightdevelopers/gopurple/internal/history"
+	"github.com/brightdevelopers/gopurple/internal/ledger"
+	"github.com/brightdevelopers/gopurple/internal/secrets"
+	"github.com/brightdevelopers/gopurple/internal/types"
 )
 
 func TestDefaultConfig(t *testing.T) {
@@ -200,4 +207,160 @@ func TestOptions(t *testing.T) {
 	if err == nil {
 		t.Error("Expected error for invalid timeout but got none")
 	}
-}
\ No newline at end of file
+	
+	// Test WithTokenRefresh
+	if err := WithTokenRefresh(0.75)(config); err != nil {
+		t.Fatalf("WithTokenRefresh failed: %v", err)
+	}
+	
+	if config.TokenRefreshFraction != 0.75 {
+		t.Errorf("Expected token refresh fraction 0.75, got %v", config.TokenRefreshFraction)
+	}
+	
+	for _, fraction := range []float64{-0.1, 1, 1.5} {
+		if err := WithTokenRefresh(fraction)(config); err == nil {
+			t.Errorf("Expected error for token refresh fraction %v but got none", fraction)
+		}
+	}
+	
+	// Test WithHTTPClient and WithMiddleware reject nil
+	if err := WithHTTPClient(nil)(config); err == nil {
+		t.Error("Expected error for nil HTTP client but got none")
+	}
+	
+	if err := WithMiddleware(nil)(config); err == nil {
+		t.Error("Expected error for nil middleware but got none")
+	}
+	
+	// Test WithRecorder
+	cassette := filepath.Join(t.TempDir(), "session.yaml")
+	if err := WithRecorder(cassette, types.RecorderReplay)(config); err == nil {
+		t.Error("Expected error for replaying a missing cassette but got none")
+	}
+	
+	if err := WithRecorder(cassette, "rewind")(config); err == nil {
+		t.Error("Expected error for unknown recorder mode but got none")
+	}
+	
+	if err := WithRecorder(cassette, types.RecorderRecord)(config); err != nil {
+		t.Fatalf("WithRecorder failed: %v", err)
+	}
+	
+	if config.RecorderPath != cassette || config.RecorderMode != types.RecorderRecord {
+		t.Errorf("Expected recorder %s in record mode, got %s in %q", cassette, config.RecorderPath, config.RecorderMode)
+	}
+	
+	// Test WithPlayerCircuitBreaker
+	if err := WithPlayerCircuitBreaker(3, time.Minute)(config); err != nil {
+		t.Fatalf("WithPlayerCircuitBreaker failed: %v", err)
+	}
+	
+	if config.PlayerBreakerThreshold != 3 || config.PlayerBreakerCooldown != time.Minute {
+		t.Errorf("Expected breaker threshold 3 and cooldown 1m, got %d and %v", config.PlayerBreakerThreshold, config.PlayerBreakerCooldown)
+	}
+	
+	if err := WithPlayerCircuitBreaker(-1, time.Minute)(config); err == nil {
+		t.Error("Expected error for negative breaker threshold but got none")
+	}
+	
+	if err := WithPlayerCircuitBreaker(3, 0)(config); err == nil {
+		t.Error("Expected error for breaker without cooldown but got none")
+	}
+	
+	// Test WithAuditLogFile and WithAuditSink
+	auditLog := filepath.Join(t.TempDir(), "audit.jsonl")
+	if err := WithAuditLogFile(auditLog)(config); err != nil {
+		t.Fatalf("WithAuditLogFile failed: %v", err)
+	}
+	
+	if sink, ok := config.AuditLog().(*audit.FileSink); !ok || sink.Path() != auditLog {
+		t.Errorf("Expected a file sink for %s, got %v", auditLog, config.AuditLog())
+	}
+	
+	if err := WithAuditSink(nil)(config); err != nil || config.AuditLog() != nil {
+		t.Errorf("Expected WithAuditSink(nil) to turn the audit log off, got %v, %v", config.AuditLog(), err)
+	}
+	
+	if err := WithAuditUser("")(config); err == nil {
+		t.Error("Expected error for empty audit user but got none")
+	}
+}
+func TestAssociationStore(t *testing.T) {
+	config := DefaultConfig()
+	if config.AssociationStore() != nil {
+		t.Error("Expected the association ledger to be off by default")
+	}
+
+	if err := WithAssociationLedgerFile("/tmp/ledger.json")(config); err != nil {
+		t.Fatalf("WithAssociationLedgerFile failed: %v", err)
+	}
+	if store, ok := config.AssociationStore().(*ledger.FileStore); !ok || store.Path() != "/tmp/ledger.json" {
+		t.Errorf("Expected file store at /tmp/ledger.json, got %v", config.AssociationStore())
+	}
+
+	memory := ledger.NewMemoryStore()
+	WithAssociationLedger(memory)(config)
+	if config.AssociationStore() != memory {
+		t.Error("Expected configured store to be used")
+	}
+
+	WithAssociationLedger(nil)(config)
+	if config.AssociationStore() != nil {
+		t.Error("Expected nil store to turn the ledger off, file included")
+	}
+}
+
+func TestSetupHistoryStore(t *testing.T) {
+	config := DefaultConfig()
+	if config.SetupHistoryStore() != nil {
+		t.Error("Expected setup history to be off by default")
+	}
+	if err := WithSetupHistoryDir("")(config); err == nil {
+		t.Error("Expected error for an empty history directory")
+	}
+
+	WithSetupHistoryDir("/tmp/history")(config)
+	if store, ok := config.SetupHistoryStore().(*history.FileStore); !ok || store.Dir() != "/tmp/history" {
+		t.Errorf("Expected file store in /tmp/history, got %v", config.SetupHistoryStore())
+	}
+
+	memory := history.NewMemoryStore()
+	WithSetupHistory(memory)(config)
+	if config.SetupHistoryStore() != memory {
+		t.Error("Expected configured store to be used")
+	}
+
+	WithSetupHistory(nil)(config)
+	if config.SetupHistoryStore() != nil {
+		t.Error("Expected nil store to turn the history off")
+	}
+}
+
+func TestSecretsResolver(t *testing.T) {
+	config := DefaultConfig()
+	if resolver := config.SecretsResolver(); resolver != nil {
+		t.Errorf("Expected references to be sent as they are by default, got %v", resolver)
+	}
+
+	WithSecretReferences(true)(config)
+	sources, ok := config.SecretsResolver().(*secrets.Sources)
+	if !ok || sources.AllowExec {
+		t.Errorf("Expected sources without exec, got %v", config.SecretsResolver())
+	}
+
+	WithSecretExec(true)(config)
+	if sources, ok := config.SecretsResolver().(*secrets.Sources); !ok || !sources.AllowExec {
+		t.Error("Expected exec references to be enabled")
+	}
+
+	custom := secrets.NewSources(false)
+	WithSecretResolver(custom)(config)
+	if config.SecretsResolver() != custom {
+		t.Error("Expected configured resolver to be used")
+	}
+
+	WithSecretResolver(nil)(config)
+	if config.SecretsResolver() != nil {
+		t.Error("Expected nil resolver to turn resolution off")
+	}
+}
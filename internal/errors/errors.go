@@ -1,18 +1,43 @@
 package errors
 
 import (
+	stderrors "errors"
 	"fmt"
 	"net/http"
+	"strings"
+)
+
+// Sentinel errors for branching with errors.Is. An APIError matches them by its
+// status code, which is kept when service methods wrap the error.
+var (
+	// ErrNotFound matches a 404 Not Found response.
+	ErrNotFound = stderrors.New("not found")
+
+	// ErrForbidden matches a 403 Forbidden response.
+	ErrForbidden = stderrors.New("forbidden")
+
+	// ErrRateLimited matches a 429 Too Many Requests response.
+	ErrRateLimited = stderrors.New("rate limited")
+
+	// ErrPlayerOffline matches a failed rDWS call on a player that is not
+	// connected to BSN.cloud: the gateway reports 502, 503 or 504, or the error
+	// or PlayerError message says the player is offline or timed out.
+	ErrPlayerOffline = stderrors.New("player offline")
 )
 
 // Error types for different categories of API errors
 
-// APIError represents an error response from the BSN.cloud API.
+// APIError represents an error response from the BSN.cloud API, or a failed SDK
+// operation wrapping the error that caused it.
 type APIError struct {
 	StatusCode int    `json:"status_code"`
 	Code       string `json:"error"`
 	Message    string `json:"error_description"`
 	Details    string `json:"details,omitempty"`
+	Operation  string `json:"operation,omitempty"`  // Service method that failed, e.g. "RDWS.GetInfo"
+	Serial     string `json:"serial,omitempty"`     // Player the operation was for
+	RequestID  string `json:"request_id,omitempty"` // Server request ID, for support
+	Err        error  `json:"-"`                    // Cause, if the operation wraps another error
 }
 
 func (e *APIError) Error() string {
@@ -22,6 +47,73 @@ func (e *APIError) Error() string {
 	return fmt.Sprintf("API error %d (%s): %s", e.StatusCode, e.Code, e.Message)
 }
 
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the error matches one of the sentinel errors.
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrPlayerOffline:
+		return e.playerOffline()
+	}
+	return false
+}
+
+// playerOffline reports whether a player operation failed because the player
+// could not be reached through BSN.cloud.
+func (e *APIError) playerOffline() bool {
+	if e.Serial == "" {
+		return false
+	}
+	switch e.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return offlineMessage(e.Message + " " + e.Details)
+}
+
+// offlineMessages are phrases in rDWS error messages that mean the player could
+// not be reached, as opposed to the player rejecting the command.
+var offlineMessages = []string{"offline", "not connected", "not online", "unreachable", "timed out", "timeout", "no response"}
+
+func offlineMessage(message string) bool {
+	message = strings.ToLower(message)
+	for _, phrase := range offlineMessages {
+		if strings.Contains(message, phrase) {
+			return true
+		}
+	}
+	return false
+}
+
+// PlayerError is the message a player, or the rDWS proxy on its behalf, returned
+// in place of a result: the player is offline or rejected the command.
+type PlayerError struct {
+	Serial  string
+	Route   string // rDWS route, e.g. "/v1/info/"
+	Message string
+	Offline bool // The player could not be reached
+}
+
+func (e *PlayerError) Error() string {
+	if e.Offline {
+		return fmt.Sprintf("player %s is unreachable (%s): %s", e.Serial, e.Route, e.Message)
+	}
+	return fmt.Sprintf("player %s failed %s: %s", e.Serial, e.Route, e.Message)
+}
+
+// Is reports whether the error matches ErrPlayerOffline.
+func (e *PlayerError) Is(target error) bool {
+	return target == ErrPlayerOffline && e.Offline
+}
+
 // AuthenticationError indicates authentication-related errors.
 type AuthenticationError struct {
 	Reason string
@@ -91,6 +183,47 @@ func NewAPIError(statusCode int, code, message, details string) *APIError {
 	}
 }
 
+// WrapError creates an APIError for a failed operation that wraps its cause. The
+// status code and request ID of an APIError in the cause are kept, so errors.Is,
+// errors.As and the Is* helpers see through the wrapping.
+func WrapError(operation, code, message string, err error) *APIError {
+	wrapped := &APIError{
+		Code:      code,
+		Message:   message,
+		Operation: operation,
+		Err:       err,
+	}
+	if err == nil {
+		return wrapped
+	}
+	wrapped.Details = err.Error()
+
+	var apiErr *APIError
+	if stderrors.As(err, &apiErr) {
+		wrapped.StatusCode = apiErr.StatusCode
+		wrapped.RequestID = apiErr.RequestID
+		wrapped.Serial = apiErr.Serial
+	}
+	return wrapped
+}
+
+// WrapPlayerError is WrapError for an operation on the player with a serial.
+func WrapPlayerError(operation, serial, code, message string, err error) *APIError {
+	wrapped := WrapError(operation, code, message, err)
+	wrapped.Serial = serial
+	return wrapped
+}
+
+// NewPlayerError creates a PlayerError, classifying the message as offline or not.
+func NewPlayerError(serial, route, message string) *PlayerError {
+	return &PlayerError{
+		Serial:  serial,
+		Route:   route,
+		Message: message,
+		Offline: offlineMessage(message),
+	}
+}
+
 // NewAuthError creates an AuthenticationError.
 func NewAuthError(reason string, err error) *AuthenticationError {
 	return &AuthenticationError{
@@ -125,44 +258,58 @@ func NewValidationError(field string, value interface{}, reason string) *Validat
 	}
 }
 
-// IsAuthenticationError checks if an error is authentication-related.
+// IsAuthenticationError checks if an error is authentication-related. Wrapped
+// errors are unwrapped.
 func IsAuthenticationError(err error) bool {
-	_, ok := err.(*AuthenticationError)
-	if ok {
+	var authErr *AuthenticationError
+	if stderrors.As(err, &authErr) {
 		return true
 	}
 	
 	// Also check for API errors with authentication status codes
-	if apiErr, ok := err.(*APIError); ok {
+	var apiErr *APIError
+	if stderrors.As(err, &apiErr) {
 		return apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden
 	}
 	
 	return false
 }
 
-// IsNetworkError checks if an error is network-related.
+// IsNetworkError checks if an error is network-related. Wrapped errors are
+// unwrapped.
 func IsNetworkError(err error) bool {
-	_, ok := err.(*NetworkError)
-	return ok
+	var networkErr *NetworkError
+	return stderrors.As(err, &networkErr)
 }
 
-// IsConfigurationError checks if an error is configuration-related.
+// IsPlayerError checks if an error is a player's rDWS error result. Wrapped errors
+// are unwrapped.
+func IsPlayerError(err error) bool {
+	var playerErr *PlayerError
+	return stderrors.As(err, &playerErr)
+}
+
+// IsConfigurationError checks if an error is configuration-related. Wrapped
+// errors are unwrapped.
 func IsConfigurationError(err error) bool {
-	_, ok := err.(*ConfigurationError)
-	return ok
+	var configErr *ConfigurationError
+	return stderrors.As(err, &configErr)
 }
 
-// IsRetryableError checks if an error might succeed on retry.
+// IsRetryableError checks if an error might succeed on retry. Wrapped errors are
+// unwrapped.
 func IsRetryableError(err error) bool {
-	if apiErr, ok := err.(*APIError); ok {
-		// Retry on server errors and rate limiting
-		return apiErr.StatusCode >= 500 || apiErr.StatusCode == http.StatusTooManyRequests
-	}
-	
-	if _, ok := err.(*NetworkError); ok {
+	var networkErr *NetworkError
+	if stderrors.As(err, &networkErr) {
 		// Retry network errors (connection failures, etc.)
 		return true
 	}
 	
+	var apiErr *APIError
+	if stderrors.As(err, &apiErr) {
+		// Retry on server errors and rate limiting
+		return apiErr.StatusCode >= 500 || apiErr.StatusCode == http.StatusTooManyRequests
+	}
+	
 	return false
 }
\ No newline at end of file
@@ -189,4 +189,55 @@ func TestIsRetryableError(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+
+func TestWrapError(t *testing.T) {
+	cause := NewAPIError(http.StatusServiceUnavailable, "unavailable", "Service unavailable", "")
+	cause.RequestID = "req-123"
+	err := WrapPlayerError("RDWS.GetInfo", "XD001", "rdws_info_failed", "Failed to get info", NewAuthError("retry", cause))
+
+	if err.StatusCode != http.StatusServiceUnavailable || err.RequestID != "req-123" || err.Serial != "XD001" || err.Operation != "RDWS.GetInfo" {
+		t.Errorf("Expected status, request ID, serial and operation to be kept, got %+v", err)
+	}
+
+	var authErr *AuthenticationError
+	if !errors.As(err, &authErr) {
+		t.Error("Expected errors.As to find the wrapped AuthenticationError")
+	}
+	if !IsAuthenticationError(err) || !IsRetryableError(err) {
+		t.Error("Expected the Is* helpers to see through the wrapping")
+	}
+	if !errors.Is(err, ErrPlayerOffline) || errors.Is(err, ErrNotFound) {
+		t.Error("Expected a 503 for a player to match only ErrPlayerOffline")
+	}
+
+	network := WrapError("Devices.List", "device_list_failed", "Failed to list devices", NewNetworkError("GET /devices", errors.New("connection reset")))
+	if !IsNetworkError(network) || !IsRetryableError(network) || network.StatusCode != 0 {
+		t.Errorf("Expected a wrapped network error to be retryable, got %+v", network)
+	}
+}
+
+func TestSentinelErrors(t *testing.T) {
+	tests := []struct {
+		status   int
+		serial   string
+		details  string
+		sentinel error
+	}{
+		{http.StatusNotFound, "", "", ErrNotFound},
+		{http.StatusForbidden, "", "", ErrForbidden},
+		{http.StatusTooManyRequests, "", "", ErrRateLimited},
+		{http.StatusGatewayTimeout, "XD001", "", ErrPlayerOffline},
+		{http.StatusBadRequest, "XD001", "player is offline", ErrPlayerOffline},
+	}
+	for _, tt := range tests {
+		err := WrapPlayerError("RDWS.GetInfo", tt.serial, "rdws_info_failed", "Failed", NewAPIError(tt.status, "error", "Request failed", tt.details))
+		if !errors.Is(err, tt.sentinel) {
+			t.Errorf("Expected status %d to match %v", tt.status, tt.sentinel)
+		}
+	}
+
+	if errors.Is(NewAPIError(http.StatusGatewayTimeout, "timeout", "Gateway timeout", ""), ErrPlayerOffline) {
+		t.Error("Expected ErrPlayerOffline to need a player operation")
+	}
+}
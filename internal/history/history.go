@@ -0,0 +1,263 @@
+// Package history keeps snapshots of B-Deploy setup records taken just before the
+// SDK updates or deletes them, so an accidental overwrite can be inspected and
+// rolled back. B-Deploy itself keeps no earlier versions of a record.
+package history
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/brightdevelopers/gopurple/internal/types"
+)
+
+// defaultDirName is the history directory inside the user's config directory.
+const defaultDirName = "bdeploy-history"
+
+// Operations that cause a snapshot.
+const (
+	OperationUpdate = "update"
+	OperationDelete = "delete"
+)
+
+// APIV2 marks a snapshot of a record read from the legacy v2 setup API.
+const APIV2 = "v2"
+
+// Snapshot is a setup record as it was just before the SDK changed or deleted it.
+type Snapshot struct {
+	SetupID     string                    `json:"setupId"`
+	NetworkName string                    `json:"networkName,omitempty"`
+	PackageName string                    `json:"packageName,omitempty"`
+	Operation   string                    `json:"operation"`     // OperationUpdate or OperationDelete
+	API         string                    `json:"api,omitempty"` // APIV2 for the v2 setup API; empty for v3
+	TakenAt     time.Time                 `json:"takenAt"`       // UTC, whole seconds
+	Record      *types.BDeploySetupRecord `json:"record"`
+}
+
+// NewSnapshot returns a snapshot of record taken now. The time is truncated to
+// whole seconds so it can be typed back exactly as listed.
+func NewSnapshot(record *types.BDeploySetupRecord, operation string) Snapshot {
+	return Snapshot{
+		SetupID:     record.ID,
+		NetworkName: record.BDeploy.NetworkName,
+		PackageName: record.BDeploy.PackageName,
+		Operation:   operation,
+		TakenAt:     time.Now().UTC().Truncate(time.Second),
+		Record:      record,
+	}
+}
+
+// At returns the newest snapshot taken at or before t, or nil if there is none.
+// snapshots must be oldest first, as returned by Store.List.
+func At(snapshots []Snapshot, t time.Time) *Snapshot {
+	var found *Snapshot
+	for i := range snapshots {
+		if snapshots[i].TakenAt.After(t) {
+			break
+		}
+		found = &snapshots[i]
+	}
+	return found
+}
+
+// Store persists snapshots. Implementations must be safe for concurrent use.
+type Store interface {
+	// Save adds a snapshot.
+	Save(ctx context.Context, snapshot Snapshot) error
+
+	// List returns the snapshots of a setup record, or of every record if setupID
+	// is empty, oldest first.
+	List(ctx context.Context, setupID string) ([]Snapshot, error)
+}
+
+// DefaultDir returns the default history directory, bdeploy-history in the
+// gopurple directory of the user's config directory (e.g. ~/.config/gopurple).
+func DefaultDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gopurple", defaultDirName), nil
+}
+
+// MemoryStore keeps snapshots in memory. It is useful in tests.
+type MemoryStore struct {
+	mu        sync.Mutex
+	snapshots []Snapshot
+}
+
+// NewMemoryStore returns an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+// Save adds a snapshot.
+func (s *MemoryStore) Save(ctx context.Context, snapshot Snapshot) error {
+	if err := checkSnapshot(snapshot); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.snapshots = append(s.snapshots, snapshot)
+	return nil
+}
+
+// List returns the snapshots of a setup record, or all snapshots, oldest first.
+func (s *MemoryStore) List(ctx context.Context, setupID string) ([]Snapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var result []Snapshot
+	for _, snapshot := range s.snapshots {
+		if setupID == "" || snapshot.SetupID == setupID {
+			result = append(result, snapshot)
+		}
+	}
+	sortSnapshots(result)
+	return result, nil
+}
+
+// FileStore keeps snapshots in a directory with one file per setup record, named
+// after its ID, holding one JSON snapshot per line. Files are only appended to.
+// Snapshots contain the records' passwords, so the directory and files are
+// readable only by their owner.
+type FileStore struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewFileStore returns a store backed by dir. The directory is created on the
+// first snapshot.
+func NewFileStore(dir string) *FileStore {
+	return &FileStore{dir: dir}
+}
+
+// Dir returns the history directory.
+func (s *FileStore) Dir() string {
+	return s.dir
+}
+
+// Save appends a snapshot to the setup record's file.
+func (s *FileStore) Save(ctx context.Context, snapshot Snapshot) error {
+	if err := checkSnapshot(snapshot); err != nil {
+		return err
+	}
+	data, err := json.Marshal(fileSnapshot{Snapshot: snapshot, Record: types.SetupRecordJSON{Record: snapshot.Record}})
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(s.file(snapshot.SetupID), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// List returns the snapshots of a setup record, or all snapshots, oldest first.
+func (s *FileStore) List(ctx context.Context, setupID string) ([]Snapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var files []string
+	if setupID != "" {
+		if err := checkSetupID(setupID); err != nil {
+			return nil, err
+		}
+		files = []string{s.file(setupID)}
+	} else {
+		matches, err := filepath.Glob(filepath.Join(s.dir, "*.jsonl"))
+		if err != nil {
+			return nil, err
+		}
+		files = matches
+	}
+
+	var result []Snapshot
+	for _, file := range files {
+		snapshots, err := readFile(file)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, snapshots...)
+	}
+	sortSnapshots(result)
+	return result, nil
+}
+
+func (s *FileStore) file(setupID string) string {
+	return filepath.Join(s.dir, setupID+".jsonl")
+}
+
+func readFile(path string) ([]Snapshot, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var snapshots []Snapshot
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var stored fileSnapshot
+		if err := json.Unmarshal(scanner.Bytes(), &stored); err != nil {
+			return nil, fmt.Errorf("failed to parse setup history %s line %d: %w", path, line, err)
+		}
+		stored.Snapshot.Record = stored.Record.Record
+		snapshots = append(snapshots, stored.Snapshot)
+	}
+	return snapshots, scanner.Err()
+}
+
+// fileSnapshot is a snapshot as it is stored in a file. Its record is encoded with
+// types.MarshalSetupRecord, so the values it sends explicitly are kept.
+type fileSnapshot struct {
+	Snapshot
+	Record types.SetupRecordJSON `json:"record"`
+}
+
+func checkSnapshot(snapshot Snapshot) error {
+	if snapshot.Record == nil {
+		return fmt.Errorf("snapshot of setup %s has no record", snapshot.SetupID)
+	}
+	return checkSetupID(snapshot.SetupID)
+}
+
+// checkSetupID rejects IDs that cannot be used as a file name.
+func checkSetupID(setupID string) error {
+	if setupID == "" || setupID == "." || setupID == ".." || strings.ContainsAny(setupID, `/\`) {
+		return fmt.Errorf("invalid setup ID %q for setup history", setupID)
+	}
+	return nil
+}
+
+// sortSnapshots sorts by time, keeping the order snapshots were saved in for equal times.
+func sortSnapshots(snapshots []Snapshot) {
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		return snapshots[i].TakenAt.Before(snapshots[j].TakenAt)
+	})
+}
@@ -0,0 +1,117 @@
+package history
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/brightdevelopers/gopurple/internal/types"
+)
+
+func testSnapshot(setupID, hostname string, takenAt time.Time) Snapshot {
+	record := &types.BDeploySetupRecord{
+		ID:       setupID,
+		Version:  "3.0.0",
+		BDeploy:  types.BDeployInfo{NetworkName: "Retail", PackageName: "pkg-" + setupID},
+		Hostname: hostname,
+	}
+	record.ForceSend("dwsEnabled")
+	snapshot := NewSnapshot(record, OperationUpdate)
+	snapshot.TakenAt = takenAt
+	return snapshot
+}
+
+func testStore(t *testing.T, store Store) {
+	t.Helper()
+	ctx := context.Background()
+	base := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	if list, err := store.List(ctx, "setup-1"); len(list) != 0 || err != nil {
+		t.Fatalf("Expected empty store, got %v (%v)", list, err)
+	}
+
+	snapshots := []Snapshot{
+		testSnapshot("setup-1", "second", base.Add(time.Hour)),
+		testSnapshot("setup-1", "first", base),
+		testSnapshot("setup-2", "other", base.Add(30*time.Minute)),
+	}
+	for _, snapshot := range snapshots {
+		if err := store.Save(ctx, snapshot); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+	}
+	if err := store.Save(ctx, Snapshot{SetupID: "../escape", Record: &types.BDeploySetupRecord{}}); err == nil {
+		t.Error("Expected error for a setup ID that is not a file name")
+	}
+	if err := store.Save(ctx, Snapshot{SetupID: "setup-3"}); err == nil {
+		t.Error("Expected error for a snapshot without a record")
+	}
+
+	list, err := store.List(ctx, "setup-1")
+	if err != nil || len(list) != 2 {
+		t.Fatalf("Expected 2 snapshots of setup-1, got %+v (%v)", list, err)
+	}
+	if list[0].Record.Hostname != "first" || list[1].Record.Hostname != "second" {
+		t.Errorf("Expected snapshots oldest first, got %s, %s", list[0].Record.Hostname, list[1].Record.Hostname)
+	}
+	if !list[0].Record.IsForceSent("dwsEnabled") || list[0].PackageName != "pkg-setup-1" {
+		t.Errorf("Expected the record to be kept as saved, got %+v", list[0])
+	}
+
+	if found := At(list, base.Add(30*time.Minute)); found == nil || found.Record.Hostname != "first" {
+		t.Errorf("Expected the first snapshot at 12:30, got %+v", found)
+	}
+	if found := At(list, base.Add(time.Hour)); found == nil || found.Record.Hostname != "second" {
+		t.Errorf("Expected the second snapshot at its own time, got %+v", found)
+	}
+	if At(list, base.Add(-time.Second)) != nil {
+		t.Error("Expected no snapshot before the first one")
+	}
+
+	all, err := store.List(ctx, "")
+	if err != nil || len(all) != 3 || all[1].SetupID != "setup-2" {
+		t.Errorf("Expected all snapshots by time, got %+v (%v)", all, err)
+	}
+}
+
+func TestNewSnapshot(t *testing.T) {
+	snapshot := NewSnapshot(&types.BDeploySetupRecord{ID: "setup-1"}, OperationDelete)
+	if snapshot.TakenAt.Nanosecond() != 0 || snapshot.TakenAt.Location() != time.UTC {
+		t.Errorf("Expected a UTC time in whole seconds, got %v", snapshot.TakenAt)
+	}
+	if snapshot.SetupID != "setup-1" || snapshot.Operation != OperationDelete {
+		t.Errorf("Unexpected snapshot %+v", snapshot)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore())
+}
+
+func TestFileStore(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "nested", "history")
+	testStore(t, NewFileStore(dir))
+
+	// A second store on the same directory sees the same snapshots
+	all, err := NewFileStore(dir).List(context.Background(), "")
+	if err != nil || len(all) != 3 {
+		t.Errorf("Expected snapshots to persist, got %d (%v)", len(all), err)
+	}
+
+	info, err := os.Stat(filepath.Join(dir, "setup-1.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected history files to be private, got %v", info.Mode().Perm())
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "broken.jsonl"), []byte("{not json\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewFileStore(dir).List(context.Background(), ""); err == nil {
+		t.Error("Expected error for a corrupt history file")
+	}
+}
@@ -2,24 +2,27 @@ package http
 
 import (
 	"context"
+	stderrors "errors"
 	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/brightdevelopers/gopurple/internal/config"
 	"github.com/brightdevelopers/gopurple/internal/errors"
+	"github.com/brightdevelopers/gopurple/internal/secrets"
 	"github.com/go-resty/resty/v2"
 )
 
 // HTTPClient wraps the resty client with BSN.cloud-specific functionality.
 type HTTPClient struct {
-	client *resty.Client
-	config *config.Config
+	client    *resty.Client
+	config    *config.Config
+	telemetry *telemetry // Nil without tracer and meter providers
 }
 
 // NewHTTPClient creates a new HTTP client with the given configuration.
 func NewHTTPClient(cfg *config.Config) *HTTPClient {
-	client := resty.New().
+	client := newRestyClient(cfg).
 		SetTimeout(cfg.Timeout).
 		SetRetryCount(cfg.RetryCount).
 		SetRetryWaitTime(1 * time.Second).
@@ -29,9 +32,9 @@ func NewHTTPClient(cfg *config.Config) *HTTPClient {
 			return time.Duration(resp.Request.Attempt) * time.Second, nil
 		}).
 		AddRetryCondition(func(resp *resty.Response, err error) bool {
-			// Retry on network errors
+			// Retry on network errors, but not on requests a cassette lacks
 			if err != nil {
-				return true
+				return !stderrors.Is(err, errNotRecorded)
 			}
 			// Retry on server errors and rate limiting
 			return resp.StatusCode() >= 500 || resp.StatusCode() == http.StatusTooManyRequests
@@ -43,12 +46,68 @@ func NewHTTPClient(cfg *config.Config) *HTTPClient {
 		SetAuthScheme("Bearer"). // Required for SetAuthToken() to work correctly
 		SetDebug(cfg.Debug)      // Enable debug logging if configured
 
+	// Keep credentials, passwords and tokens out of the debug log
+	client.OnRequestLog(func(l *resty.RequestLog) error {
+		secrets.RedactHeader(l.Header)
+		l.Body = secrets.Redact(l.Body)
+		return nil
+	})
+	client.OnResponseLog(func(l *resty.ResponseLog) error {
+		l.Body = secrets.Redact(l.Body)
+		return nil
+	})
+
+	// Run every attempt through the middleware, then log and instrument it.
+	// Telemetry wraps logging, so that log records are made inside the attempt's
+	// span. The recorder sits next to the network
+	telemetry := newTelemetry(cfg)
+	if cfg.RecorderPath != "" || len(cfg.Middleware) > 0 || cfg.Logger != nil || telemetry != nil {
+		// Pass the attempt number down to the transports
+		client.OnBeforeRequest(func(_ *resty.Client, r *resty.Request) error {
+			r.SetContext(context.WithValue(r.Context(), attemptKey{}, r.Attempt))
+			return nil
+		})
+		transport := client.GetClient().Transport
+		if transport == nil {
+			transport = http.DefaultTransport
+		}
+		if cfg.RecorderPath != "" {
+			transport = newRecorder(cfg.RecorderPath, cfg.RecorderMode, transport, cfg.Logger)
+		}
+		if len(cfg.Middleware) > 0 {
+			transport = chain(transport, cfg.Middleware)
+		}
+		if cfg.Logger != nil {
+			transport = &loggingTransport{next: transport, logger: cfg.Logger}
+		}
+		if telemetry != nil {
+			transport = &telemetryTransport{next: transport, telemetry: telemetry}
+		}
+		client.SetTransport(transport)
+	}
+
 	return &HTTPClient{
-		client: client,
-		config: cfg,
+		client:    client,
+		config:    cfg,
+		telemetry: telemetry,
 	}
 }
 
+// newRestyClient returns a resty client on a copy of the configured HTTP client,
+// so that setting the timeout and transport leaves the caller's client alone, or
+// on a default client.
+func newRestyClient(cfg *config.Config) *resty.Client {
+	if cfg.HTTPClient == nil {
+		return resty.New()
+	}
+	httpClient := *cfg.HTTPClient
+	return resty.NewWithClient(&httpClient)
+}
+
+// requestIDHeaders are the response headers that may carry the server's request
+// ID, in order of preference.
+var requestIDHeaders = []string{"X-Request-Id", "Request-Id", "X-Correlation-Id", "X-Amzn-Requestid"}
+
 // Request represents an HTTP request to be made.
 type Request struct {
 	Method      string
@@ -60,7 +119,10 @@ type Request struct {
 }
 
 // Do executes an HTTP request with error handling and response parsing.
-func (h *HTTPClient) Do(ctx context.Context, req *Request) error {
+func (h *HTTPClient) Do(ctx context.Context, req *Request) (err error) {
+	ctx, end := h.startRequest(ctx, req.Method)
+	defer func() { end(err) }()
+
 	request := h.client.R().SetContext(ctx)
 
 	// Set headers
@@ -144,14 +206,21 @@ func (h *HTTPClient) handleAPIError(resp *resty.Response, apiError *struct {
 		errorMessage = "Request failed"
 	}
 
+	apiErr := errors.NewAPIError(statusCode, errorCode, errorMessage, errorDetails)
+	for _, header := range requestIDHeaders {
+		if apiErr.RequestID = resp.Header().Get(header); apiErr.RequestID != "" {
+			break
+		}
+	}
+
 	// Create specific error types based on status code
 	switch statusCode {
 	case http.StatusUnauthorized:
-		return errors.NewAuthError("invalid or expired token", errors.NewAPIError(statusCode, errorCode, errorMessage, errorDetails))
+		return errors.NewAuthError("invalid or expired token", apiErr)
 	case http.StatusForbidden:
-		return errors.NewAuthError("insufficient permissions", errors.NewAPIError(statusCode, errorCode, errorMessage, errorDetails))
+		return errors.NewAuthError("insufficient permissions", apiErr)
 	default:
-		return errors.NewAPIError(statusCode, errorCode, errorMessage, errorDetails)
+		return apiErr
 	}
 }
 
@@ -174,7 +243,10 @@ func (h *HTTPClient) GetWithAuth(ctx context.Context, token, url string, result
 }
 
 // GetBytesWithAuth performs a GET request and returns raw bytes (for downloading files).
-func (h *HTTPClient) GetBytesWithAuth(ctx context.Context, token, url string) ([]byte, error) {
+func (h *HTTPClient) GetBytesWithAuth(ctx context.Context, token, url string) (body []byte, err error) {
+	ctx, end := h.startRequest(ctx, "GET")
+	defer func() { end(err) }()
+
 	request := h.client.R().
 		SetContext(ctx).
 		SetAuthToken(token)
@@ -251,7 +323,10 @@ func (h *HTTPClient) DeleteWithAuth(ctx context.Context, token, url string, resu
 }
 
 // PostForm performs a POST request with form data (for OAuth token requests).
-func (h *HTTPClient) PostForm(ctx context.Context, url string, data map[string]string, result interface{}) error {
+func (h *HTTPClient) PostForm(ctx context.Context, url string, data map[string]string, result interface{}) (err error) {
+	ctx, end := h.startRequest(ctx, "POST")
+	defer func() { end(err) }()
+
 	request := h.client.R().SetContext(ctx)
 
 	// Set form data
@@ -290,7 +365,10 @@ func (h *HTTPClient) PostForm(ctx context.Context, url string, data map[string]s
 }
 
 // PostFormWithAuth performs a POST request with form data and basic auth.
-func (h *HTTPClient) PostFormWithAuth(ctx context.Context, clientID, clientSecret, url string, data map[string]string, result interface{}) error {
+func (h *HTTPClient) PostFormWithAuth(ctx context.Context, clientID, clientSecret, url string, data map[string]string, result interface{}) (err error) {
+	ctx, end := h.startRequest(ctx, "POST")
+	defer func() { end(err) }()
+
 	request := h.client.R().SetContext(ctx)
 
 	// Set basic auth
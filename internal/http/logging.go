@@ -0,0 +1,140 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/brightdevelopers/gopurple/internal/secrets"
+)
+
+// maxLoggedBody is how much of a request or response body a debug record holds.
+const maxLoggedBody = 4096
+
+// Operation names the SDK call a request is made for, so that the request log
+// can say which service, player and network it concerns.
+type Operation struct {
+	Name    string // Service and method, e.g. "Devices.List"
+	Serial  string // Player serial, if the call is for one player
+	Network string // Network the session has selected, if any
+}
+
+// Service returns the service part of the operation name.
+func (o Operation) Service() string {
+	service, _, _ := strings.Cut(o.Name, ".")
+	return service
+}
+
+type operationKey struct{}
+
+type attemptKey struct{}
+
+// WithOperation returns a context that names the operation for the requests
+// made with it, replacing any operation the context named before.
+func WithOperation(ctx context.Context, op Operation) context.Context {
+	return context.WithValue(ctx, operationKey{}, op)
+}
+
+// OperationFrom returns the operation a context names, if any.
+func OperationFrom(ctx context.Context) (Operation, bool) {
+	op, ok := ctx.Value(operationKey{}).(Operation)
+	return op, ok
+}
+
+// loggingTransport records every request attempt, with credentials and
+// passwords redacted.
+type loggingTransport struct {
+	next   http.RoundTripper
+	logger *slog.Logger
+}
+
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	debug := t.logger.Enabled(ctx, slog.LevelDebug)
+
+	var requestBody string
+	if debug && req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			requestBody = readLoggedBody(body)
+		}
+	}
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	duration := time.Since(start)
+
+	attrs := make([]slog.Attr, 0, 12)
+	if op, ok := OperationFrom(ctx); ok {
+		attrs = append(attrs, slog.String("service", op.Service()), slog.String("operation", op.Name))
+		if op.Serial != "" {
+			attrs = append(attrs, slog.String("serial", op.Serial))
+		}
+		if op.Network != "" {
+			attrs = append(attrs, slog.String("network", op.Network))
+		}
+	}
+	attrs = append(attrs,
+		slog.String("method", req.Method),
+		slog.String("host", req.URL.Host),
+		slog.String("path", req.URL.Path),
+	)
+	if resp != nil {
+		attrs = append(attrs, slog.Int("status", resp.StatusCode))
+	}
+	attrs = append(attrs, slog.Duration("duration", duration))
+	if attempt, ok := ctx.Value(attemptKey{}).(int); ok {
+		attrs = append(attrs, slog.Int("attempt", attempt))
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+
+	if debug {
+		header := req.Header.Clone()
+		secrets.RedactHeader(header)
+		attrs = append(attrs, slog.Any("request_header", header))
+		if requestBody != "" {
+			attrs = append(attrs, slog.String("request_body", requestBody))
+		}
+		if resp != nil && resp.Body != nil {
+			body, readErr := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			resp.Body = io.NopCloser(bytes.NewReader(body))
+			if readErr == nil && len(body) > 0 {
+				attrs = append(attrs, slog.String("response_body", loggedBody(body)))
+			}
+		}
+	}
+
+	level := slog.LevelDebug
+	if err != nil || resp.StatusCode >= 400 {
+		level = slog.LevelWarn
+	}
+	t.logger.LogAttrs(ctx, level, "HTTP request", attrs...)
+
+	return resp, err
+}
+
+// readLoggedBody reads and closes a body for the log.
+func readLoggedBody(body io.ReadCloser) string {
+	defer body.Close()
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return ""
+	}
+	return loggedBody(data)
+}
+
+// loggedBody redacts a body and cuts it to maxLoggedBody. It redacts first, as a
+// cut secret would no longer match.
+func loggedBody(body []byte) string {
+	redacted := secrets.Redact(string(body))
+	if len(redacted) > maxLoggedBody {
+		return redacted[:maxLoggedBody] + "...(truncated)"
+	}
+	return redacted
+}
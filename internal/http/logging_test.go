@@ -0,0 +1,110 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/brightdevelopers/gopurple/internal/config"
+)
+
+func newLoggedClient(t *testing.T, level slog.Level, handler http.HandlerFunc) (*HTTPClient, *httptest.Server, *bytes.Buffer) {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	var logs bytes.Buffer
+	cfg := config.DefaultConfig()
+	cfg.RetryCount = 1
+	cfg.Logger = slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: level}))
+	return NewHTTPClient(cfg), server, &logs
+}
+
+func logRecords(t *testing.T, logs *bytes.Buffer) []map[string]interface{} {
+	var records []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("Invalid log record %q: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestLogging_Debug(t *testing.T) {
+	client, server, logs := newLoggedClient(t, slog.LevelDebug, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"server-token","name":"ok"}`))
+	})
+
+	ctx := WithOperation(context.Background(), Operation{Name: "RDWS.SetLocalDWS", Serial: "UTD41X000001", Network: "Store-East"})
+	body := map[string]string{"password": "hunter2", "name": "lobby"}
+	if err := client.PutWithAuth(ctx, "secret-token", server.URL+"/rest/v1/control/local-dws", body, nil); err != nil {
+		t.Fatalf("PutWithAuth failed: %v", err)
+	}
+
+	records := logRecords(t, logs)
+	if len(records) != 1 {
+		t.Fatalf("Expected 1 log record, got %d: %s", len(records), logs)
+	}
+	record := records[0]
+	want := map[string]interface{}{
+		"level":     "DEBUG",
+		"service":   "RDWS",
+		"operation": "RDWS.SetLocalDWS",
+		"serial":    "UTD41X000001",
+		"network":   "Store-East",
+		"method":    "PUT",
+		"path":      "/rest/v1/control/local-dws",
+		"status":    float64(200),
+		"attempt":   float64(1),
+	}
+	for key, value := range want {
+		if record[key] != value {
+			t.Errorf("Expected %s %v, got %v", key, value, record[key])
+		}
+	}
+	for _, secret := range []string{"hunter2", "secret-token", "server-token"} {
+		if strings.Contains(logs.String(), secret) {
+			t.Errorf("Expected %q to be redacted, got %s", secret, logs)
+		}
+	}
+	if !strings.Contains(record["request_body"].(string), "lobby") {
+		t.Errorf("Expected the request body in the record, got %v", record["request_body"])
+	}
+}
+
+func TestLogging_QuietAtInfo(t *testing.T) {
+	fail := true
+	client, server, logs := newLoggedClient(t, slog.LevelInfo, func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			fail = false
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{}`))
+	})
+
+	if err := client.Get(context.Background(), server.URL+"/ok", nil); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+
+	records := logRecords(t, logs)
+	if len(records) != 1 {
+		t.Fatalf("Expected only the failed attempt to be logged, got %d: %s", len(records), logs)
+	}
+	if records[0]["level"] != "WARN" || records[0]["status"] != float64(503) || records[0]["attempt"] != float64(1) {
+		t.Errorf("Expected a warning for the first attempt, got %v", records[0])
+	}
+	if _, ok := records[0]["request_header"]; ok {
+		t.Errorf("Expected no headers at info level, got %v", records[0])
+	}
+}
@@ -0,0 +1,25 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/brightdevelopers/gopurple/internal/types"
+)
+
+// doerTransport sends requests through a Doer.
+type doerTransport struct {
+	doer types.Doer
+}
+
+func (t doerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.doer.Do(req)
+}
+
+// chain wraps a transport in middleware, the first in the list outermost.
+func chain(transport http.RoundTripper, middleware []types.Middleware) http.RoundTripper {
+	var doer types.Doer = types.DoerFunc(transport.RoundTrip)
+	for i := len(middleware) - 1; i >= 0; i-- {
+		doer = middleware[i](doer)
+	}
+	return doerTransport{doer: doer}
+}
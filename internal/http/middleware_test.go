@@ -0,0 +1,98 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brightdevelopers/gopurple/internal/config"
+	"github.com/brightdevelopers/gopurple/internal/types"
+)
+
+func TestMiddleware(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"trace":"` + r.Header.Get("X-Trace") + `"}`))
+	}))
+	defer server.Close()
+
+	var order []string
+	named := func(name string) types.Middleware {
+		return func(next types.Doer) types.Doer {
+			return types.DoerFunc(func(req *http.Request) (*http.Response, error) {
+				order = append(order, name)
+				req.Header.Set("X-Trace", req.Header.Get("X-Trace")+name)
+				return next.Do(req)
+			})
+		}
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.Middleware = []types.Middleware{named("a"), named("b")}
+	client := NewHTTPClient(cfg)
+
+	var result struct {
+		Trace string `json:"trace"`
+	}
+	if err := client.Get(context.Background(), server.URL, &result); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if strings.Join(order, "") != "ab" || result.Trace != "ab" {
+		t.Errorf("Expected middleware a then b, got order %v and header %q", order, result.Trace)
+	}
+}
+
+func TestMiddleware_ShortCircuit(t *testing.T) {
+	blocked := errors.New("blocked")
+	cfg := config.DefaultConfig()
+	cfg.RetryCount = 0
+	cfg.Middleware = []types.Middleware{func(next types.Doer) types.Doer {
+		return types.DoerFunc(func(req *http.Request) (*http.Response, error) {
+			return nil, blocked
+		})
+	}}
+	client := NewHTTPClient(cfg)
+
+	err := client.Get(context.Background(), "http://player.invalid/", nil)
+	if !errors.Is(err, blocked) {
+		t.Errorf("Expected the middleware's error, got %v", err)
+	}
+}
+
+// countingTransport counts the requests it sends.
+type countingTransport struct {
+	requests int
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests++
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestCustomHTTPClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	transport := &countingTransport{}
+	custom := &http.Client{Transport: transport, Timeout: time.Minute}
+	cfg := config.DefaultConfig()
+	cfg.HTTPClient = custom
+	cfg.Middleware = []types.Middleware{func(next types.Doer) types.Doer { return next }}
+	client := NewHTTPClient(cfg)
+
+	if err := client.Get(context.Background(), server.URL, nil); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if transport.requests != 1 {
+		t.Errorf("Expected the request to go through the custom transport, got %d requests", transport.requests)
+	}
+	if custom.Transport != transport || custom.Timeout != time.Minute {
+		t.Error("Expected the caller's client to be left alone")
+	}
+}
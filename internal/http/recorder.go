@@ -0,0 +1,292 @@
+package http
+
+import (
+	"bytes"
+	"crypto/sha256"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/brightdevelopers/gopurple/internal/secrets"
+	"github.com/brightdevelopers/gopurple/internal/types"
+	"gopkg.in/yaml.v3"
+)
+
+// errNotRecorded reports a replayed request the cassette has no exchange for.
+// It is not retried, as replaying it again cannot succeed.
+var errNotRecorded = stderrors.New("no recorded exchange")
+
+// serialField matches the value of a JSON serial number field, so that serials
+// found in bodies are scrubbed as well as the ones operations are for.
+var serialField = regexp.MustCompile(`"(?i:serial|serialNumber|deviceSerial)"\s*:\s*"([^"\\]+)"`)
+
+// cassette is the YAML document a recorder writes.
+type cassette struct {
+	Interactions []*interaction `yaml:"interactions"`
+}
+
+// interaction is one exchange in a cassette.
+type interaction struct {
+	Method       string `yaml:"method"`
+	URL          string `yaml:"url"`
+	RequestBody  string `yaml:"request_body,omitempty"`
+	Status       int    `yaml:"status"`
+	ContentType  string `yaml:"content_type,omitempty"`
+	ResponseBody string `yaml:"response_body,omitempty"`
+
+	used bool // Served already in this replay
+}
+
+// cassetteHeader starts every cassette file.
+const cassetteHeader = "# gopurple HTTP cassette. Tokens, passwords and player serials are scrubbed.\n"
+
+// recorder records exchanges to a YAML cassette, or replays them from one. It
+// sits next to the network, under the middleware, logging and telemetry.
+type recorder struct {
+	mu           sync.Mutex
+	path         string
+	mode         types.RecorderMode
+	next         http.RoundTripper
+	interactions []*interaction // Unscrubbed while recording, scrubbed while replaying
+	serials      map[string]bool
+	loadErr      error
+	logger       *slog.Logger
+}
+
+func newRecorder(path string, mode types.RecorderMode, next http.RoundTripper, logger *slog.Logger) *recorder {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	r := &recorder{path: path, mode: mode, next: next, serials: map[string]bool{}, logger: logger}
+	if mode == types.RecorderReplay {
+		r.interactions, r.loadErr = readCassette(path)
+	}
+	return r
+}
+
+func (r *recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	requestBody, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	if op, ok := OperationFrom(req.Context()); ok && op.Serial != "" {
+		r.serials[op.Serial] = true
+	}
+	r.learnSerials(requestBody)
+	r.mu.Unlock()
+
+	if r.mode == types.RecorderReplay {
+		return r.replay(req, requestBody)
+	}
+	return r.record(req, requestBody)
+}
+
+func (r *recorder) record(req *http.Request, requestBody string) (*http.Response, error) {
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	responseBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(responseBody))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.learnSerials(string(responseBody))
+	r.interactions = append(r.interactions, &interaction{
+		Method:       req.Method,
+		URL:          req.URL.String(),
+		RequestBody:  requestBody,
+		Status:       resp.StatusCode,
+		ContentType:  resp.Header.Get("Content-Type"),
+		ResponseBody: string(responseBody),
+	})
+
+	// Write everything again, so serials learned since are scrubbed everywhere
+	scrubbed := make([]*interaction, len(r.interactions))
+	for i, it := range r.interactions {
+		scrubbed[i] = &interaction{
+			Method:       it.Method,
+			URL:          r.scrub(it.URL),
+			RequestBody:  r.scrub(it.RequestBody),
+			Status:       it.Status,
+			ContentType:  it.ContentType,
+			ResponseBody: r.scrub(it.ResponseBody),
+		}
+	}
+	// The request has been made either way, so a failed write is logged rather
+	// than reported as the request failing
+	if err := writeCassette(r.path, scrubbed); err != nil {
+		r.logger.Error("failed to write cassette", slog.String("path", r.path), slog.String("error", err.Error()))
+	}
+	return resp, nil
+}
+
+func (r *recorder) replay(req *http.Request, requestBody string) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.loadErr != nil {
+		return nil, fmt.Errorf("failed to read cassette: %w", r.loadErr)
+	}
+
+	// Prefer the first unused exchange with the same body, then any unused one
+	// for the URL; once all are used, serve the last again, for polling
+	url, body := r.scrub(req.URL.String()), r.scrub(requestBody)
+	var match, last *interaction
+	for _, it := range r.interactions {
+		if it.Method != req.Method || it.URL != url {
+			continue
+		}
+		last = it
+		if it.used {
+			continue
+		}
+		if it.RequestBody == body {
+			match = it
+			break
+		}
+		if match == nil {
+			match = it
+		}
+	}
+	if match == nil {
+		match = last
+	}
+	if match == nil {
+		return nil, fmt.Errorf("%w for %s %s in %s", errNotRecorded, req.Method, url, r.path)
+	}
+	match.used = true
+
+	// Give the caller back the serial the operation is for
+	responseBody := match.ResponseBody
+	if op, ok := OperationFrom(req.Context()); ok && op.Serial != "" {
+		responseBody = strings.ReplaceAll(responseBody, scrubbedSerial(op.Serial), op.Serial)
+	}
+
+	header := http.Header{}
+	if match.ContentType != "" {
+		header.Set("Content-Type", match.ContentType)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", match.Status, http.StatusText(match.Status)),
+		StatusCode:    match.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(responseBody)),
+		ContentLength: int64(len(responseBody)),
+		Request:       req,
+	}, nil
+}
+
+// learnSerials adds the serials in a body to the ones to scrub. The caller must
+// hold the lock.
+func (r *recorder) learnSerials(body string) {
+	for _, match := range serialField.FindAllStringSubmatch(body, -1) {
+		r.serials[match[1]] = true
+	}
+}
+
+// scrub masks tokens, passwords and the known serials. The caller must hold the
+// lock.
+func (r *recorder) scrub(text string) string {
+	text = secrets.Redact(text)
+
+	// Longest first, so that a serial containing another is replaced whole
+	serials := make([]string, 0, len(r.serials))
+	for serial := range r.serials {
+		serials = append(serials, serial)
+	}
+	sort.Slice(serials, func(i, j int) bool { return len(serials[i]) > len(serials[j]) })
+	for _, serial := range serials {
+		text = strings.ReplaceAll(text, serial, scrubbedSerial(serial))
+	}
+	return text
+}
+
+// scrubbedSerial returns the stand-in for a serial. It is derived from the
+// serial, so that a replayed request for the same player scrubs the same way.
+func scrubbedSerial(serial string) string {
+	sum := sha256.Sum256([]byte(serial))
+	return fmt.Sprintf("SERIAL%X", sum[:5])
+}
+
+// readRequestBody returns the request body, leaving the request able to send it.
+func readRequestBody(req *http.Request) (string, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return "", nil
+	}
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return "", err
+		}
+		defer body.Close()
+		data, err := io.ReadAll(body)
+		return string(data), err
+	}
+	data, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return "", err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	return string(data), nil
+}
+
+// writeCassette writes the exchanges to a YAML cassette, replacing the file
+// whole so that a reader never sees half of one.
+func writeCassette(path string, interactions []*interaction) error {
+	data, err := yaml.Marshal(cassette{Interactions: interactions})
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".cassette-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.WriteString(cassetteHeader + string(data)); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// readCassette reads the exchanges of a YAML cassette, rejecting unknown keys.
+func readCassette(path string) ([]*interaction, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var c cassette
+	decoder := yaml.NewDecoder(f)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&c); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return c.Interactions, nil
+}
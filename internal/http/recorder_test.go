@@ -0,0 +1,142 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/brightdevelopers/gopurple/internal/config"
+	"github.com/brightdevelopers/gopurple/internal/types"
+)
+
+func TestRecorder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/token":
+			w.Write([]byte(`{"access_token":"live-token","expires_in":3600}`))
+		case "/devices":
+			w.Write([]byte(`{"items":[{"serial":"UTD41X000002","name":"Lobby"}]}`))
+		case "/rest/v1/info/UTD41X000001":
+			w.Write([]byte(`{"data":{"result":{"serial":"UTD41X000001","model":"XT1144"}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	cassette := filepath.Join(t.TempDir(), "session.yaml")
+
+	cfg := config.DefaultConfig()
+	cfg.RetryCount = 0
+	cfg.RecorderPath = cassette
+	cfg.RecorderMode = types.RecorderRecord
+	client := NewHTTPClient(cfg)
+
+	session := func(client *HTTPClient, base string) (string, string, string) {
+		t.Helper()
+		var token types.TokenResponse
+		if err := client.PostFormWithAuth(context.Background(), "id", "client-secret", base+"/token", map[string]string{"grant_type": "client_credentials"}, &token); err != nil {
+			t.Fatalf("Token request failed: %v", err)
+		}
+		var devices struct {
+			Items []struct {
+				Serial string `json:"serial"`
+			} `json:"items"`
+		}
+		if err := client.GetWithAuth(context.Background(), token.AccessToken, base+"/devices", &devices); err != nil {
+			t.Fatalf("Device list failed: %v", err)
+		}
+		var info struct {
+			Data struct {
+				Result struct {
+					Serial string `json:"serial"`
+					Model  string `json:"model"`
+				} `json:"result"`
+			} `json:"data"`
+		}
+		ctx := WithOperation(context.Background(), Operation{Name: "RDWS.GetInfo", Serial: "UTD41X000001"})
+		body := map[string]string{"password": "hunter2"}
+		if err := client.PutWithAuth(ctx, token.AccessToken, base+"/rest/v1/info/UTD41X000001", body, &info); err != nil {
+			t.Fatalf("Info request failed: %v", err)
+		}
+		return token.AccessToken, devices.Items[0].Serial, info.Data.Result.Serial + " " + info.Data.Result.Model
+	}
+
+	recordedToken, _, recordedInfo := session(client, server.URL)
+	if recordedToken != "live-token" || recordedInfo != "UTD41X000001 XT1144" {
+		t.Fatalf("Expected live responses while recording, got %q and %q", recordedToken, recordedInfo)
+	}
+
+	data, err := os.ReadFile(cassette)
+	if err != nil {
+		t.Fatalf("Failed to read cassette: %v", err)
+	}
+	for _, secret := range []string{"live-token", "client-secret", "hunter2", "UTD41X000001", "UTD41X000002"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("Expected %q to be scrubbed from the cassette:\n%s", secret, data)
+		}
+	}
+
+	// Replay with the server gone
+	base := server.URL
+	server.Close()
+	cfg = config.DefaultConfig()
+	cfg.RetryCount = 3
+	cfg.RecorderPath = cassette
+	cfg.RecorderMode = types.RecorderReplay
+	replay := NewHTTPClient(cfg)
+
+	_, listed, info := session(replay, base)
+	if info != "UTD41X000001 XT1144" {
+		t.Errorf("Expected the operation's serial back in the replayed response, got %q", info)
+	}
+	if listed != scrubbedSerial("UTD41X000002") {
+		t.Errorf("Expected other serials to stay scrubbed, got %q", listed)
+	}
+
+	err = replay.Get(context.Background(), base+"/unknown", nil)
+	if !errors.Is(err, errNotRecorded) {
+		t.Errorf("Expected a request missing from the cassette to fail, got %v", err)
+	}
+}
+
+func TestReadCassette_Invalid(t *testing.T) {
+	cassette := filepath.Join(t.TempDir(), "bad.yaml")
+	os.WriteFile(cassette, []byte("interactions:\n  - method: \"GET\"\n    colour: blue\n"), 0600)
+	if _, err := readCassette(cassette); err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("Expected an error on line 3, got %v", err)
+	}
+}
+
+func TestRecorder_WriteFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	// The cassette's directory does not exist
+	var logged bytes.Buffer
+	cassette := filepath.Join(t.TempDir(), "missing", "session.yaml")
+	recorder := newRecorder(cassette, types.RecorderRecord, http.DefaultTransport, slog.New(slog.NewTextHandler(&logged, nil)))
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/status", nil)
+	resp, err := recorder.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("Expected the response despite the cassette write failing, got %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != `{"ok":true}` {
+		t.Errorf("Expected the live response body, got %q", body)
+	}
+	if !strings.Contains(logged.String(), "failed to write cassette") {
+		t.Errorf("Expected the write failure to be logged, got %q", logged.String())
+	}
+}
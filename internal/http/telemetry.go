@@ -0,0 +1,177 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/brightdevelopers/gopurple/internal/config"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	metricnoop "go.opentelemetry.io/otel/metric/noop"
+	"go.opentelemetry.io/otel/trace"
+	tracenoop "go.opentelemetry.io/otel/trace/noop"
+)
+
+// InstrumentationName names the tracer and meter of the SDK's spans and metrics.
+const InstrumentationName = "github.com/brightdevelopers/gopurple"
+
+// telemetry holds the OpenTelemetry tracer and instruments. A client without
+// providers has none, so requests pay nothing for it.
+type telemetry struct {
+	tracer      trace.Tracer
+	requests    metric.Int64Counter
+	duration    metric.Float64Histogram
+	retries     metric.Int64Counter
+	rateLimited metric.Int64Counter
+}
+
+// newTelemetry returns the telemetry for the configured providers, or nil if
+// neither is set.
+func newTelemetry(cfg *config.Config) *telemetry {
+	if cfg.TracerProvider == nil && cfg.MeterProvider == nil {
+		return nil
+	}
+
+	tracerProvider := cfg.TracerProvider
+	if tracerProvider == nil {
+		tracerProvider = tracenoop.NewTracerProvider()
+	}
+	meterProvider := cfg.MeterProvider
+	if meterProvider == nil {
+		meterProvider = metricnoop.NewMeterProvider()
+	}
+	meter := meterProvider.Meter(InstrumentationName)
+
+	// An instrument that fails to register comes back as a no-op one, so the
+	// errors can be ignored
+	t := &telemetry{tracer: tracerProvider.Tracer(InstrumentationName)}
+	t.requests, _ = meter.Int64Counter("gopurple.requests",
+		metric.WithDescription("HTTP request attempts"),
+		metric.WithUnit("{request}"))
+	t.duration, _ = meter.Float64Histogram("gopurple.request.duration",
+		metric.WithDescription("Duration of HTTP request attempts"),
+		metric.WithUnit("s"))
+	t.retries, _ = meter.Int64Counter("gopurple.retries",
+		metric.WithDescription("HTTP request attempts that retried a failed one"),
+		metric.WithUnit("{request}"))
+	t.rateLimited, _ = meter.Int64Counter("gopurple.rate_limited",
+		metric.WithDescription("HTTP responses with status 429 Too Many Requests"),
+		metric.WithUnit("{response}"))
+	return t
+}
+
+// operationAttributes returns the attributes naming the operation ctx is for.
+func operationAttributes(ctx context.Context) []attribute.KeyValue {
+	op, ok := OperationFrom(ctx)
+	if !ok {
+		return nil
+	}
+	return []attribute.KeyValue{
+		attribute.String("gopurple.service", op.Service()),
+		attribute.String("gopurple.operation", op.Name),
+	}
+}
+
+type operationSpanKey struct{}
+
+// StartOperation names the operation for the requests made with the returned
+// context, as WithOperation does, and starts the operation's span. The spans of
+// those requests are its children, so an operation that makes several requests
+// is one trace. The returned function ends the span, recording err.
+func (h *HTTPClient) StartOperation(ctx context.Context, op Operation) (context.Context, func(err error)) {
+	ctx = WithOperation(ctx, op)
+	if h.telemetry == nil {
+		return ctx, func(error) {}
+	}
+	ctx, end := h.telemetry.startSpan(ctx, op.Name, op)
+	return context.WithValue(ctx, operationSpanKey{}, op), end
+}
+
+// startRequest starts the span of a request, covering all its attempts. It is
+// named after the method under the span of the operation ctx is for, or after
+// the operation if it has no span, as for the auth manager's requests.
+func (h *HTTPClient) startRequest(ctx context.Context, method string) (context.Context, func(err error)) {
+	if h.telemetry == nil {
+		return ctx, func(error) {}
+	}
+
+	name := method
+	op, ok := OperationFrom(ctx)
+	if spanned, _ := ctx.Value(operationSpanKey{}).(Operation); ok && spanned != op {
+		name = op.Name
+	}
+	return h.telemetry.startSpan(ctx, name, op)
+}
+
+// startSpan starts a span with the attributes of op, the operation ctx is for.
+// The returned function ends it, recording err.
+func (t *telemetry) startSpan(ctx context.Context, name string, op Operation) (context.Context, func(err error)) {
+	attrs := operationAttributes(ctx)
+	if op.Serial != "" {
+		attrs = append(attrs, attribute.String("gopurple.serial", op.Serial))
+	}
+	if op.Network != "" {
+		attrs = append(attrs, attribute.String("gopurple.network", op.Network))
+	}
+
+	ctx, span := t.tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+	return ctx, func(err error) {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+}
+
+// telemetryTransport records a client span and metrics for every request attempt.
+type telemetryTransport struct {
+	next      http.RoundTripper
+	telemetry *telemetry
+}
+
+func (t *telemetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	attempt, _ := ctx.Value(attemptKey{}).(int)
+	opAttrs := operationAttributes(ctx)
+
+	ctx, span := t.telemetry.tracer.Start(ctx, req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", req.Method),
+			attribute.String("server.address", req.URL.Hostname()),
+			attribute.String("url.path", req.URL.Path),
+		))
+	defer span.End()
+	if attempt > 1 {
+		span.SetAttributes(attribute.Int("http.request.resend_count", attempt-1))
+		t.telemetry.retries.Add(ctx, 1, metric.WithAttributes(opAttrs...))
+	}
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	duration := time.Since(start)
+
+	attrs := append(opAttrs, attribute.String("http.request.method", req.Method))
+	switch {
+	case err != nil:
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		attrs = append(attrs, attribute.String("error.type", "transport"))
+	default:
+		span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+		if resp.StatusCode >= 400 {
+			span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+		}
+		if resp.StatusCode == http.StatusTooManyRequests {
+			t.telemetry.rateLimited.Add(ctx, 1, metric.WithAttributes(opAttrs...))
+		}
+		attrs = append(attrs, attribute.Int("http.response.status_code", resp.StatusCode))
+	}
+	t.telemetry.requests.Add(ctx, 1, metric.WithAttributes(attrs...))
+	t.telemetry.duration.Record(ctx, duration.Seconds(), metric.WithAttributes(attrs...))
+
+	return resp, err
+}
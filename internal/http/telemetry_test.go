@@ -0,0 +1,126 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/brightdevelopers/gopurple/internal/config"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTelemetry(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts++; attempts == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	spans := tracetest.NewInMemoryExporter()
+	reader := sdkmetric.NewManualReader()
+	cfg := config.DefaultConfig()
+	cfg.RetryCount = 1
+	cfg.TracerProvider = sdktrace.NewTracerProvider(sdktrace.WithSyncer(spans))
+	cfg.MeterProvider = sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	client := NewHTTPClient(cfg)
+
+	ctx := WithOperation(context.Background(), Operation{Name: "RDWS.GetHealth", Serial: "UTD41X000001"})
+	if err := client.Get(ctx, server.URL+"/rest/v1/health", nil); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+
+	// Two attempt spans under one operation span
+	ended := spans.GetSpans()
+	if len(ended) != 3 {
+		t.Fatalf("Expected 3 spans, got %d", len(ended))
+	}
+	operation := ended[2]
+	if operation.Name != "RDWS.GetHealth" {
+		t.Errorf("Expected the operation span last, got %q", operation.Name)
+	}
+	for _, attempt := range ended[:2] {
+		if attempt.Name != "GET" || attempt.Parent.SpanID() != operation.SpanContext.SpanID() {
+			t.Errorf("Expected a GET attempt span under the operation span, got %q", attempt.Name)
+		}
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("Collect failed: %v", err)
+	}
+	sums := map[string]int64{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			data, ok := m.Data.(metricdata.Sum[int64])
+			if !ok {
+				continue
+			}
+			for _, point := range data.DataPoints {
+				if operation, _ := point.Attributes.Value(attribute.Key("gopurple.operation")); operation.AsString() != "RDWS.GetHealth" {
+					t.Errorf("Expected %s to be labelled with the operation, got %v", m.Name, point.Attributes)
+				}
+				sums[m.Name] += point.Value
+			}
+		}
+	}
+	want := map[string]int64{"gopurple.requests": 2, "gopurple.retries": 1, "gopurple.rate_limited": 1}
+	for name, value := range want {
+		if sums[name] != value {
+			t.Errorf("Expected %s %d, got %d", name, value, sums[name])
+		}
+	}
+}
+
+func TestTelemetry_StartOperation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	spans := tracetest.NewInMemoryExporter()
+	cfg := config.DefaultConfig()
+	cfg.TracerProvider = sdktrace.NewTracerProvider(sdktrace.WithSyncer(spans))
+	client := NewHTTPClient(cfg)
+
+	ctx, end := client.StartOperation(context.Background(), Operation{Name: "RDWS.CapturePackets", Serial: "UTD41X000001"})
+	for i := 0; i < 2; i++ {
+		if err := client.Get(ctx, server.URL+"/rest/v1/capture", nil); err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+	}
+	end(nil)
+
+	// Two request spans, each with an attempt span, under one operation span
+	ended := spans.GetSpans()
+	if len(ended) != 5 {
+		t.Fatalf("Expected 5 spans, got %d", len(ended))
+	}
+	operation := ended[4]
+	if operation.Name != "RDWS.CapturePackets" || operation.Parent.IsValid() {
+		t.Errorf("Expected the operation span last and a root, got %q", operation.Name)
+	}
+	for _, request := range []int{1, 3} {
+		if ended[request].Name != "GET" || ended[request].Parent.SpanID() != operation.SpanContext.SpanID() {
+			t.Errorf("Expected a GET request span under the operation span, got %q", ended[request].Name)
+		}
+	}
+}
+
+func TestTelemetry_Unset(t *testing.T) {
+	client := NewHTTPClient(config.DefaultConfig())
+	if client.telemetry != nil {
+		t.Error("Expected no telemetry without providers")
+	}
+	if _, ok := client.GetClient().GetClient().Transport.(*telemetryTransport); ok {
+		t.Error("Expected the transport to be left alone without providers")
+	}
+}
@@ -0,0 +1,264 @@
+// Package ledger records which B-Deploy setup record each player was associated
+// with. The B-Deploy device API accepts a setupId when a device is updated but
+// never returns it, so the ledger is the only durable record of an association
+// made through the SDK.
+package ledger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// fileVersion is the format version written to ledger files.
+	fileVersion = 1
+
+	// defaultFileName is the ledger file name inside the user's config directory.
+	defaultFileName = "bdeploy-associations.json"
+)
+
+// Entry is the setup record a player was last associated with.
+type Entry struct {
+	NetworkName string    `json:"networkName"`
+	Serial      string    `json:"serial"`
+	DeviceID    string    `json:"deviceId,omitempty"`
+	SetupID     string    `json:"setupId"`
+	SetupName   string    `json:"setupName,omitempty"` // Package name of the setup record, if known
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// Store persists ledger entries, keyed by network and serial. Implementations
+// must be safe for concurrent use.
+type Store interface {
+	// Get returns the entry for a player and whether there is one.
+	Get(ctx context.Context, networkName, serial string) (Entry, bool, error)
+
+	// List returns every entry in a network, or in all networks if networkName is
+	// empty, sorted by network and serial.
+	List(ctx context.Context, networkName string) ([]Entry, error)
+
+	// Put adds or replaces the entry for the player.
+	Put(ctx context.Context, entry Entry) error
+
+	// Delete removes the entry for a player. Deleting a missing entry is not an error.
+	Delete(ctx context.Context, networkName, serial string) error
+}
+
+// DefaultPath returns the default ledger file, bdeploy-associations.json in the
+// gopurple directory of the user's config directory (e.g. ~/.config/gopurple).
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gopurple", defaultFileName), nil
+}
+
+// MemoryStore keeps entries in memory. It is useful in tests and for programs
+// that persist associations themselves.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[entryKey]Entry
+}
+
+type entryKey struct {
+	network string
+	serial  string
+}
+
+// NewMemoryStore returns an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: map[entryKey]Entry{}}
+}
+
+// Get returns the entry for a player.
+func (s *MemoryStore) Get(ctx context.Context, networkName, serial string) (Entry, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.entries[entryKey{networkName, serial}]
+	return entry, ok, nil
+}
+
+// List returns the entries in a network, or all entries if networkName is empty.
+func (s *MemoryStore) List(ctx context.Context, networkName string) ([]Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return filterEntries(s.entries, networkName), nil
+}
+
+// Put adds or replaces the entry for the player.
+func (s *MemoryStore) Put(ctx context.Context, entry Entry) error {
+	if err := checkEntry(entry); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[entryKey{entry.NetworkName, entry.Serial}] = entry
+	return nil
+}
+
+// Delete removes the entry for a player.
+func (s *MemoryStore) Delete(ctx context.Context, networkName, serial string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, entryKey{networkName, serial})
+	return nil
+}
+
+// FileStore keeps entries in a JSON file. The file is read on every call and
+// replaced atomically on every change, so several processes can share it as
+// long as they do not write at the same moment.
+type FileStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// ledgerFile is the on-disk format of a FileStore.
+type ledgerFile struct {
+	Version int     `json:"version"`
+	Entries []Entry `json:"entries"`
+}
+
+// NewFileStore returns a store backed by the JSON file at path. The file and its
+// directory are created on the first change.
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+// Path returns the ledger file.
+func (s *FileStore) Path() string {
+	return s.path
+}
+
+// Get returns the entry for a player.
+func (s *FileStore) Get(ctx context.Context, networkName, serial string) (Entry, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries, err := s.load()
+	if err != nil {
+		return Entry{}, false, err
+	}
+	entry, ok := entries[entryKey{networkName, serial}]
+	return entry, ok, nil
+}
+
+// List returns the entries in a network, or all entries if networkName is empty.
+func (s *FileStore) List(ctx context.Context, networkName string) ([]Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	return filterEntries(entries, networkName), nil
+}
+
+// Put adds or replaces the entry for the player.
+func (s *FileStore) Put(ctx context.Context, entry Entry) error {
+	if err := checkEntry(entry); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries, err := s.load()
+	if err != nil {
+		return err
+	}
+	entries[entryKey{entry.NetworkName, entry.Serial}] = entry
+	return s.save(entries)
+}
+
+// Delete removes the entry for a player.
+func (s *FileStore) Delete(ctx context.Context, networkName, serial string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries, err := s.load()
+	if err != nil {
+		return err
+	}
+	key := entryKey{networkName, serial}
+	if _, ok := entries[key]; !ok {
+		return nil
+	}
+	delete(entries, key)
+	return s.save(entries)
+}
+
+func (s *FileStore) load() (map[entryKey]Entry, error) {
+	entries := map[entryKey]Entry{}
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return entries, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var file ledgerFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse association ledger %s: %w", s.path, err)
+	}
+	if file.Version > fileVersion {
+		return nil, fmt.Errorf("association ledger %s has unsupported version %d", s.path, file.Version)
+	}
+	for _, entry := range file.Entries {
+		entries[entryKey{entry.NetworkName, entry.Serial}] = entry
+	}
+	return entries, nil
+}
+
+func (s *FileStore) save(entries map[entryKey]Entry) error {
+	data, err := json.MarshalIndent(ledgerFile{Version: fileVersion, Entries: filterEntries(entries, "")}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(s.path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, ".ledger-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+func checkEntry(entry Entry) error {
+	if entry.NetworkName == "" || entry.Serial == "" {
+		return fmt.Errorf("ledger entry needs a network name and serial")
+	}
+	return nil
+}
+
+// filterEntries returns the entries in a network, or all of them, sorted.
+func filterEntries(entries map[entryKey]Entry, networkName string) []Entry {
+	result := []Entry{}
+	for key, entry := range entries {
+		if networkName == "" || key.network == networkName {
+			result = append(result, entry)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].NetworkName != result[j].NetworkName {
+			return result[i].NetworkName < result[j].NetworkName
+		}
+		return result[i].Serial < result[j].Serial
+	})
+	return result
+}
@@ -0,0 +1,83 @@
+package ledger
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func testStore(t *testing.T, store Store) {
+	t.Helper()
+	ctx := context.Background()
+
+	if _, ok, err := store.Get(ctx, "Retail", "XD001"); ok || err != nil {
+		t.Fatalf("Expected empty store, got ok=%v err=%v", ok, err)
+	}
+
+	entries := []Entry{
+		{NetworkName: "Retail", Serial: "XD002", SetupID: "setup-2", SetupName: "store-2"},
+		{NetworkName: "Retail", Serial: "XD001", SetupID: "setup-1", UpdatedAt: time.Now().UTC()},
+		{NetworkName: "Lab", Serial: "XD001", SetupID: "setup-9"},
+	}
+	for _, entry := range entries {
+		if err := store.Put(ctx, entry); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+	}
+	if err := store.Put(ctx, Entry{Serial: "XD003"}); err == nil {
+		t.Error("Expected error for entry without network")
+	}
+
+	entry, ok, err := store.Get(ctx, "Retail", "XD002")
+	if err != nil || !ok || entry.SetupName != "store-2" {
+		t.Errorf("Unexpected entry %+v (ok=%v, err=%v)", entry, ok, err)
+	}
+
+	list, err := store.List(ctx, "Retail")
+	if err != nil || len(list) != 2 || list[0].Serial != "XD001" {
+		t.Errorf("Expected sorted Retail entries, got %+v (%v)", list, err)
+	}
+	if all, _ := store.List(ctx, ""); len(all) != 3 || all[0].NetworkName != "Lab" {
+		t.Errorf("Expected all entries sorted by network, got %+v", all)
+	}
+
+	// Put replaces the entry for the same player
+	store.Put(ctx, Entry{NetworkName: "Retail", Serial: "XD001", SetupID: "setup-3"})
+	if entry, _, _ := store.Get(ctx, "Retail", "XD001"); entry.SetupID != "setup-3" {
+		t.Errorf("Expected replaced entry, got %+v", entry)
+	}
+
+	if err := store.Delete(ctx, "Retail", "XD001"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := store.Delete(ctx, "Retail", "XD404"); err != nil {
+		t.Errorf("Expected deleting a missing entry to succeed, got %v", err)
+	}
+	if _, ok, _ := store.Get(ctx, "Retail", "XD001"); ok {
+		t.Error("Expected entry to be deleted")
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore())
+}
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "ledger.json")
+	testStore(t, NewFileStore(path))
+
+	// A second store on the same file sees the same entries
+	list, err := NewFileStore(path).List(context.Background(), "")
+	if err != nil || len(list) != 2 {
+		t.Errorf("Expected entries to persist, got %+v (%v)", list, err)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"version": 99, "entries": []}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewFileStore(path).List(context.Background(), ""); err == nil {
+		t.Error("Expected error for unsupported ledger version")
+	}
+}
@@ -0,0 +1,218 @@
+package logs
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/brightdevelopers/gopurple/internal/errors"
+	"github.com/brightdevelopers/gopurple/internal/types"
+)
+
+const (
+	// defaultCollectConcurrency is how many players are fetched at once.
+	defaultCollectConcurrency = 4
+
+	// manifestName is the archive entry describing the collection.
+	manifestName = "manifest.json"
+)
+
+// Client is the subset of the rDWS service used to fetch logs.
+type Client interface {
+	GetLogs(ctx context.Context, serial string) (*types.RDWSLogs, error)
+	GetCrashDump(ctx context.Context, serial string) (*types.RDWSCrashDump, error)
+}
+
+// CollectOptions controls what Collect fetches from each player.
+type CollectOptions struct {
+	CrashDumps  bool // Also fetch crash dumps
+	Concurrency int  // Players fetched in parallel (default: 4)
+}
+
+// PlayerResult describes what was collected from one player.
+type PlayerResult struct {
+	Serial     string   `json:"serial"`
+	Logs       []string `json:"logs,omitempty"`       // Archive paths of the log files
+	CrashDumps []string `json:"crashDumps,omitempty"` // Archive paths of the crash dumps
+	Error      string   `json:"error,omitempty"`      // Why the logs could not be fetched
+}
+
+// Manifest is stored in every archive as manifest.json and returned by Collect.
+type Manifest struct {
+	CollectedAt time.Time      `json:"collectedAt"`
+	Players     []PlayerResult `json:"players"`
+}
+
+// Failed returns the players whose logs could not be fetched.
+func (m *Manifest) Failed() []PlayerResult {
+	var failed []PlayerResult
+	for _, player := range m.Players {
+		if player.Error != "" {
+			failed = append(failed, player)
+		}
+	}
+	return failed
+}
+
+// ArchiveName returns the conventional file name for an archive collected at t.
+func ArchiveName(t time.Time) string {
+	return fmt.Sprintf("bs-logs-%s.tar.gz", t.UTC().Format("20060102T150405Z"))
+}
+
+// fetched holds the files retrieved from one player before they are archived.
+type fetched struct {
+	serial string
+	logs   []types.RDWSLogFile
+	dumps  []types.RDWSCrashDumpFile
+	err    error
+}
+
+// Collect fetches logs (and optionally crash dumps) from every player and writes them
+// to w as a gzip-compressed tar archive laid out as <serial>/logs/<name> and
+// <serial>/crashdumps/<name>, alongside a manifest.json. A player that cannot be
+// reached is recorded in the manifest rather than failing the whole collection.
+func Collect(ctx context.Context, client Client, serials []string, w io.Writer, opts CollectOptions) (*Manifest, error) {
+	if len(serials) == 0 {
+		return nil, errors.NewValidationError("serials", serials, "at least one device serial is required")
+	}
+	for _, serial := range serials {
+		if serial == "" {
+			return nil, errors.NewValidationError("serials", serials, "device serial cannot be empty")
+		}
+	}
+
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultCollectConcurrency
+	}
+
+	collectedAt := time.Now().UTC().Truncate(time.Second)
+
+	// Fetch from all players, a few at a time
+	results := make([]fetched, len(serials))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, serial := range serials {
+		wg.Add(1)
+		go func(i int, serial string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			results[i] = fetchPlayer(ctx, client, serial, opts.CrashDumps)
+		}(i, serial)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].serial < results[j].serial
+	})
+
+	// Write the archive
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	manifest := &Manifest{CollectedAt: collectedAt}
+	for _, result := range results {
+		player := PlayerResult{Serial: result.serial}
+		if result.err != nil {
+			player.Error = result.err.Error()
+		}
+
+		for _, file := range result.logs {
+			name := path.Join(result.serial, "logs", archiveFileName(file.Name))
+			if err := writeEntry(tw, name, []byte(file.Content), collectedAt); err != nil {
+				return nil, err
+			}
+			player.Logs = append(player.Logs, name)
+		}
+
+		for _, file := range result.dumps {
+			modified := collectedAt
+			if t, err := time.Parse(time.RFC3339, file.Timestamp); err == nil {
+				modified = t
+			}
+			name := path.Join(result.serial, "crashdumps", archiveFileName(file.Name))
+			if err := writeEntry(tw, name, []byte(file.Content), modified); err != nil {
+				return nil, err
+			}
+			player.CrashDumps = append(player.CrashDumps, name)
+		}
+
+		manifest.Players = append(manifest.Players, player)
+	}
+
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := writeEntry(tw, manifestName, manifestData, collectedAt); err != nil {
+		return nil, err
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+func fetchPlayer(ctx context.Context, client Client, serial string, crashDumps bool) fetched {
+	result := fetched{serial: serial}
+
+	logs, err := client.GetLogs(ctx, serial)
+	if err != nil {
+		result.err = err
+		return result
+	}
+	result.logs = logs.Files
+
+	if crashDumps {
+		dumps, err := client.GetCrashDump(ctx, serial)
+		if err != nil {
+			result.err = err
+			return result
+		}
+		result.dumps = dumps.Files
+	}
+
+	return result
+}
+
+// archiveFileName reduces a player-supplied file name to a single safe path element.
+func archiveFileName(name string) string {
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	if name == "." || name == "/" || name == ".." || name == "" {
+		return "unnamed"
+	}
+	return name
+}
+
+func writeEntry(tw *tar.Writer, name string, data []byte, modified time.Time) error {
+	header := &tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: modified,
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
@@ -0,0 +1,222 @@
+package logs
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	stderrors "errors"
+	"io"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brightdevelopers/gopurple/internal/types"
+)
+
+// fakeLogClient returns canned logs per serial.
+type fakeLogClient struct {
+	logs  map[string][]types.RDWSLogFile
+	dumps map[string][]types.RDWSCrashDumpFile
+}
+
+func (f *fakeLogClient) GetLogs(ctx context.Context, serial string) (*types.RDWSLogs, error) {
+	files, ok := f.logs[serial]
+	if !ok {
+		return nil, stderrors.New("player offline")
+	}
+	return &types.RDWSLogs{Files: files}, nil
+}
+
+func (f *fakeLogClient) GetCrashDump(ctx context.Context, serial string) (*types.RDWSCrashDump, error) {
+	return &types.RDWSCrashDump{Files: f.dumps[serial]}, nil
+}
+
+const testSyslog = `Oct 18 10:15:00 brightsign-A1 user.info brightsign[812]: Presentation started
+Oct 18 10:15:02 brightsign-A1 kern.warning kernel: eth0: link down
+Oct 18 10:15:03 brightsign-A1 user.err autorun[900]: Script runtime error
+   backtrace: #0 Main() autorun.brs(42)
+Oct 18 10:15:09 brightsign-A1 daemon.notice ntpd[77]: time synchronized
+`
+
+func newFakeLogClient() *fakeLogClient {
+	return &fakeLogClient{
+		logs: map[string][]types.RDWSLogFile{
+			"A1": {{Name: "syslog", Content: testSyslog}},
+			"B2": {{Name: "/var/log/syslog", Content: "Oct 18 09:00:00 brightsign-B2 user.err autorun[1]: Script runtime error\n"}},
+		},
+		dumps: map[string][]types.RDWSCrashDumpFile{
+			"A1": {{Name: "crash-1.txt", Timestamp: "2026-10-17T08:00:00Z", Content: "dump"}},
+		},
+	}
+}
+
+func archiveEntries(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("archive is not gzip: %v", err)
+	}
+	entries := map[string]string{}
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("archive is not tar: %v", err)
+		}
+		contents, _ := io.ReadAll(tr)
+		entries[header.Name] = string(contents)
+	}
+	return entries
+}
+
+func TestCollect(t *testing.T) {
+	var buf bytes.Buffer
+	manifest, err := Collect(context.Background(), newFakeLogClient(), []string{"B2", "A1", "C3"}, &buf, CollectOptions{CrashDumps: true, Concurrency: 2})
+	if err != nil {
+		t.Fatalf("Collect returned error: %v", err)
+	}
+
+	var names []string
+	for name := range archiveEntries(t, buf.Bytes()) {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	expected := []string{"A1/crashdumps/crash-1.txt", "A1/logs/syslog", "B2/logs/syslog", "manifest.json"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected archive entries %v, got %v", expected, names)
+	}
+
+	if len(manifest.Players) != 3 || manifest.Players[0].Serial != "A1" {
+		t.Errorf("Expected players sorted by serial, got %+v", manifest.Players)
+	}
+	failed := manifest.Failed()
+	if len(failed) != 1 || failed[0].Serial != "C3" {
+		t.Errorf("Expected C3 to be recorded as failed, got %+v", failed)
+	}
+}
+
+func TestCollect_Validation(t *testing.T) {
+	var buf bytes.Buffer
+	if _, err := Collect(context.Background(), newFakeLogClient(), nil, &buf, CollectOptions{}); err == nil {
+		t.Error("Expected error when collecting from no players")
+	}
+	if _, err := Collect(context.Background(), newFakeLogClient(), []string{"A1", ""}, &buf, CollectOptions{}); err == nil {
+		t.Error("Expected error when collecting with an empty serial")
+	}
+}
+
+func TestParseLine(t *testing.T) {
+	ref := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		line     string
+		expected LogEntry
+	}{
+		{
+			"Oct 18 10:15:02 brightsign-A1 kern.warning kernel: eth0: link down",
+			LogEntry{Time: time.Date(2026, 10, 18, 10, 15, 2, 0, time.UTC), Host: "brightsign-A1", Facility: "kern", Severity: SeverityWarning, Tag: "kernel", Message: "eth0: link down"},
+		},
+		{
+			"<11>Oct  3 08:00:00 player.local autorun[12]: failed",
+			LogEntry{Time: time.Date(2026, 10, 3, 8, 0, 0, 0, time.UTC), Host: "player.local", Facility: "user", Severity: SeverityError, Tag: "autorun[12]", Message: "failed"},
+		},
+		{
+			"Dec 31 23:59:59 kernel: year boundary",
+			LogEntry{Time: time.Date(2025, 12, 31, 23, 59, 59, 0, time.UTC), Severity: SeverityUnknown, Tag: "kernel", Message: "year boundary"},
+		},
+		{
+			"2026-10-18T10:15:00.5Z brightsign-A1 daemon.info ntpd: ok",
+			LogEntry{Time: time.Date(2026, 10, 18, 10, 15, 0, 5e8, time.UTC), Host: "brightsign-A1", Facility: "daemon", Severity: SeverityInfo, Tag: "ntpd", Message: "ok"},
+		},
+	}
+	for _, c := range cases {
+		entry, ok := ParseLine(c.line, ref)
+		if !ok {
+			t.Errorf("Expected %q to parse", c.line)
+			continue
+		}
+		if !reflect.DeepEqual(entry, c.expected) {
+			t.Errorf("ParseLine(%q):\n got  %+v\n want %+v", c.line, entry, c.expected)
+		}
+	}
+
+	if entry, ok := ParseLine("   backtrace: #0", ref); ok || entry.Message != "   backtrace: #0" {
+		t.Errorf("Expected line without timestamp not to parse, got %+v", entry)
+	}
+}
+
+func TestParse_Continuations(t *testing.T) {
+	entries, err := Parse(strings.NewReader(testSyslog), time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	if len(entries) != 4 {
+		t.Fatalf("Expected 4 entries, got %d", len(entries))
+	}
+	if !strings.Contains(entries[2].Message, "autorun.brs(42)") {
+		t.Errorf("Expected backtrace to be joined to the previous entry, got %q", entries[2].Message)
+	}
+	if entries[3].Line != 5 {
+		t.Errorf("Expected line numbers to count continuation lines, got %d", entries[3].Line)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	var buf bytes.Buffer
+	if _, err := Collect(context.Background(), newFakeLogClient(), []string{"A1", "B2"}, &buf, CollectOptions{}); err != nil {
+		t.Fatalf("Collect returned error: %v", err)
+	}
+	archive := buf.Bytes()
+
+	warning := SeverityWarning
+	matches, err := Search(bytes.NewReader(archive), Query{MaxSeverity: &warning})
+	if err != nil {
+		t.Fatalf("Search returned error: %v", err)
+	}
+	var got []string
+	for _, entry := range matches {
+		got = append(got, entry.Serial+":"+entry.Severity.String())
+	}
+	expected := []string{"A1:warning", "A1:err", "B2:err"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected severity matches %v, got %v", expected, got)
+	}
+
+	matches, err = Search(bytes.NewReader(archive), Query{Pattern: regexp.MustCompile(`runtime error`), Serial: "b2"})
+	if err != nil {
+		t.Fatalf("Search returned error: %v", err)
+	}
+	if len(matches) != 1 || matches[0].Serial != "B2" || matches[0].File != "syslog" {
+		t.Errorf("Expected one regex match from B2, got %+v", matches)
+	}
+
+	if _, err := Search(strings.NewReader("not an archive"), Query{}); err == nil {
+		t.Error("Expected error searching something that is not an archive")
+	}
+}
+
+func TestParseSeverity(t *testing.T) {
+	cases := map[string]Severity{"warning": SeverityWarning, "WARN": SeverityWarning, "err": SeverityError, "error": SeverityError, "2": SeverityCritical}
+	for name, expected := range cases {
+		if severity, err := ParseSeverity(name); err != nil || severity != expected {
+			t.Errorf("ParseSeverity(%q) = %v, %v; expected %v", name, severity, err, expected)
+		}
+	}
+	if _, err := ParseSeverity("loud"); err == nil {
+		t.Error("Expected error for unknown severity")
+	}
+}
+
+func TestArchiveName(t *testing.T) {
+	name := ArchiveName(time.Date(2026, 10, 18, 10, 15, 0, 0, time.UTC))
+	if name != "bs-logs-20261018T101500Z.tar.gz" {
+		t.Errorf("Unexpected archive name %q", name)
+	}
+}
@@ -0,0 +1,236 @@
+package logs
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Severity is a syslog severity level. Lower values are more severe.
+type Severity int
+
+const (
+	SeverityUnknown   Severity = -1
+	SeverityEmergency Severity = 0
+	SeverityAlert     Severity = 1
+	SeverityCritical  Severity = 2
+	SeverityError     Severity = 3
+	SeverityWarning   Severity = 4
+	SeverityNotice    Severity = 5
+	SeverityInfo      Severity = 6
+	SeverityDebug     Severity = 7
+)
+
+var severityNames = []string{"emerg", "alert", "crit", "err", "warning", "notice", "info", "debug"}
+
+// severityAliases maps alternative spellings used by syslog implementations.
+var severityAliases = map[string]Severity{
+	"panic": SeverityEmergency, "emergency": SeverityEmergency,
+	"critical": SeverityCritical,
+	"error":    SeverityError,
+	"warn":     SeverityWarning,
+}
+
+func (s Severity) String() string {
+	if s < 0 || int(s) >= len(severityNames) {
+		return "unknown"
+	}
+	return severityNames[s]
+}
+
+// MarshalText encodes the severity by name.
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText decodes a severity name.
+func (s *Severity) UnmarshalText(text []byte) error {
+	if string(text) == "unknown" {
+		*s = SeverityUnknown
+		return nil
+	}
+	severity, err := ParseSeverity(string(text))
+	if err != nil {
+		return err
+	}
+	*s = severity
+	return nil
+}
+
+// ParseSeverity converts a severity name (e.g. "warning", "err", "crit") or number (0-7).
+func ParseSeverity(name string) (Severity, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for i, known := range severityNames {
+		if name == known {
+			return Severity(i), nil
+		}
+	}
+	if severity, ok := severityAliases[name]; ok {
+		return severity, nil
+	}
+	if n, err := strconv.Atoi(name); err == nil && n >= 0 && n < len(severityNames) {
+		return Severity(n), nil
+	}
+	return SeverityUnknown, fmt.Errorf("unknown severity %q", name)
+}
+
+var facilityNames = []string{
+	"kern", "user", "mail", "daemon", "auth", "syslog", "lpr", "news",
+	"uucp", "cron", "authpriv", "ftp", "ntp", "security", "console", "solaris-cron",
+	"local0", "local1", "local2", "local3", "local4", "local5", "local6", "local7",
+}
+
+func isFacility(name string) bool {
+	for _, known := range facilityNames {
+		if name == known {
+			return true
+		}
+	}
+	return false
+}
+
+// LogEntry is a single parsed syslog line.
+type LogEntry struct {
+	Serial   string    `json:"serial,omitempty"` // Player the entry came from (set when searching an archive)
+	File     string    `json:"file,omitempty"`   // Log file the entry came from (set when searching an archive)
+	Line     int       `json:"line,omitempty"`   // 1-based line number within File
+	Time     time.Time `json:"time,omitempty"`
+	Host     string    `json:"host,omitempty"`
+	Facility string    `json:"facility,omitempty"`
+	Severity Severity  `json:"severity"`
+	Tag      string    `json:"tag,omitempty"` // Program name, e.g. "kernel" or "brightsign[123]"
+	Message  string    `json:"message"`
+}
+
+var (
+	// <PRI> prefix written by syslog senders
+	priorityPattern = regexp.MustCompile(`^<(\d{1,3})>`)
+
+	// "Oct 18 10:15:00" (RFC 3164) with optional fractional seconds
+	bsdTimePattern = regexp.MustCompile(`^([A-Z][a-z]{2} [ \d]\d \d{2}:\d{2}:\d{2}(?:\.\d+)?) `)
+
+	// "2026-10-18T10:15:00.123Z" (RFC 3339 / RFC 5424)
+	isoTimePattern = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(?:\.\d+)?(?:Z|[+-]\d{2}:\d{2})) `)
+
+	// "facility.severity" token written by busybox syslogd
+	levelPattern = regexp.MustCompile(`^([a-z0-9-]+)\.([a-z]+) `)
+
+	// "program[pid]: " tag
+	tagPattern = regexp.MustCompile(`^([^\s:\[]+(?:\[\d+\])?): `)
+)
+
+// hasLevel reports whether s starts with a "facility.severity" token.
+func hasLevel(s string) bool {
+	m := levelPattern.FindStringSubmatch(s)
+	if m == nil || !isFacility(m[1]) {
+		return false
+	}
+	_, err := ParseSeverity(m[2])
+	return err == nil
+}
+
+// ParseLine parses a BrightSign syslog line. Lines in RFC 3164 format carry no year,
+// so the timestamp is placed in the year before ref when it would otherwise fall
+// after it. ok is false when the line has no recognizable timestamp; the entry then
+// holds the whole line as its message.
+func ParseLine(line string, ref time.Time) (entry LogEntry, ok bool) {
+	entry = LogEntry{Severity: SeverityUnknown}
+	rest := strings.TrimRight(line, "\r\n")
+
+	if m := priorityPattern.FindStringSubmatch(rest); m != nil {
+		pri, _ := strconv.Atoi(m[1])
+		if pri/8 < len(facilityNames) {
+			entry.Facility = facilityNames[pri/8]
+		}
+		entry.Severity = Severity(pri % 8)
+		rest = rest[len(m[0]):]
+	}
+
+	switch {
+	case bsdTimePattern.MatchString(rest):
+		m := bsdTimePattern.FindStringSubmatch(rest)
+		t, err := time.ParseInLocation("Jan _2 15:04:05", m[1], time.UTC)
+		if err != nil {
+			entry.Message = line
+			return entry, false
+		}
+		year := ref.Year()
+		if ref.IsZero() {
+			year = time.Now().Year()
+		}
+		t = t.AddDate(year, 0, 0)
+		if !ref.IsZero() && t.After(ref.Add(24*time.Hour)) {
+			t = t.AddDate(-1, 0, 0)
+		}
+		entry.Time = t
+		rest = rest[len(m[0]):]
+	case isoTimePattern.MatchString(rest):
+		m := isoTimePattern.FindStringSubmatch(rest)
+		t, err := time.Parse(time.RFC3339Nano, m[1])
+		if err != nil {
+			entry.Message = line
+			return entry, false
+		}
+		entry.Time = t
+		rest = rest[len(m[0]):]
+	default:
+		entry.Message = rest
+		return entry, false
+	}
+
+	// Host name, unless the next token is already the facility.severity pair or tag
+	if !hasLevel(rest) && !tagPattern.MatchString(rest) {
+		if host, after, found := strings.Cut(rest, " "); found {
+			entry.Host = host
+			rest = after
+		}
+	}
+
+	if hasLevel(rest) {
+		m := levelPattern.FindStringSubmatch(rest)
+		entry.Facility = m[1]
+		entry.Severity, _ = ParseSeverity(m[2])
+		rest = rest[len(m[0]):]
+	}
+
+	if m := tagPattern.FindStringSubmatch(rest); m != nil {
+		entry.Tag = m[1]
+		rest = rest[len(m[0]):]
+	}
+
+	entry.Message = rest
+	return entry, true
+}
+
+// Parse reads syslog lines from r. Lines without a timestamp are treated as
+// continuations of the previous entry, which keeps multi-line messages such as
+// BrightScript backtraces together.
+func Parse(r io.Reader, ref time.Time) ([]LogEntry, error) {
+	var entries []LogEntry
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		entry, ok := ParseLine(line, ref)
+		if !ok && len(entries) > 0 {
+			last := &entries[len(entries)-1]
+			last.Message += "\n" + entry.Message
+			continue
+		}
+		entry.Line = lineNo
+		entries = append(entries, entry)
+	}
+
+	return entries, scanner.Err()
+}
@@ -0,0 +1,118 @@
+package logs
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Query selects log entries when searching an archive. Zero-valued fields match everything.
+type Query struct {
+	Pattern     *regexp.Regexp // Matched against the entry message and tag
+	MaxSeverity *Severity      // Only entries at least this severe (e.g. SeverityWarning includes errors)
+	Serial      string         // Only entries from this player
+	Since       time.Time      // Only entries at or after this time
+	Until       time.Time      // Only entries before this time
+}
+
+// Matches reports whether the entry satisfies the query.
+func (q Query) Matches(entry LogEntry) bool {
+	if q.Serial != "" && !strings.EqualFold(entry.Serial, q.Serial) {
+		return false
+	}
+	if q.MaxSeverity != nil && (entry.Severity == SeverityUnknown || entry.Severity > *q.MaxSeverity) {
+		return false
+	}
+	if !q.Since.IsZero() && entry.Time.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && !entry.Time.Before(q.Until) {
+		return false
+	}
+	if q.Pattern != nil && !q.Pattern.MatchString(entry.Message) && !q.Pattern.MatchString(entry.Tag) {
+		return false
+	}
+	return true
+}
+
+// Search parses every log file in an archive written by Collect and returns the
+// entries matching q, ordered by player, file and line. Crash dumps are not searched.
+func Search(r io.Reader, q Query) ([]LogEntry, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log archive: %w", err)
+	}
+	defer gz.Close()
+
+	type logFile struct {
+		serial string
+		name   string
+		data   []byte
+	}
+
+	// Read everything first: the manifest, which dates the entries, is written last
+	var files []logFile
+	var manifest Manifest
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read log archive: %w", err)
+		}
+
+		if header.Name == manifestName {
+			if err := json.NewDecoder(tr).Decode(&manifest); err != nil {
+				return nil, fmt.Errorf("failed to read archive manifest: %w", err)
+			}
+			continue
+		}
+
+		parts := strings.Split(header.Name, "/")
+		if len(parts) != 3 || parts[1] != "logs" {
+			continue
+		}
+		if q.Serial != "" && !strings.EqualFold(parts[0], q.Serial) {
+			continue
+		}
+
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", header.Name, err)
+		}
+		files = append(files, logFile{serial: parts[0], name: parts[2], data: data})
+	}
+
+	sort.SliceStable(files, func(i, j int) bool {
+		if files[i].serial != files[j].serial {
+			return files[i].serial < files[j].serial
+		}
+		return files[i].name < files[j].name
+	})
+
+	var matches []LogEntry
+	for _, file := range files {
+		entries, err := Parse(bytes.NewReader(file.data), manifest.CollectedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s/%s: %w", file.serial, file.name, err)
+		}
+		for _, entry := range entries {
+			entry.Serial = file.serial
+			entry.File = file.name
+			if q.Matches(entry) {
+				matches = append(matches, entry)
+			}
+		}
+	}
+
+	return matches, nil
+}
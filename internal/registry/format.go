@@ -0,0 +1,152 @@
+package registry
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/brightdevelopers/gopurple/internal/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// Format is a snapshot document encoding.
+type Format string
+
+const (
+	FormatJSON Format = "json"
+	FormatYAML Format = "yaml"
+)
+
+// FormatForPath picks the document format from a file extension, defaulting to JSON.
+func FormatForPath(filename string) Format {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".yaml", ".yml":
+		return FormatYAML
+	default:
+		return FormatJSON
+	}
+}
+
+// Encode writes the snapshot to w in the given format.
+func (s *Snapshot) Encode(w io.Writer, format Format) error {
+	switch format {
+	case FormatJSON:
+		data, err := json.MarshalIndent(s, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(append(data, '\n'))
+		return err
+	case FormatYAML:
+		return s.encodeYAML(w)
+	default:
+		return errors.NewValidationError("format", format, "format must be json or yaml")
+	}
+}
+
+// Decode reads a snapshot document. Documents without a version are treated as
+// version 1 so desired-state files can be written by hand.
+func Decode(r io.Reader, format Format) (*Snapshot, error) {
+	var snapshot *Snapshot
+	var err error
+
+	switch format {
+	case FormatJSON:
+		snapshot = &Snapshot{}
+		err = json.NewDecoder(r).Decode(snapshot)
+	case FormatYAML:
+		snapshot, err = decodeYAML(r)
+	default:
+		return nil, errors.NewValidationError("format", format, "format must be json or yaml")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode registry snapshot: %w", err)
+	}
+
+	if snapshot.Version == 0 {
+		snapshot.Version = CurrentVersion
+	}
+	if snapshot.Version > CurrentVersion {
+		return nil, errors.NewValidationError("version", snapshot.Version,
+			fmt.Sprintf("unsupported registry snapshot version (newest supported is %d)", CurrentVersion))
+	}
+	if snapshot.Sections == nil {
+		snapshot.Sections = map[string]map[string]string{}
+	}
+
+	return snapshot, nil
+}
+
+// LoadFile reads a snapshot from a .json, .yaml or .yml file.
+func LoadFile(filename string) (*Snapshot, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Decode(f, FormatForPath(filename))
+}
+
+// SaveFile writes the snapshot to a file, choosing the format from its extension.
+func (s *Snapshot) SaveFile(filename string) error {
+	var buf bytes.Buffer
+	if err := s.Encode(&buf, FormatForPath(filename)); err != nil {
+		return err
+	}
+	return os.WriteFile(filename, buf.Bytes(), 0644)
+}
+
+// encodeYAML writes the snapshot as block-style YAML. Values that would read back
+// as something else, such as "yes", "0x10" or "", are quoted.
+func (s *Snapshot) encodeYAML(w io.Writer) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(s); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// yamlSnapshot is the YAML form of a snapshot. Registry values are decoded as nodes
+// so their text is kept as written and a key without a value is reported rather
+// than read as "".
+type yamlSnapshot struct {
+	Version  int                             `yaml:"version"`
+	Serial   string                          `yaml:"serial"`
+	TakenAt  time.Time                       `yaml:"takenAt"`
+	Sections map[string]map[string]yaml.Node `yaml:"sections"`
+}
+
+// decodeYAML reads a YAML snapshot document, rejecting unknown fields.
+func decodeYAML(r io.Reader) (*Snapshot, error) {
+	var document yamlSnapshot
+	decoder := yaml.NewDecoder(r)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&document); err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	snapshot := &Snapshot{
+		Version:  document.Version,
+		Serial:   document.Serial,
+		TakenAt:  document.TakenAt,
+		Sections: make(map[string]map[string]string, len(document.Sections)),
+	}
+	for section, values := range document.Sections {
+		snapshot.Sections[section] = make(map[string]string, len(values))
+		for key, node := range values {
+			if node.Kind != yaml.ScalarNode || node.Tag == "!!null" {
+				return nil, fmt.Errorf("line %d: registry value for %q must be a scalar", node.Line, key)
+			}
+			snapshot.Sections[section][key] = node.Value
+		}
+	}
+
+	return snapshot, nil
+}
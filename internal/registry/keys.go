@@ -0,0 +1,400 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/brightdevelopers/gopurple/internal/errors"
+	"github.com/brightdevelopers/gopurple/internal/types"
+)
+
+// Well-known registry sections.
+const (
+	SectionNetworking   = "networking"
+	SectionHTML         = "html"
+	SectionBrightScript = "brightscript"
+	SectionAutorun      = "autorun"
+	SectionRDWS         = "rdws"
+)
+
+// KnownSections lists the registry sections described by this package.
+var KnownSections = []string{SectionAutorun, SectionBrightScript, SectionHTML, SectionNetworking, SectionRDWS}
+
+// ValueClient is the subset of the rDWS service used by typed registry keys.
+type ValueClient interface {
+	GetRegistryValue(ctx context.Context, serial string, section string, key string) (*types.RDWSRegistryValue, error)
+	SetRegistryValue(ctx context.Context, serial string, section string, key string, value string) (bool, error)
+}
+
+// ValueType identifies how a registry value is encoded on the player.
+type ValueType string
+
+const (
+	TypeString   ValueType = "string"
+	TypeBool     ValueType = "bool"
+	TypeInt      ValueType = "int"
+	TypeDuration ValueType = "duration"
+	TypeURL      ValueType = "url"
+)
+
+// Key describes a registry key, its value type and the values it accepts.
+type Key struct {
+	Section     string
+	Name        string
+	Type        ValueType
+	Description string
+
+	// Min and Max bound TypeInt and TypeDuration values (in Unit); ignored when both are zero.
+	Min, Max int64
+
+	// Unit is the duration stored as 1 on the player for TypeDuration keys (default: time.Second).
+	Unit time.Duration
+
+	// True and False are the encodings written for TypeBool keys (default: "1" and "0").
+	True, False string
+
+	// Schemes restricts TypeURL keys (default: http and https).
+	Schemes []string
+
+	// Pattern restricts TypeString keys.
+	Pattern *regexp.Regexp
+}
+
+// String returns the key as "section/name".
+func (k Key) String() string {
+	return k.Section + "/" + k.Name
+}
+
+func (k Key) trueValue() string {
+	if k.True == "" {
+		return "1"
+	}
+	return k.True
+}
+
+func (k Key) falseValue() string {
+	if k.False == "" {
+		return "0"
+	}
+	return k.False
+}
+
+func (k Key) unit() time.Duration {
+	if k.Unit == 0 {
+		return time.Second
+	}
+	return k.Unit
+}
+
+func (k Key) invalid(value string, reason string) error {
+	return errors.NewValidationError(k.String(), value, reason)
+}
+
+// Validate reports whether value is an acceptable raw registry value for the key.
+func (k Key) Validate(value string) error {
+	switch k.Type {
+	case TypeBool:
+		_, err := k.parseBool(value)
+		return err
+	case TypeInt, TypeDuration:
+		_, err := k.parseInt(value)
+		return err
+	case TypeURL:
+		_, err := k.parseURL(value)
+		return err
+	default:
+		if k.Pattern != nil && !k.Pattern.MatchString(value) {
+			return k.invalid(value, fmt.Sprintf("value must match %s", k.Pattern))
+		}
+		return nil
+	}
+}
+
+func (k Key) parseBool(value string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case strings.ToLower(k.trueValue()), "1", "yes", "true", "on":
+		return true, nil
+	case strings.ToLower(k.falseValue()), "0", "no", "false", "off", "":
+		return false, nil
+	default:
+		return false, k.invalid(value, fmt.Sprintf("value must be %q or %q", k.trueValue(), k.falseValue()))
+	}
+}
+
+func (k Key) parseInt(value string) (int64, error) {
+	n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil {
+		return 0, k.invalid(value, "value must be an integer")
+	}
+	if err := k.checkRange(n); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+func (k Key) checkRange(n int64) error {
+	if (k.Min != 0 || k.Max != 0) && (n < k.Min || n > k.Max) {
+		return k.invalid(strconv.FormatInt(n, 10), fmt.Sprintf("value must be between %d and %d", k.Min, k.Max))
+	}
+	return nil
+}
+
+func (k Key) parseURL(value string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSpace(value))
+	if err != nil || u.Host == "" {
+		return nil, k.invalid(value, "value must be an absolute URL")
+	}
+
+	schemes := k.Schemes
+	if len(schemes) == 0 {
+		schemes = []string{"http", "https"}
+	}
+	for _, scheme := range schemes {
+		if strings.EqualFold(u.Scheme, scheme) {
+			return u, nil
+		}
+	}
+	return nil, k.invalid(value, fmt.Sprintf("URL scheme must be one of %s", strings.Join(schemes, ", ")))
+}
+
+// get reads the raw value of the key from the player.
+func (k Key) get(ctx context.Context, client ValueClient, serial string) (string, error) {
+	value, err := client.GetRegistryValue(ctx, serial, k.Section, k.Name)
+	if err != nil {
+		return "", err
+	}
+	return value.Value, nil
+}
+
+// set validates and writes a raw value for the key.
+func (k Key) set(ctx context.Context, client ValueClient, serial string, value string) error {
+	if err := k.Validate(value); err != nil {
+		return err
+	}
+	_, err := client.SetRegistryValue(ctx, serial, k.Section, k.Name, value)
+	return err
+}
+
+// StringKey is a registry key holding free-form text.
+type StringKey struct{ Key }
+
+// Get reads the value from the player.
+func (k StringKey) Get(ctx context.Context, client ValueClient, serial string) (string, error) {
+	return k.get(ctx, client, serial)
+}
+
+// Set validates and writes the value to the player.
+func (k StringKey) Set(ctx context.Context, client ValueClient, serial string, value string) error {
+	return k.set(ctx, client, serial, value)
+}
+
+// BoolKey is a registry key holding an on/off flag.
+type BoolKey struct{ Key }
+
+// Get reads the flag from the player. A missing or empty value reads as false.
+func (k BoolKey) Get(ctx context.Context, client ValueClient, serial string) (bool, error) {
+	raw, err := k.get(ctx, client, serial)
+	if err != nil {
+		return false, err
+	}
+	return k.parseBool(raw)
+}
+
+// Set writes the flag to the player using the key's true/false encoding.
+func (k BoolKey) Set(ctx context.Context, client ValueClient, serial string, value bool) error {
+	if value {
+		return k.set(ctx, client, serial, k.trueValue())
+	}
+	return k.set(ctx, client, serial, k.falseValue())
+}
+
+// IntKey is a registry key holding an integer such as a port number.
+type IntKey struct{ Key }
+
+// Get reads the integer from the player.
+func (k IntKey) Get(ctx context.Context, client ValueClient, serial string) (int64, error) {
+	raw, err := k.get(ctx, client, serial)
+	if err != nil {
+		return 0, err
+	}
+	return k.parseInt(raw)
+}
+
+// Set validates and writes the integer to the player.
+func (k IntKey) Set(ctx context.Context, client ValueClient, serial string, value int64) error {
+	return k.set(ctx, client, serial, strconv.FormatInt(value, 10))
+}
+
+// DurationKey is a registry key holding a whole number of Unit (seconds by default).
+type DurationKey struct{ Key }
+
+// Get reads the duration from the player.
+func (k DurationKey) Get(ctx context.Context, client ValueClient, serial string) (time.Duration, error) {
+	raw, err := k.get(ctx, client, serial)
+	if err != nil {
+		return 0, err
+	}
+	n, err := k.parseInt(raw)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(n) * k.unit(), nil
+}
+
+// Set writes the duration to the player. The duration must be a whole number of Unit.
+func (k DurationKey) Set(ctx context.Context, client ValueClient, serial string, value time.Duration) error {
+	if value%k.unit() != 0 {
+		return k.invalid(value.String(), fmt.Sprintf("duration must be a whole number of %s", k.unit()))
+	}
+	return k.set(ctx, client, serial, strconv.FormatInt(int64(value/k.unit()), 10))
+}
+
+// URLKey is a registry key holding an absolute URL.
+type URLKey struct{ Key }
+
+// Get reads the URL from the player.
+func (k URLKey) Get(ctx context.Context, client ValueClient, serial string) (*url.URL, error) {
+	raw, err := k.get(ctx, client, serial)
+	if err != nil {
+		return nil, err
+	}
+	return k.parseURL(raw)
+}
+
+// Set validates and writes the URL to the player.
+func (k URLKey) Set(ctx context.Context, client ValueClient, serial string, value *url.URL) error {
+	if value == nil {
+		return k.invalid("", "URL cannot be nil")
+	}
+	return k.set(ctx, client, serial, value.String())
+}
+
+var hostnamePattern = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9-]{0,61}[A-Za-z0-9])?$`)
+
+// Well-known registry keys. Keys that are not listed here can still be described
+// with a Key value and used through the typed wrappers.
+var (
+	// Networking
+	NetworkingHostname = StringKey{Key{Section: SectionNetworking, Name: "hostname", Type: TypeString,
+		Description: "Player hostname", Pattern: hostnamePattern}}
+	NetworkingLocalDWS = BoolKey{Key{Section: SectionNetworking, Name: "dwse", Type: TypeBool,
+		Description: "Enable the local Diagnostic Web Server", True: "yes", False: "no"}}
+	NetworkingLocalDWSPassword = StringKey{Key{Section: SectionNetworking, Name: "dwsp", Type: TypeString,
+		Description: "Local Diagnostic Web Server password"}}
+	NetworkingSSHPort = IntKey{Key{Section: SectionNetworking, Name: "ssh", Type: TypeInt,
+		Description: "SSH port (enables SSH when set)", Min: 1, Max: 65535}}
+	NetworkingTelnetPort = IntKey{Key{Section: SectionNetworking, Name: "telnet", Type: TypeInt,
+		Description: "Telnet port (enables telnet when set)", Min: 1, Max: 65535}}
+	NetworkingRecoveryURL = URLKey{Key{Section: SectionNetworking, Name: "ru", Type: TypeURL,
+		Description: "Recovery URL used when no presentation is found"}}
+	NetworkingCurlDebug = BoolKey{Key{Section: SectionNetworking, Name: "curl_debug", Type: TypeBool,
+		Description: "Log curl debug output to the system log"}}
+
+	// HTML
+	HTMLWebInspector = BoolKey{Key{Section: SectionHTML, Name: "enable_web_inspector", Type: TypeBool,
+		Description: "Enable the Chromium web inspector for HTML widgets"}}
+
+	// BrightScript
+	BrightScriptDebug = BoolKey{Key{Section: SectionBrightScript, Name: "debug", Type: TypeBool,
+		Description: "Drop into the BrightScript debugger on script errors"}}
+
+	// Autorun
+	AutorunTimeout = DurationKey{Key{Section: SectionAutorun, Name: "timeout", Type: TypeDuration,
+		Description: "Seconds to wait for storage before running autorun", Min: 0, Max: 3600}}
+
+	// rDWS
+	RDWSEnabled = BoolKey{Key{Section: SectionRDWS, Name: "enabled", Type: TypeBool,
+		Description: "Enable the remote Diagnostic Web Server connection"}}
+	RDWSServerURL = URLKey{Key{Section: SectionRDWS, Name: "url", Type: TypeURL,
+		Description: "Remote Diagnostic Web Server endpoint", Schemes: []string{"https", "wss"}}}
+)
+
+// KnownKeys lists the well-known registry keys, sorted by section and name.
+var KnownKeys = sortedKnownKeys(
+	NetworkingHostname.Key, NetworkingLocalDWS.Key, NetworkingLocalDWSPassword.Key,
+	NetworkingSSHPort.Key, NetworkingTelnetPort.Key, NetworkingRecoveryURL.Key, NetworkingCurlDebug.Key,
+	HTMLWebInspector.Key,
+	BrightScriptDebug.Key,
+	AutorunTimeout.Key,
+	RDWSEnabled.Key, RDWSServerURL.Key,
+)
+
+func sortedKnownKeys(keys ...Key) []Key {
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].String() < keys[j].String()
+	})
+	return keys
+}
+
+// LookupKey returns the well-known definition of section/name, if there is one.
+// Registry section and key names are case-insensitive on the player.
+func LookupKey(section, name string) (Key, bool) {
+	for _, key := range KnownKeys {
+		if strings.EqualFold(key.Section, section) && strings.EqualFold(key.Name, name) {
+			return key, true
+		}
+	}
+	return Key{}, false
+}
+
+// ValidateSection returns an error for section names that are not well-known,
+// suggesting the closest known section. The player accepts any section name, so a
+// misspelling would otherwise be written without complaint and never read.
+func ValidateSection(section string) error {
+	for _, known := range KnownSections {
+		if strings.EqualFold(section, known) {
+			return nil
+		}
+	}
+
+	reason := "unknown registry section"
+	best, bestDistance := "", 3
+	for _, known := range KnownSections {
+		if d := editDistance(strings.ToLower(section), known); d < bestDistance {
+			best, bestDistance = known, d
+		}
+	}
+	if best != "" {
+		reason = fmt.Sprintf("unknown registry section (did you mean %q?)", best)
+	}
+	return errors.NewValidationError("section", section, reason)
+}
+
+// ValidateValue checks a raw value against the well-known definition of section/name.
+// Unknown keys in known sections are accepted as-is.
+func ValidateValue(section, name, value string) error {
+	if err := ValidateSection(section); err != nil {
+		return err
+	}
+	if key, ok := LookupKey(section, name); ok {
+		return key.Validate(value)
+	}
+	return nil
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,166 @@
+package registry
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brightdevelopers/gopurple/internal/types"
+)
+
+// fakeValues serves single registry values from an in-memory registry.
+type fakeValues struct {
+	*fakeRegistry
+}
+
+func (f fakeValues) GetRegistryValue(ctx context.Context, serial string, section string, key string) (*types.RDWSRegistryValue, error) {
+	return &types.RDWSRegistryValue{Section: section, Key: key, Value: f.sections[section][key]}, nil
+}
+
+func TestBoolKey(t *testing.T) {
+	player := fakeValues{newFakeRegistry()}
+	ctx := context.Background()
+
+	if err := NetworkingLocalDWS.Set(ctx, player, "ABC123", true); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if raw := player.sections["networking"]["dwse"]; raw != "yes" {
+		t.Errorf("Expected dwse to be written as \"yes\", got %q", raw)
+	}
+	enabled, err := NetworkingLocalDWS.Get(ctx, player, "ABC123")
+	if err != nil || !enabled {
+		t.Errorf("Expected local DWS to read as enabled, got %v (%v)", enabled, err)
+	}
+
+	if err := HTMLWebInspector.Set(ctx, player, "ABC123", false); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if raw := player.sections["html"]["enable_web_inspector"]; raw != "0" {
+		t.Errorf("Expected web inspector to be written as \"0\", got %q", raw)
+	}
+
+	player.sections["brightscript"] = map[string]string{"debug": "maybe"}
+	if _, err := BrightScriptDebug.Get(ctx, player, "ABC123"); err == nil {
+		t.Error("Expected error reading an unrecognized boolean value")
+	}
+}
+
+func TestIntKey(t *testing.T) {
+	player := fakeValues{newFakeRegistry()}
+	ctx := context.Background()
+
+	if err := NetworkingSSHPort.Set(ctx, player, "ABC123", 22); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	port, err := NetworkingSSHPort.Get(ctx, player, "ABC123")
+	if err != nil || port != 22 {
+		t.Errorf("Expected port 22, got %d (%v)", port, err)
+	}
+
+	if err := NetworkingSSHPort.Set(ctx, player, "ABC123", 70000); err == nil {
+		t.Error("Expected error setting an out-of-range port")
+	}
+	if player.sections["networking"]["ssh"] != "22" {
+		t.Error("Expected invalid value not to be written")
+	}
+}
+
+func TestDurationKey(t *testing.T) {
+	player := fakeValues{newFakeRegistry()}
+	ctx := context.Background()
+
+	if err := AutorunTimeout.Set(ctx, player, "ABC123", 2*time.Minute); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if raw := player.sections["autorun"]["timeout"]; raw != "120" {
+		t.Errorf("Expected timeout to be written in seconds, got %q", raw)
+	}
+	timeout, err := AutorunTimeout.Get(ctx, player, "ABC123")
+	if err != nil || timeout != 2*time.Minute {
+		t.Errorf("Expected 2m timeout, got %v (%v)", timeout, err)
+	}
+
+	if err := AutorunTimeout.Set(ctx, player, "ABC123", 1500*time.Millisecond); err == nil {
+		t.Error("Expected error setting a fractional duration")
+	}
+}
+
+func TestURLKey(t *testing.T) {
+	player := fakeValues{newFakeRegistry()}
+	ctx := context.Background()
+
+	recovery, _ := url.Parse("https://example.com/recovery")
+	if err := NetworkingRecoveryURL.Set(ctx, player, "ABC123", recovery); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	got, err := NetworkingRecoveryURL.Get(ctx, player, "ABC123")
+	if err != nil || got.String() != recovery.String() {
+		t.Errorf("Expected %s, got %v (%v)", recovery, got, err)
+	}
+
+	ftp, _ := url.Parse("ftp://example.com/recovery")
+	if err := NetworkingRecoveryURL.Set(ctx, player, "ABC123", ftp); err == nil {
+		t.Error("Expected error setting a URL with an unsupported scheme")
+	}
+	relative, _ := url.Parse("/recovery")
+	if err := NetworkingRecoveryURL.Set(ctx, player, "ABC123", relative); err == nil {
+		t.Error("Expected error setting a relative URL")
+	}
+}
+
+func TestStringKeyPattern(t *testing.T) {
+	if err := NetworkingHostname.Validate("lobby-01"); err != nil {
+		t.Errorf("Expected valid hostname, got %v", err)
+	}
+	if err := NetworkingHostname.Validate("lobby_01!"); err == nil {
+		t.Error("Expected error for invalid hostname")
+	}
+}
+
+func TestValidateSection(t *testing.T) {
+	if err := ValidateSection("Networking"); err != nil {
+		t.Errorf("Expected section names to be case-insensitive, got %v", err)
+	}
+
+	err := ValidateSection("netwroking")
+	if err == nil {
+		t.Fatal("Expected error for misspelled section")
+	}
+	if !strings.Contains(err.Error(), `"networking"`) {
+		t.Errorf("Expected suggestion of networking, got %v", err)
+	}
+
+	if err := ValidateSection("completely-different"); err == nil || strings.Contains(err.Error(), "did you mean") {
+		t.Errorf("Expected error without suggestion, got %v", err)
+	}
+}
+
+func TestValidateValue(t *testing.T) {
+	if err := ValidateValue("networking", "SSH", "abc"); err == nil {
+		t.Error("Expected error for non-numeric ssh port")
+	}
+	if err := ValidateValue("networking", "custom_key", "anything"); err != nil {
+		t.Errorf("Expected unknown keys in known sections to be accepted, got %v", err)
+	}
+	if err := ValidateValue("htlm", "enable_web_inspector", "1"); err == nil {
+		t.Error("Expected error for misspelled section")
+	}
+}
+
+func TestKnownKeys(t *testing.T) {
+	seen := map[string]bool{}
+	for _, key := range KnownKeys {
+		if seen[key.String()] {
+			t.Errorf("Duplicate known key %s", key)
+		}
+		seen[key.String()] = true
+		if err := ValidateSection(key.Section); err != nil {
+			t.Errorf("Known key %s uses unknown section: %v", key, err)
+		}
+		if key.Description == "" {
+			t.Errorf("Known key %s has no description", key)
+		}
+	}
+}
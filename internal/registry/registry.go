@@ -0,0 +1,265 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/brightdevelopers/gopurple/internal/errors"
+	"github.com/brightdevelopers/gopurple/internal/types"
+)
+
+// CurrentVersion is the snapshot document format version written by this package.
+const CurrentVersion = 1
+
+// Client is the subset of the rDWS service used to read and write a player's registry.
+type Client interface {
+	GetRegistry(ctx context.Context, serial string) (*types.RDWSRegistry, error)
+	SetRegistryValue(ctx context.Context, serial string, section string, key string, value string) (bool, error)
+	DeleteRegistryValue(ctx context.Context, serial string, section string, key string) (bool, error)
+	FlushRegistry(ctx context.Context, serial string) (bool, error)
+}
+
+// Snapshot is a versioned copy of a player's registry.
+type Snapshot struct {
+	Version  int                          `json:"version" yaml:"version"`
+	Serial   string                       `json:"serial,omitempty" yaml:"serial,omitempty"`
+	TakenAt  time.Time                    `json:"takenAt,omitempty" yaml:"takenAt,omitempty"`
+	Sections map[string]map[string]string `json:"sections" yaml:"sections"`
+}
+
+// Take reads the complete registry of a player into a snapshot.
+func Take(ctx context.Context, client Client, serial string) (*Snapshot, error) {
+	if serial == "" {
+		return nil, errors.NewValidationError("serial", serial, "device serial cannot be empty")
+	}
+
+	registry, err := client.GetRegistry(ctx, serial)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &Snapshot{
+		Version:  CurrentVersion,
+		Serial:   serial,
+		TakenAt:  time.Now().UTC().Truncate(time.Second),
+		Sections: make(map[string]map[string]string, len(registry.Sections)),
+	}
+	for section, values := range registry.Sections {
+		copied := make(map[string]string, len(values))
+		for key, value := range values {
+			copied[key] = value
+		}
+		snapshot.Sections[section] = copied
+	}
+
+	return snapshot, nil
+}
+
+// Get returns the value of a key and whether it is present.
+func (s *Snapshot) Get(section, key string) (string, bool) {
+	value, ok := s.Sections[section][key]
+	return value, ok
+}
+
+// ChangeType describes how a registry key differs between two snapshots.
+type ChangeType string
+
+const (
+	ChangeAdd    ChangeType = "add"
+	ChangeModify ChangeType = "modify"
+	ChangeDelete ChangeType = "delete"
+)
+
+// Change is a single registry key difference.
+type Change struct {
+	Type     ChangeType `json:"type"`
+	Section  string     `json:"section"`
+	Key      string     `json:"key"`
+	OldValue string     `json:"oldValue,omitempty"`
+	NewValue string     `json:"newValue,omitempty"`
+}
+
+func (c Change) String() string {
+	switch c.Type {
+	case ChangeAdd:
+		return fmt.Sprintf("+ %s/%s = %q", c.Section, c.Key, c.NewValue)
+	case ChangeDelete:
+		return fmt.Sprintf("- %s/%s (was %q)", c.Section, c.Key, c.OldValue)
+	default:
+		return fmt.Sprintf("~ %s/%s: %q -> %q", c.Section, c.Key, c.OldValue, c.NewValue)
+	}
+}
+
+// Diff returns the changes needed to turn from into to, sorted by section and key.
+// Every section of both snapshots is compared, which makes it suitable for comparing
+// two players.
+func Diff(from, to *Snapshot) []Change {
+	sections := make(map[string]bool)
+	for section := range from.Sections {
+		sections[section] = true
+	}
+	for section := range to.Sections {
+		sections[section] = true
+	}
+	return diffSections(from, to, sections)
+}
+
+// plan returns the changes needed to bring current to desired. Only sections present
+// in desired are considered, so a desired-state file can manage a few sections without
+// wiping the rest of the registry.
+func plan(current, desired *Snapshot) []Change {
+	sections := make(map[string]bool)
+	for section := range desired.Sections {
+		sections[section] = true
+	}
+	return diffSections(current, desired, sections)
+}
+
+func diffSections(from, to *Snapshot, sections map[string]bool) []Change {
+	var changes []Change
+	for section := range sections {
+		oldValues := from.Sections[section]
+		newValues := to.Sections[section]
+
+		for key, newValue := range newValues {
+			oldValue, ok := oldValues[key]
+			switch {
+			case !ok:
+				changes = append(changes, Change{Type: ChangeAdd, Section: section, Key: key, NewValue: newValue})
+			case oldValue != newValue:
+				changes = append(changes, Change{Type: ChangeModify, Section: section, Key: key, OldValue: oldValue, NewValue: newValue})
+			}
+		}
+		for key, oldValue := range oldValues {
+			if _, ok := newValues[key]; !ok {
+				changes = append(changes, Change{Type: ChangeDelete, Section: section, Key: key, OldValue: oldValue})
+			}
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Section != changes[j].Section {
+			return changes[i].Section < changes[j].Section
+		}
+		return changes[i].Key < changes[j].Key
+	})
+	return changes
+}
+
+// DefaultProtectedKeys lists registry keys that Apply will not modify or delete
+// unless ApplyOptions.AllowProtected is set; new keys can still be added. Patterns
+// use path.Match syntax against "section/key"; changing these could leave a player
+// unable to reach the network.
+var DefaultProtectedKeys = []string{
+	"networking/*",
+}
+
+// ApplyOptions controls how Apply changes a player's registry.
+type ApplyOptions struct {
+	DryRun         bool     // Compute the changes without writing them
+	AllowProtected bool     // Allow modifying and deleting protected keys
+	ProtectedKeys  []string // Overrides DefaultProtectedKeys when non-nil
+}
+
+// ApplyResult describes the outcome of Apply.
+type ApplyResult struct {
+	Serial   string   `json:"serial"`
+	Changes  []Change `json:"changes"`           // Changes that were (or, for a dry run, would be) made
+	Skipped  []Change `json:"skipped,omitempty"` // Changes to protected keys that were not made
+	Applied  int      `json:"applied"`           // Number of changes written to the player
+	Verified bool     `json:"verified"`          // The registry matched the desired state after flushing
+}
+
+// IsProtected reports whether section/key matches one of the patterns.
+func IsProtected(patterns []string, section, key string) bool {
+	name := strings.ToLower(section + "/" + key)
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(strings.ToLower(pattern), name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply brings a player's registry in line with desired by setting and deleting keys,
+// flushes the registry to disk and re-reads it to verify the result. Only sections
+// present in desired are changed. Modifications and deletions of protected keys are
+// skipped and reported in the result unless opts.AllowProtected is set.
+func Apply(ctx context.Context, client Client, serial string, desired *Snapshot, opts ApplyOptions) (*ApplyResult, error) {
+	if serial == "" {
+		return nil, errors.NewValidationError("serial", serial, "device serial cannot be empty")
+	}
+	if desired == nil {
+		return nil, errors.NewValidationError("desired", desired, "desired registry state cannot be nil")
+	}
+
+	current, err := Take(ctx, client, serial)
+	if err != nil {
+		return nil, err
+	}
+
+	protected := opts.ProtectedKeys
+	if protected == nil {
+		protected = DefaultProtectedKeys
+	}
+
+	result := &ApplyResult{Serial: serial}
+	for _, change := range plan(current, desired) {
+		if change.Type != ChangeAdd && !opts.AllowProtected && IsProtected(protected, change.Section, change.Key) {
+			result.Skipped = append(result.Skipped, change)
+			continue
+		}
+		result.Changes = append(result.Changes, change)
+	}
+
+	if opts.DryRun || len(result.Changes) == 0 {
+		result.Verified = len(result.Changes) == 0
+		return result, nil
+	}
+
+	// Write the changes
+	for _, change := range result.Changes {
+		if change.Type == ChangeDelete {
+			_, err = client.DeleteRegistryValue(ctx, serial, change.Section, change.Key)
+		} else {
+			_, err = client.SetRegistryValue(ctx, serial, change.Section, change.Key, change.NewValue)
+		}
+		if err != nil {
+			return result, err
+		}
+		result.Applied++
+	}
+
+	if _, err := client.FlushRegistry(ctx, serial); err != nil {
+		return result, err
+	}
+
+	// Re-read the registry and confirm every change took effect
+	after, err := Take(ctx, client, serial)
+	if err != nil {
+		return result, err
+	}
+
+	var mismatches []string
+	for _, change := range result.Changes {
+		value, ok := after.Get(change.Section, change.Key)
+		switch {
+		case change.Type == ChangeDelete && ok:
+			mismatches = append(mismatches, fmt.Sprintf("%s/%s still present", change.Section, change.Key))
+		case change.Type != ChangeDelete && (!ok || value != change.NewValue):
+			mismatches = append(mismatches, fmt.Sprintf("%s/%s is %q, expected %q", change.Section, change.Key, value, change.NewValue))
+		}
+	}
+	if len(mismatches) > 0 {
+		return result, errors.NewAPIError(0, "registry_verify_failed",
+			fmt.Sprintf("Registry on device with serial '%s' does not match the desired state after applying", serial),
+			strings.Join(mismatches, "; "))
+	}
+
+	result.Verified = true
+	return result, nil
+}
@@ -0,0 +1,291 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brightdevelopers/gopurple/internal/types"
+)
+
+// fakeRegistry is an in-memory player registry.
+type fakeRegistry struct {
+	sections map[string]map[string]string
+	ignore   map[string]bool // "section/key" writes that silently do nothing
+	flushed  int
+	writes   []string
+}
+
+func newFakeRegistry() *fakeRegistry {
+	return &fakeRegistry{
+		sections: map[string]map[string]string{
+			"networking": {"dhcp": "yes", "hostname": "lobby"},
+			"html":       {"enable_web_inspector": "0", "widget": "old"},
+			"autorun":    {"timeout": "30"},
+		},
+		ignore: map[string]bool{},
+	}
+}
+
+func (f *fakeRegistry) GetRegistry(ctx context.Context, serial string) (*types.RDWSRegistry, error) {
+	copied := map[string]map[string]string{}
+	for section, values := range f.sections {
+		copied[section] = map[string]string{}
+		for key, value := range values {
+			copied[section][key] = value
+		}
+	}
+	return &types.RDWSRegistry{Sections: copied}, nil
+}
+
+func (f *fakeRegistry) SetRegistryValue(ctx context.Context, serial string, section string, key string, value string) (bool, error) {
+	f.writes = append(f.writes, "set "+section+"/"+key)
+	if f.ignore[section+"/"+key] {
+		return true, nil
+	}
+	if f.sections[section] == nil {
+		f.sections[section] = map[string]string{}
+	}
+	f.sections[section][key] = value
+	return true, nil
+}
+
+func (f *fakeRegistry) DeleteRegistryValue(ctx context.Context, serial string, section string, key string) (bool, error) {
+	f.writes = append(f.writes, "delete "+section+"/"+key)
+	delete(f.sections[section], key)
+	return true, nil
+}
+
+func (f *fakeRegistry) FlushRegistry(ctx context.Context, serial string) (bool, error) {
+	f.flushed++
+	return true, nil
+}
+
+func TestDiff(t *testing.T) {
+	from := &Snapshot{Sections: map[string]map[string]string{
+		"html":    {"a": "1", "b": "2"},
+		"autorun": {"x": "1"},
+	}}
+	to := &Snapshot{Sections: map[string]map[string]string{
+		"html":       {"a": "1", "b": "3", "c": "4"},
+		"networking": {"dhcp": "no"},
+	}}
+
+	expected := []Change{
+		{Type: ChangeDelete, Section: "autorun", Key: "x", OldValue: "1"},
+		{Type: ChangeModify, Section: "html", Key: "b", OldValue: "2", NewValue: "3"},
+		{Type: ChangeAdd, Section: "html", Key: "c", NewValue: "4"},
+		{Type: ChangeAdd, Section: "networking", Key: "dhcp", NewValue: "no"},
+	}
+	if changes := Diff(from, to); !reflect.DeepEqual(changes, expected) {
+		t.Errorf("Expected changes %v, got %v", expected, changes)
+	}
+
+	if changes := Diff(from, from); len(changes) != 0 {
+		t.Errorf("Expected no changes comparing a snapshot with itself, got %v", changes)
+	}
+}
+
+func TestApply(t *testing.T) {
+	player := newFakeRegistry()
+	desired := &Snapshot{Sections: map[string]map[string]string{
+		"html": {"enable_web_inspector": "1", "new_key": "value"},
+	}}
+
+	result, err := Apply(context.Background(), player, "ABC123", desired, ApplyOptions{})
+	if err != nil {
+		t.Fatalf("Apply returned error: %v", err)
+	}
+
+	if !result.Verified || result.Applied != 3 {
+		t.Errorf("Expected 3 verified changes, got %+v", result)
+	}
+	if player.flushed != 1 {
+		t.Errorf("Expected registry to be flushed once, got %d", player.flushed)
+	}
+	if !reflect.DeepEqual(player.sections["html"], desired.Sections["html"]) {
+		t.Errorf("Expected html section %v, got %v", desired.Sections["html"], player.sections["html"])
+	}
+	if player.sections["autorun"]["timeout"] != "30" {
+		t.Error("Expected sections missing from the desired state to be left alone")
+	}
+}
+
+func TestApply_ProtectedKeys(t *testing.T) {
+	player := newFakeRegistry()
+	desired := &Snapshot{Sections: map[string]map[string]string{
+		"networking": {"hostname": "lobby-2", "timeserver": "time.example.com"},
+	}}
+
+	result, err := Apply(context.Background(), player, "ABC123", desired, ApplyOptions{})
+	if err != nil {
+		t.Fatalf("Apply returned error: %v", err)
+	}
+	if len(result.Skipped) != 2 || result.Skipped[0].Key != "dhcp" || result.Skipped[1].Key != "hostname" {
+		t.Errorf("Expected deletion of networking/dhcp and modification of networking/hostname to be skipped, got %v", result.Skipped)
+	}
+	if player.sections["networking"]["dhcp"] != "yes" {
+		t.Error("Expected protected key to survive apply")
+	}
+	if player.sections["networking"]["hostname"] != "lobby" {
+		t.Error("Expected protected key to keep its value")
+	}
+	if player.sections["networking"]["timeserver"] != "time.example.com" {
+		t.Error("Expected new keys to still be added to a protected section")
+	}
+
+	result, err = Apply(context.Background(), player, "ABC123", desired, ApplyOptions{AllowProtected: true})
+	if err != nil {
+		t.Fatalf("Apply returned error: %v", err)
+	}
+	if _, ok := player.sections["networking"]["dhcp"]; ok || len(result.Skipped) != 0 {
+		t.Error("Expected protected key to be deleted with AllowProtected")
+	}
+	if player.sections["networking"]["hostname"] != "lobby-2" {
+		t.Error("Expected protected key to be modified with AllowProtected")
+	}
+}
+
+func TestApply_DryRun(t *testing.T) {
+	player := newFakeRegistry()
+	desired := &Snapshot{Sections: map[string]map[string]string{"autorun": {"timeout": "60"}}}
+
+	result, err := Apply(context.Background(), player, "ABC123", desired, ApplyOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("Apply returned error: %v", err)
+	}
+	if len(result.Changes) != 1 || result.Applied != 0 {
+		t.Errorf("Expected one planned change and nothing applied, got %+v", result)
+	}
+	if len(player.writes) != 0 || player.flushed != 0 {
+		t.Errorf("Expected dry run not to write, got %v", player.writes)
+	}
+}
+
+func TestApply_VerifyFailure(t *testing.T) {
+	player := newFakeRegistry()
+	player.ignore["autorun/timeout"] = true
+	desired := &Snapshot{Sections: map[string]map[string]string{"autorun": {"timeout": "60"}}}
+
+	result, err := Apply(context.Background(), player, "ABC123", desired, ApplyOptions{})
+	if err == nil {
+		t.Fatal("Expected verification error when a write does not take effect")
+	}
+	if result == nil || result.Verified {
+		t.Error("Expected result to be returned unverified")
+	}
+	if !strings.Contains(err.Error(), "autorun/timeout") {
+		t.Errorf("Expected error to name the mismatched key, got %v", err)
+	}
+}
+
+func TestApply_Validation(t *testing.T) {
+	player := newFakeRegistry()
+
+	if _, err := Apply(context.Background(), player, "", &Snapshot{}, ApplyOptions{}); err == nil {
+		t.Error("Expected error when applying with empty serial")
+	}
+	if _, err := Apply(context.Background(), player, "ABC123", nil, ApplyOptions{}); err == nil {
+		t.Error("Expected error when applying nil desired state")
+	}
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	snapshot, err := Take(context.Background(), newFakeRegistry(), "ABC123")
+	if err != nil {
+		t.Fatalf("Take returned error: %v", err)
+	}
+	snapshot.Sections["html"]["quoted: key"] = "line1\nline2 \"x\" # not a comment"
+	snapshot.Sections["empty"] = map[string]string{}
+	snapshot.Sections["autorun"]["blank"] = ""
+
+	for _, format := range []Format{FormatJSON, FormatYAML} {
+		var buf bytes.Buffer
+		if err := snapshot.Encode(&buf, format); err != nil {
+			t.Fatalf("Encode(%s) failed: %v", format, err)
+		}
+		decoded, err := Decode(&buf, format)
+		if err != nil {
+			t.Fatalf("Decode(%s) failed: %v", format, err)
+		}
+		if !reflect.DeepEqual(decoded, snapshot) {
+			t.Errorf("%s round trip mismatch:\n got  %+v\n want %+v", format, decoded, snapshot)
+		}
+	}
+}
+
+func TestDecodeYAML_HandWritten(t *testing.T) {
+	input := `# desired state for lobby players
+sections:
+  html:
+    enable_web_inspector: 1   # on for debugging
+    url: 'http://example.com/it''s'
+  "odd section":
+    key: "value"
+`
+	snapshot, err := Decode(strings.NewReader(input), FormatYAML)
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+
+	expected := map[string]map[string]string{
+		"html":        {"enable_web_inspector": "1", "url": "http://example.com/it's"},
+		"odd section": {"key": "value"},
+	}
+	if !reflect.DeepEqual(snapshot.Sections, expected) {
+		t.Errorf("Expected sections %v, got %v", expected, snapshot.Sections)
+	}
+	if snapshot.Version != CurrentVersion {
+		t.Errorf("Expected missing version to default to %d, got %d", CurrentVersion, snapshot.Version)
+	}
+}
+
+func TestDecode_Errors(t *testing.T) {
+	inputs := map[string]string{
+		"future version": "version: 99\nsections: {}\n",
+		"unknown field":  "colour: blue\n",
+		"bad nesting":    "sections:\n  html:\n    key:\n",
+		"unterminated":   "sections:\n  html:\n    key: \"oops\n",
+	}
+	for name, input := range inputs {
+		if _, err := Decode(strings.NewReader(input), FormatYAML); err == nil {
+			t.Errorf("Expected error decoding %s", name)
+		}
+	}
+
+	if _, err := Decode(strings.NewReader(`{"version": 2}`), FormatJSON); err == nil {
+		t.Error("Expected error decoding future JSON version")
+	}
+}
+
+func TestFormatForPath(t *testing.T) {
+	cases := map[string]Format{
+		"lobby.yaml":  FormatYAML,
+		"lobby.YML":   FormatYAML,
+		"lobby.json":  FormatJSON,
+		"lobby":       FormatJSON,
+		"snapshot.db": FormatJSON,
+	}
+	for filename, expected := range cases {
+		if format := FormatForPath(filename); format != expected {
+			t.Errorf("FormatForPath(%q) = %s, expected %s", filename, format, expected)
+		}
+	}
+}
+
+func TestTake_CopiesRegistry(t *testing.T) {
+	player := newFakeRegistry()
+	snapshot, err := Take(context.Background(), player, "ABC123")
+	if err != nil {
+		t.Fatalf("Take returned error: %v", err)
+	}
+	if snapshot.Version != CurrentVersion || snapshot.Serial != "ABC123" {
+		t.Errorf("Unexpected snapshot metadata: %+v", snapshot)
+	}
+	if time.Since(snapshot.TakenAt) > time.Minute {
+		t.Errorf("Expected TakenAt to be recent, got %v", snapshot.TakenAt)
+	}
+}
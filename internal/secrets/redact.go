@@ -0,0 +1,48 @@
+package secrets
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/brightdevelopers/gopurple/internal/types"
+)
+
+// secretJSONField matches a string value of a JSON key that holds a password or
+// token: the setup record secrets and the OAuth2 and DWS password fields.
+var secretJSONField = regexp.MustCompile(`("(?:dwsPassword|lwsPassword|sfnPassword|usbUpdatePassword|passphrase|token|password|previous_password|access_token|refresh_token|id_token|client_secret)"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+
+// secretFormField matches a form or query value that holds a password or token.
+var secretFormField = regexp.MustCompile(`\b(client_secret|password|access_token|refresh_token)=[^&\s]*`)
+
+// secretName matches the name of a parameter that holds a password or token.
+var secretName = regexp.MustCompile(`(?i)password|passphrase|secret|token`)
+
+// IsSecretName reports whether a parameter or field with the given name holds a
+// password or token, e.g. "password" or "DWSPassword".
+func IsSecretName(name string) bool {
+	return secretName.MatchString(name)
+}
+
+// Redact masks passwords and tokens in a JSON or form-encoded body, for debug
+// logs. Secret references are masked too, as the body cannot tell them apart.
+func Redact(body string) string {
+	body = secretJSONField.ReplaceAllString(body, `${1}"`+types.RedactedSecret+`"`)
+	return secretFormField.ReplaceAllString(body, "${1}="+types.RedactedSecret)
+}
+
+// RedactHeader masks the credentials in the Authorization header, keeping the
+// scheme (e.g. "Bearer ********").
+func RedactHeader(header http.Header) {
+	for _, name := range []string{"Authorization", "Proxy-Authorization"} {
+		value := header.Get(name)
+		if value == "" {
+			continue
+		}
+		if scheme, _, ok := strings.Cut(value, " "); ok {
+			header.Set(name, scheme+" "+types.RedactedSecret)
+		} else {
+			header.Set(name, types.RedactedSecret)
+		}
+	}
+}
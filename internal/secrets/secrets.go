@@ -0,0 +1,179 @@
+// Package secrets resolves the secret references that B-Deploy setup records may
+// hold instead of passwords (see types.SecretSchemes), and redacts secrets from
+// debug logs.
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/brightdevelopers/gopurple/internal/types"
+)
+
+// Resolver turns a secret reference such as "env:WIFI_PASSPHRASE" into the secret.
+type Resolver interface {
+	Resolve(ctx context.Context, ref string) (string, error)
+}
+
+// Sources resolves references from the environment, files, commands and
+// age or sops encrypted files. The age and sops references run the age and sops
+// commands, which must be installed.
+type Sources struct {
+	// AllowExec enables exec: references. It is off by default because a setup
+	// record fetched from B-Deploy and sent back would run whatever command it names.
+	AllowExec bool
+
+	// AgeIdentity is the identity file age: references are decrypted with. It
+	// defaults to BS_AGE_IDENTITY, then SOPS_AGE_KEY_FILE.
+	AgeIdentity string
+
+	lookupEnv func(string) (string, bool)
+	command   func(ctx context.Context, name string, args ...string) ([]byte, error)
+}
+
+// NewSources returns a resolver for env:, file:, age: and sops: references, and
+// for exec: references if allowExec is set.
+func NewSources(allowExec bool) *Sources {
+	return &Sources{AllowExec: allowExec}
+}
+
+// Resolve returns the secret a reference names. A trailing newline is removed
+// from file and command output, and a secret that turns out to be empty is an
+// error, since it is almost always a missing variable or key.
+func (s *Sources) Resolve(ctx context.Context, ref string) (string, error) {
+	scheme, target, ok := strings.Cut(ref, ":")
+	if !ok || !types.IsSecretReference(ref) {
+		return "", fmt.Errorf("not a secret reference (expected one of %s)", strings.Join(types.SecretSchemes, ", "))
+	}
+
+	var value string
+	var err error
+	switch scheme {
+	case "env":
+		value, err = s.env(target)
+	case "file":
+		value, err = readFile(target)
+	case "exec":
+		value, err = s.exec(ctx, target)
+	case "age":
+		value, err = s.age(ctx, target)
+	case "sops":
+		value, err = s.sops(ctx, target)
+	}
+	if err != nil {
+		return "", err
+	}
+	if value == "" {
+		return "", fmt.Errorf("secret %s is empty", ref)
+	}
+	return value, nil
+}
+
+func (s *Sources) getenv(name string) (string, bool) {
+	if s.lookupEnv != nil {
+		return s.lookupEnv(name)
+	}
+	return os.LookupEnv(name)
+}
+
+func (s *Sources) run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	if s.command != nil {
+		return s.command(ctx, name, args...)
+	}
+	output, err := exec.CommandContext(ctx, name, args...).Output()
+	if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+		return nil, fmt.Errorf("%s failed: %s", name, strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s failed: %w", name, err)
+	}
+	return output, nil
+}
+
+func (s *Sources) env(name string) (string, error) {
+	value, ok := s.getenv(name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+	return value, nil
+}
+
+func readFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return trimNewline(string(data)), nil
+}
+
+// exec runs a command, split on spaces without a shell, and returns its output.
+func (s *Sources) exec(ctx context.Context, command string) (string, error) {
+	if !s.AllowExec {
+		return "", fmt.Errorf("exec: secret references are disabled (enable them with WithSecretExec or BS_SECRET_EXEC=1)")
+	}
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return "", fmt.Errorf("exec: secret reference has no command")
+	}
+	output, err := s.run(ctx, args[0], args[1:]...)
+	if err != nil {
+		return "", err
+	}
+	return trimNewline(string(output)), nil
+}
+
+// age decrypts an age-encrypted file.
+func (s *Sources) age(ctx context.Context, path string) (string, error) {
+	identity := s.AgeIdentity
+	for _, name := range []string{"BS_AGE_IDENTITY", "SOPS_AGE_KEY_FILE"} {
+		if identity == "" {
+			identity, _ = s.getenv(name)
+		}
+	}
+	if identity == "" {
+		return "", fmt.Errorf("no age identity for %s (set BS_AGE_IDENTITY)", path)
+	}
+	output, err := s.run(ctx, "age", "--decrypt", "--identity", identity, path)
+	if err != nil {
+		return "", err
+	}
+	return trimNewline(string(output)), nil
+}
+
+// sops decrypts a sops-encrypted file, or one value of it when the target ends
+// in #key with a dotted key path such as #stores.wifi.psk.
+func (s *Sources) sops(ctx context.Context, target string) (string, error) {
+	path, key, _ := strings.Cut(target, "#")
+	args := []string{"--decrypt"}
+	if key != "" {
+		args = append(args, "--extract", sopsExtract(key))
+	}
+	output, err := s.run(ctx, "sops", append(args, path)...)
+	if err != nil {
+		return "", err
+	}
+	return trimNewline(string(output)), nil
+}
+
+// sopsExtract converts a dotted key path to sops' --extract syntax, e.g.
+// stores.wifi.0 to ["stores"]["wifi"][0].
+func sopsExtract(key string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(key, ".") {
+		if _, err := strconv.Atoi(part); err == nil {
+			fmt.Fprintf(&b, "[%s]", part)
+		} else {
+			fmt.Fprintf(&b, "[%q]", part)
+		}
+	}
+	return b.String()
+}
+
+func trimNewline(s string) string {
+	s = strings.TrimSuffix(s, "\n")
+	return strings.TrimSuffix(s, "\r")
+}
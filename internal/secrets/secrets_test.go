@@ -0,0 +1,106 @@
+package secrets
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testSources(allowExec bool) (*Sources, *[]string) {
+	var commands []string
+	s := NewSources(allowExec)
+	s.lookupEnv = func(name string) (string, bool) {
+		value, ok := map[string]string{"WIFI": "wifi-passphrase", "EMPTY": ""}[name]
+		return value, ok
+	}
+	s.command = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		commands = append(commands, name+" "+strings.Join(args, " "))
+		return []byte("from-" + name + "\n"), nil
+	}
+	return s, &commands
+}
+
+func TestSources_Resolve(t *testing.T) {
+	ctx := context.Background()
+	s, commands := testSources(true)
+
+	path := filepath.Join(t.TempDir(), "lws.txt")
+	if err := os.WriteFile(path, []byte("lws-password\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	s.AgeIdentity = "key.txt"
+
+	for ref, expected := range map[string]string{
+		"env:WIFI":                  "wifi-passphrase",
+		"file:" + path:              "lws-password",
+		"exec:pass show retail/lws": "from-pass",
+		"age:secrets/wifi.age":      "from-age",
+		"sops:secrets.yaml#wifi.0":  "from-sops",
+	} {
+		value, err := s.Resolve(ctx, ref)
+		if err != nil || value != expected {
+			t.Errorf("Resolve(%q) = %q, %v; expected %q", ref, value, err, expected)
+		}
+	}
+
+	for _, expected := range []string{
+		"pass show retail/lws",
+		"age --decrypt --identity key.txt secrets/wifi.age",
+		`sops --decrypt --extract ["wifi"][0] secrets.yaml`,
+	} {
+		found := false
+		for _, command := range *commands {
+			found = found || command == expected
+		}
+		if !found {
+			t.Errorf("Expected command %q, got %v", expected, *commands)
+		}
+	}
+
+	for _, ref := range []string{"env:MISSING", "env:EMPTY", "file:/does/not/exist", "plain-password"} {
+		if _, err := s.Resolve(ctx, ref); err == nil {
+			t.Errorf("Expected an error for %q", ref)
+		}
+	}
+}
+
+func TestSources_ExecDisabled(t *testing.T) {
+	s, commands := testSources(false)
+	if _, err := s.Resolve(context.Background(), "exec:pass show wifi"); err == nil {
+		t.Error("Expected exec: references to be refused by default")
+	}
+	if _, err := s.Resolve(context.Background(), "age:wifi.age"); err == nil {
+		t.Error("Expected an error for age: without an identity")
+	}
+	if len(*commands) != 0 {
+		t.Errorf("Expected no commands to run, got %v", *commands)
+	}
+}
+
+func TestRedact(t *testing.T) {
+	body := `{"passphrase": "wifi-passphrase", "lwsPassword":"a\"b", "ssid": "store", "bsnDeviceRegistrationTokenEntity": {"token": "abc", "scope": "cert"}}`
+	redacted := Redact(body)
+	for _, secret := range []string{"wifi-passphrase", `a\"b`, "abc"} {
+		if strings.Contains(redacted, secret) {
+			t.Errorf("Expected %q to be redacted, got %s", secret, redacted)
+		}
+	}
+	if !strings.Contains(redacted, `"ssid": "store"`) || !strings.Contains(redacted, `"scope": "cert"`) {
+		t.Errorf("Expected other values to be kept, got %s", redacted)
+	}
+
+	form := Redact("grant_type=client_credentials&client_secret=s3cret")
+	if form != "grant_type=client_credentials&client_secret=********" {
+		t.Errorf("Unexpected redacted form %s", form)
+	}
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer eyJhbGciOi")
+	RedactHeader(header)
+	if header.Get("Authorization") != "Bearer ********" {
+		t.Errorf("Unexpected redacted header %s", header.Get("Authorization"))
+	}
+}
@@ -0,0 +1,213 @@
+package services
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/user"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/brightdevelopers/gopurple/internal/audit"
+	"github.com/brightdevelopers/gopurple/internal/auth"
+	"github.com/brightdevelopers/gopurple/internal/config"
+	"github.com/brightdevelopers/gopurple/internal/secrets"
+	"github.com/brightdevelopers/gopurple/internal/types"
+)
+
+// auditParams are the parameters of an audited call. Values are formatted with
+// fmt.Sprint; the values of secret names are redacted.
+type auditParams map[string]interface{}
+
+type auditedKey struct{}
+
+// errDeclined is the audited outcome of a call the player answered without
+// success, though without an error either.
+var errDeclined = stderrors.New("player reported no success")
+
+// auditor writes an audit record for every state-changing call.
+type auditor struct {
+	sink        audit.Sink
+	clientID    string
+	user        string
+	authManager *auth.AuthManager
+	logger      *slog.Logger
+}
+
+// newAuditor returns the auditor for the configured sink, or nil if there is none.
+func newAuditor(cfg *config.Config, authManager *auth.AuthManager) *auditor {
+	sink := cfg.AuditLog()
+	if sink == nil {
+		return nil
+	}
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &auditor{
+		sink:        sink,
+		clientID:    cfg.ClientID,
+		user:        auditUser(cfg),
+		authManager: authManager,
+		logger:      logger,
+	}
+}
+
+// auditUser returns the configured audit user, or the name of the user running
+// the program.
+func auditUser(cfg *config.Config) string {
+	if cfg.AuditUser != "" {
+		return cfg.AuditUser
+	}
+	if current, err := user.Current(); err == nil {
+		return current.Username
+	}
+	return os.Getenv("USER")
+}
+
+// begin starts auditing a call. The returned function writes the record with
+// the call's outcome. Calls made within an audited one, like the Delete that
+// DeleteBySerial makes, are not recorded again.
+func (a *auditor) begin(ctx context.Context, operation, target string, params auditParams) (context.Context, func(err error)) {
+	if a == nil || ctx.Value(auditedKey{}) != nil {
+		return ctx, func(error) {}
+	}
+
+	record := audit.Record{
+		Time:      time.Now().UTC(),
+		ClientID:  a.clientID,
+		User:      a.user,
+		Operation: operation,
+		Target:    target,
+		Params:    redactParams(params),
+	}
+	return context.WithValue(ctx, auditedKey{}, true), func(err error) {
+		if network, netErr := a.authManager.GetCurrentNetwork(); netErr == nil {
+			record.Network = network.Name
+		}
+		record.Outcome = audit.OutcomeSuccess
+		if err != nil {
+			record.Outcome = audit.OutcomeFailure
+			record.Error = secrets.Redact(err.Error())
+		}
+
+		// The call has happened either way, so a lost record is logged rather
+		// than reported as the call failing
+		if writeErr := a.sink.Write(context.WithoutCancel(ctx), record); writeErr != nil {
+			a.logger.Error("failed to write audit record",
+				slog.String("operation", operation), slog.String("target", target), slog.String("error", writeErr.Error()))
+		}
+	}
+}
+
+// declined returns err, or errDeclined for a call that returned no error but
+// reported no success.
+func declined(ok bool, err error) error {
+	if err == nil && !ok {
+		return errDeclined
+	}
+	return err
+}
+
+// redactParams formats the parameters of a call, masking secrets.
+func redactParams(params auditParams) map[string]string {
+	if len(params) == 0 {
+		return nil
+	}
+	redacted := make(map[string]string, len(params))
+	for name, value := range params {
+		if secrets.IsSecretName(name) {
+			redacted[name] = types.RedactedSecret
+		} else {
+			redacted[name] = fmt.Sprint(value)
+		}
+	}
+	return redacted
+}
+
+// The parameters of audited calls that take a request. Each accepts nil, as
+// calls are audited before their arguments are checked.
+
+func setupParams(record *types.BDeploySetupRecord) auditParams {
+	if record == nil {
+		return nil
+	}
+	return auditParams{"packageName": record.BDeploy.PackageName, "setupType": record.SetupType}
+}
+
+// changedFields returns the names of the fields a patch changes, not their
+// values, which may be secrets.
+func changedFields(changes map[string]interface{}) string {
+	fields := make([]string, 0, len(changes))
+	for field := range changes {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return strings.Join(fields, ",")
+}
+
+func deviceRequestSerial(request *types.BDeployDeviceRequest) string {
+	if request == nil {
+		return ""
+	}
+	return request.Serial
+}
+
+func deviceParams(request *types.BDeployDeviceRequest) auditParams {
+	if request == nil {
+		return nil
+	}
+	return auditParams{"serial": request.Serial, "name": request.Name, "setupId": request.SetupID}
+}
+
+func dwsPasswordParams(request *types.DWSPasswordRequest) auditParams {
+	if request == nil {
+		return nil
+	}
+	return auditParams{"password": request.Password, "previousPassword": request.PreviousPassword}
+}
+
+func timeParams(request *types.RDWSTimeSetRequest) auditParams {
+	if request == nil {
+		return nil
+	}
+	return auditParams{"date": request.Date, "time": request.Time, "applyTimezone": request.ApplyTimezone}
+}
+
+func networkConfigParams(iface string, request *types.RDWSNetworkConfigSetRequest) auditParams {
+	params := auditParams{"interface": iface}
+	if request != nil {
+		params["type"] = request.Data.Type
+		params["ipAddress"] = request.Data.IPAddress
+		params["netmask"] = request.Data.Netmask
+		params["gateway"] = request.Data.Gateway
+		params["dns"] = strings.Join(request.Data.DNS, ",")
+	}
+	return params
+}
+
+func packetCaptureParams(request *types.RDWSPacketCaptureStartRequest) auditParams {
+	if request == nil {
+		return nil
+	}
+	return auditParams{"interface": request.Data.Interface, "duration": request.Data.Duration, "filter": request.Data.Filter}
+}
+
+func firmwareParams(firmwareURL string, autoReboot *bool) auditParams {
+	params := auditParams{"firmwareURL": firmwareURL}
+	if autoReboot != nil {
+		params["autoReboot"] = *autoReboot
+	}
+	return params
+}
+
+// registryParams masks the value of a registry key that holds a password or token.
+func registryParams(section, key, value string) auditParams {
+	if secrets.IsSecretName(key) {
+		value = types.RedactedSecret
+	}
+	return auditParams{"section": section, "key": key, "value": value}
+}
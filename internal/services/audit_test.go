@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	stderrors "errors"
+	"strings"
+	"testing"
+
+	"github.com/brightdevelopers/gopurple/internal/audit"
+	"github.com/brightdevelopers/gopurple/internal/auth"
+	"github.com/brightdevelopers/gopurple/internal/config"
+	"github.com/brightdevelopers/gopurple/internal/http"
+	"github.com/brightdevelopers/gopurple/internal/types"
+)
+
+func TestAuditor(t *testing.T) {
+	sink := audit.NewMemorySink()
+	cfg := config.DefaultConfig()
+	cfg.ClientID = "test-id"
+	cfg.ClientSecret = "test-secret"
+	cfg.AuditSink = sink
+	cfg.AuditUser = "jdoe"
+	httpClient := http.NewHTTPClient(cfg)
+	service := NewRDWSService(cfg, httpClient, auth.NewAuthManager(cfg, httpClient))
+
+	// A call that fails its checks is recorded, with the password masked
+	if _, err := service.SetSSHStatus(context.Background(), "", true, 22, "hunter2"); err == nil {
+		t.Fatal("Expected error for empty serial")
+	}
+	records := sink.Records()
+	if len(records) != 1 {
+		t.Fatalf("Expected 1 record, got %d", len(records))
+	}
+	record := records[0]
+	if record.Operation != "RDWS.SetSSHStatus" || record.ClientID != "test-id" || record.User != "jdoe" || record.Time.IsZero() {
+		t.Errorf("Unexpected record %+v", record)
+	}
+	if record.Outcome != audit.OutcomeFailure || !strings.Contains(record.Error, "serial") {
+		t.Errorf("Expected a failure with the validation error, got %q: %q", record.Outcome, record.Error)
+	}
+	if record.Params["password"] != types.RedactedSecret || record.Params["port"] != "22" || record.Params["enabled"] != "true" {
+		t.Errorf("Expected the password masked and the other parameters kept, got %v", record.Params)
+	}
+
+	// Reads are not recorded
+	service.GetSSHStatus(context.Background(), "")
+	if len(sink.Records()) != 1 {
+		t.Errorf("Expected reads not to be recorded, got %d records", len(sink.Records()))
+	}
+}
+
+func TestAuditor_Nested(t *testing.T) {
+	sink := audit.NewMemorySink()
+	cfg := config.DefaultConfig()
+	cfg.AuditSink = sink
+	a := newAuditor(cfg, auth.NewAuthManager(cfg, http.NewHTTPClient(cfg)))
+
+	ctx, outer := a.begin(context.Background(), "Devices.DeleteBySerial", "XD001", nil)
+	_, inner := a.begin(ctx, "Devices.Delete", "42", nil)
+	inner(nil)
+	outer(declined(false, nil))
+
+	records := sink.Records()
+	if len(records) != 1 || records[0].Operation != "Devices.DeleteBySerial" || records[0].Target != "XD001" {
+		t.Fatalf("Expected only the outer call to be recorded, got %+v", records)
+	}
+	if records[0].Outcome != audit.OutcomeFailure || records[0].Error != errDeclined.Error() {
+		t.Errorf("Expected a call without success to be a failure, got %q: %q", records[0].Outcome, records[0].Error)
+	}
+
+	// Without a sink nothing is recorded
+	cfg.AuditSink = nil
+	if newAuditor(cfg, nil) != nil {
+		t.Error("Expected no auditor without a sink")
+	}
+	var none *auditor
+	_, done := none.begin(context.Background(), "RDWS.FlushRegistry", "XD001", nil)
+	done(stderrors.New("failed"))
+}
+
+func TestAuditParams(t *testing.T) {
+	params := redactParams(registryParams("networking", "wifi_passphrase", "s3cret"))
+	if params["value"] != types.RedactedSecret || params["key"] != "wifi_passphrase" {
+		t.Errorf("Expected the value of a secret registry key masked, got %v", params)
+	}
+	params = redactParams(registryParams("html", "url", "http://example.com"))
+	if params["value"] != "http://example.com" {
+		t.Errorf("Expected other registry values kept, got %v", params)
+	}
+	if fields := changedFields(map[string]interface{}{"timeZone": "UTC", "dwsPassword": "x"}); fields != "dwsPassword,timeZone" {
+		t.Errorf("Expected the sorted field names, got %q", fields)
+	}
+}
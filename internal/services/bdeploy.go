@@ -4,11 +4,15 @@ import (
 	"context"
 	"fmt"
 	"net/url"
+	"time"
 
 	"github.com/brightdevelopers/gopurple/internal/auth"
 	"github.com/brightdevelopers/gopurple/internal/config"
 	"github.com/brightdevelopers/gopurple/internal/errors"
+	"github.com/brightdevelopers/gopurple/internal/history"
 	"github.com/brightdevelopers/gopurple/internal/http"
+	"github.com/brightdevelopers/gopurple/internal/ledger"
+	"github.com/brightdevelopers/gopurple/internal/secrets"
 	"github.com/brightdevelopers/gopurple/internal/types"
 )
 
@@ -19,7 +23,14 @@ type BDeployService interface {
 	GetSetupRecord(ctx context.Context, setupID string) (*types.BDeploySetupRecord, error)
 	AddSetupRecord(ctx context.Context, record *types.BDeploySetupRecord) (*types.BDeployCreateResponse, error)
 	UpdateSetupRecord(ctx context.Context, setupID string, record *types.BDeploySetupRecord) (*types.BDeploySetupRecord, error)
+	PatchSetupRecord(ctx context.Context, setupID string, changes map[string]interface{}) (*types.BDeploySetupRecord, error)
+	ValidateSetupRecord(record *types.BDeploySetupRecord) error
 	DeleteSetupRecord(ctx context.Context, setupID string) (*types.BDeployDeleteResponse, error)
+	GetSetupRecordsV2(ctx context.Context, opts ...BDeployListOption) (*types.BDeployRecordList, error)
+	GetSetupRecordV2(ctx context.Context, setupID string) (*types.BDeploySetupRecord, error)
+	AddSetupRecordV2(ctx context.Context, record *types.BDeploySetupRecord) (*types.BDeployCreateResponse, error)
+	UpdateSetupRecordV2(ctx context.Context, setupID string, record *types.BDeploySetupRecord) (*types.BDeploySetupRecord, error)
+	DeleteSetupRecordV2(ctx context.Context, setupID string) (*types.BDeployDeleteResponse, error)
 	GetDeviceBySerial(ctx context.Context, serial string) (*types.BDeployDeviceResponse, error)
 	GetAllDevices(ctx context.Context, opts ...BDeployDeviceListOption) (*types.BDeployDeviceListResponse, error)
 	CreateDevice(ctx context.Context, request *types.BDeployDeviceRequest) (string, error)
@@ -29,10 +40,13 @@ type BDeployService interface {
 
 // bDeployService implements the BDeployService interface.
 type bDeployService struct {
-	config         *config.Config
-	httpClient     *http.HTTPClient
-	authManager    *auth.AuthManager
-	currentNetwork string // Track the current network context for device API calls
+	config      *config.Config
+	httpClient  *http.HTTPClient
+	authManager *auth.AuthManager
+	ledger      ledger.Store     // Device associations, since B-Deploy does not return setupId (nil if disabled)
+	history     history.Store    // Snapshots of setup records taken before they change (nil if off)
+	secrets     secrets.Resolver // Resolves secret references in setup records (nil to refuse them)
+	audit       *auditor         // Records state-changing calls (nil if off)
 }
 
 // NewBDeployService creates a new B-Deploy service.
@@ -41,6 +55,10 @@ func NewBDeployService(cfg *config.Config, httpClient *http.HTTPClient, authMana
 		config:      cfg,
 		httpClient:  httpClient,
 		authManager: authManager,
+		ledger:      cfg.AssociationStore(),
+		history:     cfg.SetupHistoryStore(),
+		secrets:     cfg.SecretsResolver(),
+		audit:       newAuditor(cfg, authManager),
 	}
 }
 
@@ -50,41 +68,30 @@ func (s *bDeployService) SetNetworkContext(ctx context.Context, networkName stri
 		return errors.NewValidationError("networkName", networkName, "network name cannot be empty")
 	}
 
-	// Ensure we have authentication
-	if err := s.authManager.EnsureValid(ctx); err != nil {
-		return err
+	// The network is part of the session, which the auth manager keeps and
+	// selects again when the token is refreshed
+	if err := s.authManager.SetNetwork(ctx, networkName); err != nil {
+		return errors.WrapError("BDeploy.SetNetworkContext", "network_context_failed",
+			fmt.Sprintf("Failed to set network context to '%s'", networkName), err)
 	}
 
-	// Get access token
-	token, err := s.authManager.GetToken()
-	if err != nil {
-		return err
-	}
-
-	// Build the BSN.cloud network context endpoint
-	contextURL := "https://api.bsn.cloud/2022/06/REST/Self/Session/Network"
-
-	// Build request body
-	request := &types.NetworkContextRequest{
-		Name: networkName,
-	}
+	return nil
+}
 
-	// Make the API request - PUT to set network context
-	var response interface{} // API returns empty body on success
-	err = s.httpClient.PutWithAuth(ctx, token, contextURL, request, &response)
-	if err != nil {
-		return errors.NewAPIError(0, "network_context_failed",
-			fmt.Sprintf("Failed to set network context to '%s'", networkName), err.Error())
+// networkName returns the session's network for device API calls, or the
+// configured network before the session has selected one.
+func (s *bDeployService) networkName() string {
+	if network, err := s.authManager.GetCurrentNetwork(); err == nil && network.Name != "" {
+		return network.Name
 	}
-
-	// Store the network name for device API calls
-	s.currentNetwork = networkName
-
-	return nil
+	return s.config.NetworkName
 }
 
 // GetSetupRecords retrieves B-Deploy setup records with optional filtering.
-func (s *bDeployService) GetSetupRecords(ctx context.Context, opts ...BDeployListOption) (*types.BDeployRecordList, error) {
+func (s *bDeployService) GetSetupRecords(ctx context.Context, opts ...BDeployListOption) (_ *types.BDeployRecordList, err error) {
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "BDeploy.GetSetupRecords", "")
+	defer func() { traced(err) }()
+
 	// Ensure we have authentication
 	if err := s.authManager.EnsureValid(ctx); err != nil {
 		return nil, err
@@ -125,7 +132,7 @@ func (s *bDeployService) GetSetupRecords(ctx context.Context, opts ...BDeployLis
 	var apiResponse types.BDeployAPIResponse
 	err = s.httpClient.GetWithAuth(ctx, token, baseURL, &apiResponse)
 	if err != nil {
-		return nil, errors.NewAPIError(0, "bdeploy_records_failed", "Failed to get B-Deploy setup records", err.Error())
+		return nil, errors.WrapError("BDeploy.GetSetupRecords", "bdeploy_records_failed", "Failed to get B-Deploy setup records", err)
 	}
 
 	// Check for API-level errors
@@ -143,7 +150,10 @@ func (s *bDeployService) GetSetupRecords(ctx context.Context, opts ...BDeployLis
 }
 
 // GetSetupRecord retrieves a single B-Deploy setup record by ID.
-func (s *bDeployService) GetSetupRecord(ctx context.Context, setupID string) (*types.BDeploySetupRecord, error) {
+func (s *bDeployService) GetSetupRecord(ctx context.Context, setupID string) (_ *types.BDeploySetupRecord, err error) {
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "BDeploy.GetSetupRecord", "")
+	defer func() { traced(err) }()
+
 	if setupID == "" {
 		return nil, errors.NewValidationError("setupID", setupID, "setup ID cannot be empty")
 	}
@@ -164,10 +174,10 @@ func (s *bDeployService) GetSetupRecord(ctx context.Context, setupID string) (*t
 	getURL := fmt.Sprintf("https://provision.bsn.cloud/rest-setup/v3/setup/?_id=%s", url.QueryEscape(setupID))
 
 	// Make the API request - B-Deploy API returns array format with full setup record structure
-	var apiResponse types.BDeployFullRecordAPIResponse
+	var apiResponse setupRecordsResponse
 	err = s.httpClient.GetWithAuth(ctx, token, getURL, &apiResponse)
 	if err != nil {
-		return nil, errors.NewAPIError(0, "bdeploy_get_failed", "Failed to get B-Deploy setup record", err.Error())
+		return nil, errors.WrapError("BDeploy.GetSetupRecord", "bdeploy_get_failed", "Failed to get B-Deploy setup record", err)
 	}
 
 	// Check for API-level errors
@@ -181,14 +191,25 @@ func (s *bDeployService) GetSetupRecord(ctx context.Context, setupID string) (*t
 	}
 
 	// Return the first (and should be only) record
-	return &apiResponse.Result[0], nil
+	return apiResponse.Result[0].Record, nil
 }
 
-// AddSetupRecord creates a new B-Deploy setup record.
-func (s *bDeployService) AddSetupRecord(ctx context.Context, record *types.BDeploySetupRecord) (*types.BDeployCreateResponse, error) {
+// AddSetupRecord creates a new B-Deploy setup record. Secret references in the
+// record are resolved in the copy that is sent.
+func (s *bDeployService) AddSetupRecord(ctx context.Context, record *types.BDeploySetupRecord) (_ *types.BDeployCreateResponse, err error) {
+	ctx, audited := s.audit.begin(ctx, "BDeploy.AddSetupRecord", "", setupParams(record))
+	defer func() { audited(err) }()
+
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "BDeploy.AddSetupRecord", "")
+	defer func() { traced(err) }()
+
 	if record == nil {
 		return nil, errors.NewValidationError("record", "nil", "setup record cannot be nil")
 	}
+	send, err := s.prepareSetupRecord(ctx, record)
+	if err != nil {
+		return nil, err
+	}
 
 	// Ensure we have authentication
 	if err := s.authManager.EnsureValid(ctx); err != nil {
@@ -206,9 +227,9 @@ func (s *bDeployService) AddSetupRecord(ctx context.Context, record *types.BDepl
 
 	// Make the API request - B-Deploy API returns wrapper format with full record in result
 	var apiResponse types.BDeployCreateAPIResponse
-	err = s.httpClient.PostWithAuth(ctx, token, createURL, record, &apiResponse)
+	err = s.httpClient.PostWithAuth(ctx, token, createURL, types.SetupRecordJSON{Record: send}, &apiResponse)
 	if err != nil {
-		return nil, errors.NewAPIError(0, "bdeploy_create_failed", "Failed to create B-Deploy setup record", err.Error())
+		return nil, errors.WrapError("BDeploy.AddSetupRecord", "bdeploy_create_failed", "Failed to create B-Deploy setup record", err)
 	}
 
 	// Check for API-level errors
@@ -225,14 +246,26 @@ func (s *bDeployService) AddSetupRecord(ctx context.Context, record *types.BDepl
 	return response, nil
 }
 
-// UpdateSetupRecord updates an existing B-Deploy setup record.
-func (s *bDeployService) UpdateSetupRecord(ctx context.Context, setupID string, record *types.BDeploySetupRecord) (*types.BDeploySetupRecord, error) {
+// UpdateSetupRecord updates an existing B-Deploy setup record. Secret references
+// in the record are resolved in the copy that is sent. With a setup history
+// configured, the current record is saved to it first.
+func (s *bDeployService) UpdateSetupRecord(ctx context.Context, setupID string, record *types.BDeploySetupRecord) (_ *types.BDeploySetupRecord, err error) {
+	ctx, audited := s.audit.begin(ctx, "BDeploy.UpdateSetupRecord", setupID, setupParams(record))
+	defer func() { audited(err) }()
+
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "BDeploy.UpdateSetupRecord", "")
+	defer func() { traced(err) }()
+
 	if setupID == "" {
 		return nil, errors.NewValidationError("setupID", setupID, "setup ID cannot be empty")
 	}
 	if record == nil {
 		return nil, errors.NewValidationError("record", "nil", "setup record cannot be nil")
 	}
+	send, err := s.prepareSetupRecord(ctx, record)
+	if err != nil {
+		return nil, err
+	}
 
 	// Ensure we have authentication
 	if err := s.authManager.EnsureValid(ctx); err != nil {
@@ -245,17 +278,22 @@ func (s *bDeployService) UpdateSetupRecord(ctx context.Context, setupID string,
 		return nil, err
 	}
 
+	if err := s.snapshotSetup(ctx, setupID, history.OperationUpdate); err != nil {
+		return nil, err
+	}
+
 	// Ensure the record ID matches the setupID parameter
 	record.ID = setupID
+	send.ID = setupID
 
 	// Build the B-Deploy setup update endpoint
 	updateURL := "https://provision.bsn.cloud/rest-setup/v3/setup"
 
 	// Make the API request - B-Deploy API returns wrapper format with full record in result
-	var apiResponse types.BDeployUpdateAPIResponse
-	err = s.httpClient.PutWithAuth(ctx, token, updateURL, record, &apiResponse)
+	var apiResponse setupRecordResponse
+	err = s.httpClient.PutWithAuth(ctx, token, updateURL, types.SetupRecordJSON{Record: send}, &apiResponse)
 	if err != nil {
-		return nil, errors.NewAPIError(0, "bdeploy_update_failed", "Failed to update B-Deploy setup record", err.Error())
+		return nil, errors.WrapError("BDeploy.UpdateSetupRecord", "bdeploy_update_failed", "Failed to update B-Deploy setup record", err)
 	}
 
 	// Check for API-level errors
@@ -264,11 +302,197 @@ func (s *bDeployService) UpdateSetupRecord(ctx context.Context, setupID string,
 	}
 
 	// Return the updated setup record
-	return apiResponse.Result, nil
+	return apiResponse.Result.Record, nil
 }
 
-// DeleteSetupRecord deletes a B-Deploy setup record by ID.
-func (s *bDeployService) DeleteSetupRecord(ctx context.Context, setupID string) (*types.BDeployDeleteResponse, error) {
+// PatchSetupRecord changes selected fields of an existing setup record. The record is
+// fetched with GetSetupRecord, changes are applied with BDeploySetupRecord.ApplyPatch
+// (keys are JSON field names such as "dwsEnabled"; false and 0 are sent explicitly
+// and nil resets a field), and the result is saved with UpdateSetupRecord. Secret
+// references are not resolved, as the record holds the values B-Deploy stores.
+//
+// The read-modify-write is not atomic: a change made by someone else between the
+// fetch and the update is overwritten.
+func (s *bDeployService) PatchSetupRecord(ctx context.Context, setupID string, changes map[string]interface{}) (_ *types.BDeploySetupRecord, err error) {
+	ctx, audited := s.audit.begin(ctx, "BDeploy.PatchSetupRecord", setupID, auditParams{"fields": changedFields(changes)})
+	defer func() { audited(err) }()
+
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "BDeploy.PatchSetupRecord", "")
+	defer func() { traced(err) }()
+
+	if setupID == "" {
+		return nil, errors.NewValidationError("setupID", setupID, "setup ID cannot be empty")
+	}
+	if len(changes) == 0 {
+		return nil, errors.NewValidationError("changes", changes, "at least one change is required")
+	}
+	for name := range changes {
+		if !types.IsSetupRecordField(name) {
+			return nil, errors.NewValidationError(name, changes[name], "unknown setup record field")
+		}
+	}
+
+	record, err := s.GetSetupRecord(ctx, setupID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The changes come from the caller, so their secret references are resolved
+	// here; the rest of the record was read from B-Deploy and is sent as it is
+	changes, err = s.resolvePatchSecrets(ctx, changes, "")
+	if err != nil {
+		return nil, err
+	}
+	if err := record.ApplyPatch(changes); err != nil {
+		return nil, err
+	}
+
+	return s.UpdateSetupRecord(WithoutSecretResolution(ctx), setupID, record)
+}
+
+// resolvePatchSecrets returns changes with the secret references in password and
+// token fields resolved, or an error if there is no resolver to resolve them.
+// Nested maps are resolved with their dotted path as prefix.
+func (s *bDeployService) resolvePatchSecrets(ctx context.Context, changes map[string]interface{}, prefix string) (map[string]interface{}, error) {
+	resolved := make(map[string]interface{}, len(changes))
+	for name, value := range changes {
+		path := prefix + name
+		switch v := value.(type) {
+		case string:
+			if types.IsSecretSetupField(path) && types.IsSecretReference(v) {
+				if s.secrets == nil {
+					return nil, types.NewUnresolvedSecretError(path, v)
+				}
+				secret, err := s.secrets.Resolve(ctx, v)
+				if err != nil {
+					return nil, errors.NewValidationError(path, v, err.Error())
+				}
+				value = secret
+			}
+		case map[string]interface{}:
+			nested, err := s.resolvePatchSecrets(ctx, v, path+".")
+			if err != nil {
+				return nil, err
+			}
+			value = nested
+		}
+		resolved[name] = value
+	}
+	return resolved, nil
+}
+
+// setupRecordsResponse and setupRecordResponse are the B-Deploy responses that hold
+// full setup records. The records are decoded with types.UnmarshalSetupRecord, so
+// zero values B-Deploy returned are kept in ForceSendFields.
+type setupRecordsResponse struct {
+	Error  interface{}             `json:"error"`
+	Result []types.SetupRecordJSON `json:"result"`
+}
+
+type setupRecordResponse struct {
+	Error  interface{}           `json:"error"`
+	Result types.SetupRecordJSON `json:"result"`
+}
+
+// snapshotSetup saves the current version of a setup record to the setup history
+// before it is changed. Without a snapshot the change is not made, since the
+// history is only useful if it is complete.
+func (s *bDeployService) snapshotSetup(ctx context.Context, setupID, operation string) error {
+	return s.saveSnapshot(ctx, setupID, operation, "", s.GetSetupRecord)
+}
+
+// snapshotSetupV2 is snapshotSetup for records changed through the v2 setup API.
+func (s *bDeployService) snapshotSetupV2(ctx context.Context, setupID, operation string) error {
+	return s.saveSnapshot(ctx, setupID, operation, history.APIV2, s.GetSetupRecordV2)
+}
+
+// saveSnapshot saves the record get returns to the setup history, marked with
+// the setup API it was read from.
+func (s *bDeployService) saveSnapshot(ctx context.Context, setupID, operation, api string, get func(context.Context, string) (*types.BDeploySetupRecord, error)) error {
+	if s.history == nil {
+		return nil
+	}
+
+	current, err := get(ctx, setupID)
+	if err != nil {
+		return fmt.Errorf("failed to snapshot setup record %s before %s: %w", setupID, operation, err)
+	}
+	current.ID = setupID
+	snapshot := history.NewSnapshot(current, operation)
+	snapshot.API = api
+	if err := s.history.Save(ctx, snapshot); err != nil {
+		return fmt.Errorf("failed to save setup history for %s: %w", setupID, err)
+	}
+	return nil
+}
+
+type storedValuesKey struct{}
+
+// WithoutSecretResolution returns a context in which AddSetupRecord and
+// UpdateSetupRecord send records as they are, without resolving or rejecting
+// secret references. It is only for records holding values read back from
+// B-Deploy, where a stored value that happens to look like "file:..." is data,
+// not a reference.
+func WithoutSecretResolution(ctx context.Context) context.Context {
+	return context.WithValue(ctx, storedValuesKey{}, true)
+}
+
+// prepareSetupRecord returns the record to send: a copy with its secret references
+// resolved, if it has any, checked with validateSetupRecord. Without a resolver a
+// record with references is refused, since B-Deploy would store the reference
+// itself as the secret.
+func (s *bDeployService) prepareSetupRecord(ctx context.Context, record *types.BDeploySetupRecord) (*types.BDeploySetupRecord, error) {
+	if ctx.Value(storedValuesKey{}) == nil {
+		if s.secrets == nil {
+			if err := record.CheckSecretReferences(); err != nil {
+				return nil, err
+			}
+		} else {
+			resolved, err := record.ResolveSecrets(ctx, s.secrets.Resolve)
+			if err != nil {
+				return nil, err
+			}
+			record = resolved
+		}
+	}
+	if err := s.validateSetupRecord(record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// ValidateSetupRecord checks a record the way AddSetupRecord and UpdateSetupRecord
+// do before sending it: secret references are refused without a resolver, and
+// nothing else is checked when validation is disabled.
+func (s *bDeployService) ValidateSetupRecord(record *types.BDeploySetupRecord) error {
+	if record == nil {
+		return errors.NewValidationError("record", record, "setup record cannot be nil")
+	}
+	if s.secrets == nil {
+		if err := record.CheckSecretReferences(); err != nil {
+			return err
+		}
+	}
+	return s.validateSetupRecord(record)
+}
+
+// validateSetupRecord checks a record before it is sent, unless validation is disabled.
+func (s *bDeployService) validateSetupRecord(record *types.BDeploySetupRecord) error {
+	if s.config != nil && s.config.SkipSetupValidation {
+		return nil
+	}
+	return record.Validate()
+}
+
+// DeleteSetupRecord deletes a B-Deploy setup record by ID. With a setup history
+// configured, the record is saved to it first.
+func (s *bDeployService) DeleteSetupRecord(ctx context.Context, setupID string) (_ *types.BDeployDeleteResponse, err error) {
+	ctx, audited := s.audit.begin(ctx, "BDeploy.DeleteSetupRecord", setupID, nil)
+	defer func() { audited(err) }()
+
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "BDeploy.DeleteSetupRecord", "")
+	defer func() { traced(err) }()
+
 	if setupID == "" {
 		return nil, errors.NewValidationError("setupID", setupID, "setup ID cannot be empty")
 	}
@@ -284,6 +508,10 @@ func (s *bDeployService) DeleteSetupRecord(ctx context.Context, setupID string)
 		return nil, err
 	}
 
+	if err := s.snapshotSetup(ctx, setupID, history.OperationDelete); err != nil {
+		return nil, err
+	}
+
 	// Build the B-Deploy setup deletion endpoint
 	// Note: Using v3 API with query parameter format (v3 path parameter format doesn't work)
 	deleteURL := fmt.Sprintf("https://provision.bsn.cloud/rest-setup/v3/setup/?_id=%s", url.QueryEscape(setupID))
@@ -292,14 +520,268 @@ func (s *bDeployService) DeleteSetupRecord(ctx context.Context, setupID string)
 	var response types.BDeployDeleteResponse
 	err = s.httpClient.DeleteWithAuth(ctx, token, deleteURL, &response)
 	if err != nil {
-		return nil, errors.NewAPIError(0, "bdeploy_delete_failed", "Failed to delete B-Deploy setup record", err.Error())
+		return nil, errors.WrapError("BDeploy.DeleteSetupRecord", "bdeploy_delete_failed", "Failed to delete B-Deploy setup record", err)
+	}
+
+	return &response, nil
+}
+
+// setupV2URL is the legacy v2 setup endpoint. Records stored there are separate
+// from v3 records; see BDeploySetupRecord.ToV3 for moving them across.
+const setupV2URL = "https://provision.bsn.cloud/rest-setup/v2/setup/"
+
+// GetSetupRecordsV2 retrieves setup records stored with the legacy v2 setup API.
+func (s *bDeployService) GetSetupRecordsV2(ctx context.Context, opts ...BDeployListOption) (_ *types.BDeployRecordList, err error) {
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "BDeploy.GetSetupRecordsV2", "")
+	defer func() { traced(err) }()
+
+	// Ensure we have authentication
+	if err := s.authManager.EnsureValid(ctx); err != nil {
+		return nil, err
+	}
+
+	// Get access token
+	token, err := s.authManager.GetToken()
+	if err != nil {
+		return nil, err
+	}
+
+	// Build query parameters from options
+	config := &bDeployListConfig{}
+	for _, opt := range opts {
+		opt.apply(config)
+	}
+
+	params := url.Values{}
+	if config.networkName != "" {
+		params.Set("NetworkName", config.networkName)
+	}
+	if config.username != "" {
+		params.Set("username", config.username)
+	}
+	if config.packageName != "" {
+		params.Set("packageName", config.packageName)
+	}
+
+	listURL := setupV2URL
+	if len(params) > 0 {
+		listURL += "?" + params.Encode()
+	}
+
+	var apiResponse types.BDeployAPIResponse
+	err = s.httpClient.GetWithAuth(ctx, token, listURL, &apiResponse)
+	if err != nil {
+		return nil, errors.WrapError("BDeploy.GetSetupRecordsV2", "bdeploy_records_failed", "Failed to get B-Deploy v2 setup records", err)
+	}
+
+	// Check for API-level errors
+	if apiResponse.Error != nil {
+		return nil, errors.NewAPIError(0, "bdeploy_api_error", "B-Deploy API returned an error", fmt.Sprintf("%v", apiResponse.Error))
+	}
+
+	return &types.BDeployRecordList{
+		Items:      apiResponse.Result,
+		TotalCount: len(apiResponse.Result),
+	}, nil
+}
+
+// GetSetupRecordV2 retrieves a single v2 setup record by ID.
+func (s *bDeployService) GetSetupRecordV2(ctx context.Context, setupID string) (_ *types.BDeploySetupRecord, err error) {
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "BDeploy.GetSetupRecordV2", "")
+	defer func() { traced(err) }()
+
+	if setupID == "" {
+		return nil, errors.NewValidationError("setupID", setupID, "setup ID cannot be empty")
+	}
+
+	// Ensure we have authentication
+	if err := s.authManager.EnsureValid(ctx); err != nil {
+		return nil, err
+	}
+
+	// Get access token
+	token, err := s.authManager.GetToken()
+	if err != nil {
+		return nil, err
+	}
+
+	getURL := setupV2URL + "?_id=" + url.QueryEscape(setupID)
+
+	var apiResponse setupRecordsResponse
+	err = s.httpClient.GetWithAuth(ctx, token, getURL, &apiResponse)
+	if err != nil {
+		return nil, errors.WrapError("BDeploy.GetSetupRecordV2", "bdeploy_get_failed", "Failed to get B-Deploy v2 setup record", err)
+	}
+
+	// Check for API-level errors
+	if apiResponse.Error != nil {
+		return nil, errors.NewAPIError(0, "bdeploy_api_error", "B-Deploy API returned an error", fmt.Sprintf("%v", apiResponse.Error))
+	}
+
+	if len(apiResponse.Result) == 0 {
+		return nil, errors.NewAPIError(404, "bdeploy_not_found", "Setup record not found", fmt.Sprintf("No v2 setup record found with ID: %s", setupID))
+	}
+
+	return apiResponse.Result[0].Record, nil
+}
+
+// AddSetupRecordV2 creates a setup record with the legacy v2 setup API. An empty
+// version is sent as 2.0.0.
+func (s *bDeployService) AddSetupRecordV2(ctx context.Context, record *types.BDeploySetupRecord) (_ *types.BDeployCreateResponse, err error) {
+	ctx, audited := s.audit.begin(ctx, "BDeploy.AddSetupRecordV2", "", setupParams(record))
+	defer func() { audited(err) }()
+
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "BDeploy.AddSetupRecordV2", "")
+	defer func() { traced(err) }()
+
+	if record == nil {
+		return nil, errors.NewValidationError("record", "nil", "setup record cannot be nil")
+	}
+	send, err := s.checkV2Record(ctx, record)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ensure we have authentication
+	if err := s.authManager.EnsureValid(ctx); err != nil {
+		return nil, err
+	}
+
+	// Get access token
+	token, err := s.authManager.GetToken()
+	if err != nil {
+		return nil, err
+	}
+
+	var apiResponse types.BDeployCreateAPIResponse
+	err = s.httpClient.PostWithAuth(ctx, token, setupV2URL, types.SetupRecordJSON{Record: send}, &apiResponse)
+	if err != nil {
+		return nil, errors.WrapError("BDeploy.AddSetupRecordV2", "bdeploy_create_failed", "Failed to create B-Deploy v2 setup record", err)
+	}
+
+	// Check for API-level errors
+	if apiResponse.Error != nil {
+		return nil, errors.NewAPIError(0, "bdeploy_api_error", "B-Deploy API returned an error", fmt.Sprintf("%v", apiResponse.Error))
+	}
+
+	return &types.BDeployCreateResponse{
+		ID:      apiResponse.Result,
+		Success: true,
+	}, nil
+}
+
+// UpdateSetupRecordV2 updates a v2 setup record. Unlike v3, the v2 API takes the
+// record ID as a query parameter. An empty version is sent as 2.0.0. With a
+// setup history configured, the current record is saved to it first.
+func (s *bDeployService) UpdateSetupRecordV2(ctx context.Context, setupID string, record *types.BDeploySetupRecord) (_ *types.BDeploySetupRecord, err error) {
+	ctx, audited := s.audit.begin(ctx, "BDeploy.UpdateSetupRecordV2", setupID, setupParams(record))
+	defer func() { audited(err) }()
+
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "BDeploy.UpdateSetupRecordV2", "")
+	defer func() { traced(err) }()
+
+	if setupID == "" {
+		return nil, errors.NewValidationError("setupID", setupID, "setup ID cannot be empty")
+	}
+	if record == nil {
+		return nil, errors.NewValidationError("record", "nil", "setup record cannot be nil")
+	}
+	send, err := s.checkV2Record(ctx, record)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ensure we have authentication
+	if err := s.authManager.EnsureValid(ctx); err != nil {
+		return nil, err
+	}
+
+	// Get access token
+	token, err := s.authManager.GetToken()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.snapshotSetupV2(ctx, setupID, history.OperationUpdate); err != nil {
+		return nil, err
+	}
+
+	send.ID = setupID
+	updateURL := setupV2URL + "?_id=" + url.QueryEscape(setupID)
+
+	var apiResponse setupRecordResponse
+	err = s.httpClient.PutWithAuth(ctx, token, updateURL, types.SetupRecordJSON{Record: send}, &apiResponse)
+	if err != nil {
+		return nil, errors.WrapError("BDeploy.UpdateSetupRecordV2", "bdeploy_update_failed", "Failed to update B-Deploy v2 setup record", err)
+	}
+
+	// Check for API-level errors
+	if apiResponse.Error != nil {
+		return nil, errors.NewAPIError(0, "bdeploy_api_error", "B-Deploy API returned an error", fmt.Sprintf("%v", apiResponse.Error))
+	}
+
+	return apiResponse.Result.Record, nil
+}
+
+// checkV2Record returns the record to send to the v2 API, as prepareSetupRecord
+// does, with an empty version defaulted in a copy.
+func (s *bDeployService) checkV2Record(ctx context.Context, record *types.BDeploySetupRecord) (*types.BDeploySetupRecord, error) {
+	if record.Version == "" {
+		copied := *record
+		copied.Version = types.SetupVersion2
+		record = &copied
+	}
+	if record.Version != types.SetupVersion2 {
+		return nil, errors.NewValidationError("version", record.Version, "the v2 setup API only accepts version 2.0.0 records (see ToV2)")
+	}
+	return s.prepareSetupRecord(ctx, record)
+}
+
+// DeleteSetupRecordV2 deletes a v2 setup record by ID. With a setup history
+// configured, the record is saved to it first.
+func (s *bDeployService) DeleteSetupRecordV2(ctx context.Context, setupID string) (_ *types.BDeployDeleteResponse, err error) {
+	ctx, audited := s.audit.begin(ctx, "BDeploy.DeleteSetupRecordV2", setupID, nil)
+	defer func() { audited(err) }()
+
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "BDeploy.DeleteSetupRecordV2", "")
+	defer func() { traced(err) }()
+
+	if setupID == "" {
+		return nil, errors.NewValidationError("setupID", setupID, "setup ID cannot be empty")
+	}
+
+	// Ensure we have authentication
+	if err := s.authManager.EnsureValid(ctx); err != nil {
+		return nil, err
+	}
+
+	// Get access token
+	token, err := s.authManager.GetToken()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.snapshotSetupV2(ctx, setupID, history.OperationDelete); err != nil {
+		return nil, err
+	}
+
+	deleteURL := setupV2URL + "?_id=" + url.QueryEscape(setupID)
+
+	var response types.BDeployDeleteResponse
+	err = s.httpClient.DeleteWithAuth(ctx, token, deleteURL, &response)
+	if err != nil {
+		return nil, errors.WrapError("BDeploy.DeleteSetupRecordV2", "bdeploy_delete_failed", "Failed to delete B-Deploy v2 setup record", err)
 	}
 
 	return &response, nil
 }
 
 // GetDeviceBySerial retrieves a B-Deploy device setup record by serial number.
-func (s *bDeployService) GetDeviceBySerial(ctx context.Context, serial string) (*types.BDeployDeviceResponse, error) {
+// A missing setupId is filled in from the association ledger.
+func (s *bDeployService) GetDeviceBySerial(ctx context.Context, serial string) (_ *types.BDeployDeviceResponse, err error) {
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "BDeploy.GetDeviceBySerial", serial)
+	defer func() { traced(err) }()
+
 	if serial == "" {
 		return nil, errors.NewValidationError("serial", serial, "serial number cannot be empty")
 	}
@@ -319,8 +801,8 @@ func (s *bDeployService) GetDeviceBySerial(ctx context.Context, serial string) (
 	// Include NetworkName if we have a current network set (required for full device record with setupId)
 	params := url.Values{}
 	params.Set("serial", serial)
-	if s.currentNetwork != "" {
-		params.Set("NetworkName", s.currentNetwork)
+	if network := s.networkName(); network != "" {
+		params.Set("NetworkName", network)
 	}
 	deviceURL := fmt.Sprintf("https://provision.bsn.cloud/rest-device/v2/device/?%s", params.Encode())
 
@@ -328,6 +810,7 @@ func (s *bDeployService) GetDeviceBySerial(ctx context.Context, serial string) (
 	var wrappedResponse types.BDeployDeviceResponse
 	err = s.httpClient.GetWithAuth(ctx, token, deviceURL, &wrappedResponse)
 	if err == nil && wrappedResponse.Result.Players != nil && len(wrappedResponse.Result.Players) > 0 {
+		s.enrichFromLedger(ctx, wrappedResponse.Result.Players)
 		return &wrappedResponse, nil
 	}
 
@@ -338,6 +821,8 @@ func (s *bDeployService) GetDeviceBySerial(ctx context.Context, serial string) (
 		return nil, err
 	}
 
+	s.enrichFromLedger(ctx, directResult.Players)
+
 	// Wrap the direct response in the expected format
 	return &types.BDeployDeviceResponse{
 		Error:  nil,
@@ -348,7 +833,11 @@ func (s *bDeployService) GetDeviceBySerial(ctx context.Context, serial string) (
 // GetAllDevices retrieves all B-Deploy devices on the network.
 // This method uses the network context set via SetNetworkContext.
 // The network context must be set before calling this method, or it may return 0 devices.
-func (s *bDeployService) GetAllDevices(ctx context.Context, opts ...BDeployDeviceListOption) (*types.BDeployDeviceListResponse, error) {
+// Missing setupIds are filled in from the association ledger.
+func (s *bDeployService) GetAllDevices(ctx context.Context, opts ...BDeployDeviceListOption) (_ *types.BDeployDeviceListResponse, err error) {
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "BDeploy.GetAllDevices", "")
+	defer func() { traced(err) }()
+
 	// Ensure we have authentication
 	if err := s.authManager.EnsureValid(ctx); err != nil {
 		return nil, err
@@ -369,8 +858,8 @@ func (s *bDeployService) GetAllDevices(ctx context.Context, opts ...BDeployDevic
 	params := url.Values{}
 
 	// Add NetworkName query parameter if we have a current network set
-	if s.currentNetwork != "" {
-		params.Set("NetworkName", s.currentNetwork)
+	if network := s.networkName(); network != "" {
+		params.Set("NetworkName", network)
 	}
 
 	// Add setupName filter if specified (using query[setupName] format)
@@ -390,6 +879,7 @@ func (s *bDeployService) GetAllDevices(ctx context.Context, opts ...BDeployDevic
 	var wrappedResponse types.BDeployDeviceListAPIResponse
 	err = s.httpClient.GetWithAuth(ctx, token, deviceListURL, &wrappedResponse)
 	if err == nil && wrappedResponse.Result != nil {
+		s.enrichFromLedger(ctx, wrappedResponse.Result.Players)
 		return wrappedResponse.Result, nil
 	}
 
@@ -400,6 +890,7 @@ func (s *bDeployService) GetAllDevices(ctx context.Context, opts ...BDeployDevic
 		return nil, err
 	}
 
+	s.enrichFromLedger(ctx, directResponse.Players)
 	return &directResponse, nil
 }
 
@@ -485,8 +976,15 @@ func WithSetupName(setupName string) BDeployDeviceListOption {
 }
 
 // CreateDevice creates a new B-Deploy device record.
-// This registers a device serial number with the B-Deploy system.
-func (s *bDeployService) CreateDevice(ctx context.Context, request *types.BDeployDeviceRequest) (string, error) {
+// This registers a device serial number with the B-Deploy system. A device created
+// with a SetupID is recorded in the association ledger.
+func (s *bDeployService) CreateDevice(ctx context.Context, request *types.BDeployDeviceRequest) (_ string, err error) {
+	ctx, audited := s.audit.begin(ctx, "BDeploy.CreateDevice", deviceRequestSerial(request), deviceParams(request))
+	defer func() { audited(err) }()
+
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "BDeploy.CreateDevice", deviceRequestSerial(request))
+	defer func() { traced(err) }()
+
 	if request.Serial == "" {
 		return "", errors.NewValidationError("serial", request.Serial, "serial number cannot be empty")
 	}
@@ -520,18 +1018,36 @@ func (s *bDeployService) CreateDevice(ctx context.Context, request *types.BDeplo
 		return "", fmt.Errorf("API error creating device: %v", response.Error)
 	}
 
+	if request.SetupID != "" {
+		if err := s.recordAssociation(ctx, response.Result, request); err != nil {
+			return "", fmt.Errorf("device created but failed to record association: %w", err)
+		}
+	}
+
 	return response.Result, nil
 }
 
 // UpdateDevice updates an existing B-Deploy device record.
-// This is used to associate a device with a setup ID.
-func (s *bDeployService) UpdateDevice(ctx context.Context, deviceID string, request *types.BDeployDeviceRequest) (*types.BDeployDevice, error) {
+// This is used to associate a device with a setup ID. The association is written
+// to the association ledger. B-Deploy clears the association of an update without
+// a setupId, so an update without a SetupID sends the setup ID the ledger has for
+// the device. Set Dissociate to send a null setupId and remove the ledger entry.
+func (s *bDeployService) UpdateDevice(ctx context.Context, deviceID string, request *types.BDeployDeviceRequest) (_ *types.BDeployDevice, err error) {
+	ctx, audited := s.audit.begin(ctx, "BDeploy.UpdateDevice", deviceID, deviceParams(request))
+	defer func() { audited(err) }()
+
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "BDeploy.UpdateDevice", deviceRequestSerial(request))
+	defer func() { traced(err) }()
+
 	if deviceID == "" {
 		return nil, errors.NewValidationError("deviceID", deviceID, "device ID cannot be empty")
 	}
 	if request.Serial == "" {
 		return nil, errors.NewValidationError("serial", request.Serial, "serial number cannot be empty")
 	}
+	if request.Dissociate && request.SetupID != "" {
+		return nil, errors.NewValidationError("setupId", request.SetupID, "setup ID must be empty to dissociate a device")
+	}
 
 	// Ensure we have authentication
 	if err := s.authManager.EnsureValid(ctx); err != nil {
@@ -546,11 +1062,21 @@ func (s *bDeployService) UpdateDevice(ctx context.Context, deviceID string, requ
 	// Ensure the device ID is set in the request
 	request.ID = deviceID
 
+	// Keep the device's setup unless it is being dissociated
+	request, err = s.keepAssociation(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	var body interface{} = request
+	if request.Dissociate {
+		body = dissociateBody{BDeployDeviceRequest: request}
+	}
+
 	// PUT to /rest-device/v2/device?_id={deviceID}
 	updateURL := fmt.Sprintf("https://provision.bsn.cloud/rest-device/v2/device?_id=%s", url.QueryEscape(deviceID))
 
 	var response types.BDeployDeviceUpdateResponse
-	err = s.httpClient.PutWithAuth(ctx, token, updateURL, request, &response)
+	err = s.httpClient.PutWithAuth(ctx, token, updateURL, body, &response)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update device: %w", err)
 	}
@@ -559,6 +1085,12 @@ func (s *bDeployService) UpdateDevice(ctx context.Context, deviceID string, requ
 		return nil, fmt.Errorf("API error updating device: %v", response.Error)
 	}
 
+	// Record the association before anything reads the device back, since
+	// B-Deploy will not return the setupId we just sent
+	if err := s.recordAssociation(ctx, deviceID, request); err != nil {
+		return nil, fmt.Errorf("device updated but failed to record association: %w", err)
+	}
+
 	// The API may return success without a result body
 	// In this case, fetch the device to verify the update
 	if response.Result == nil {
@@ -601,7 +1133,13 @@ func (s *bDeployService) UpdateDevice(ctx context.Context, deviceID string, requ
 
 // DeleteDevice removes a device from the B-Deploy system.
 // Either deviceID or serial must be provided. If both are provided, deviceID takes precedence.
-func (s *bDeployService) DeleteDevice(ctx context.Context, deviceID string, serial string) error {
+func (s *bDeployService) DeleteDevice(ctx context.Context, deviceID string, serial string) (err error) {
+	ctx, audited := s.audit.begin(ctx, "BDeploy.DeleteDevice", deviceID, auditParams{"serial": serial})
+	defer func() { audited(err) }()
+
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "BDeploy.DeleteDevice", serial)
+	defer func() { traced(err) }()
+
 	// Validate that at least one identifier is provided
 	if deviceID == "" && serial == "" {
 		return errors.NewValidationError("deviceID/serial", "", "either device ID or serial number must be provided")
@@ -648,5 +1186,121 @@ func (s *bDeployService) DeleteDevice(ctx context.Context, deviceID string, seri
 		return fmt.Errorf("API error deleting device: %s", response.Error)
 	}
 
+	if err := s.forgetAssociation(ctx, deviceID, serial); err != nil {
+		return fmt.Errorf("device deleted but failed to remove association: %w", err)
+	}
+
+	return nil
+}
+
+// GetDeviceBySerial and GetAllDevices never see a setupId from B-Deploy (see
+// docs/bdeploy-api-limitations.md), so associations made by UpdateDevice are
+// kept in the association ledger and filled back in from there.
+
+// enrichFromLedger fills in the setupId of devices B-Deploy returned without one.
+// The ledger is best effort here: if it cannot be read, devices are left as is.
+func (s *bDeployService) enrichFromLedger(ctx context.Context, devices []types.BDeployDevice) {
+	if s.ledger == nil || len(devices) == 0 {
+		return
+	}
+	entries, err := s.ledger.List(ctx, "")
+	if err != nil {
+		return
+	}
+
+	type key struct{ network, serial string }
+	setupIDs := make(map[key]string, len(entries))
+	for _, entry := range entries {
+		setupIDs[key{entry.NetworkName, entry.Serial}] = entry.SetupID
+	}
+
+	for i := range devices {
+		device := &devices[i]
+		if device.SetupID != "" {
+			continue
+		}
+		network := device.NetworkName
+		if network == "" {
+			network = s.networkName()
+		}
+		device.SetupID = setupIDs[key{network, device.Serial}]
+	}
+}
+
+// dissociateBody is the body of a device update that dissociates the device: its
+// setupId is sent as an explicit null.
+type dissociateBody struct {
+	*types.BDeployDeviceRequest
+	SetupID *string `json:"setupId"`
+}
+
+// keepAssociation returns the request to send for a device update. An update
+// without a setup ID that is not a dissociation gets the device's setup ID from
+// the ledger, in a copy, since B-Deploy would otherwise clear it.
+func (s *bDeployService) keepAssociation(ctx context.Context, request *types.BDeployDeviceRequest) (*types.BDeployDeviceRequest, error) {
+	if s.ledger == nil || request.Dissociate || request.SetupID != "" {
+		return request, nil
+	}
+	network := request.NetworkName
+	if network == "" {
+		network = s.networkName()
+	}
+	entry, ok, err := s.ledger.Get(ctx, network, request.Serial)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the device's association: %w", err)
+	}
+	if !ok {
+		return request, nil
+	}
+	kept := *request
+	kept.SetupID = entry.SetupID
+	return &kept, nil
+}
+
+// recordAssociation writes the association just sent to B-Deploy to the ledger,
+// or removes the entry when the update left the device without a setup.
+func (s *bDeployService) recordAssociation(ctx context.Context, deviceID string, request *types.BDeployDeviceRequest) error {
+	if s.ledger == nil {
+		return nil
+	}
+	network := request.NetworkName
+	if network == "" {
+		network = s.networkName()
+	}
+	if request.SetupID == "" {
+		return s.ledger.Delete(ctx, network, request.Serial)
+	}
+
+	entry := ledger.Entry{
+		NetworkName: network,
+		Serial:      request.Serial,
+		DeviceID:    deviceID,
+		SetupID:     request.SetupID,
+		UpdatedAt:   time.Now().UTC(),
+	}
+	// The package name is what B-Deploy reports as the device's setupName, which
+	// is how the ledger is checked later; the association stands without it
+	if record, err := s.GetSetupRecord(ctx, request.SetupID); err == nil {
+		entry.SetupName = record.BDeploy.PackageName
+	}
+	return s.ledger.Put(ctx, entry)
+}
+
+// forgetAssociation removes a deleted device from the ledger.
+func (s *bDeployService) forgetAssociation(ctx context.Context, deviceID, serial string) error {
+	if s.ledger == nil {
+		return nil
+	}
+	entries, err := s.ledger.List(ctx, "")
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if (deviceID != "" && entry.DeviceID == deviceID) || (deviceID == "" && entry.Serial == serial) {
+			if err := s.ledger.Delete(ctx, entry.NetworkName, entry.Serial); err != nil {
+				return err
+			}
+		}
+	}
 	return nil
 }
@@ -0,0 +1,409 @@
+package services
+
+import (
+	"context"
+	stderrors "errors"
+	"io"
+	nethttp "net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brightdevelopers/gopurple/internal/auth"
+	"github.com/brightdevelopers/gopurple/internal/config"
+	"github.com/brightdevelopers/gopurple/internal/history"
+	"github.com/brightdevelopers/gopurple/internal/http"
+	"github.com/brightdevelopers/gopurple/internal/ledger"
+	"github.com/brightdevelopers/gopurple/internal/types"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newTestBDeployService(opts ...config.Option) BDeployService {
+	cfg := config.DefaultConfig()
+	cfg.ClientID = "test-id"
+	cfg.ClientSecret = "test-secret"
+	cfg.TokenEndpoint = "http://127.0.0.1:1/token"
+	cfg.RetryCount = 0
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	httpClient := http.NewHTTPClient(cfg)
+	authManager := auth.NewAuthManager(cfg, httpClient)
+	return NewBDeployService(cfg, httpClient, authManager)
+}
+
+func TestBDeployService_ValidatesSetupRecords(t *testing.T) {
+	ctx := context.Background()
+	record := &types.BDeploySetupRecord{SetupType: "bsn", ProxyPort: 70000, UseProxy: true}
+
+	var validationErr *types.SetupValidationError
+	if _, err := newTestBDeployService().AddSetupRecord(ctx, record); !stderrors.As(err, &validationErr) {
+		t.Errorf("Expected AddSetupRecord to reject an invalid record, got %v", err)
+	}
+	if _, err := newTestBDeployService().UpdateSetupRecord(ctx, "setup-1", record); !stderrors.As(err, &validationErr) {
+		t.Errorf("Expected UpdateSetupRecord to reject an invalid record, got %v", err)
+	}
+
+	// With validation disabled the record is sent, failing here on authentication instead
+	_, err := newTestBDeployService(config.WithSetupValidation(false)).AddSetupRecord(ctx, record)
+	if err == nil || stderrors.As(err, &validationErr) {
+		t.Errorf("Expected validation to be skipped, got %v", err)
+	}
+}
+
+func TestBDeployService_PatchSetupRecord(t *testing.T) {
+	service := newTestBDeployService()
+	ctx := context.Background()
+
+	if _, err := service.PatchSetupRecord(ctx, "", map[string]interface{}{"dwsEnabled": false}); err == nil {
+		t.Error("Expected error when patching with empty setup ID")
+	}
+	if _, err := service.PatchSetupRecord(ctx, "setup-1", nil); err == nil {
+		t.Error("Expected error when patching with no changes")
+	}
+	if _, err := service.PatchSetupRecord(ctx, "setup-1", map[string]interface{}{"dwsEnable": false}); err == nil {
+		t.Error("Expected error when patching an unknown field")
+	}
+
+	// Without authentication the fetch fails
+	if _, err := service.PatchSetupRecord(ctx, "setup-1", map[string]interface{}{"dwsEnabled": false}); err == nil {
+		t.Error("Expected error when patching without authentication")
+	}
+}
+
+// roundTripFunc answers requests in tests without a network.
+type roundTripFunc func(req *nethttp.Request) (*nethttp.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *nethttp.Request) (*nethttp.Response, error) {
+	return f(req)
+}
+
+func jsonResponse(req *nethttp.Request, body string) *nethttp.Response {
+	return &nethttp.Response{
+		StatusCode: nethttp.StatusOK,
+		Header:     nethttp.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}
+}
+
+func TestBDeployService_PatchSetupRecord_StoredSecrets(t *testing.T) {
+	stored := `{"_id":"setup-1","version":"3.0.0","setupType":"bsn","bDeploy":{"username":"u","networkName":"n","packageName":"p"},` +
+		`"useWireless":true,"ssid":"store","passphrase":"file:/etc/wifi-key"}`
+	var sent string
+	transport := roundTripFunc(func(req *nethttp.Request) (*nethttp.Response, error) {
+		if req.Method == nethttp.MethodPut {
+			body, _ := io.ReadAll(req.Body)
+			sent = string(body)
+			return jsonResponse(req, `{"error":null,"result":`+sent+`}`), nil
+		}
+		return jsonResponse(req, `{"error":null,"result":[`+stored+`]}`), nil
+	})
+
+	// The passphrase B-Deploy stores looks like a reference, but is sent back as it is
+	service := newTestBDeployService(
+		config.WithHTTPClient(&nethttp.Client{Transport: transport}),
+		config.WithAccessToken("token", time.Now().Add(time.Hour)),
+		config.WithSecretResolver(staticSecrets("resolved")),
+	)
+	if _, err := service.PatchSetupRecord(context.Background(), "setup-1", map[string]interface{}{"dwsEnabled": false}); err != nil {
+		t.Fatalf("PatchSetupRecord failed: %v", err)
+	}
+	if !strings.Contains(sent, `"passphrase":"file:/etc/wifi-key"`) || strings.Contains(sent, "resolved") {
+		t.Errorf("Expected the stored passphrase to be sent unresolved, got %s", sent)
+	}
+
+	// A reference in the changes comes from the caller and is resolved
+	if _, err := service.PatchSetupRecord(context.Background(), "setup-1", map[string]interface{}{"lwsPassword": "env:LWS"}); err != nil {
+		t.Fatalf("PatchSetupRecord failed: %v", err)
+	}
+	if !strings.Contains(sent, `"lwsPassword":"resolved"`) {
+		t.Errorf("Expected the new password to be resolved, got %s", sent)
+	}
+
+	// and refused without a resolver
+	service = newTestBDeployService(
+		config.WithHTTPClient(&nethttp.Client{Transport: transport}),
+		config.WithAccessToken("token", time.Now().Add(time.Hour)),
+	)
+	sent = ""
+	if _, err := service.PatchSetupRecord(context.Background(), "setup-1", map[string]interface{}{"lwsPassword": "env:LWS"}); err == nil || sent != "" {
+		t.Errorf("Expected a reference without a resolver to be refused, got %v (sent %s)", err, sent)
+	}
+}
+
+func TestBDeployService_PatchSetupRecord_Trace(t *testing.T) {
+	stored := `{"_id":"setup-1","version":"3.0.0","setupType":"bsn","bDeploy":{"username":"u","networkName":"n","packageName":"p"}}`
+	transport := roundTripFunc(func(req *nethttp.Request) (*nethttp.Response, error) {
+		if req.Method == nethttp.MethodPut {
+			body, _ := io.ReadAll(req.Body)
+			return jsonResponse(req, `{"error":null,"result":`+string(body)+`}`), nil
+		}
+		return jsonResponse(req, `{"error":null,"result":[`+stored+`]}`), nil
+	})
+	spans := tracetest.NewInMemoryExporter()
+	service := newTestBDeployService(
+		config.WithHTTPClient(&nethttp.Client{Transport: transport}),
+		config.WithAccessToken("token", time.Now().Add(time.Hour)),
+		config.WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(spans))),
+	)
+	if _, err := service.PatchSetupRecord(context.Background(), "setup-1", map[string]interface{}{"dwsEnabled": false}); err != nil {
+		t.Fatalf("PatchSetupRecord failed: %v", err)
+	}
+
+	// The get and the update are children of one operation span, with their
+	// request spans under them
+	ended := spans.GetSpans()
+	parents := map[string]string{}
+	names := map[trace.SpanID]string{}
+	for _, span := range ended {
+		names[span.SpanContext.SpanID()] = span.Name
+	}
+	for _, span := range ended {
+		if span.SpanContext.TraceID() != ended[0].SpanContext.TraceID() {
+			t.Errorf("Expected one trace, got span %q in another", span.Name)
+		}
+		if span.SpanKind != trace.SpanKindClient {
+			parents[span.Name] = names[span.Parent.SpanID()]
+		}
+	}
+	expected := map[string]string{
+		"BDeploy.PatchSetupRecord":  "",
+		"BDeploy.GetSetupRecord":    "BDeploy.PatchSetupRecord",
+		"GET":                       "BDeploy.GetSetupRecord",
+		"BDeploy.UpdateSetupRecord": "BDeploy.PatchSetupRecord",
+		"PUT":                       "BDeploy.UpdateSetupRecord",
+	}
+	if !reflect.DeepEqual(parents, expected) {
+		t.Errorf("Unexpected span parents:\n got  %v\n want %v", parents, expected)
+	}
+}
+
+func TestBDeployService_V2SetupRecords(t *testing.T) {
+	service := newTestBDeployService()
+	ctx := context.Background()
+
+	if _, err := service.GetSetupRecordV2(ctx, ""); err == nil {
+		t.Error("Expected error when getting a v2 record with empty setup ID")
+	}
+	if _, err := service.DeleteSetupRecordV2(ctx, ""); err == nil {
+		t.Error("Expected error when deleting a v2 record with empty setup ID")
+	}
+
+	record := &types.BDeploySetupRecord{Version: types.SetupVersion3, SetupType: "bsn"}
+	if _, err := service.AddSetupRecordV2(ctx, record); err == nil {
+		t.Error("Expected AddSetupRecordV2 to reject a v3 record")
+	}
+
+	var validationErr *types.SetupValidationError
+	record = &types.BDeploySetupRecord{SetupType: "bsn"}
+	if _, err := service.UpdateSetupRecordV2(ctx, "setup-1", record); !stderrors.As(err, &validationErr) {
+		t.Errorf("Expected UpdateSetupRecordV2 to validate the record, got %v", err)
+	}
+	if record.Version != "" {
+		t.Errorf("Expected the caller's record to keep its empty version, got %q", record.Version)
+	}
+}
+
+func TestBDeployService_V2SetupHistory(t *testing.T) {
+	stored := `{"_id":"setup-1","version":"2.0.0","setupType":"bsn","bDeploy":{"username":"u","networkName":"n","packageName":"p"}}`
+	var sent string
+	transport := roundTripFunc(func(req *nethttp.Request) (*nethttp.Response, error) {
+		switch req.Method {
+		case nethttp.MethodGet:
+			return jsonResponse(req, `{"error":null,"result":[`+stored+`]}`), nil
+		case nethttp.MethodPut:
+			body, _ := io.ReadAll(req.Body)
+			sent = string(body)
+			return jsonResponse(req, `{"error":null,"result":`+sent+`}`), nil
+		default:
+			return jsonResponse(req, `{"success":true}`), nil
+		}
+	})
+	store := history.NewMemoryStore()
+	service := newTestBDeployService(
+		config.WithHTTPClient(&nethttp.Client{Transport: transport}),
+		config.WithAccessToken("token", time.Now().Add(time.Hour)),
+		config.WithSetupHistory(store),
+	)
+	ctx := context.Background()
+
+	record := &types.BDeploySetupRecord{SetupType: "bsn", BDeploy: types.BDeployInfo{Username: "u", NetworkName: "n", PackageName: "p"}, Hostname: "lobby"}
+	if _, err := service.UpdateSetupRecordV2(ctx, "setup-1", record); err != nil {
+		t.Fatalf("UpdateSetupRecordV2 failed: %v", err)
+	}
+	if record.Version != "" || record.ID != "" || !strings.Contains(sent, `"version":"2.0.0"`) || !strings.Contains(sent, `"_id":"setup-1"`) {
+		t.Errorf("Expected version 2.0.0 and the ID to be sent without changing the record, got %+v and %s", record, sent)
+	}
+	if _, err := service.DeleteSetupRecordV2(ctx, "setup-1"); err != nil {
+		t.Fatalf("DeleteSetupRecordV2 failed: %v", err)
+	}
+
+	snapshots, err := store.List(ctx, "setup-1")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(snapshots) != 2 || snapshots[0].Operation != history.OperationUpdate || snapshots[1].Operation != history.OperationDelete {
+		t.Fatalf("Expected an update and a delete snapshot, got %+v", snapshots)
+	}
+	if snapshots[0].API != history.APIV2 || snapshots[0].Record.Version != types.SetupVersion2 {
+		t.Errorf("Expected the snapshot to hold the stored v2 record, got %+v", snapshots[0].Record)
+	}
+}
+
+func TestBDeployService_SetupHistory(t *testing.T) {
+	ctx := context.Background()
+	store := history.NewMemoryStore()
+	service := newTestBDeployService(config.WithSetupHistory(store)).(*bDeployService)
+
+	if err := newTestBDeployService().(*bDeployService).snapshotSetup(ctx, "setup-1", history.OperationUpdate); err != nil {
+		t.Errorf("Expected no snapshot without a setup history, got %v", err)
+	}
+
+	// Without authentication the snapshot cannot be taken, so nothing is changed
+	record := &types.BDeploySetupRecord{
+		Version:   "3.0.0",
+		SetupType: "bsn",
+		BDeploy:   types.BDeployInfo{Username: "u", NetworkName: "n", PackageName: "p"},
+	}
+	if _, err := service.UpdateSetupRecord(ctx, "setup-1", record); err == nil {
+		t.Error("Expected UpdateSetupRecord to fail when the snapshot cannot be taken")
+	}
+	if snapshots, _ := store.List(ctx, ""); len(snapshots) != 0 {
+		t.Errorf("Expected no snapshots, got %d", len(snapshots))
+	}
+}
+
+// staticSecrets resolves every reference to a fixed value.
+type staticSecrets string
+
+func (v staticSecrets) Resolve(ctx context.Context, ref string) (string, error) {
+	return string(v), nil
+}
+
+func TestBDeployService_PrepareSetupRecord(t *testing.T) {
+	ctx := context.Background()
+	record := &types.BDeploySetupRecord{
+		Version:     "3.0.0",
+		SetupType:   "bsn",
+		BDeploy:     types.BDeployInfo{Username: "u", NetworkName: "n", PackageName: "p"},
+		UseWireless: true,
+		SSID:        "store",
+		Passphrase:  "env:WIFI",
+	}
+
+	service := newTestBDeployService(config.WithSecretResolver(staticSecrets("wifi-passphrase"))).(*bDeployService)
+	send, err := service.prepareSetupRecord(ctx, record)
+	if err != nil {
+		t.Fatalf("prepareSetupRecord failed: %v", err)
+	}
+	if send.Passphrase != "wifi-passphrase" || record.Passphrase != "env:WIFI" {
+		t.Errorf("Expected the reference to be resolved in a copy, got %q (record %q)", send.Passphrase, record.Passphrase)
+	}
+
+	// The resolved value is validated, not the reference
+	service = newTestBDeployService(config.WithSecretResolver(staticSecrets("short"))).(*bDeployService)
+	if _, err := service.prepareSetupRecord(ctx, record); err == nil {
+		t.Error("Expected the resolved passphrase to be validated")
+	}
+
+	// Without a resolver references are refused, unless the values were read
+	// back from B-Deploy
+	service = newTestBDeployService(config.WithSecretResolver(nil)).(*bDeployService)
+	if _, err := service.prepareSetupRecord(ctx, record); err == nil || !strings.Contains(err.Error(), "WithSecretReferences") {
+		t.Errorf("Expected a reference without a resolver to be refused, got %v", err)
+	}
+	if err := service.ValidateSetupRecord(record); err == nil {
+		t.Error("Expected ValidateSetupRecord to refuse a reference without a resolver")
+	}
+	if send, err := service.prepareSetupRecord(WithoutSecretResolution(ctx), record); err != nil || send.Passphrase != "env:WIFI" {
+		t.Errorf("Expected stored values to be sent as is, got %+v (%v)", send, err)
+	}
+}
+
+func TestBDeployService_UpdateDeviceSetupID(t *testing.T) {
+	var sent string
+	transport := roundTripFunc(func(req *nethttp.Request) (*nethttp.Response, error) {
+		if req.Method == nethttp.MethodPut {
+			body, _ := io.ReadAll(req.Body)
+			sent = string(body)
+			return jsonResponse(req, `{"error":null,"result":{"_id":"dev-1","serial":"XD001"}}`), nil
+		}
+		return jsonResponse(req, `{"error":"not found","result":[]}`), nil
+	})
+	ctx := context.Background()
+	store := ledger.NewMemoryStore()
+	store.Put(ctx, ledger.Entry{NetworkName: "Retail", Serial: "XD001", SetupID: "setup-1"})
+	service := newTestBDeployService(
+		config.WithHTTPClient(&nethttp.Client{Transport: transport}),
+		config.WithAccessToken("token", time.Now().Add(time.Hour)),
+		config.WithAssociationLedger(store),
+		config.WithNetwork("Retail"),
+	)
+
+	// A plain update sends the setup ID the ledger has, so B-Deploy keeps it
+	request := &types.BDeployDeviceRequest{Serial: "XD001", Name: "Lobby"}
+	if _, err := service.UpdateDevice(ctx, "dev-1", request); err != nil {
+		t.Fatalf("UpdateDevice failed: %v", err)
+	}
+	if !strings.Contains(sent, `"setupId":"setup-1"`) || request.SetupID != "" {
+		t.Errorf("Expected the ledger's setup ID to be sent in a copy, got %s (request %q)", sent, request.SetupID)
+	}
+	if _, ok, _ := store.Get(ctx, "Retail", "XD001"); !ok {
+		t.Error("Expected a plain update to keep the ledger entry")
+	}
+
+	// Dissociating sends an explicit null and removes the entry
+	if _, err := service.UpdateDevice(ctx, "dev-1", &types.BDeployDeviceRequest{Serial: "XD001", Dissociate: true}); err != nil {
+		t.Fatalf("UpdateDevice failed: %v", err)
+	}
+	if !strings.Contains(sent, `"setupId":null`) || strings.Count(sent, "setupId") != 1 {
+		t.Errorf("Expected a null setupId to be sent, got %s", sent)
+	}
+	if _, ok, _ := store.Get(ctx, "Retail", "XD001"); ok {
+		t.Error("Expected dissociation to remove the ledger entry")
+	}
+}
+
+func TestBDeployService_EnrichFromLedger(t *testing.T) {
+	ctx := context.Background()
+	store := ledger.NewMemoryStore()
+	store.Put(ctx, ledger.Entry{NetworkName: "Retail", Serial: "XD001", SetupID: "setup-1"})
+	store.Put(ctx, ledger.Entry{NetworkName: "Lab", Serial: "XD002", SetupID: "setup-2"})
+
+	service := newTestBDeployService(config.WithAssociationLedger(store), config.WithNetwork("Retail")).(*bDeployService)
+
+	devices := []types.BDeployDevice{
+		{Serial: "XD001"},
+		{Serial: "XD002"},
+		{Serial: "XD002", NetworkName: "Lab"},
+		{Serial: "XD003", SetupID: "from-api"},
+	}
+	service.enrichFromLedger(ctx, devices)
+
+	expected := []string{"setup-1", "", "setup-2", "from-api"}
+	for i, device := range devices {
+		if device.SetupID != expected[i] {
+			t.Errorf("Device %d: expected setupId %q, got %q", i, expected[i], device.SetupID)
+		}
+	}
+
+	// An update that leaves the device without a setup removes the entry
+	if err := service.recordAssociation(ctx, "dev-1", &types.BDeployDeviceRequest{Serial: "XD001"}); err != nil {
+		t.Fatalf("recordAssociation failed: %v", err)
+	}
+	if _, ok, _ := store.Get(ctx, "Retail", "XD001"); ok {
+		t.Error("Expected dissociation to remove the ledger entry")
+	}
+
+	if err := service.forgetAssociation(ctx, "", "XD002"); err != nil {
+		t.Fatalf("forgetAssociation failed: %v", err)
+	}
+	if entries, _ := store.List(ctx, ""); len(entries) != 0 {
+		t.Errorf("Expected deleted device to be removed from the ledger, got %+v", entries)
+	}
+}
@@ -0,0 +1,154 @@
+package services
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/brightdevelopers/gopurple/internal/config"
+	"github.com/brightdevelopers/gopurple/internal/errors"
+	"github.com/brightdevelopers/gopurple/internal/types"
+)
+
+// defaultBreakerCooldown is the cooldown of a breaker configured without one.
+const defaultBreakerCooldown = time.Minute
+
+// playerBreaker is a circuit breaker per player serial, so that calls to a player
+// that keeps being unreachable fail at once.
+type playerBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	onChange  func(types.PlayerBreakerEvent)
+
+	mu      sync.Mutex
+	players map[string]*breakerEntry
+}
+
+// breakerEntry is the breaker of one player. Closed players with no failures
+// have none.
+type breakerEntry struct {
+	state    types.BreakerState
+	failures int
+	until    time.Time // When an open breaker half-opens
+	trial    bool      // A half-open breaker's trial call is in flight
+}
+
+// newPlayerBreaker returns the configured breaker, or nil if it is off.
+func newPlayerBreaker(cfg *config.Config) *playerBreaker {
+	if cfg.PlayerBreakerThreshold <= 0 {
+		return nil
+	}
+	cooldown := cfg.PlayerBreakerCooldown
+	if cooldown <= 0 {
+		cooldown = defaultBreakerCooldown
+	}
+	return &playerBreaker{
+		threshold: cfg.PlayerBreakerThreshold,
+		cooldown:  cooldown,
+		onChange:  cfg.OnPlayerBreaker,
+		players:   map[string]*breakerEntry{},
+	}
+}
+
+// allow returns an error matching ErrPlayerOffline if calls to the player should
+// fail at once. Once the cooldown is over it lets one trial call through.
+func (b *playerBreaker) allow(serial, route string) error {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	entry := b.players[serial]
+	if entry == nil || entry.state == types.BreakerClosed {
+		b.mu.Unlock()
+		return nil
+	}
+
+	var event *types.PlayerBreakerEvent
+	if entry.state == types.BreakerOpen && !time.Now().Before(entry.until) {
+		entry.state = types.BreakerHalfOpen
+		entry.until = time.Time{}
+		event = &types.PlayerBreakerEvent{Serial: serial, State: types.BreakerHalfOpen, Failures: entry.failures}
+	}
+	var err error
+	if entry.state == types.BreakerHalfOpen && !entry.trial {
+		entry.trial = true
+	} else {
+		message := fmt.Sprintf("unreachable on the last %d calls; waiting for a trial call", entry.failures)
+		if entry.state == types.BreakerOpen {
+			message = fmt.Sprintf("unreachable on the last %d calls; not calling it again until %s", entry.failures, entry.until.Format(time.RFC3339))
+		}
+		err = &errors.PlayerError{Serial: serial, Route: route, Message: message, Offline: true}
+	}
+	b.mu.Unlock()
+
+	b.notify(event)
+	return err
+}
+
+// record counts the result of a call that allow let through. The error is
+// classified as the service would report it, so gateway errors count as offline.
+func (b *playerBreaker) record(ctx context.Context, serial string, err error) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	entry := b.players[serial]
+	var event *types.PlayerBreakerEvent
+	switch {
+	case err != nil && ctx.Err() != nil:
+		// The caller gave up, which says nothing about the player
+		if entry != nil {
+			entry.trial = false
+		}
+
+	case err != nil && stderrors.Is(errors.WrapPlayerError("", serial, "", "", err), errors.ErrPlayerOffline):
+		if entry == nil {
+			entry = &breakerEntry{state: types.BreakerClosed}
+			b.players[serial] = entry
+		}
+		entry.failures++
+		entry.trial = false
+		if entry.state == types.BreakerHalfOpen || (entry.state == types.BreakerClosed && entry.failures >= b.threshold) {
+			entry.state = types.BreakerOpen
+			entry.until = time.Now().Add(b.cooldown)
+			event = &types.PlayerBreakerEvent{Serial: serial, State: types.BreakerOpen, Failures: entry.failures, Until: entry.until}
+		}
+
+	default:
+		// The player answered, even if with an error
+		if entry != nil {
+			if entry.state != types.BreakerClosed {
+				event = &types.PlayerBreakerEvent{Serial: serial, State: types.BreakerClosed}
+			}
+			delete(b.players, serial)
+		}
+	}
+	b.mu.Unlock()
+
+	b.notify(event)
+}
+
+// state returns the state of the player's breaker.
+func (b *playerBreaker) state(serial string) types.BreakerState {
+	if b == nil {
+		return types.BreakerClosed
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if entry := b.players[serial]; entry != nil {
+		return entry.state
+	}
+	return types.BreakerClosed
+}
+
+func (b *playerBreaker) notify(event *types.PlayerBreakerEvent) {
+	if event != nil && b.onChange != nil {
+		b.onChange(*event)
+	}
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/brightdevelopers/gopurple/internal/config"
+	"github.com/brightdevelopers/gopurple/internal/errors"
+	"github.com/brightdevelopers/gopurple/internal/types"
+)
+
+func TestPlayerBreaker(t *testing.T) {
+	var events []types.PlayerBreakerEvent
+	b := newPlayerBreaker(&config.Config{
+		PlayerBreakerThreshold: 2,
+		PlayerBreakerCooldown:  50 * time.Millisecond,
+		OnPlayerBreaker:        func(e types.PlayerBreakerEvent) { events = append(events, e) },
+	})
+	ctx := context.Background()
+	offline := errors.NewPlayerError("XD001", "/v1/info/", "Player is offline")
+
+	// A rejected command is an answer, so it does not count
+	b.record(ctx, "XD001", offline)
+	b.record(ctx, "XD001", errors.NewPlayerError("XD001", "/v1/info/", "invalid command"))
+	b.record(ctx, "XD001", offline)
+	if state := b.state("XD001"); state != types.BreakerClosed {
+		t.Fatalf("Expected the breaker to stay closed below the threshold, got %s", state)
+	}
+
+	// A gateway timeout counts, as the service reports it as offline
+	b.record(ctx, "XD001", &errors.APIError{StatusCode: http.StatusGatewayTimeout})
+	if state := b.state("XD001"); state != types.BreakerOpen {
+		t.Fatalf("Expected the breaker to open at the threshold, got %s", state)
+	}
+	if len(events) != 1 || events[0].State != types.BreakerOpen || events[0].Failures != 2 || events[0].Until.IsZero() {
+		t.Fatalf("Expected an open event, got %+v", events)
+	}
+
+	err := b.allow("XD001", "/v1/info/")
+	if !stderrors.Is(err, errors.ErrPlayerOffline) {
+		t.Fatalf("Expected an open breaker to fail with ErrPlayerOffline, got %v", err)
+	}
+	if err := b.allow("XD002", "/v1/info/"); err != nil {
+		t.Errorf("Expected other players to be unaffected, got %v", err)
+	}
+
+	// After the cooldown one trial goes through, and a failed one opens it again
+	time.Sleep(60 * time.Millisecond)
+	if err := b.allow("XD001", "/v1/info/"); err != nil {
+		t.Fatalf("Expected a trial call after the cooldown, got %v", err)
+	}
+	if err := b.allow("XD001", "/v1/info/"); !stderrors.Is(err, errors.ErrPlayerOffline) {
+		t.Errorf("Expected calls during the trial to fail fast, got %v", err)
+	}
+	b.record(ctx, "XD001", offline)
+	if state := b.state("XD001"); state != types.BreakerOpen {
+		t.Fatalf("Expected a failed trial to open the breaker, got %s", state)
+	}
+
+	// A successful trial closes it
+	time.Sleep(60 * time.Millisecond)
+	if err := b.allow("XD001", "/v1/info/"); err != nil {
+		t.Fatalf("Expected a trial call after the cooldown, got %v", err)
+	}
+	b.record(ctx, "XD001", nil)
+	if state := b.state("XD001"); state != types.BreakerClosed {
+		t.Fatalf("Expected a successful trial to close the breaker, got %s", state)
+	}
+
+	want := []types.BreakerState{types.BreakerOpen, types.BreakerHalfOpen, types.BreakerOpen, types.BreakerHalfOpen, types.BreakerClosed}
+	if len(events) != len(want) {
+		t.Fatalf("Expected %d events, got %+v", len(want), events)
+	}
+	for i, state := range want {
+		if events[i].State != state || events[i].Serial != "XD001" {
+			t.Errorf("Expected event %d to be %s for XD001, got %+v", i, state, events[i])
+		}
+	}
+}
+
+func TestPlayerBreaker_Canceled(t *testing.T) {
+	b := newPlayerBreaker(&config.Config{PlayerBreakerThreshold: 1, PlayerBreakerCooldown: time.Minute})
+
+	// A call the caller gave up on says nothing about the player
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	b.record(ctx, "XD001", &errors.APIError{StatusCode: http.StatusGatewayTimeout})
+	if state := b.state("XD001"); state != types.BreakerClosed {
+		t.Errorf("Expected a canceled call not to count, got %s", state)
+	}
+}
+
+func TestPlayerBreaker_Disabled(t *testing.T) {
+	b := newPlayerBreaker(&config.Config{})
+	if b != nil {
+		t.Fatal("Expected no breaker without a threshold")
+	}
+	b.record(context.Background(), "XD001", errors.NewPlayerError("XD001", "/v1/info/", "Player is offline"))
+	if err := b.allow("XD001", "/v1/info/"); err != nil {
+		t.Errorf("Expected a disabled breaker to allow every call, got %v", err)
+	}
+}
+
+func TestNewNetworkRDWSService_SharesBreaker(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.PlayerBreakerThreshold = 1
+	parent := NewRDWSService(cfg, nil, nil)
+
+	handleCfg := *cfg
+	handleCfg.NetworkName = "Store-East"
+	handle := NewNetworkRDWSService(parent, &handleCfg, nil, nil)
+
+	if handle.(*rdwsService).breaker != parent.(*rdwsService).breaker {
+		t.Error("Expected a network handle to share the client's player breakers")
+	}
+}
@@ -54,6 +54,7 @@ type deviceService struct {
 	config      *config.Config
 	httpClient  *http.HTTPClient
 	authManager *auth.AuthManager
+	audit       *auditor // Records state-changing calls (nil if off)
 }
 
 // NewDeviceService creates a new device service.
@@ -62,11 +63,15 @@ func NewDeviceService(cfg *config.Config, httpClient *http.HTTPClient, authManag
 		config:      cfg,
 		httpClient:  httpClient,
 		authManager: authManager,
+		audit:       newAuditor(cfg, authManager),
 	}
 }
 
 // List retrieves a list of devices with optional filtering and pagination.
-func (s *deviceService) List(ctx context.Context, opts ...ListOption) (*types.DeviceList, error) {
+func (s *deviceService) List(ctx context.Context, opts ...ListOption) (_ *types.DeviceList, err error) {
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "Devices.List", "")
+	defer func() { traced(err) }()
+
 	// Ensure we have authentication and network context
 	if err := s.authManager.EnsureValid(ctx); err != nil {
 		return nil, err
@@ -112,14 +117,17 @@ func (s *deviceService) List(ctx context.Context, opts ...ListOption) (*types.De
 	var deviceList types.DeviceList
 	err = s.httpClient.GetWithAuth(ctx, token, baseURL, &deviceList)
 	if err != nil {
-		return nil, errors.NewAPIError(0, "device_list_failed", "Failed to list devices", err.Error())
+		return nil, errors.WrapError("Devices.List", "device_list_failed", "Failed to list devices", err)
 	}
 
 	return &deviceList, nil
 }
 
 // Get retrieves a device by its serial number.
-func (s *deviceService) Get(ctx context.Context, serial string) (*types.Device, error) {
+func (s *deviceService) Get(ctx context.Context, serial string) (_ *types.Device, err error) {
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "Devices.Get", serial)
+	defer func() { traced(err) }()
+
 	if serial == "" {
 		return nil, errors.NewValidationError("serial", serial, "device serial cannot be empty")
 	}
@@ -147,15 +155,18 @@ func (s *deviceService) Get(ctx context.Context, serial string) (*types.Device,
 	var device types.Device
 	err = s.httpClient.GetWithAuth(ctx, token, deviceURL, &device)
 	if err != nil {
-		return nil, errors.NewAPIError(0, "device_get_failed",
-			fmt.Sprintf("Failed to get device with serial '%s'", serial), err.Error())
+		return nil, errors.WrapPlayerError("Devices.Get", serial, "device_get_failed",
+			fmt.Sprintf("Failed to get device with serial '%s'", serial), err)
 	}
 
 	return &device, nil
 }
 
 // GetByID retrieves a device by its ID.
-func (s *deviceService) GetByID(ctx context.Context, id int) (*types.Device, error) {
+func (s *deviceService) GetByID(ctx context.Context, id int) (_ *types.Device, err error) {
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "Devices.GetByID", "")
+	defer func() { traced(err) }()
+
 	if id <= 0 {
 		return nil, errors.NewValidationError("id", id, "device ID must be positive")
 	}
@@ -183,15 +194,21 @@ func (s *deviceService) GetByID(ctx context.Context, id int) (*types.Device, err
 	var device types.Device
 	err = s.httpClient.GetWithAuth(ctx, token, deviceURL, &device)
 	if err != nil {
-		return nil, errors.NewAPIError(0, "device_get_failed",
-			fmt.Sprintf("Failed to get device with ID %d", id), err.Error())
+		return nil, errors.WrapError("Devices.GetByID", "device_get_failed",
+			fmt.Sprintf("Failed to get device with ID %d", id), err)
 	}
 
 	return &device, nil
 }
 
 // Update updates a device by its ID.
-func (s *deviceService) Update(ctx context.Context, id int, device *types.Device) (*types.Device, error) {
+func (s *deviceService) Update(ctx context.Context, id int, device *types.Device) (_ *types.Device, err error) {
+	ctx, audited := s.audit.begin(ctx, "Devices.Update", strconv.Itoa(id), nil)
+	defer func() { audited(err) }()
+
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "Devices.Update", "")
+	defer func() { traced(err) }()
+
 	if id <= 0 {
 		return nil, errors.NewValidationError("id", id, "device ID must be positive")
 	}
@@ -222,15 +239,21 @@ func (s *deviceService) Update(ctx context.Context, id int, device *types.Device
 	var updatedDevice types.Device
 	err = s.httpClient.PutWithAuth(ctx, token, deviceURL, device, &updatedDevice)
 	if err != nil {
-		return nil, errors.NewAPIError(0, "device_update_failed",
-			fmt.Sprintf("Failed to update device with ID %d", id), err.Error())
+		return nil, errors.WrapError("Devices.Update", "device_update_failed",
+			fmt.Sprintf("Failed to update device with ID %d", id), err)
 	}
 
 	return &updatedDevice, nil
 }
 
 // UpdateBySerial updates a device by its serial number.
-func (s *deviceService) UpdateBySerial(ctx context.Context, serial string, device *types.Device) (*types.Device, error) {
+func (s *deviceService) UpdateBySerial(ctx context.Context, serial string, device *types.Device) (_ *types.Device, err error) {
+	ctx, audited := s.audit.begin(ctx, "Devices.UpdateBySerial", serial, nil)
+	defer func() { audited(err) }()
+
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "Devices.UpdateBySerial", serial)
+	defer func() { traced(err) }()
+
 	// First get the device to find its ID
 	existingDevice, err := s.Get(ctx, serial)
 	if err != nil {
@@ -242,7 +265,13 @@ func (s *deviceService) UpdateBySerial(ctx context.Context, serial string, devic
 }
 
 // Delete removes a device from the network by ID.
-func (s *deviceService) Delete(ctx context.Context, id int) error {
+func (s *deviceService) Delete(ctx context.Context, id int) (err error) {
+	ctx, audited := s.audit.begin(ctx, "Devices.Delete", strconv.Itoa(id), nil)
+	defer func() { audited(err) }()
+
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "Devices.Delete", "")
+	defer func() { traced(err) }()
+
 	if id <= 0 {
 		return errors.NewValidationError("id", fmt.Sprintf("%d", id), "device ID must be positive")
 	}
@@ -269,14 +298,20 @@ func (s *deviceService) Delete(ctx context.Context, id int) error {
 	// Make the API request - DELETE returns no content on success
 	err = s.httpClient.DeleteWithAuth(ctx, token, deleteURL, nil)
 	if err != nil {
-		return errors.NewAPIError(0, "device_delete_failed", "Failed to delete device", err.Error())
+		return errors.WrapError("Devices.Delete", "device_delete_failed", "Failed to delete device", err)
 	}
 
 	return nil
 }
 
 // DeleteBySerial removes a device from the network by serial number.
-func (s *deviceService) DeleteBySerial(ctx context.Context, serial string) error {
+func (s *deviceService) DeleteBySerial(ctx context.Context, serial string) (err error) {
+	ctx, audited := s.audit.begin(ctx, "Devices.DeleteBySerial", serial, nil)
+	defer func() { audited(err) }()
+
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "Devices.DeleteBySerial", serial)
+	defer func() { traced(err) }()
+
 	if serial == "" {
 		return errors.NewValidationError("serial", serial, "device serial cannot be empty")
 	}
@@ -292,7 +327,10 @@ func (s *deviceService) DeleteBySerial(ctx context.Context, serial string) error
 }
 
 // ListGroups retrieves all device groups in the network.
-func (s *deviceService) ListGroups(ctx context.Context) (*types.GroupList, error) {
+func (s *deviceService) ListGroups(ctx context.Context) (_ *types.GroupList, err error) {
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "Devices.ListGroups", "")
+	defer func() { traced(err) }()
+
 	// Ensure we have authentication and network context
 	if err := s.authManager.EnsureValid(ctx); err != nil {
 		return nil, err
@@ -316,14 +354,20 @@ func (s *deviceService) ListGroups(ctx context.Context) (*types.GroupList, error
 	var groups types.GroupList
 	err = s.httpClient.GetWithAuth(ctx, token, groupsURL, &groups)
 	if err != nil {
-		return nil, errors.NewAPIError(0, "groups_list_failed", "Failed to list groups", err.Error())
+		return nil, errors.WrapError("Devices.ListGroups", "groups_list_failed", "Failed to list groups", err)
 	}
 
 	return &groups, nil
 }
 
 // CreateGroup creates a new device group.
-func (s *deviceService) CreateGroup(ctx context.Context, name string) (*types.Group, error) {
+func (s *deviceService) CreateGroup(ctx context.Context, name string) (_ *types.Group, err error) {
+	ctx, audited := s.audit.begin(ctx, "Devices.CreateGroup", name, nil)
+	defer func() { audited(err) }()
+
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "Devices.CreateGroup", "")
+	defer func() { traced(err) }()
+
 	if name == "" {
 		return nil, errors.NewValidationError("name", name, "group name cannot be empty")
 	}
@@ -354,15 +398,18 @@ func (s *deviceService) CreateGroup(ctx context.Context, name string) (*types.Gr
 	var group types.Group
 	err = s.httpClient.PostWithAuth(ctx, token, groupsURL, groupRequest, &group)
 	if err != nil {
-		return nil, errors.NewAPIError(0, "group_create_failed",
-			fmt.Sprintf("Failed to create group '%s'", name), err.Error())
+		return nil, errors.WrapError("Devices.CreateGroup", "group_create_failed",
+			fmt.Sprintf("Failed to create group '%s'", name), err)
 	}
 
 	return &group, nil
 }
 
 // GetGroup retrieves a specific device group by ID.
-func (s *deviceService) GetGroup(ctx context.Context, id int) (*types.Group, error) {
+func (s *deviceService) GetGroup(ctx context.Context, id int) (_ *types.Group, err error) {
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "Devices.GetGroup", "")
+	defer func() { traced(err) }()
+
 	if id <= 0 {
 		return nil, errors.NewValidationError("id", fmt.Sprintf("%d", id), "group ID must be positive")
 	}
@@ -390,15 +437,18 @@ func (s *deviceService) GetGroup(ctx context.Context, id int) (*types.Group, err
 	var group types.Group
 	err = s.httpClient.GetWithAuth(ctx, token, groupURL, &group)
 	if err != nil {
-		return nil, errors.NewAPIError(0, "group_get_failed",
-			fmt.Sprintf("Failed to get group with ID %d", id), err.Error())
+		return nil, errors.WrapError("Devices.GetGroup", "group_get_failed",
+			fmt.Sprintf("Failed to get group with ID %d", id), err)
 	}
 
 	return &group, nil
 }
 
 // GetGroupByName retrieves a specific device group by name.
-func (s *deviceService) GetGroupByName(ctx context.Context, name string) (*types.Group, error) {
+func (s *deviceService) GetGroupByName(ctx context.Context, name string) (_ *types.Group, err error) {
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "Devices.GetGroupByName", "")
+	defer func() { traced(err) }()
+
 	if name == "" {
 		return nil, errors.NewValidationError("name", name, "group name cannot be empty")
 	}
@@ -426,15 +476,21 @@ func (s *deviceService) GetGroupByName(ctx context.Context, name string) (*types
 	var group types.Group
 	err = s.httpClient.GetWithAuth(ctx, token, groupURL, &group)
 	if err != nil {
-		return nil, errors.NewAPIError(0, "group_get_failed",
-			fmt.Sprintf("Failed to get group with name '%s'", name), err.Error())
+		return nil, errors.WrapError("Devices.GetGroupByName", "group_get_failed",
+			fmt.Sprintf("Failed to get group with name '%s'", name), err)
 	}
 
 	return &group, nil
 }
 
 // UpdateGroup updates an existing device group.
-func (s *deviceService) UpdateGroup(ctx context.Context, id int, group *types.Group) (*types.Group, error) {
+func (s *deviceService) UpdateGroup(ctx context.Context, id int, group *types.Group) (_ *types.Group, err error) {
+	ctx, audited := s.audit.begin(ctx, "Devices.UpdateGroup", strconv.Itoa(id), nil)
+	defer func() { audited(err) }()
+
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "Devices.UpdateGroup", "")
+	defer func() { traced(err) }()
+
 	if id <= 0 {
 		return nil, errors.NewValidationError("id", fmt.Sprintf("%d", id), "group ID must be positive")
 	}
@@ -465,15 +521,21 @@ func (s *deviceService) UpdateGroup(ctx context.Context, id int, group *types.Gr
 	var updatedGroup types.Group
 	err = s.httpClient.PutWithAuth(ctx, token, groupURL, group, &updatedGroup)
 	if err != nil {
-		return nil, errors.NewAPIError(0, "group_update_failed",
-			fmt.Sprintf("Failed to update group with ID %d", id), err.Error())
+		return nil, errors.WrapError("Devices.UpdateGroup", "group_update_failed",
+			fmt.Sprintf("Failed to update group with ID %d", id), err)
 	}
 
 	return &updatedGroup, nil
 }
 
 // DeleteGroup removes a device group from the network.
-func (s *deviceService) DeleteGroup(ctx context.Context, id int) error {
+func (s *deviceService) DeleteGroup(ctx context.Context, id int) (err error) {
+	ctx, audited := s.audit.begin(ctx, "Devices.DeleteGroup", strconv.Itoa(id), nil)
+	defer func() { audited(err) }()
+
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "Devices.DeleteGroup", "")
+	defer func() { traced(err) }()
+
 	if id <= 0 {
 		return errors.NewValidationError("id", fmt.Sprintf("%d", id), "group ID must be positive")
 	}
@@ -500,15 +562,18 @@ func (s *deviceService) DeleteGroup(ctx context.Context, id int) error {
 	// Make the API request - DELETE returns no content on success
 	err = s.httpClient.DeleteWithAuth(ctx, token, groupURL, nil)
 	if err != nil {
-		return errors.NewAPIError(0, "group_delete_failed",
-			fmt.Sprintf("Failed to delete group with ID %d", id), err.Error())
+		return errors.WrapError("Devices.DeleteGroup", "group_delete_failed",
+			fmt.Sprintf("Failed to delete group with ID %d", id), err)
 	}
 
 	return nil
 }
 
 // GetDownloads retrieves the list of content downloads for a device by device ID.
-func (s *deviceService) GetDownloads(ctx context.Context, id int) (*types.DeviceDownloadList, error) {
+func (s *deviceService) GetDownloads(ctx context.Context, id int) (_ *types.DeviceDownloadList, err error) {
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "Devices.GetDownloads", "")
+	defer func() { traced(err) }()
+
 	if id <= 0 {
 		return nil, errors.NewValidationError("id", fmt.Sprintf("%d", id), "device ID must be positive")
 	}
@@ -536,15 +601,18 @@ func (s *deviceService) GetDownloads(ctx context.Context, id int) (*types.Device
 	var downloadList types.DeviceDownloadList
 	err = s.httpClient.GetWithAuth(ctx, token, downloadsURL, &downloadList)
 	if err != nil {
-		return nil, errors.NewAPIError(0, "device_downloads_get_failed",
-			fmt.Sprintf("Failed to get downloads for device with ID %d", id), err.Error())
+		return nil, errors.WrapError("Devices.GetDownloads", "device_downloads_get_failed",
+			fmt.Sprintf("Failed to get downloads for device with ID %d", id), err)
 	}
 
 	return &downloadList, nil
 }
 
 // GetDownloadsBySerial retrieves the list of content downloads for a device by serial number.
-func (s *deviceService) GetDownloadsBySerial(ctx context.Context, serial string) (*types.DeviceDownloadList, error) {
+func (s *deviceService) GetDownloadsBySerial(ctx context.Context, serial string) (_ *types.DeviceDownloadList, err error) {
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "Devices.GetDownloadsBySerial", serial)
+	defer func() { traced(err) }()
+
 	if serial == "" {
 		return nil, errors.NewValidationError("serial", serial, "device serial cannot be empty")
 	}
@@ -560,7 +628,10 @@ func (s *deviceService) GetDownloadsBySerial(ctx context.Context, serial string)
 }
 
 // GetOperations retrieves the list of operations for a device by device ID.
-func (s *deviceService) GetOperations(ctx context.Context, id int) (*types.DeviceOperationList, error) {
+func (s *deviceService) GetOperations(ctx context.Context, id int) (_ *types.DeviceOperationList, err error) {
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "Devices.GetOperations", "")
+	defer func() { traced(err) }()
+
 	if id <= 0 {
 		return nil, errors.NewValidationError("id", fmt.Sprintf("%d", id), "device ID must be positive")
 	}
@@ -588,15 +659,18 @@ func (s *deviceService) GetOperations(ctx context.Context, id int) (*types.Devic
 	var operationList types.DeviceOperationList
 	err = s.httpClient.GetWithAuth(ctx, token, operationsURL, &operationList)
 	if err != nil {
-		return nil, errors.NewAPIError(0, "device_operations_get_failed",
-			fmt.Sprintf("Failed to get operations for device with ID %d", id), err.Error())
+		return nil, errors.WrapError("Devices.GetOperations", "device_operations_get_failed",
+			fmt.Sprintf("Failed to get operations for device with ID %d", id), err)
 	}
 
 	return &operationList, nil
 }
 
 // GetOperationsBySerial retrieves the list of operations for a device by serial number.
-func (s *deviceService) GetOperationsBySerial(ctx context.Context, serial string) (*types.DeviceOperationList, error) {
+func (s *deviceService) GetOperationsBySerial(ctx context.Context, serial string) (_ *types.DeviceOperationList, err error) {
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "Devices.GetOperationsBySerial", serial)
+	defer func() { traced(err) }()
+
 	if serial == "" {
 		return nil, errors.NewValidationError("serial", serial, "device serial cannot be empty")
 	}
@@ -660,7 +734,10 @@ func WithSort(expression string) ListOption {
 }
 
 // GetStatus retrieves the current operational status of a device by ID.
-func (s *deviceService) GetStatus(ctx context.Context, id int) (*types.DeviceStatus, error) {
+func (s *deviceService) GetStatus(ctx context.Context, id int) (_ *types.DeviceStatus, err error) {
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "Devices.GetStatus", "")
+	defer func() { traced(err) }()
+
 	if id <= 0 {
 		return nil, errors.NewValidationError("id", id, "device ID must be positive")
 	}
@@ -724,7 +801,10 @@ func (s *deviceService) GetStatus(ctx context.Context, id int) (*types.DeviceSta
 }
 
 // GetStatusBySerial retrieves the current operational status of a device by serial number.
-func (s *deviceService) GetStatusBySerial(ctx context.Context, serial string) (*types.DeviceStatus, error) {
+func (s *deviceService) GetStatusBySerial(ctx context.Context, serial string) (_ *types.DeviceStatus, err error) {
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "Devices.GetStatusBySerial", serial)
+	defer func() { traced(err) }()
+
 	if serial == "" {
 		return nil, errors.NewValidationError("serial", serial, "device serial cannot be empty")
 	}
@@ -742,7 +822,10 @@ func (s *deviceService) GetStatusBySerial(ctx context.Context, serial string) (*
 // GetErrors retrieves error logs and diagnostic information for a device by ID.
 // Note: Based on the API documentation, device errors might not have a dedicated endpoint.
 // This implementation attempts to use the /Errors endpoint if it exists, otherwise returns empty list.
-func (s *deviceService) GetErrors(ctx context.Context, id int, opts ...ListOption) (*types.DeviceErrorList, error) {
+func (s *deviceService) GetErrors(ctx context.Context, id int, opts ...ListOption) (_ *types.DeviceErrorList, err error) {
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "Devices.GetErrors", "")
+	defer func() { traced(err) }()
+
 	if id <= 0 {
 		return nil, errors.NewValidationError("id", id, "device ID must be positive")
 	}
@@ -758,7 +841,10 @@ func (s *deviceService) GetErrors(ctx context.Context, id int, opts ...ListOptio
 }
 
 // GetErrorsBySerial retrieves error logs and diagnostic information for a device by serial number.
-func (s *deviceService) GetErrorsBySerial(ctx context.Context, serial string, opts ...ListOption) (*types.DeviceErrorList, error) {
+func (s *deviceService) GetErrorsBySerial(ctx context.Context, serial string, opts ...ListOption) (_ *types.DeviceErrorList, err error) {
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "Devices.GetErrorsBySerial", serial)
+	defer func() { traced(err) }()
+
 	if serial == "" {
 		return nil, errors.NewValidationError("serial", serial, "device serial cannot be empty")
 	}
@@ -825,8 +911,8 @@ func (s *deviceService) GetErrorsBySerial(ctx context.Context, serial string, op
 				TotalCount:  0,
 			}, nil
 		}
-		return nil, errors.NewAPIError(0, "device_errors_failed",
-			fmt.Sprintf("Failed to get errors for device with serial '%s'", serial), err.Error())
+		return nil, errors.WrapPlayerError("Devices.GetErrorsBySerial", serial, "device_errors_failed",
+			fmt.Sprintf("Failed to get errors for device with serial '%s'", serial), err)
 	}
 
 	return &errorList, nil
@@ -834,7 +920,10 @@ func (s *deviceService) GetErrorsBySerial(ctx context.Context, serial string, op
 
 // Reboot initiates a remote reboot of the device by ID.
 // This uses the RDWS (Remote Diagnostic Web Service) API to send a reboot command.
-func (s *deviceService) Reboot(ctx context.Context, id int, rebootType types.RebootType) (*types.RebootResponse, error) {
+func (s *deviceService) Reboot(ctx context.Context, id int, rebootType types.RebootType) (_ *types.RebootResponse, err error) {
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "Devices.Reboot", "")
+	defer func() { traced(err) }()
+
 	if id <= 0 {
 		return nil, errors.NewValidationError("id", id, "device ID must be positive")
 	}
@@ -850,7 +939,13 @@ func (s *deviceService) Reboot(ctx context.Context, id int, rebootType types.Reb
 }
 
 // RebootBySerial initiates a remote reboot of the device by serial number.
-func (s *deviceService) RebootBySerial(ctx context.Context, serial string, rebootType types.RebootType) (*types.RebootResponse, error) {
+func (s *deviceService) RebootBySerial(ctx context.Context, serial string, rebootType types.RebootType) (result *types.RebootResponse, err error) {
+	ctx, audited := s.audit.begin(ctx, "Devices.RebootBySerial", serial, auditParams{"rebootType": rebootType})
+	defer func() { audited(declined(err != nil || result.Status != "failed", err)) }()
+
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "Devices.RebootBySerial", serial)
+	defer func() { traced(err) }()
+
 	if serial == "" {
 		return nil, errors.NewValidationError("serial", serial, "device serial cannot be empty")
 	}
@@ -924,10 +1019,10 @@ func (s *deviceService) RebootBySerial(ctx context.Context, serial string, reboo
 		Method string `json:"method"`
 	}
 
-	err = s.httpClient.PutWithAuth(ctx, token, rebootURL, requestBody, &rawResponse)
+	err = doRDWS(ctx, s.httpClient, token, "PUT", rebootURL, serial, requestBody, &rawResponse)
 	if err != nil {
-		return nil, errors.NewAPIError(0, "device_reboot_failed",
-			fmt.Sprintf("Failed to reboot device with serial '%s'", serial), err.Error())
+		return nil, errors.WrapPlayerError("Devices.RebootBySerial", serial, "device_reboot_failed",
+			fmt.Sprintf("Failed to reboot device with serial '%s'", serial), err)
 	}
 
 	// Convert the rDWS response to our RebootResponse format
@@ -955,7 +1050,10 @@ func (s *deviceService) RebootBySerial(ctx context.Context, serial string, reboo
 }
 
 // TakeSnapshot initiates a remote screenshot of the device by ID.
-func (s *deviceService) TakeSnapshot(ctx context.Context, id int, request *types.SnapshotRequest) (*types.SnapshotResponse, error) {
+func (s *deviceService) TakeSnapshot(ctx context.Context, id int, request *types.SnapshotRequest) (_ *types.SnapshotResponse, err error) {
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "Devices.TakeSnapshot", "")
+	defer func() { traced(err) }()
+
 	if id <= 0 {
 		return nil, errors.NewValidationError("id", id, "device ID must be positive")
 	}
@@ -971,7 +1069,10 @@ func (s *deviceService) TakeSnapshot(ctx context.Context, id int, request *types
 }
 
 // TakeSnapshotBySerial initiates a remote screenshot of the device by serial number.
-func (s *deviceService) TakeSnapshotBySerial(ctx context.Context, serial string, request *types.SnapshotRequest) (*types.SnapshotResponse, error) {
+func (s *deviceService) TakeSnapshotBySerial(ctx context.Context, serial string, request *types.SnapshotRequest) (_ *types.SnapshotResponse, err error) {
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "Devices.TakeSnapshotBySerial", serial)
+	defer func() { traced(err) }()
+
 	if serial == "" {
 		return nil, errors.NewValidationError("serial", serial, "device serial cannot be empty")
 	}
@@ -1027,10 +1128,10 @@ func (s *deviceService) TakeSnapshotBySerial(ctx context.Context, serial string,
 		Method string `json:"method"`
 	}
 
-	err = s.httpClient.PostWithAuth(ctx, token, snapshotURL, requestBody, &rawResponse)
+	err = doRDWS(ctx, s.httpClient, token, "POST", snapshotURL, serial, requestBody, &rawResponse)
 	if err != nil {
-		return nil, errors.NewAPIError(0, "device_snapshot_failed",
-			fmt.Sprintf("Failed to take snapshot of device with serial '%s'", serial), err.Error())
+		return nil, errors.WrapPlayerError("Devices.TakeSnapshotBySerial", serial, "device_snapshot_failed",
+			fmt.Sprintf("Failed to take snapshot of device with serial '%s'", serial), err)
 	}
 
 	// Return the snapshot response
@@ -1038,7 +1139,10 @@ func (s *deviceService) TakeSnapshotBySerial(ctx context.Context, serial string,
 }
 
 // Reprovision initiates a remote re-provision of the device by ID.
-func (s *deviceService) Reprovision(ctx context.Context, id int) (*types.ReprovisionResponse, error) {
+func (s *deviceService) Reprovision(ctx context.Context, id int) (_ *types.ReprovisionResponse, err error) {
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "Devices.Reprovision", "")
+	defer func() { traced(err) }()
+
 	if id <= 0 {
 		return nil, errors.NewValidationError("id", id, "device ID must be positive")
 	}
@@ -1054,7 +1158,13 @@ func (s *deviceService) Reprovision(ctx context.Context, id int) (*types.Reprovi
 }
 
 // ReprovisionBySerial initiates a remote re-provision of the device by serial number.
-func (s *deviceService) ReprovisionBySerial(ctx context.Context, serial string) (*types.ReprovisionResponse, error) {
+func (s *deviceService) ReprovisionBySerial(ctx context.Context, serial string) (_ *types.ReprovisionResponse, err error) {
+	ctx, audited := s.audit.begin(ctx, "Devices.ReprovisionBySerial", serial, nil)
+	defer func() { audited(err) }()
+
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "Devices.ReprovisionBySerial", serial)
+	defer func() { traced(err) }()
+
 	if serial == "" {
 		return nil, errors.NewValidationError("serial", serial, "device serial cannot be empty")
 	}
@@ -1087,10 +1197,10 @@ func (s *deviceService) ReprovisionBySerial(ctx context.Context, serial string)
 		Method string `json:"method"`
 	}
 
-	err = s.httpClient.GetWithAuth(ctx, token, reprovisionURL, &rawResponse)
+	err = doRDWS(ctx, s.httpClient, token, "GET", reprovisionURL, serial, nil, &rawResponse)
 	if err != nil {
-		return nil, errors.NewAPIError(0, "device_reprovision_failed",
-			fmt.Sprintf("Failed to re-provision device with serial '%s'", serial), err.Error())
+		return nil, errors.WrapPlayerError("Devices.ReprovisionBySerial", serial, "device_reprovision_failed",
+			fmt.Sprintf("Failed to re-provision device with serial '%s'", serial), err)
 	}
 
 	// Return the re-provision response
@@ -1098,7 +1208,10 @@ func (s *deviceService) ReprovisionBySerial(ctx context.Context, serial string)
 }
 
 // GetDWSPassword retrieves DWS password information by device ID.
-func (s *deviceService) GetDWSPassword(ctx context.Context, id int) (*types.DWSPasswordGetResponse, error) {
+func (s *deviceService) GetDWSPassword(ctx context.Context, id int) (_ *types.DWSPasswordGetResponse, err error) {
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "Devices.GetDWSPassword", "")
+	defer func() { traced(err) }()
+
 	if id <= 0 {
 		return nil, errors.NewValidationError("id", id, "device ID must be positive")
 	}
@@ -1114,7 +1227,10 @@ func (s *deviceService) GetDWSPassword(ctx context.Context, id int) (*types.DWSP
 }
 
 // GetDWSPasswordBySerial retrieves DWS password information by device serial.
-func (s *deviceService) GetDWSPasswordBySerial(ctx context.Context, serial string) (*types.DWSPasswordGetResponse, error) {
+func (s *deviceService) GetDWSPasswordBySerial(ctx context.Context, serial string) (_ *types.DWSPasswordGetResponse, err error) {
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "Devices.GetDWSPasswordBySerial", serial)
+	defer func() { traced(err) }()
+
 	if serial == "" {
 		return nil, errors.NewValidationError("serial", serial, "device serial cannot be empty")
 	}
@@ -1147,10 +1263,10 @@ func (s *deviceService) GetDWSPasswordBySerial(ctx context.Context, serial strin
 		Method string `json:"method"`
 	}
 
-	err = s.httpClient.GetWithAuth(ctx, token, dwsPasswordURL, &rawResponse)
+	err = doRDWS(ctx, s.httpClient, token, "GET", dwsPasswordURL, serial, nil, &rawResponse)
 	if err != nil {
-		return nil, errors.NewAPIError(0, "device_dws_password_get_failed",
-			fmt.Sprintf("Failed to get DWS password info for device with serial '%s'", serial), err.Error())
+		return nil, errors.WrapPlayerError("Devices.GetDWSPasswordBySerial", serial, "device_dws_password_get_failed",
+			fmt.Sprintf("Failed to get DWS password info for device with serial '%s'", serial), err)
 	}
 
 	// Return the DWS password response
@@ -1158,7 +1274,10 @@ func (s *deviceService) GetDWSPasswordBySerial(ctx context.Context, serial strin
 }
 
 // SetDWSPassword sets DWS password by device ID.
-func (s *deviceService) SetDWSPassword(ctx context.Context, id int, request *types.DWSPasswordRequest) (*types.DWSPasswordSetResponse, error) {
+func (s *deviceService) SetDWSPassword(ctx context.Context, id int, request *types.DWSPasswordRequest) (_ *types.DWSPasswordSetResponse, err error) {
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "Devices.SetDWSPassword", "")
+	defer func() { traced(err) }()
+
 	if id <= 0 {
 		return nil, errors.NewValidationError("id", id, "device ID must be positive")
 	}
@@ -1174,7 +1293,13 @@ func (s *deviceService) SetDWSPassword(ctx context.Context, id int, request *typ
 }
 
 // SetDWSPasswordBySerial sets DWS password by device serial.
-func (s *deviceService) SetDWSPasswordBySerial(ctx context.Context, serial string, request *types.DWSPasswordRequest) (*types.DWSPasswordSetResponse, error) {
+func (s *deviceService) SetDWSPasswordBySerial(ctx context.Context, serial string, request *types.DWSPasswordRequest) (_ *types.DWSPasswordSetResponse, err error) {
+	ctx, audited := s.audit.begin(ctx, "Devices.SetDWSPasswordBySerial", serial, dwsPasswordParams(request))
+	defer func() { audited(err) }()
+
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "Devices.SetDWSPasswordBySerial", serial)
+	defer func() { traced(err) }()
+
 	if serial == "" {
 		return nil, errors.NewValidationError("serial", serial, "device serial cannot be empty")
 	}
@@ -1216,10 +1341,10 @@ func (s *deviceService) SetDWSPasswordBySerial(ctx context.Context, serial strin
 		Method string `json:"method"`
 	}
 
-	err = s.httpClient.PutWithAuth(ctx, token, dwsPasswordURL, requestBody, &rawResponse)
+	err = doRDWS(ctx, s.httpClient, token, "PUT", dwsPasswordURL, serial, requestBody, &rawResponse)
 	if err != nil {
-		return nil, errors.NewAPIError(0, "device_dws_password_set_failed",
-			fmt.Sprintf("Failed to set DWS password for device with serial '%s'", serial), err.Error())
+		return nil, errors.WrapPlayerError("Devices.SetDWSPasswordBySerial", serial, "device_dws_password_set_failed",
+			fmt.Sprintf("Failed to set DWS password for device with serial '%s'", serial), err)
 	}
 
 	// Return the DWS password response
@@ -40,7 +40,10 @@ func NewDeviceWebPageService(cfg *config.Config, httpClient *http.HTTPClient, au
 }
 
 // List retrieves all device web pages.
-func (s *deviceWebPageService) List(ctx context.Context) (*types.DeviceWebPageList, error) {
+func (s *deviceWebPageService) List(ctx context.Context) (_ *types.DeviceWebPageList, err error) {
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "DeviceWebPages.List", "")
+	defer func() { traced(err) }()
+
 	// Ensure we have authentication and network context
 	if err := s.authManager.EnsureValid(ctx); err != nil {
 		return nil, err
@@ -63,15 +66,18 @@ func (s *deviceWebPageService) List(ctx context.Context) (*types.DeviceWebPageLi
 	// Make the request
 	var result types.DeviceWebPageList
 	if err := s.httpClient.GetWithAuth(ctx, token, webPagesURL, &result); err != nil {
-		return nil, errors.NewAPIError(0, "devicewebpages_list_failed",
-			"Failed to list device web pages", err.Error())
+		return nil, errors.WrapError("DeviceWebPages.List", "devicewebpages_list_failed",
+			"Failed to list device web pages", err)
 	}
 
 	return &result, nil
 }
 
 // GetByID retrieves a specific device web page by ID.
-func (s *deviceWebPageService) GetByID(ctx context.Context, id int) (*types.DeviceWebPage, error) {
+func (s *deviceWebPageService) GetByID(ctx context.Context, id int) (_ *types.DeviceWebPage, err error) {
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "DeviceWebPages.GetByID", "")
+	defer func() { traced(err) }()
+
 	if id <= 0 {
 		return nil, errors.NewValidationError("id", id, "device web page ID must be positive")
 	}
@@ -98,8 +104,8 @@ func (s *deviceWebPageService) GetByID(ctx context.Context, id int) (*types.Devi
 	// Make the request
 	var result types.DeviceWebPage
 	if err := s.httpClient.GetWithAuth(ctx, token, webPageURL, &result); err != nil {
-		return nil, errors.NewAPIError(0, "devicewebpage_get_failed",
-			fmt.Sprintf("Failed to get device web page with ID %d", id), err.Error())
+		return nil, errors.WrapError("DeviceWebPages.GetByID", "devicewebpage_get_failed",
+			fmt.Sprintf("Failed to get device web page with ID %d", id), err)
 	}
 
 	return &result, nil
@@ -107,7 +113,10 @@ func (s *deviceWebPageService) GetByID(ctx context.Context, id int) (*types.Devi
 
 // GetDefault retrieves the default presentation web page.
 // This is required when creating presentations to reference the default device web page.
-func (s *deviceWebPageService) GetDefault(ctx context.Context) (*types.DeviceWebPage, error) {
+func (s *deviceWebPageService) GetDefault(ctx context.Context) (_ *types.DeviceWebPage, err error) {
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "DeviceWebPages.GetDefault", "")
+	defer func() { traced(err) }()
+
 	// List all web pages
 	list, err := s.List(ctx)
 	if err != nil {
@@ -0,0 +1,20 @@
+package services
+
+import (
+	"context"
+
+	"github.com/brightdevelopers/gopurple/internal/auth"
+	"github.com/brightdevelopers/gopurple/internal/http"
+)
+
+// startOperation names the operation, player and network the requests made with
+// ctx are for, for the request log, and starts the operation's span, under which
+// the spans of its requests and of any operations it calls are made. The returned
+// function ends the span, recording err.
+func startOperation(ctx context.Context, httpClient *http.HTTPClient, authManager *auth.AuthManager, operation, serial string) (context.Context, func(err error)) {
+	op := http.Operation{Name: operation, Serial: serial}
+	if network, err := authManager.GetCurrentNetwork(); err == nil {
+		op.Network = network.Name
+	}
+	return httpClient.StartOperation(ctx, op)
+}
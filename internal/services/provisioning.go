@@ -22,6 +22,7 @@ type provisioningService struct {
 	config      *config.Config
 	httpClient  *http.HTTPClient
 	authManager *auth.AuthManager
+	audit       *auditor // Records state-changing calls (nil if off)
 }
 
 // NewProvisioningService creates a new provisioning service.
@@ -30,6 +31,7 @@ func NewProvisioningService(cfg *config.Config, httpClient *http.HTTPClient, aut
 		config:      cfg,
 		httpClient:  httpClient,
 		authManager: authManager,
+		audit:       newAuditor(cfg, authManager),
 	}
 }
 
@@ -43,7 +45,13 @@ func NewProvisioningService(cfg *config.Config, httpClient *http.HTTPClient, aut
 //
 // The returned token should be embedded in B-Deploy setup records to enable
 // player registration during provisioning.
-func (s *provisioningService) GenerateDeviceToken(ctx context.Context) (*types.BSNTokenEntity, error) {
+func (s *provisioningService) GenerateDeviceToken(ctx context.Context) (_ *types.BSNTokenEntity, err error) {
+	ctx, audited := s.audit.begin(ctx, "Provisioning.GenerateDeviceToken", "", nil)
+	defer func() { audited(err) }()
+
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "Provisioning.GenerateDeviceToken", "")
+	defer func() { traced(err) }()
+
 	// Ensure we have authentication
 	if err := s.authManager.EnsureValid(ctx); err != nil {
 		return nil, err
@@ -63,8 +71,8 @@ func (s *provisioningService) GenerateDeviceToken(ctx context.Context) (*types.B
 	var response types.BSNTokenEntity
 	err = s.httpClient.PostWithAuth(ctx, token, tokenURL, nil, &response)
 	if err != nil {
-		return nil, errors.NewAPIError(0, "token_generation_failed",
-			"Failed to generate device registration token", err.Error())
+		return nil, errors.WrapError("Provisioning.GenerateDeviceToken", "token_generation_failed",
+			"Failed to generate device registration token", err)
 	}
 
 	// Validate response has required fields
@@ -84,7 +92,10 @@ func (s *provisioningService) GenerateDeviceToken(ctx context.Context) (*types.B
 // ValidateDeviceToken validates a device registration token and retrieves its metadata.
 //
 // Required scope: bsn.api.main.devices.setups.token.validate
-func (s *provisioningService) ValidateDeviceToken(ctx context.Context, tokenValue string) (*types.BSNTokenEntity, error) {
+func (s *provisioningService) ValidateDeviceToken(ctx context.Context, tokenValue string) (_ *types.BSNTokenEntity, err error) {
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "Provisioning.ValidateDeviceToken", "")
+	defer func() { traced(err) }()
+
 	if tokenValue == "" {
 		return nil, errors.NewValidationError("token", tokenValue, "token cannot be empty")
 	}
@@ -107,8 +118,8 @@ func (s *provisioningService) ValidateDeviceToken(ctx context.Context, tokenValu
 	var response types.BSNTokenEntity
 	err = s.httpClient.GetWithAuth(ctx, token, validateURL, &response)
 	if err != nil {
-		return nil, errors.NewAPIError(0, "token_validation_failed",
-			"Failed to validate device registration token", err.Error())
+		return nil, errors.WrapError("Provisioning.ValidateDeviceToken", "token_validation_failed",
+			"Failed to validate device registration token", err)
 	}
 
 	return &response, nil
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io/fs"
 	"net/url"
 
 	"github.com/brightdevelopers/gopurple/internal/auth"
@@ -28,6 +29,9 @@ type RDWSService interface {
 	CreateFolder(ctx context.Context, serial string, path string) (bool, error)
 	RenameFile(ctx context.Context, serial string, path string, newName string) (bool, error)
 	DeleteFile(ctx context.Context, serial string, path string) (bool, error)
+	DownloadFile(ctx context.Context, serial string, path string) ([]byte, error)
+	WalkFiles(ctx context.Context, serial string, root string, fn fs.WalkDirFunc) error
+	Glob(ctx context.Context, serial string, pattern string) ([]string, error)
 
 	// Control
 	GetLocalDWS(ctx context.Context, serial string) (*types.RDWSLocalDWSInfo, error)
@@ -44,6 +48,7 @@ type RDWSService interface {
 	GetPacketCaptureStatus(ctx context.Context, serial string) (*types.RDWSPacketCaptureStatus, error)
 	StartPacketCapture(ctx context.Context, serial string, request *types.RDWSPacketCaptureStartRequest) (string, error)
 	StopPacketCapture(ctx context.Context, serial string) (string, error)
+	CapturePackets(ctx context.Context, serial string, request *PacketCaptureRequest) (*PacketCaptureResult, error)
 	GetTelnetStatus(ctx context.Context, serial string) (*types.RDWSTelnetInfo, error)
 	SetTelnetStatus(ctx context.Context, serial string, enabled bool, port int) (bool, error)
 	GetSSHStatus(ctx context.Context, serial string) (*types.RDWSSSHInfo, error)
@@ -77,6 +82,8 @@ type rdwsService struct {
 	config      *config.Config
 	httpClient  *http.HTTPClient
 	authManager *auth.AuthManager
+	breaker     *playerBreaker // Nil unless the circuit breaker is configured
+	audit       *auditor       // Records state-changing calls (nil if off)
 }
 
 // NewRDWSService creates a new rDWS service.
@@ -85,12 +92,47 @@ func NewRDWSService(cfg *config.Config, httpClient *http.HTTPClient, authManager
 		config:      cfg,
 		httpClient:  httpClient,
 		authManager: authManager,
+		breaker:     newPlayerBreaker(cfg),
+		audit:       newAuditor(cfg, authManager),
 	}
 }
 
+// NewNetworkRDWSService creates the rDWS service of a network handle. It is like
+// NewRDWSService but shares the player circuit breakers of parent, the service of
+// the client the handle belongs to, since a player that is offline is offline
+// whichever session asks.
+func NewNetworkRDWSService(parent RDWSService, cfg *config.Config, httpClient *http.HTTPClient, authManager *auth.AuthManager) RDWSService {
+	service := NewRDWSService(cfg, httpClient, authManager).(*rdwsService)
+	if p, ok := parent.(*rdwsService); ok {
+		service.breaker = p.breaker
+	}
+	return service
+}
+
+// do makes an rDWS call through the player's circuit breaker.
+func (s *rdwsService) do(ctx context.Context, token, method, requestURL, serial string, body, response interface{}) error {
+	if err := s.breaker.allow(serial, rdwsRoute(requestURL)); err != nil {
+		return err
+	}
+	err := doRDWS(ctx, s.httpClient, token, method, requestURL, serial, body, response)
+	s.breaker.record(ctx, serial, err)
+	return err
+}
+
+// rdwsRoute returns the path of an rDWS URL, to name the route in errors.
+func rdwsRoute(requestURL string) string {
+	if parsed, err := url.Parse(requestURL); err == nil {
+		return parsed.Path
+	}
+	return requestURL
+}
+
 // GetInfo retrieves general information about a player via rDWS.
 // This includes hardware details, network configuration, firmware version, and more.
-func (s *rdwsService) GetInfo(ctx context.Context, serial string) (*types.RDWSInfo, error) {
+func (s *rdwsService) GetInfo(ctx context.Context, serial string) (_ *types.RDWSInfo, err error) {
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "RDWS.GetInfo", serial)
+	defer func() { traced(err) }()
+
 	if serial == "" {
 		return nil, errors.NewValidationError("serial", serial, "device serial cannot be empty")
 	}
@@ -115,17 +157,20 @@ func (s *rdwsService) GetInfo(ctx context.Context, serial string) (*types.RDWSIn
 
 	// Make the API request
 	var response types.RDWSInfoResponse
-	err = s.httpClient.GetWithAuth(ctx, token, infoURL, &response)
+	err = s.do(ctx, token, "GET", infoURL, serial, nil, &response)
 	if err != nil {
-		return nil, errors.NewAPIError(0, "rdws_info_failed",
-			fmt.Sprintf("Failed to get info for device with serial '%s'", serial), err.Error())
+		return nil, errors.WrapPlayerError("RDWS.GetInfo", serial, "rdws_info_failed",
+			fmt.Sprintf("Failed to get info for device with serial '%s'", serial), err)
 	}
 
 	return &response.Data.Result, nil
 }
 
 // GetTime retrieves the current date and time configured on a player.
-func (s *rdwsService) GetTime(ctx context.Context, serial string) (*types.RDWSTimeInfo, error) {
+func (s *rdwsService) GetTime(ctx context.Context, serial string) (_ *types.RDWSTimeInfo, err error) {
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "RDWS.GetTime", serial)
+	defer func() { traced(err) }()
+
 	if serial == "" {
 		return nil, errors.NewValidationError("serial", serial, "device serial cannot be empty")
 	}
@@ -150,17 +195,23 @@ func (s *rdwsService) GetTime(ctx context.Context, serial string) (*types.RDWSTi
 
 	// Make the API request
 	var response types.RDWSTimeResponse
-	err = s.httpClient.GetWithAuth(ctx, token, timeURL, &response)
+	err = s.do(ctx, token, "GET", timeURL, serial, nil, &response)
 	if err != nil {
-		return nil, errors.NewAPIError(0, "rdws_time_failed",
-			fmt.Sprintf("Failed to get time for device with serial '%s'", serial), err.Error())
+		return nil, errors.WrapPlayerError("RDWS.GetTime", serial, "rdws_time_failed",
+			fmt.Sprintf("Failed to get time for device with serial '%s'", serial), err)
 	}
 
 	return &response.Data.Result, nil
 }
 
 // SetTime sets the date and time on a player.
-func (s *rdwsService) SetTime(ctx context.Context, serial string, request *types.RDWSTimeSetRequest) (bool, error) {
+func (s *rdwsService) SetTime(ctx context.Context, serial string, request *types.RDWSTimeSetRequest) (ok bool, err error) {
+	ctx, audited := s.audit.begin(ctx, "RDWS.SetTime", serial, timeParams(request))
+	defer func() { audited(declined(ok, err)) }()
+
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "RDWS.SetTime", serial)
+	defer func() { traced(err) }()
+
 	if serial == "" {
 		return false, errors.NewValidationError("serial", serial, "device serial cannot be empty")
 	}
@@ -194,10 +245,10 @@ func (s *rdwsService) SetTime(ctx context.Context, serial string, request *types
 
 	// Make the API request
 	var response types.RDWSTimeSetResponse
-	err = s.httpClient.PutWithAuth(ctx, token, timeURL, requestBody, &response)
+	err = s.do(ctx, token, "PUT", timeURL, serial, requestBody, &response)
 	if err != nil {
-		return false, errors.NewAPIError(0, "rdws_time_set_failed",
-			fmt.Sprintf("Failed to set time for device with serial '%s'", serial), err.Error())
+		return false, errors.WrapPlayerError("RDWS.SetTime", serial, "rdws_time_set_failed",
+			fmt.Sprintf("Failed to set time for device with serial '%s'", serial), err)
 	}
 
 	return response.Data.Result, nil
@@ -205,7 +256,10 @@ func (s *rdwsService) SetTime(ctx context.Context, serial string, request *types
 
 // GetHealth retrieves the current health status of a player.
 // This is primarily used to determine if a player can respond to WebSocket requests.
-func (s *rdwsService) GetHealth(ctx context.Context, serial string) (*types.RDWSHealthInfo, error) {
+func (s *rdwsService) GetHealth(ctx context.Context, serial string) (_ *types.RDWSHealthInfo, err error) {
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "RDWS.GetHealth", serial)
+	defer func() { traced(err) }()
+
 	if serial == "" {
 		return nil, errors.NewValidationError("serial", serial, "device serial cannot be empty")
 	}
@@ -230,17 +284,20 @@ func (s *rdwsService) GetHealth(ctx context.Context, serial string) (*types.RDWS
 
 	// Make the API request
 	var response types.RDWSHealthResponse
-	err = s.httpClient.GetWithAuth(ctx, token, healthURL, &response)
+	err = s.do(ctx, token, "GET", healthURL, serial, nil, &response)
 	if err != nil {
-		return nil, errors.NewAPIError(0, "rdws_health_failed",
-			fmt.Sprintf("Failed to get health for device with serial '%s'", serial), err.Error())
+		return nil, errors.WrapPlayerError("RDWS.GetHealth", serial, "rdws_health_failed",
+			fmt.Sprintf("Failed to get health for device with serial '%s'", serial), err)
 	}
 
 	return &response.Data.Result, nil
 }
 
 // ListFiles lists the directories and/or files in a path on the player.
-func (s *rdwsService) ListFiles(ctx context.Context, serial string, path string) (*types.RDWSFileListResponse, error) {
+func (s *rdwsService) ListFiles(ctx context.Context, serial string, path string) (_ *types.RDWSFileListResponse, err error) {
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "RDWS.ListFiles", serial)
+	defer func() { traced(err) }()
+
 	if serial == "" {
 		return nil, errors.NewValidationError("serial", serial, "device serial cannot be empty")
 	}
@@ -268,10 +325,10 @@ func (s *rdwsService) ListFiles(ctx context.Context, serial string, path string)
 
 	// Make the API request
 	var response types.RDWSFileListResponse
-	err = s.httpClient.GetWithAuth(ctx, token, filesURL, &response)
+	err = s.do(ctx, token, "GET", filesURL, serial, nil, &response)
 	if err != nil {
-		return nil, errors.NewAPIError(0, "rdws_files_list_failed",
-			fmt.Sprintf("Failed to list files for device with serial '%s' at path '%s'", serial, path), err.Error())
+		return nil, errors.WrapPlayerError("RDWS.ListFiles", serial, "rdws_files_list_failed",
+			fmt.Sprintf("Failed to list files for device with serial '%s' at path '%s'", serial, path), err)
 	}
 
 	return &response, nil
@@ -279,7 +336,13 @@ func (s *rdwsService) ListFiles(ctx context.Context, serial string, path string)
 
 // UploadFile uploads a file to the player storage.
 // fileContents should be either plain text or Data URL (base64-encoded) format.
-func (s *rdwsService) UploadFile(ctx context.Context, serial string, path string, fileName string, fileContents string, fileType string) (bool, error) {
+func (s *rdwsService) UploadFile(ctx context.Context, serial string, path string, fileName string, fileContents string, fileType string) (ok bool, err error) {
+	ctx, audited := s.audit.begin(ctx, "RDWS.UploadFile", serial, auditParams{"path": path, "fileName": fileName, "fileType": fileType, "size": len(fileContents)})
+	defer func() { audited(declined(ok, err)) }()
+
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "RDWS.UploadFile", serial)
+	defer func() { traced(err) }()
+
 	if serial == "" {
 		return false, errors.NewValidationError("serial", serial, "device serial cannot be empty")
 	}
@@ -321,17 +384,23 @@ func (s *rdwsService) UploadFile(ctx context.Context, serial string, path string
 
 	// Make the API request
 	var response types.RDWSFileUploadResponse
-	err = s.httpClient.PutWithAuth(ctx, token, filesURL, request, &response)
+	err = s.do(ctx, token, "PUT", filesURL, serial, request, &response)
 	if err != nil {
-		return false, errors.NewAPIError(0, "rdws_file_upload_failed",
-			fmt.Sprintf("Failed to upload file '%s' to device with serial '%s'", fileName, serial), err.Error())
+		return false, errors.WrapPlayerError("RDWS.UploadFile", serial, "rdws_file_upload_failed",
+			fmt.Sprintf("Failed to upload file '%s' to device with serial '%s'", fileName, serial), err)
 	}
 
 	return response.Data.Result.Success, nil
 }
 
 // CreateFolder creates a new folder on the player storage.
-func (s *rdwsService) CreateFolder(ctx context.Context, serial string, path string) (bool, error) {
+func (s *rdwsService) CreateFolder(ctx context.Context, serial string, path string) (ok bool, err error) {
+	ctx, audited := s.audit.begin(ctx, "RDWS.CreateFolder", serial, auditParams{"path": path})
+	defer func() { audited(declined(ok, err)) }()
+
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "RDWS.CreateFolder", serial)
+	defer func() { traced(err) }()
+
 	if serial == "" {
 		return false, errors.NewValidationError("serial", serial, "device serial cannot be empty")
 	}
@@ -359,17 +428,23 @@ func (s *rdwsService) CreateFolder(ctx context.Context, serial string, path stri
 
 	// Make the API request (PUT with no body creates a folder)
 	var response types.RDWSFileOperationResponse
-	err = s.httpClient.PutWithAuth(ctx, token, folderURL, nil, &response)
+	err = s.do(ctx, token, "PUT", folderURL, serial, nil, &response)
 	if err != nil {
-		return false, errors.NewAPIError(0, "rdws_folder_create_failed",
-			fmt.Sprintf("Failed to create folder at '%s' on device with serial '%s'", path, serial), err.Error())
+		return false, errors.WrapPlayerError("RDWS.CreateFolder", serial, "rdws_folder_create_failed",
+			fmt.Sprintf("Failed to create folder at '%s' on device with serial '%s'", path, serial), err)
 	}
 
 	return response.Data.Result.Success, nil
 }
 
 // RenameFile renames a file on the player storage.
-func (s *rdwsService) RenameFile(ctx context.Context, serial string, path string, newName string) (bool, error) {
+func (s *rdwsService) RenameFile(ctx context.Context, serial string, path string, newName string) (ok bool, err error) {
+	ctx, audited := s.audit.begin(ctx, "RDWS.RenameFile", serial, auditParams{"path": path, "newName": newName})
+	defer func() { audited(declined(ok, err)) }()
+
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "RDWS.RenameFile", serial)
+	defer func() { traced(err) }()
+
 	if serial == "" {
 		return false, errors.NewValidationError("serial", serial, "device serial cannot be empty")
 	}
@@ -404,17 +479,23 @@ func (s *rdwsService) RenameFile(ctx context.Context, serial string, path string
 
 	// Make the API request
 	var response types.RDWSFileOperationResponse
-	err = s.httpClient.PostWithAuth(ctx, token, filesURL, request, &response)
+	err = s.do(ctx, token, "POST", filesURL, serial, request, &response)
 	if err != nil {
-		return false, errors.NewAPIError(0, "rdws_file_rename_failed",
-			fmt.Sprintf("Failed to rename file '%s' on device with serial '%s'", path, serial), err.Error())
+		return false, errors.WrapPlayerError("RDWS.RenameFile", serial, "rdws_file_rename_failed",
+			fmt.Sprintf("Failed to rename file '%s' on device with serial '%s'", path, serial), err)
 	}
 
 	return response.Data.Result.Success, nil
 }
 
 // DeleteFile deletes a file from the player storage.
-func (s *rdwsService) DeleteFile(ctx context.Context, serial string, path string) (bool, error) {
+func (s *rdwsService) DeleteFile(ctx context.Context, serial string, path string) (ok bool, err error) {
+	ctx, audited := s.audit.begin(ctx, "RDWS.DeleteFile", serial, auditParams{"path": path})
+	defer func() { audited(declined(ok, err)) }()
+
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "RDWS.DeleteFile", serial)
+	defer func() { traced(err) }()
+
 	if serial == "" {
 		return false, errors.NewValidationError("serial", serial, "device serial cannot be empty")
 	}
@@ -442,17 +523,65 @@ func (s *rdwsService) DeleteFile(ctx context.Context, serial string, path string
 
 	// Make the API request
 	var response types.RDWSFileOperationResponse
-	err = s.httpClient.DeleteWithAuth(ctx, token, filesURL, &response)
+	err = s.do(ctx, token, "DELETE", filesURL, serial, nil, &response)
 	if err != nil {
-		return false, errors.NewAPIError(0, "rdws_file_delete_failed",
-			fmt.Sprintf("Failed to delete file '%s' on device with serial '%s'", path, serial), err.Error())
+		return false, errors.WrapPlayerError("RDWS.DeleteFile", serial, "rdws_file_delete_failed",
+			fmt.Sprintf("Failed to delete file '%s' on device with serial '%s'", path, serial), err)
 	}
 
 	return response.Data.Result.Success, nil
 }
 
+// DownloadFile retrieves the raw contents of a file from the player storage.
+func (s *rdwsService) DownloadFile(ctx context.Context, serial string, path string) (_ []byte, err error) {
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "RDWS.DownloadFile", serial)
+	defer func() { traced(err) }()
+
+	if serial == "" {
+		return nil, errors.NewValidationError("serial", serial, "device serial cannot be empty")
+	}
+	if path == "" {
+		return nil, errors.NewValidationError("path", path, "file path cannot be empty")
+	}
+
+	// Ensure we have authentication and network context
+	if err := s.authManager.EnsureValid(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := s.authManager.EnsureNetworkSet(ctx); err != nil {
+		return nil, err
+	}
+
+	// Get access token
+	token, err := s.authManager.GetToken()
+	if err != nil {
+		return nil, err
+	}
+
+	// Build the rDWS file download endpoint URL (raw returns the file contents instead of a listing)
+	filesURL := fmt.Sprintf("https://ws.bsn.cloud/rest/v1/files/%s?destinationType=player&destinationName=%s&raw", path, serial)
+
+	// Make the API request
+	if err := s.breaker.allow(serial, rdwsRoute(filesURL)); err != nil {
+		return nil, errors.WrapPlayerError("RDWS.DownloadFile", serial, "rdws_file_download_failed",
+			fmt.Sprintf("Failed to download file '%s' from device with serial '%s'", path, serial), err)
+	}
+	contents, err := s.httpClient.GetBytesWithAuth(ctx, token, filesURL)
+	s.breaker.record(ctx, serial, err)
+	if err != nil {
+		return nil, errors.WrapPlayerError("RDWS.DownloadFile", serial, "rdws_file_download_failed",
+			fmt.Sprintf("Failed to download file '%s' from device with serial '%s'", path, serial), err)
+	}
+
+	return contents, nil
+}
+
 // GetLocalDWS retrieves the current state of local DWS on a player.
-func (s *rdwsService) GetLocalDWS(ctx context.Context, serial string) (*types.RDWSLocalDWSInfo, error) {
+func (s *rdwsService) GetLocalDWS(ctx context.Context, serial string) (_ *types.RDWSLocalDWSInfo, err error) {
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "RDWS.GetLocalDWS", serial)
+	defer func() { traced(err) }()
+
 	if serial == "" {
 		return nil, errors.NewValidationError("serial", serial, "device serial cannot be empty")
 	}
@@ -477,17 +606,23 @@ func (s *rdwsService) GetLocalDWS(ctx context.Context, serial string) (*types.RD
 
 	// Make the API request
 	var response types.RDWSLocalDWSResponse
-	err = s.httpClient.GetWithAuth(ctx, token, localDWSURL, &response)
+	err = s.do(ctx, token, "GET", localDWSURL, serial, nil, &response)
 	if err != nil {
-		return nil, errors.NewAPIError(0, "rdws_local_dws_get_failed",
-			fmt.Sprintf("Failed to get local DWS status for device with serial '%s'", serial), err.Error())
+		return nil, errors.WrapPlayerError("RDWS.GetLocalDWS", serial, "rdws_local_dws_get_failed",
+			fmt.Sprintf("Failed to get local DWS status for device with serial '%s'", serial), err)
 	}
 
 	return &response.Data.Result, nil
 }
 
 // SetLocalDWS enables or disables local DWS on a player.
-func (s *rdwsService) SetLocalDWS(ctx context.Context, serial string, enabled bool) (bool, error) {
+func (s *rdwsService) SetLocalDWS(ctx context.Context, serial string, enabled bool) (ok bool, err error) {
+	ctx, audited := s.audit.begin(ctx, "RDWS.SetLocalDWS", serial, auditParams{"enabled": enabled})
+	defer func() { audited(declined(ok, err)) }()
+
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "RDWS.SetLocalDWS", serial)
+	defer func() { traced(err) }()
+
 	if serial == "" {
 		return false, errors.NewValidationError("serial", serial, "device serial cannot be empty")
 	}
@@ -516,17 +651,20 @@ func (s *rdwsService) SetLocalDWS(ctx context.Context, serial string, enabled bo
 
 	// Make the API request
 	var response types.RDWSLocalDWSSetResponse
-	err = s.httpClient.PutWithAuth(ctx, token, localDWSURL, request, &response)
+	err = s.do(ctx, token, "PUT", localDWSURL, serial, request, &response)
 	if err != nil {
-		return false, errors.NewAPIError(0, "rdws_local_dws_set_failed",
-			fmt.Sprintf("Failed to set local DWS status for device with serial '%s'", serial), err.Error())
+		return false, errors.WrapPlayerError("RDWS.SetLocalDWS", serial, "rdws_local_dws_set_failed",
+			fmt.Sprintf("Failed to set local DWS status for device with serial '%s'", serial), err)
 	}
 
 	return response.Data.Result.Success, nil
 }
 
 // GetDiagnostics runs network diagnostics on a player.
-func (s *rdwsService) GetDiagnostics(ctx context.Context, serial string) (*types.RDWSDiagnosticsInfo, error) {
+func (s *rdwsService) GetDiagnostics(ctx context.Context, serial string) (_ *types.RDWSDiagnosticsInfo, err error) {
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "RDWS.GetDiagnostics", serial)
+	defer func() { traced(err) }()
+
 	if serial == "" {
 		return nil, errors.NewValidationError("serial", serial, "device serial cannot be empty")
 	}
@@ -551,17 +689,20 @@ func (s *rdwsService) GetDiagnostics(ctx context.Context, serial string) (*types
 
 	// Make the API request
 	var response types.RDWSDiagnosticsResponse
-	err = s.httpClient.GetWithAuth(ctx, token, diagnosticsURL, &response)
+	err = s.do(ctx, token, "GET", diagnosticsURL, serial, nil, &response)
 	if err != nil {
-		return nil, errors.NewAPIError(0, "rdws_diagnostics_failed",
-			fmt.Sprintf("Failed to run diagnostics for device with serial '%s'", serial), err.Error())
+		return nil, errors.WrapPlayerError("RDWS.GetDiagnostics", serial, "rdws_diagnostics_failed",
+			fmt.Sprintf("Failed to run diagnostics for device with serial '%s'", serial), err)
 	}
 
 	return &response.Data.Result, nil
 }
 
 // DNSLookup tests name resolution on a specified DNS address.
-func (s *rdwsService) DNSLookup(ctx context.Context, serial string, domain string) (*types.RDWSDNSLookupResult, error) {
+func (s *rdwsService) DNSLookup(ctx context.Context, serial string, domain string) (_ *types.RDWSDNSLookupResult, err error) {
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "RDWS.DNSLookup", serial)
+	defer func() { traced(err) }()
+
 	if serial == "" {
 		return nil, errors.NewValidationError("serial", serial, "device serial cannot be empty")
 	}
@@ -589,17 +730,20 @@ func (s *rdwsService) DNSLookup(ctx context.Context, serial string, domain strin
 
 	// Make the API request
 	var response types.RDWSDNSLookupResponse
-	err = s.httpClient.GetWithAuth(ctx, token, dnsURL, &response)
+	err = s.do(ctx, token, "GET", dnsURL, serial, nil, &response)
 	if err != nil {
-		return nil, errors.NewAPIError(0, "rdws_dns_lookup_failed",
-			fmt.Sprintf("Failed to perform DNS lookup for domain '%s' on device with serial '%s'", domain, serial), err.Error())
+		return nil, errors.WrapPlayerError("RDWS.DNSLookup", serial, "rdws_dns_lookup_failed",
+			fmt.Sprintf("Failed to perform DNS lookup for domain '%s' on device with serial '%s'", domain, serial), err)
 	}
 
 	return &response.Data.Result, nil
 }
 
 // Ping pings a specified IP or DNS address on the local network.
-func (s *rdwsService) Ping(ctx context.Context, serial string, host string) (*types.RDWSPingResult, error) {
+func (s *rdwsService) Ping(ctx context.Context, serial string, host string) (_ *types.RDWSPingResult, err error) {
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "RDWS.Ping", serial)
+	defer func() { traced(err) }()
+
 	if serial == "" {
 		return nil, errors.NewValidationError("serial", serial, "device serial cannot be empty")
 	}
@@ -627,17 +771,20 @@ func (s *rdwsService) Ping(ctx context.Context, serial string, host string) (*ty
 
 	// Make the API request
 	var response types.RDWSPingResponse
-	err = s.httpClient.GetWithAuth(ctx, token, pingURL, &response)
+	err = s.do(ctx, token, "GET", pingURL, serial, nil, &response)
 	if err != nil {
-		return nil, errors.NewAPIError(0, "rdws_ping_failed",
-			fmt.Sprintf("Failed to ping host '%s' from device with serial '%s'", host, serial), err.Error())
+		return nil, errors.WrapPlayerError("RDWS.Ping", serial, "rdws_ping_failed",
+			fmt.Sprintf("Failed to ping host '%s' from device with serial '%s'", host, serial), err)
 	}
 
 	return &response.Data.Result, nil
 }
 
 // TraceRoute performs a trace-route diagnostic on a specified IP or DNS address.
-func (s *rdwsService) TraceRoute(ctx context.Context, serial string, host string) (*types.RDWSTraceRouteResult, error) {
+func (s *rdwsService) TraceRoute(ctx context.Context, serial string, host string) (_ *types.RDWSTraceRouteResult, err error) {
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "RDWS.TraceRoute", serial)
+	defer func() { traced(err) }()
+
 	if serial == "" {
 		return nil, errors.NewValidationError("serial", serial, "device serial cannot be empty")
 	}
@@ -665,17 +812,20 @@ func (s *rdwsService) TraceRoute(ctx context.Context, serial string, host string
 
 	// Make the API request
 	var response types.RDWSTraceRouteResponse
-	err = s.httpClient.GetWithAuth(ctx, token, traceURL, &response)
+	err = s.do(ctx, token, "GET", traceURL, serial, nil, &response)
 	if err != nil {
-		return nil, errors.NewAPIError(0, "rdws_trace_route_failed",
-			fmt.Sprintf("Failed to trace route to host '%s' from device with serial '%s'", host, serial), err.Error())
+		return nil, errors.WrapPlayerError("RDWS.TraceRoute", serial, "rdws_trace_route_failed",
+			fmt.Sprintf("Failed to trace route to host '%s' from device with serial '%s'", host, serial), err)
 	}
 
 	return &response.Data.Result, nil
 }
 
 // GetNetworkConfig retrieves network interface settings for a player.
-func (s *rdwsService) GetNetworkConfig(ctx context.Context, serial string, iface string) (*types.RDWSNetworkConfig, error) {
+func (s *rdwsService) GetNetworkConfig(ctx context.Context, serial string, iface string) (_ *types.RDWSNetworkConfig, err error) {
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "RDWS.GetNetworkConfig", serial)
+	defer func() { traced(err) }()
+
 	if serial == "" {
 		return nil, errors.NewValidationError("serial", serial, "device serial cannot be empty")
 	}
@@ -703,17 +853,23 @@ func (s *rdwsService) GetNetworkConfig(ctx context.Context, serial string, iface
 
 	// Make the API request
 	var response types.RDWSNetworkConfigResponse
-	err = s.httpClient.GetWithAuth(ctx, token, netConfigURL, &response)
+	err = s.do(ctx, token, "GET", netConfigURL, serial, nil, &response)
 	if err != nil {
-		return nil, errors.NewAPIError(0, "rdws_network_config_get_failed",
-			fmt.Sprintf("Failed to get network configuration for interface '%s' on device with serial '%s'", iface, serial), err.Error())
+		return nil, errors.WrapPlayerError("RDWS.GetNetworkConfig", serial, "rdws_network_config_get_failed",
+			fmt.Sprintf("Failed to get network configuration for interface '%s' on device with serial '%s'", iface, serial), err)
 	}
 
 	return &response.Data.Result, nil
 }
 
 // SetNetworkConfig applies test network configuration to a player.
-func (s *rdwsService) SetNetworkConfig(ctx context.Context, serial string, iface string, request *types.RDWSNetworkConfigSetRequest) (bool, error) {
+func (s *rdwsService) SetNetworkConfig(ctx context.Context, serial string, iface string, request *types.RDWSNetworkConfigSetRequest) (ok bool, err error) {
+	ctx, audited := s.audit.begin(ctx, "RDWS.SetNetworkConfig", serial, networkConfigParams(iface, request))
+	defer func() { audited(declined(ok, err)) }()
+
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "RDWS.SetNetworkConfig", serial)
+	defer func() { traced(err) }()
+
 	if serial == "" {
 		return false, errors.NewValidationError("serial", serial, "device serial cannot be empty")
 	}
@@ -744,17 +900,20 @@ func (s *rdwsService) SetNetworkConfig(ctx context.Context, serial string, iface
 
 	// Make the API request
 	var response types.RDWSNetworkConfigSetResponse
-	err = s.httpClient.PutWithAuth(ctx, token, netConfigURL, request, &response)
+	err = s.do(ctx, token, "PUT", netConfigURL, serial, request, &response)
 	if err != nil {
-		return false, errors.NewAPIError(0, "rdws_network_config_set_failed",
-			fmt.Sprintf("Failed to set network configuration for interface '%s' on device with serial '%s'", iface, serial), err.Error())
+		return false, errors.WrapPlayerError("RDWS.SetNetworkConfig", serial, "rdws_network_config_set_failed",
+			fmt.Sprintf("Failed to set network configuration for interface '%s' on device with serial '%s'", iface, serial), err)
 	}
 
 	return response.Data.Result.Success, nil
 }
 
 // GetNetworkNeighborhood retrieves information about the player's network neighborhood.
-func (s *rdwsService) GetNetworkNeighborhood(ctx context.Context, serial string) (*types.RDWSNetworkNeighborhoodResult, error) {
+func (s *rdwsService) GetNetworkNeighborhood(ctx context.Context, serial string) (_ *types.RDWSNetworkNeighborhoodResult, err error) {
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "RDWS.GetNetworkNeighborhood", serial)
+	defer func() { traced(err) }()
+
 	if serial == "" {
 		return nil, errors.NewValidationError("serial", serial, "device serial cannot be empty")
 	}
@@ -779,17 +938,20 @@ func (s *rdwsService) GetNetworkNeighborhood(ctx context.Context, serial string)
 
 	// Make the API request
 	var response types.RDWSNetworkNeighborhoodResponse
-	err = s.httpClient.GetWithAuth(ctx, token, neighborhoodURL, &response)
+	err = s.do(ctx, token, "GET", neighborhoodURL, serial, nil, &response)
 	if err != nil {
-		return nil, errors.NewAPIError(0, "rdws_network_neighborhood_failed",
-			fmt.Sprintf("Failed to get network neighborhood for device with serial '%s'", serial), err.Error())
+		return nil, errors.WrapPlayerError("RDWS.GetNetworkNeighborhood", serial, "rdws_network_neighborhood_failed",
+			fmt.Sprintf("Failed to get network neighborhood for device with serial '%s'", serial), err)
 	}
 
 	return &response.Data.Result, nil
 }
 
 // GetPacketCaptureStatus gets the current status of a packet capture operation.
-func (s *rdwsService) GetPacketCaptureStatus(ctx context.Context, serial string) (*types.RDWSPacketCaptureStatus, error) {
+func (s *rdwsService) GetPacketCaptureStatus(ctx context.Context, serial string) (_ *types.RDWSPacketCaptureStatus, err error) {
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "RDWS.GetPacketCaptureStatus", serial)
+	defer func() { traced(err) }()
+
 	if serial == "" {
 		return nil, errors.NewValidationError("serial", serial, "device serial cannot be empty")
 	}
@@ -814,17 +976,23 @@ func (s *rdwsService) GetPacketCaptureStatus(ctx context.Context, serial string)
 
 	// Make the API request
 	var response types.RDWSPacketCaptureResponse
-	err = s.httpClient.GetWithAuth(ctx, token, packetCaptureURL, &response)
+	err = s.do(ctx, token, "GET", packetCaptureURL, serial, nil, &response)
 	if err != nil {
-		return nil, errors.NewAPIError(0, "rdws_packet_capture_status_failed",
-			fmt.Sprintf("Failed to get packet capture status for device with serial '%s'", serial), err.Error())
+		return nil, errors.WrapPlayerError("RDWS.GetPacketCaptureStatus", serial, "rdws_packet_capture_status_failed",
+			fmt.Sprintf("Failed to get packet capture status for device with serial '%s'", serial), err)
 	}
 
 	return &response.Data.Result, nil
 }
 
 // StartPacketCapture starts a packet capture operation on the player.
-func (s *rdwsService) StartPacketCapture(ctx context.Context, serial string, request *types.RDWSPacketCaptureStartRequest) (string, error) {
+func (s *rdwsService) StartPacketCapture(ctx context.Context, serial string, request *types.RDWSPacketCaptureStartRequest) (_ string, err error) {
+	ctx, audited := s.audit.begin(ctx, "RDWS.StartPacketCapture", serial, packetCaptureParams(request))
+	defer func() { audited(err) }()
+
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "RDWS.StartPacketCapture", serial)
+	defer func() { traced(err) }()
+
 	if serial == "" {
 		return "", errors.NewValidationError("serial", serial, "device serial cannot be empty")
 	}
@@ -852,17 +1020,23 @@ func (s *rdwsService) StartPacketCapture(ctx context.Context, serial string, req
 
 	// Make the API request
 	var response types.RDWSPacketCaptureStartResponse
-	err = s.httpClient.PostWithAuth(ctx, token, packetCaptureURL, request, &response)
+	err = s.do(ctx, token, "POST", packetCaptureURL, serial, request, &response)
 	if err != nil {
-		return "", errors.NewAPIError(0, "rdws_packet_capture_start_failed",
-			fmt.Sprintf("Failed to start packet capture on device with serial '%s'", serial), err.Error())
+		return "", errors.WrapPlayerError("RDWS.StartPacketCapture", serial, "rdws_packet_capture_start_failed",
+			fmt.Sprintf("Failed to start packet capture on device with serial '%s'", serial), err)
 	}
 
 	return response.Data.Result.FilePath, nil
 }
 
 // StopPacketCapture stops a running packet capture operation on the player.
-func (s *rdwsService) StopPacketCapture(ctx context.Context, serial string) (string, error) {
+func (s *rdwsService) StopPacketCapture(ctx context.Context, serial string) (_ string, err error) {
+	ctx, audited := s.audit.begin(ctx, "RDWS.StopPacketCapture", serial, nil)
+	defer func() { audited(err) }()
+
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "RDWS.StopPacketCapture", serial)
+	defer func() { traced(err) }()
+
 	if serial == "" {
 		return "", errors.NewValidationError("serial", serial, "device serial cannot be empty")
 	}
@@ -887,17 +1061,20 @@ func (s *rdwsService) StopPacketCapture(ctx context.Context, serial string) (str
 
 	// Make the API request
 	var response types.RDWSPacketCaptureStopResponse
-	err = s.httpClient.DeleteWithAuth(ctx, token, packetCaptureURL, &response)
+	err = s.do(ctx, token, "DELETE", packetCaptureURL, serial, nil, &response)
 	if err != nil {
-		return "", errors.NewAPIError(0, "rdws_packet_capture_stop_failed",
-			fmt.Sprintf("Failed to stop packet capture on device with serial '%s'", serial), err.Error())
+		return "", errors.WrapPlayerError("RDWS.StopPacketCapture", serial, "rdws_packet_capture_stop_failed",
+			fmt.Sprintf("Failed to stop packet capture on device with serial '%s'", serial), err)
 	}
 
 	return response.Data.Result.FilePath, nil
 }
 
 // GetTelnetStatus gets telnet information (enabled status and port number).
-func (s *rdwsService) GetTelnetStatus(ctx context.Context, serial string) (*types.RDWSTelnetInfo, error) {
+func (s *rdwsService) GetTelnetStatus(ctx context.Context, serial string) (_ *types.RDWSTelnetInfo, err error) {
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "RDWS.GetTelnetStatus", serial)
+	defer func() { traced(err) }()
+
 	if serial == "" {
 		return nil, errors.NewValidationError("serial", serial, "device serial cannot be empty")
 	}
@@ -922,17 +1099,23 @@ func (s *rdwsService) GetTelnetStatus(ctx context.Context, serial string) (*type
 
 	// Make the API request
 	var response types.RDWSTelnetResponse
-	err = s.httpClient.GetWithAuth(ctx, token, telnetURL, &response)
+	err = s.do(ctx, token, "GET", telnetURL, serial, nil, &response)
 	if err != nil {
-		return nil, errors.NewAPIError(0, "rdws_telnet_get_failed",
-			fmt.Sprintf("Failed to get telnet status for device with serial '%s'", serial), err.Error())
+		return nil, errors.WrapPlayerError("RDWS.GetTelnetStatus", serial, "rdws_telnet_get_failed",
+			fmt.Sprintf("Failed to get telnet status for device with serial '%s'", serial), err)
 	}
 
 	return &response.Data.Result, nil
 }
 
 // SetTelnetStatus enables or disables telnet on the player.
-func (s *rdwsService) SetTelnetStatus(ctx context.Context, serial string, enabled bool, port int) (bool, error) {
+func (s *rdwsService) SetTelnetStatus(ctx context.Context, serial string, enabled bool, port int) (ok bool, err error) {
+	ctx, audited := s.audit.begin(ctx, "RDWS.SetTelnetStatus", serial, auditParams{"enabled": enabled, "port": port})
+	defer func() { audited(declined(ok, err)) }()
+
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "RDWS.SetTelnetStatus", serial)
+	defer func() { traced(err) }()
+
 	if serial == "" {
 		return false, errors.NewValidationError("serial", serial, "device serial cannot be empty")
 	}
@@ -964,17 +1147,20 @@ func (s *rdwsService) SetTelnetStatus(ctx context.Context, serial string, enable
 
 	// Make the API request
 	var response types.RDWSTelnetSetResponse
-	err = s.httpClient.PutWithAuth(ctx, token, telnetURL, request, &response)
+	err = s.do(ctx, token, "PUT", telnetURL, serial, request, &response)
 	if err != nil {
-		return false, errors.NewAPIError(0, "rdws_telnet_set_failed",
-			fmt.Sprintf("Failed to set telnet status for device with serial '%s'", serial), err.Error())
+		return false, errors.WrapPlayerError("RDWS.SetTelnetStatus", serial, "rdws_telnet_set_failed",
+			fmt.Sprintf("Failed to set telnet status for device with serial '%s'", serial), err)
 	}
 
 	return response.Data.Result.Success, nil
 }
 
 // GetSSHStatus gets SSH information (enabled status and port number).
-func (s *rdwsService) GetSSHStatus(ctx context.Context, serial string) (*types.RDWSSSHInfo, error) {
+func (s *rdwsService) GetSSHStatus(ctx context.Context, serial string) (_ *types.RDWSSSHInfo, err error) {
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "RDWS.GetSSHStatus", serial)
+	defer func() { traced(err) }()
+
 	if serial == "" {
 		return nil, errors.NewValidationError("serial", serial, "device serial cannot be empty")
 	}
@@ -999,10 +1185,10 @@ func (s *rdwsService) GetSSHStatus(ctx context.Context, serial string) (*types.R
 
 	// Make the API request
 	var response types.RDWSSSHResponse
-	err = s.httpClient.GetWithAuth(ctx, token, sshURL, &response)
+	err = s.do(ctx, token, "GET", sshURL, serial, nil, &response)
 	if err != nil {
-		return nil, errors.NewAPIError(0, "rdws_ssh_get_failed",
-			fmt.Sprintf("Failed to get SSH status for device with serial '%s'", serial), err.Error())
+		return nil, errors.WrapPlayerError("RDWS.GetSSHStatus", serial, "rdws_ssh_get_failed",
+			fmt.Sprintf("Failed to get SSH status for device with serial '%s'", serial), err)
 	}
 
 	return &response.Data.Result, nil
@@ -1010,7 +1196,13 @@ func (s *rdwsService) GetSSHStatus(ctx context.Context, serial string) (*types.R
 
 // SetSSHStatus enables or disables SSH on the player.
 // If password is non-empty, it will be set. If password is empty, the existing password is not changed.
-func (s *rdwsService) SetSSHStatus(ctx context.Context, serial string, enabled bool, port int, password string) (bool, error) {
+func (s *rdwsService) SetSSHStatus(ctx context.Context, serial string, enabled bool, port int, password string) (ok bool, err error) {
+	ctx, audited := s.audit.begin(ctx, "RDWS.SetSSHStatus", serial, auditParams{"enabled": enabled, "port": port, "password": password})
+	defer func() { audited(declined(ok, err)) }()
+
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "RDWS.SetSSHStatus", serial)
+	defer func() { traced(err) }()
+
 	if serial == "" {
 		return false, errors.NewValidationError("serial", serial, "device serial cannot be empty")
 	}
@@ -1045,10 +1237,10 @@ func (s *rdwsService) SetSSHStatus(ctx context.Context, serial string, enabled b
 
 	// Make the API request
 	var response types.RDWSSSHSetResponse
-	err = s.httpClient.PutWithAuth(ctx, token, sshURL, request, &response)
+	err = s.do(ctx, token, "PUT", sshURL, serial, request, &response)
 	if err != nil {
-		return false, errors.NewAPIError(0, "rdws_ssh_set_failed",
-			fmt.Sprintf("Failed to set SSH status for device with serial '%s'", serial), err.Error())
+		return false, errors.WrapPlayerError("RDWS.SetSSHStatus", serial, "rdws_ssh_set_failed",
+			fmt.Sprintf("Failed to set SSH status for device with serial '%s'", serial), err)
 	}
 
 	return response.Data.Result.Success, nil
@@ -1057,7 +1249,13 @@ func (s *rdwsService) SetSSHStatus(ctx context.Context, serial string, enabled b
 // ReformatStorage reformats the specified storage device on a player.
 // WARNING: This operation will ERASE ALL DATA on the specified storage device.
 // Common device names: "sd", "ssd", "usb"
-func (s *rdwsService) ReformatStorage(ctx context.Context, serial string, deviceName string) (bool, error) {
+func (s *rdwsService) ReformatStorage(ctx context.Context, serial string, deviceName string) (ok bool, err error) {
+	ctx, audited := s.audit.begin(ctx, "RDWS.ReformatStorage", serial, auditParams{"deviceName": deviceName})
+	defer func() { audited(declined(ok, err)) }()
+
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "RDWS.ReformatStorage", serial)
+	defer func() { traced(err) }()
+
 	if serial == "" {
 		return false, errors.NewValidationError("serial", serial, "device serial cannot be empty")
 	}
@@ -1085,10 +1283,10 @@ func (s *rdwsService) ReformatStorage(ctx context.Context, serial string, device
 
 	// Make the API request
 	var response types.RDWSStorageReformatResponse
-	err = s.httpClient.DeleteWithAuth(ctx, token, storageURL, &response)
+	err = s.do(ctx, token, "DELETE", storageURL, serial, nil, &response)
 	if err != nil {
-		return false, errors.NewAPIError(0, "rdws_storage_reformat_failed",
-			fmt.Sprintf("Failed to reformat storage device '%s' on device with serial '%s'", deviceName, serial), err.Error())
+		return false, errors.WrapPlayerError("RDWS.ReformatStorage", serial, "rdws_storage_reformat_failed",
+			fmt.Sprintf("Failed to reformat storage device '%s' on device with serial '%s'", deviceName, serial), err)
 	}
 
 	return response.Data.Result.Success, nil
@@ -1096,7 +1294,13 @@ func (s *rdwsService) ReformatStorage(ctx context.Context, serial string, device
 
 // SendCustomData sends custom data to a player via UDP port 5000.
 // This allows sending custom commands or data to player applications listening on UDP port 5000.
-func (s *rdwsService) SendCustomData(ctx context.Context, serial string, data string) (bool, error) {
+func (s *rdwsService) SendCustomData(ctx context.Context, serial string, data string) (ok bool, err error) {
+	ctx, audited := s.audit.begin(ctx, "RDWS.SendCustomData", serial, auditParams{"data": data})
+	defer func() { audited(declined(ok, err)) }()
+
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "RDWS.SendCustomData", serial)
+	defer func() { traced(err) }()
+
 	if serial == "" {
 		return false, errors.NewValidationError("serial", serial, "device serial cannot be empty")
 	}
@@ -1128,10 +1332,10 @@ func (s *rdwsService) SendCustomData(ctx context.Context, serial string, data st
 
 	// Make the API request
 	var response types.RDWSCustomDataResponse
-	err = s.httpClient.PutWithAuth(ctx, token, customURL, request, &response)
+	err = s.do(ctx, token, "PUT", customURL, serial, request, &response)
 	if err != nil {
-		return false, errors.NewAPIError(0, "rdws_custom_data_failed",
-			fmt.Sprintf("Failed to send custom data to device with serial '%s'", serial), err.Error())
+		return false, errors.WrapPlayerError("RDWS.SendCustomData", serial, "rdws_custom_data_failed",
+			fmt.Sprintf("Failed to send custom data to device with serial '%s'", serial), err)
 	}
 
 	return response.Data.Result.Success, nil
@@ -1141,7 +1345,13 @@ func (s *rdwsService) SendCustomData(ctx context.Context, serial string, data st
 // The player will download the firmware from the specified URL and apply it.
 // If autoReboot is nil or true, the player will reboot automatically after the firmware update is applied.
 // If autoReboot is false, the player will NOT automatically reboot and will require manual reboot.
-func (s *rdwsService) DownloadFirmware(ctx context.Context, serial string, firmwareURL string, autoReboot *bool) (bool, error) {
+func (s *rdwsService) DownloadFirmware(ctx context.Context, serial string, firmwareURL string, autoReboot *bool) (ok bool, err error) {
+	ctx, audited := s.audit.begin(ctx, "RDWS.DownloadFirmware", serial, firmwareParams(firmwareURL, autoReboot))
+	defer func() { audited(declined(ok, err)) }()
+
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "RDWS.DownloadFirmware", serial)
+	defer func() { traced(err) }()
+
 	if serial == "" {
 		return false, errors.NewValidationError("serial", serial, "device serial cannot be empty")
 	}
@@ -1180,17 +1390,20 @@ func (s *rdwsService) DownloadFirmware(ctx context.Context, serial string, firmw
 
 	// Make the API request using GET (not POST)
 	var response types.RDWSFirmwareDownloadResponse
-	err = s.httpClient.GetWithAuth(ctx, token, firmwareDownloadURL, &response)
+	err = s.do(ctx, token, "GET", firmwareDownloadURL, serial, nil, &response)
 	if err != nil {
-		return false, errors.NewAPIError(0, "rdws_firmware_download_failed",
-			fmt.Sprintf("Failed to initiate firmware download on device with serial '%s'", serial), err.Error())
+		return false, errors.WrapPlayerError("RDWS.DownloadFirmware", serial, "rdws_firmware_download_failed",
+			fmt.Sprintf("Failed to initiate firmware download on device with serial '%s'", serial), err)
 	}
 
 	return response.Data.Result.Success, nil
 }
 
 // GetRegistry retrieves the complete player registry dump.
-func (s *rdwsService) GetRegistry(ctx context.Context, serial string) (*types.RDWSRegistry, error) {
+func (s *rdwsService) GetRegistry(ctx context.Context, serial string) (_ *types.RDWSRegistry, err error) {
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "RDWS.GetRegistry", serial)
+	defer func() { traced(err) }()
+
 	if serial == "" {
 		return nil, errors.NewValidationError("serial", serial, "device serial cannot be empty")
 	}
@@ -1215,17 +1428,20 @@ func (s *rdwsService) GetRegistry(ctx context.Context, serial string) (*types.RD
 
 	// Make the API request
 	var response types.RDWSRegistryResponse
-	err = s.httpClient.GetWithAuth(ctx, token, registryURL, &response)
+	err = s.do(ctx, token, "GET", registryURL, serial, nil, &response)
 	if err != nil {
-		return nil, errors.NewAPIError(0, "rdws_registry_get_failed",
-			fmt.Sprintf("Failed to get registry from device with serial '%s'", serial), err.Error())
+		return nil, errors.WrapPlayerError("RDWS.GetRegistry", serial, "rdws_registry_get_failed",
+			fmt.Sprintf("Failed to get registry from device with serial '%s'", serial), err)
 	}
 
 	return &response.Data.Result, nil
 }
 
 // GetRegistryValue retrieves a specific value from the player registry.
-func (s *rdwsService) GetRegistryValue(ctx context.Context, serial string, section string, key string) (*types.RDWSRegistryValue, error) {
+func (s *rdwsService) GetRegistryValue(ctx context.Context, serial string, section string, key string) (_ *types.RDWSRegistryValue, err error) {
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "RDWS.GetRegistryValue", serial)
+	defer func() { traced(err) }()
+
 	if serial == "" {
 		return nil, errors.NewValidationError("serial", serial, "device serial cannot be empty")
 	}
@@ -1256,10 +1472,10 @@ func (s *rdwsService) GetRegistryValue(ctx context.Context, serial string, secti
 
 	// Make the API request
 	var response types.RDWSRegistryValueResponse
-	err = s.httpClient.GetWithAuth(ctx, token, registryURL, &response)
+	err = s.do(ctx, token, "GET", registryURL, serial, nil, &response)
 	if err != nil {
-		return nil, errors.NewAPIError(0, "rdws_registry_value_get_failed",
-			fmt.Sprintf("Failed to get registry value '%s/%s' from device with serial '%s'", section, key, serial), err.Error())
+		return nil, errors.WrapPlayerError("RDWS.GetRegistryValue", serial, "rdws_registry_value_get_failed",
+			fmt.Sprintf("Failed to get registry value '%s/%s' from device with serial '%s'", section, key, serial), err)
 	}
 
 	return &types.RDWSRegistryValue{
@@ -1270,7 +1486,13 @@ func (s *rdwsService) GetRegistryValue(ctx context.Context, serial string, secti
 }
 
 // SetRegistryValue sets a specific value in the player registry.
-func (s *rdwsService) SetRegistryValue(ctx context.Context, serial string, section string, key string, value string) (bool, error) {
+func (s *rdwsService) SetRegistryValue(ctx context.Context, serial string, section string, key string, value string) (ok bool, err error) {
+	ctx, audited := s.audit.begin(ctx, "RDWS.SetRegistryValue", serial, registryParams(section, key, value))
+	defer func() { audited(declined(ok, err)) }()
+
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "RDWS.SetRegistryValue", serial)
+	defer func() { traced(err) }()
+
 	if serial == "" {
 		return false, errors.NewValidationError("serial", serial, "device serial cannot be empty")
 	}
@@ -1305,17 +1527,23 @@ func (s *rdwsService) SetRegistryValue(ctx context.Context, serial string, secti
 
 	// Make the API request
 	var response types.RDWSRegistrySetResponse
-	err = s.httpClient.PutWithAuth(ctx, token, registryURL, request, &response)
+	err = s.do(ctx, token, "PUT", registryURL, serial, request, &response)
 	if err != nil {
-		return false, errors.NewAPIError(0, "rdws_registry_value_set_failed",
-			fmt.Sprintf("Failed to set registry value '%s/%s' on device with serial '%s'", section, key, serial), err.Error())
+		return false, errors.WrapPlayerError("RDWS.SetRegistryValue", serial, "rdws_registry_value_set_failed",
+			fmt.Sprintf("Failed to set registry value '%s/%s' on device with serial '%s'", section, key, serial), err)
 	}
 
 	return response.Data.Result.Success, nil
 }
 
 // DeleteRegistryValue deletes a key-value pair from the player registry.
-func (s *rdwsService) DeleteRegistryValue(ctx context.Context, serial string, section string, key string) (bool, error) {
+func (s *rdwsService) DeleteRegistryValue(ctx context.Context, serial string, section string, key string) (ok bool, err error) {
+	ctx, audited := s.audit.begin(ctx, "RDWS.DeleteRegistryValue", serial, auditParams{"section": section, "key": key})
+	defer func() { audited(declined(ok, err)) }()
+
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "RDWS.DeleteRegistryValue", serial)
+	defer func() { traced(err) }()
+
 	if serial == "" {
 		return false, errors.NewValidationError("serial", serial, "device serial cannot be empty")
 	}
@@ -1346,17 +1574,23 @@ func (s *rdwsService) DeleteRegistryValue(ctx context.Context, serial string, se
 
 	// Make the API request
 	var response types.RDWSRegistryDeleteResponse
-	err = s.httpClient.DeleteWithAuth(ctx, token, registryURL, &response)
+	err = s.do(ctx, token, "DELETE", registryURL, serial, nil, &response)
 	if err != nil {
-		return false, errors.NewAPIError(0, "rdws_registry_value_delete_failed",
-			fmt.Sprintf("Failed to delete registry value '%s/%s' from device with serial '%s'", section, key, serial), err.Error())
+		return false, errors.WrapPlayerError("RDWS.DeleteRegistryValue", serial, "rdws_registry_value_delete_failed",
+			fmt.Sprintf("Failed to delete registry value '%s/%s' from device with serial '%s'", section, key, serial), err)
 	}
 
 	return response.Data.Result.Success, nil
 }
 
 // FlushRegistry flushes the player registry immediately to disk.
-func (s *rdwsService) FlushRegistry(ctx context.Context, serial string) (bool, error) {
+func (s *rdwsService) FlushRegistry(ctx context.Context, serial string) (ok bool, err error) {
+	ctx, audited := s.audit.begin(ctx, "RDWS.FlushRegistry", serial, nil)
+	defer func() { audited(declined(ok, err)) }()
+
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "RDWS.FlushRegistry", serial)
+	defer func() { traced(err) }()
+
 	if serial == "" {
 		return false, errors.NewValidationError("serial", serial, "device serial cannot be empty")
 	}
@@ -1381,17 +1615,20 @@ func (s *rdwsService) FlushRegistry(ctx context.Context, serial string) (bool, e
 
 	// Make the API request
 	var response types.RDWSRegistryFlushResponse
-	err = s.httpClient.PutWithAuth(ctx, token, registryURL, nil, &response)
+	err = s.do(ctx, token, "PUT", registryURL, serial, nil, &response)
 	if err != nil {
-		return false, errors.NewAPIError(0, "rdws_registry_flush_failed",
-			fmt.Sprintf("Failed to flush registry on device with serial '%s'", serial), err.Error())
+		return false, errors.WrapPlayerError("RDWS.FlushRegistry", serial, "rdws_registry_flush_failed",
+			fmt.Sprintf("Failed to flush registry on device with serial '%s'", serial), err)
 	}
 
 	return response.Data.Result.Success, nil
 }
 
 // GetRecoveryURL retrieves the recovery URL from the player registry.
-func (s *rdwsService) GetRecoveryURL(ctx context.Context, serial string) (*types.RDWSRecoveryURL, error) {
+func (s *rdwsService) GetRecoveryURL(ctx context.Context, serial string) (_ *types.RDWSRecoveryURL, err error) {
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "RDWS.GetRecoveryURL", serial)
+	defer func() { traced(err) }()
+
 	if serial == "" {
 		return nil, errors.NewValidationError("serial", serial, "device serial cannot be empty")
 	}
@@ -1416,10 +1653,10 @@ func (s *rdwsService) GetRecoveryURL(ctx context.Context, serial string) (*types
 
 	// Make the API request
 	var response types.RDWSRecoveryURLResponse
-	err = s.httpClient.GetWithAuth(ctx, token, recoveryURL, &response)
+	err = s.do(ctx, token, "GET", recoveryURL, serial, nil, &response)
 	if err != nil {
-		return nil, errors.NewAPIError(0, "rdws_recovery_url_get_failed",
-			fmt.Sprintf("Failed to get recovery URL from device with serial '%s'", serial), err.Error())
+		return nil, errors.WrapPlayerError("RDWS.GetRecoveryURL", serial, "rdws_recovery_url_get_failed",
+			fmt.Sprintf("Failed to get recovery URL from device with serial '%s'", serial), err)
 	}
 
 	return &types.RDWSRecoveryURL{
@@ -1428,7 +1665,13 @@ func (s *rdwsService) GetRecoveryURL(ctx context.Context, serial string) (*types
 }
 
 // SetRecoveryURL sets the recovery URL in the player registry.
-func (s *rdwsService) SetRecoveryURL(ctx context.Context, serial string, recoveryURL string) (bool, error) {
+func (s *rdwsService) SetRecoveryURL(ctx context.Context, serial string, recoveryURL string) (ok bool, err error) {
+	ctx, audited := s.audit.begin(ctx, "RDWS.SetRecoveryURL", serial, auditParams{"recoveryURL": recoveryURL})
+	defer func() { audited(declined(ok, err)) }()
+
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "RDWS.SetRecoveryURL", serial)
+	defer func() { traced(err) }()
+
 	if serial == "" {
 		return false, errors.NewValidationError("serial", serial, "device serial cannot be empty")
 	}
@@ -1460,17 +1703,20 @@ func (s *rdwsService) SetRecoveryURL(ctx context.Context, serial string, recover
 
 	// Make the API request
 	var response types.RDWSRecoveryURLSetResponse
-	err = s.httpClient.PutWithAuth(ctx, token, recoveryURLEndpoint, request, &response)
+	err = s.do(ctx, token, "PUT", recoveryURLEndpoint, serial, request, &response)
 	if err != nil {
-		return false, errors.NewAPIError(0, "rdws_recovery_url_set_failed",
-			fmt.Sprintf("Failed to set recovery URL on device with serial '%s'", serial), err.Error())
+		return false, errors.WrapPlayerError("RDWS.SetRecoveryURL", serial, "rdws_recovery_url_set_failed",
+			fmt.Sprintf("Failed to set recovery URL on device with serial '%s'", serial), err)
 	}
 
 	return response.Data.Result.Success, nil
 }
 
 // GetLogs retrieves log files from the player
-func (s *rdwsService) GetLogs(ctx context.Context, serial string) (*types.RDWSLogs, error) {
+func (s *rdwsService) GetLogs(ctx context.Context, serial string) (_ *types.RDWSLogs, err error) {
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "RDWS.GetLogs", serial)
+	defer func() { traced(err) }()
+
 	if serial == "" {
 		return nil, errors.NewValidationError("serial", serial, "device serial cannot be empty")
 	}
@@ -1495,25 +1741,17 @@ func (s *rdwsService) GetLogs(ctx context.Context, serial string) (*types.RDWSLo
 
 	// Make the API request
 	var response types.RDWSLogsResponse
-	err = s.httpClient.GetWithAuth(ctx, token, logsEndpoint, &response)
+	err = s.do(ctx, token, "GET", logsEndpoint, serial, nil, &response)
 	if err != nil {
-		return nil, errors.NewAPIError(0, "rdws_logs_failed",
-			fmt.Sprintf("Failed to get logs from device with serial '%s'", serial), err.Error())
-	}
-
-	// Check if result is an error string or a success object
-	var errorString string
-	if err := json.Unmarshal(response.Data.Result, &errorString); err == nil {
-		// Result is a string (error message)
-		return nil, errors.NewAPIError(0, "rdws_logs_error",
-			fmt.Sprintf("Device returned error for serial '%s'", serial), errorString)
+		return nil, errors.WrapPlayerError("RDWS.GetLogs", serial, "rdws_logs_failed",
+			fmt.Sprintf("Failed to get logs from device with serial '%s'", serial), err)
 	}
 
-	// Try to unmarshal as success response
+	// A string result was returned as a PlayerError, so this is the success object
 	var result types.RDWSLogsResult
 	if err := json.Unmarshal(response.Data.Result, &result); err != nil {
-		return nil, errors.NewAPIError(0, "rdws_logs_parse_failed",
-			fmt.Sprintf("Failed to parse logs response from device with serial '%s'", serial), err.Error())
+		return nil, errors.WrapPlayerError("RDWS.GetLogs", serial, "rdws_logs_parse_failed",
+			fmt.Sprintf("Failed to parse logs response from device with serial '%s'", serial), err)
 	}
 
 	// Convert response to return type
@@ -1525,7 +1763,10 @@ func (s *rdwsService) GetLogs(ctx context.Context, serial string) (*types.RDWSLo
 }
 
 // GetCrashDump retrieves crash dump files from the player
-func (s *rdwsService) GetCrashDump(ctx context.Context, serial string) (*types.RDWSCrashDump, error) {
+func (s *rdwsService) GetCrashDump(ctx context.Context, serial string) (_ *types.RDWSCrashDump, err error) {
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "RDWS.GetCrashDump", serial)
+	defer func() { traced(err) }()
+
 	if serial == "" {
 		return nil, errors.NewValidationError("serial", serial, "device serial cannot be empty")
 	}
@@ -1550,25 +1791,17 @@ func (s *rdwsService) GetCrashDump(ctx context.Context, serial string) (*types.R
 
 	// Make the API request
 	var response types.RDWSCrashDumpResponse
-	err = s.httpClient.GetWithAuth(ctx, token, crashDumpEndpoint, &response)
+	err = s.do(ctx, token, "GET", crashDumpEndpoint, serial, nil, &response)
 	if err != nil {
-		return nil, errors.NewAPIError(0, "rdws_crash_dump_failed",
-			fmt.Sprintf("Failed to get crash dump from device with serial '%s'", serial), err.Error())
-	}
-
-	// Check if result is an error string or a success object
-	var errorString string
-	if err := json.Unmarshal(response.Data.Result, &errorString); err == nil {
-		// Result is a string (error message)
-		return nil, errors.NewAPIError(0, "rdws_crash_dump_error",
-			fmt.Sprintf("Device returned error for serial '%s'", serial), errorString)
+		return nil, errors.WrapPlayerError("RDWS.GetCrashDump", serial, "rdws_crash_dump_failed",
+			fmt.Sprintf("Failed to get crash dump from device with serial '%s'", serial), err)
 	}
 
-	// Try to unmarshal as success response
+	// A string result was returned as a PlayerError, so this is the success object
 	var result types.RDWSCrashDumpResult
 	if err := json.Unmarshal(response.Data.Result, &result); err != nil {
-		return nil, errors.NewAPIError(0, "rdws_crash_dump_parse_failed",
-			fmt.Sprintf("Failed to parse crash dump response from device with serial '%s'", serial), err.Error())
+		return nil, errors.WrapPlayerError("RDWS.GetCrashDump", serial, "rdws_crash_dump_parse_failed",
+			fmt.Sprintf("Failed to parse crash dump response from device with serial '%s'", serial), err)
 	}
 
 	// Convert response to return type
@@ -0,0 +1,203 @@
+package services
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/brightdevelopers/gopurple/internal/errors"
+	"github.com/brightdevelopers/gopurple/internal/types"
+)
+
+const (
+	// defaultCapturePollInterval is how often CapturePackets checks capture status.
+	defaultCapturePollInterval = 5 * time.Second
+
+	// captureCleanupTimeout bounds the stop, download and delete steps, which still run
+	// after the caller's context has been cancelled.
+	captureCleanupTimeout = 2 * time.Minute
+)
+
+// PacketCaptureRequest describes a complete packet capture run for CapturePackets.
+type PacketCaptureRequest struct {
+	Interface      string        // Network interface to capture on (default: "eth0")
+	Duration       time.Duration // How long to capture for (required)
+	Filter         string        // Optional tcpdump filter expression
+	Output         io.Writer     // Destination for the downloaded pcap file (required)
+	PollInterval   time.Duration // How often to poll capture status (default: 5s)
+	KeepRemoteFile bool          // Leave the capture file on the player after downloading it
+}
+
+// PacketCaptureResult describes the outcome of CapturePackets.
+type PacketCaptureResult struct {
+	FilePath      string        `json:"filePath"`      // Capture file path on the player
+	Bytes         int64         `json:"bytes"`         // Number of bytes written to Output
+	Elapsed       time.Duration `json:"elapsed"`       // Time between start and stop
+	Interrupted   bool          `json:"interrupted"`   // The context was cancelled before the duration elapsed
+	RemoteDeleted bool          `json:"remoteDeleted"` // The capture file was removed from the player
+}
+
+// CapturePackets runs a packet capture on the player from start to finish: it starts
+// the capture, polls until the requested duration elapses or ctx is cancelled, stops
+// the capture, downloads the capture file to request.Output, checks that it is a
+// pcap or pcapng file and finally deletes it from the player.
+//
+// Cancelling ctx ends the capture early; the partial capture is still retrieved and
+// the result is marked as interrupted.
+func (s *rdwsService) CapturePackets(ctx context.Context, serial string, request *PacketCaptureRequest) (_ *PacketCaptureResult, err error) {
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "RDWS.CapturePackets", serial)
+	defer func() { traced(err) }()
+
+	return capturePackets(ctx, s, serial, request)
+}
+
+func capturePackets(ctx context.Context, service RDWSService, serial string, request *PacketCaptureRequest) (*PacketCaptureResult, error) {
+	if serial == "" {
+		return nil, errors.NewValidationError("serial", serial, "device serial cannot be empty")
+	}
+	if request == nil {
+		return nil, errors.NewValidationError("request", request, "packet capture request cannot be nil")
+	}
+	if request.Duration < time.Second {
+		return nil, errors.NewValidationError("duration", request.Duration, "capture duration must be at least one second")
+	}
+	if request.Output == nil {
+		return nil, errors.NewValidationError("output", nil, "capture output writer cannot be nil")
+	}
+
+	iface := request.Interface
+	if iface == "" {
+		iface = "eth0"
+	}
+	pollInterval := request.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = defaultCapturePollInterval
+	}
+
+	// Start the capture
+	startRequest := &types.RDWSPacketCaptureStartRequest{}
+	startRequest.Data.Interface = iface
+	startRequest.Data.Duration = int(request.Duration.Round(time.Second) / time.Second)
+	startRequest.Data.Filter = request.Filter
+
+	filePath, err := service.StartPacketCapture(ctx, serial, startRequest)
+	if err != nil {
+		return nil, err
+	}
+	started := time.Now()
+
+	result := &PacketCaptureResult{FilePath: filePath}
+
+	// Poll until the capture finishes on its own, the duration elapses or ctx is cancelled
+	running := true
+	deadline := time.NewTimer(request.Duration)
+	defer deadline.Stop()
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+poll:
+	for {
+		select {
+		case <-ctx.Done():
+			result.Interrupted = true
+			break poll
+		case <-deadline.C:
+			break poll
+		case <-ticker.C:
+			status, err := service.GetPacketCaptureStatus(ctx, serial)
+			if err != nil {
+				if ctx.Err() != nil {
+					result.Interrupted = true
+					break poll
+				}
+				continue
+			}
+			if status.FilePath != "" {
+				result.FilePath = status.FilePath
+			}
+			if !status.Running {
+				running = false
+				break poll
+			}
+		}
+	}
+
+	// The remaining steps must run even if the caller gave up waiting
+	cleanupCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), captureCleanupTimeout)
+	defer cancel()
+
+	stoppedPath, err := service.StopPacketCapture(cleanupCtx, serial)
+	if err != nil && running {
+		return result, err
+	}
+	if stoppedPath != "" {
+		result.FilePath = stoppedPath
+	}
+	result.Elapsed = time.Since(started)
+
+	if result.FilePath == "" {
+		return result, errors.NewAPIError(0, "rdws_packet_capture_no_file",
+			fmt.Sprintf("Packet capture on device with serial '%s' did not report a capture file", serial), "")
+	}
+
+	// Download and verify the capture file
+	remotePath := captureFilePath(result.FilePath)
+	contents, err := service.DownloadFile(cleanupCtx, serial, remotePath)
+	if err != nil {
+		return result, err
+	}
+
+	if err := checkPcapHeader(contents); err != nil {
+		return result, errors.WrapPlayerError("RDWS.CapturePackets", serial, "rdws_packet_capture_invalid",
+			fmt.Sprintf("Capture file '%s' from device with serial '%s' is not a valid pcap file", result.FilePath, serial), err)
+	}
+
+	n, err := request.Output.Write(contents)
+	result.Bytes = int64(n)
+	if err != nil {
+		return result, err
+	}
+
+	if request.KeepRemoteFile {
+		return result, nil
+	}
+
+	deleted, err := service.DeleteFile(cleanupCtx, serial, remotePath)
+	if err != nil {
+		return result, err
+	}
+	result.RemoteDeleted = deleted
+
+	return result, nil
+}
+
+// captureFilePath converts the capture file path reported by the player (for example
+// "/storage/sd/capture.pcap") into the form expected by the rDWS files endpoints ("sd/capture.pcap").
+func captureFilePath(reported string) string {
+	p := strings.TrimPrefix(reported, "/")
+	return strings.TrimPrefix(p, "storage/")
+}
+
+// checkPcapHeader verifies that data starts with a libpcap or pcapng file header.
+func checkPcapHeader(data []byte) error {
+	if len(data) < 4 {
+		return fmt.Errorf("file is too short (%d bytes) to contain a pcap header", len(data))
+	}
+
+	switch binary.BigEndian.Uint32(data[:4]) {
+	case 0xa1b2c3d4, 0xd4c3b2a1, 0xa1b23c4d, 0x4d3cb2a1:
+		// libpcap with microsecond or nanosecond timestamps, either byte order
+		if len(data) < 24 {
+			return fmt.Errorf("file is too short (%d bytes) to contain a pcap global header", len(data))
+		}
+		return nil
+	case 0x0a0d0d0a:
+		// pcapng section header block
+		return nil
+	default:
+		return fmt.Errorf("unrecognized magic number %#08x", binary.BigEndian.Uint32(data[:4]))
+	}
+}
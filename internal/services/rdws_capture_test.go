@@ -0,0 +1,213 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	stderrors "errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/brightdevelopers/gopurple/internal/types"
+)
+
+// fakeCapturePlayer simulates the packet capture and file endpoints of a player.
+type fakeCapturePlayer struct {
+	RDWSService
+	filePath   string
+	contents   []byte
+	stopAfter  int // capture reports not running after this many status polls (0 = never)
+	stopErr    error
+	downloaded string
+
+	mu      sync.Mutex
+	polls   int
+	stopped bool
+	deleted string
+}
+
+func (f *fakeCapturePlayer) StartPacketCapture(ctx context.Context, serial string, request *types.RDWSPacketCaptureStartRequest) (string, error) {
+	return f.filePath, nil
+}
+
+func (f *fakeCapturePlayer) GetPacketCaptureStatus(ctx context.Context, serial string) (*types.RDWSPacketCaptureStatus, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.polls++
+	running := f.stopAfter == 0 || f.polls < f.stopAfter
+	return &types.RDWSPacketCaptureStatus{Running: running, FilePath: f.filePath}, nil
+}
+
+func (f *fakeCapturePlayer) StopPacketCapture(ctx context.Context, serial string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+	f.stopped = true
+	return f.filePath, f.stopErr
+}
+
+func (f *fakeCapturePlayer) DownloadFile(ctx context.Context, serial string, path string) ([]byte, error) {
+	f.downloaded = path
+	return f.contents, nil
+}
+
+func (f *fakeCapturePlayer) DeleteFile(ctx context.Context, serial string, path string) (bool, error) {
+	f.deleted = path
+	return true, nil
+}
+
+func testPcapFile() []byte {
+	header := make([]byte, 24)
+	binary.LittleEndian.PutUint32(header[0:4], 0xa1b2c3d4)
+	binary.LittleEndian.PutUint16(header[4:6], 2)
+	binary.LittleEndian.PutUint16(header[6:8], 4)
+	binary.LittleEndian.PutUint32(header[16:20], 65535)
+	binary.LittleEndian.PutUint32(header[20:24], 1)
+	return header
+}
+
+func TestCapturePackets_DurationElapses(t *testing.T) {
+	player := &fakeCapturePlayer{filePath: "/storage/sd/capture.pcap", contents: testPcapFile()}
+
+	var out bytes.Buffer
+	result, err := capturePackets(context.Background(), player, "ABC123", &PacketCaptureRequest{
+		Duration:     time.Second,
+		Output:       &out,
+		PollInterval: 100 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("capturePackets returned error: %v", err)
+	}
+
+	if !player.stopped {
+		t.Error("Expected capture to be stopped")
+	}
+	if player.downloaded != "sd/capture.pcap" {
+		t.Errorf("Expected download of sd/capture.pcap, got %q", player.downloaded)
+	}
+	if player.deleted != "sd/capture.pcap" || !result.RemoteDeleted {
+		t.Errorf("Expected remote capture file to be deleted, got %q", player.deleted)
+	}
+	if !bytes.Equal(out.Bytes(), player.contents) || result.Bytes != int64(len(player.contents)) {
+		t.Errorf("Expected %d bytes written to output, got %d", len(player.contents), out.Len())
+	}
+	if result.Interrupted {
+		t.Error("Expected capture not to be marked as interrupted")
+	}
+}
+
+func TestCapturePackets_FinishesEarly(t *testing.T) {
+	player := &fakeCapturePlayer{filePath: "sd/capture.pcap", contents: testPcapFile(), stopAfter: 2, stopErr: stderrors.New("no capture running")}
+
+	var out bytes.Buffer
+	result, err := capturePackets(context.Background(), player, "ABC123", &PacketCaptureRequest{
+		Duration:       time.Minute,
+		Output:         &out,
+		PollInterval:   10 * time.Millisecond,
+		KeepRemoteFile: true,
+	})
+	if err != nil {
+		t.Fatalf("Expected stop error to be ignored once capture finished, got %v", err)
+	}
+	if result.Elapsed >= time.Minute {
+		t.Errorf("Expected capture to finish before the duration, took %v", result.Elapsed)
+	}
+	if player.deleted != "" || result.RemoteDeleted {
+		t.Error("Expected remote capture file to be kept")
+	}
+}
+
+func TestCapturePackets_Cancelled(t *testing.T) {
+	player := &fakeCapturePlayer{filePath: "sd/capture.pcap", contents: testPcapFile()}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	var out bytes.Buffer
+	result, err := capturePackets(ctx, player, "ABC123", &PacketCaptureRequest{
+		Duration:     time.Minute,
+		Output:       &out,
+		PollInterval: 10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("capturePackets returned error: %v", err)
+	}
+	if !result.Interrupted {
+		t.Error("Expected capture to be marked as interrupted")
+	}
+	if !player.stopped {
+		t.Error("Expected capture to be stopped after cancellation")
+	}
+	if out.Len() == 0 {
+		t.Error("Expected partial capture to be retrieved after cancellation")
+	}
+}
+
+func TestCapturePackets_InvalidFile(t *testing.T) {
+	player := &fakeCapturePlayer{filePath: "sd/capture.pcap", contents: []byte("tcpdump: eth0: No such device")}
+
+	var out bytes.Buffer
+	_, err := capturePackets(context.Background(), player, "ABC123", &PacketCaptureRequest{
+		Duration:     time.Second,
+		Output:       &out,
+		PollInterval: time.Second,
+	})
+	if err == nil {
+		t.Fatal("Expected error for invalid capture file")
+	}
+	if out.Len() != 0 {
+		t.Error("Expected nothing to be written for invalid capture file")
+	}
+	if player.deleted != "" {
+		t.Error("Expected invalid capture file to be kept on the player")
+	}
+}
+
+func TestCapturePackets_Validation(t *testing.T) {
+	service := createTestRDWSService()
+	ctx := context.Background()
+	var out bytes.Buffer
+
+	if _, err := service.CapturePackets(ctx, "", &PacketCaptureRequest{Duration: time.Second, Output: &out}); err == nil {
+		t.Error("Expected error when capturing with empty serial")
+	}
+	if _, err := service.CapturePackets(ctx, "ABC123DEF456", nil); err == nil {
+		t.Error("Expected error when capturing with nil request")
+	}
+	if _, err := service.CapturePackets(ctx, "ABC123DEF456", &PacketCaptureRequest{Output: &out}); err == nil {
+		t.Error("Expected error when capturing without a duration")
+	}
+	if _, err := service.CapturePackets(ctx, "ABC123DEF456", &PacketCaptureRequest{Duration: time.Second}); err == nil {
+		t.Error("Expected error when capturing without an output writer")
+	}
+}
+
+func TestCheckPcapHeader(t *testing.T) {
+	swapped := testPcapFile()
+	binary.BigEndian.PutUint32(swapped[0:4], 0xa1b2c3d4)
+
+	valid := map[string][]byte{
+		"little endian": testPcapFile(),
+		"big endian":    swapped,
+		"pcapng":        {0x0a, 0x0d, 0x0d, 0x0a, 0x1c, 0x00, 0x00, 0x00},
+	}
+	for name, data := range valid {
+		if err := checkPcapHeader(data); err != nil {
+			t.Errorf("Expected %s header to be valid, got %v", name, err)
+		}
+	}
+
+	invalid := map[string][]byte{
+		"empty":     nil,
+		"truncated": testPcapFile()[:8],
+		"text":      []byte("<html>not found</html>"),
+	}
+	for name, data := range invalid {
+		if err := checkPcapHeader(data); err == nil {
+			t.Errorf("Expected %s header to be rejected", name)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+
+	"github.com/brightdevelopers/gopurple/internal/errors"
+	"github.com/brightdevelopers/gopurple/internal/http"
+)
+
+// rdwsEnvelope is the wrapper rDWS puts around every player response.
+type rdwsEnvelope struct {
+	Route  string `json:"route"`
+	Method string `json:"method"`
+	Data   struct {
+		Result json.RawMessage `json:"result"`
+	} `json:"data"`
+}
+
+// doRDWS sends an rDWS request for a player and decodes the response into
+// response, an rDWS response type with a Data.Result field. The rDWS proxy
+// answers with HTTP 200 and a plain string in data.result when the player is
+// offline or rejects the command; unless Data.Result is a string, that is
+// returned as a *errors.PlayerError instead of failing to decode.
+func doRDWS(ctx context.Context, httpClient *http.HTTPClient, token, method, requestURL, serial string, body, response interface{}) error {
+	var raw json.RawMessage
+	err := httpClient.DoWithAuth(ctx, token, &http.Request{
+		Method: method,
+		URL:    requestURL,
+		Body:   body,
+		Result: &raw,
+	})
+	if err != nil {
+		return err
+	}
+	return decodeRDWS(raw, serial, requestURL, response)
+}
+
+// decodeRDWS decodes an rDWS response body into response.
+func decodeRDWS(raw []byte, serial, requestURL string, response interface{}) error {
+	if len(raw) == 0 || response == nil {
+		return nil
+	}
+
+	var envelope rdwsEnvelope
+	if err := json.Unmarshal(raw, &envelope); err != nil {
+		return fmt.Errorf("invalid rDWS response: %w", err)
+	}
+	var message string
+	if json.Unmarshal(envelope.Data.Result, &message) == nil && !stringResult(response) {
+		route := envelope.Route
+		if route == "" {
+			if parsed, err := url.Parse(requestURL); err == nil {
+				route = parsed.Path
+			}
+		}
+		return errors.NewPlayerError(serial, route, message)
+	}
+
+	if err := json.Unmarshal(raw, response); err != nil {
+		return fmt.Errorf("invalid rDWS response: %w", err)
+	}
+	return nil
+}
+
+// stringResult reports whether a response type's Data.Result is a string, so a
+// string result is the answer rather than an error.
+func stringResult(response interface{}) bool {
+	value := reflect.Indirect(reflect.ValueOf(response))
+	if value.Kind() != reflect.Struct {
+		return false
+	}
+	data := value.FieldByName("Data")
+	if data.Kind() != reflect.Struct {
+		return false
+	}
+	result := data.FieldByName("Result")
+	return result.IsValid() && result.Kind() == reflect.String
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	stderrors "errors"
+	"testing"
+
+	"github.com/brightdevelopers/gopurple/internal/errors"
+	"github.com/brightdevelopers/gopurple/internal/types"
+)
+
+func TestDecodeRDWS(t *testing.T) {
+	const infoURL = "https://ws.bsn.cloud/rest/v1/info/?destinationType=player&destinationName=XD001"
+
+	var info types.RDWSInfoResponse
+	if err := decodeRDWS([]byte(`{"route":"/v1/info/","method":"GET","data":{"result":{"serial":"XD001","model":"XD1035"}}}`), "XD001", infoURL, &info); err != nil {
+		t.Fatalf("decodeRDWS failed: %v", err)
+	}
+	if info.Data.Result.Serial != "XD001" {
+		t.Errorf("Expected the result to be decoded, got %+v", info.Data.Result)
+	}
+
+	err := decodeRDWS([]byte(`{"route":"/v1/info/","data":{"result":"Player is offline"}}`), "XD001", infoURL, &types.RDWSInfoResponse{})
+	var playerErr *errors.PlayerError
+	if !stderrors.As(err, &playerErr) || !playerErr.Offline || playerErr.Route != "/v1/info/" || playerErr.Serial != "XD001" {
+		t.Fatalf("Expected an offline PlayerError, got %v", err)
+	}
+	if !stderrors.Is(err, errors.ErrPlayerOffline) {
+		t.Error("Expected an offline PlayerError to match ErrPlayerOffline")
+	}
+
+	// Without a route in the envelope the request path is used
+	err = decodeRDWS([]byte(`{"data":{"result":"invalid command"}}`), "XD001", infoURL, &types.RDWSLogsResponse{})
+	if !stderrors.As(err, &playerErr) || playerErr.Offline || playerErr.Route != "/rest/v1/info/" {
+		t.Fatalf("Expected a PlayerError for a rejected command, got %v", err)
+	}
+	if stderrors.Is(err, errors.ErrPlayerOffline) {
+		t.Error("Expected a rejected command not to match ErrPlayerOffline")
+	}
+
+	// A string is the answer when the result is meant to be one
+	var capture struct {
+		Data struct {
+			Result string `json:"result"`
+		} `json:"data"`
+	}
+	if err := decodeRDWS([]byte(`{"data":{"result":"capture.pcap"}}`), "XD001", infoURL, &capture); err != nil || capture.Data.Result != "capture.pcap" {
+		t.Errorf("Expected a string result to decode, got %q, %v", capture.Data.Result, err)
+	}
+
+	if err := decodeRDWS([]byte(`{"data":{"result":{"serial":42}}}`), "XD001", infoURL, &types.RDWSInfoResponse{}); err == nil || errors.IsPlayerError(err) {
+		t.Errorf("Expected a decode error for a malformed result, got %v", err)
+	}
+}
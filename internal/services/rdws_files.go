@@ -0,0 +1,467 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	stderrors "errors"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/brightdevelopers/gopurple/internal/errors"
+	"github.com/brightdevelopers/gopurple/internal/types"
+)
+
+// errNotDir is returned when a directory operation is attempted on a file.
+var errNotDir = stderrors.New("not a directory")
+
+// listFunc lists a single path on a player's storage.
+type listFunc func(ctx context.Context, path string) (*types.RDWSFileListResult, error)
+
+// WalkFiles walks the file tree rooted at root on the player, calling fn for each
+// file or directory in the tree, including root.
+//
+// It follows the fs.WalkDir contract: entries are visited in lexical order, fn may
+// return fs.SkipDir or fs.SkipAll, and a directory that cannot be listed is reported
+// through a second call to fn with the listing error. As with fs.WalkDir, a
+// directory is listed only after fn has accepted it, so skipping one with
+// fs.SkipDir saves its request; listings are therefore made one at a time.
+func (s *rdwsService) WalkFiles(ctx context.Context, serial string, root string, fn fs.WalkDirFunc) (err error) {
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "RDWS.WalkFiles", serial)
+	defer func() { traced(err) }()
+
+	if serial == "" {
+		return errors.NewValidationError("serial", serial, "device serial cannot be empty")
+	}
+	if root == "" {
+		return errors.NewValidationError("root", root, "root path cannot be empty")
+	}
+	if fn == nil {
+		return errors.NewValidationError("fn", nil, "walk function cannot be nil")
+	}
+
+	list := func(ctx context.Context, p string) (*types.RDWSFileListResult, error) {
+		response, err := s.ListFiles(ctx, serial, p)
+		if err != nil {
+			return nil, err
+		}
+		return &response.Data.Result, nil
+	}
+
+	return walkFiles(ctx, list, strings.Trim(root, "/"), fn)
+}
+
+// Glob returns the paths on the player that match pattern, using path.Match syntax.
+// The first path element must name a storage device without wildcards, for example
+// "sd/*.brs" or "sd/autoplugins/*/manifest.json".
+func (s *rdwsService) Glob(ctx context.Context, serial string, pattern string) (_ []string, err error) {
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "RDWS.Glob", serial)
+	defer func() { traced(err) }()
+
+	if serial == "" {
+		return nil, errors.NewValidationError("serial", serial, "device serial cannot be empty")
+	}
+
+	root, rest, _ := strings.Cut(strings.Trim(pattern, "/"), "/")
+	if root == "" || hasGlobMeta(root) {
+		return nil, errors.NewValidationError("pattern", pattern, "pattern must start with a storage device name such as 'sd'")
+	}
+	if rest == "" {
+		rest = "."
+	}
+
+	matches, err := globFS(NewPlayerFS(ctx, s, serial, root), rest)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, match := range matches {
+		matches[i] = path.Join(root, match)
+	}
+	return matches, nil
+}
+
+// walkFiles mirrors fs.WalkDir on top of list.
+func walkFiles(ctx context.Context, list listFunc, root string, fn fs.WalkDirFunc) error {
+	var err error
+	result, listErr := list(ctx, root)
+	if listErr != nil {
+		err = fn(root, nil, listErr)
+	} else {
+		self, entries, _ := listingEntries(root, result)
+		err = walkDir(ctx, list, root, self, entries, fn)
+	}
+
+	if err == fs.SkipDir || err == fs.SkipAll {
+		return nil
+	}
+	return err
+}
+
+// walkDir visits name and, if it is a directory fn accepts, everything below it.
+// entries is the listing of name if it has been made already, or nil. Directories
+// are listed only once fn has accepted them, so skipping one costs no request.
+func walkDir(ctx context.Context, list listFunc, name string, d fs.DirEntry, entries []fs.DirEntry, fn fs.WalkDirFunc) error {
+	if err := fn(name, d, nil); err != nil || !d.IsDir() {
+		if err == fs.SkipDir && d.IsDir() {
+			err = nil
+		}
+		return err
+	}
+
+	if entries == nil {
+		result, err := list(ctx, name)
+		if err == nil {
+			_, entries, err = listingEntries(name, result)
+		}
+		if err == errNotDir {
+			// An entry without a type that turned out to be a file
+			return nil
+		}
+		if err != nil {
+			if err := fn(name, d, err); err != nil {
+				if err == fs.SkipDir {
+					err = nil
+				}
+				return err
+			}
+		}
+	}
+
+	for _, entry := range entries {
+		if err := walkDir(ctx, list, path.Join(name, entry.Name()), entry, nil, fn); err != nil {
+			if err == fs.SkipDir {
+				break
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// listingEntries converts a ListFiles result for dir into a directory entry for dir
+// itself and its sorted children. It returns errNotDir when dir is a file.
+func listingEntries(dir string, result *types.RDWSFileListResult) (*rdwsFileInfo, []fs.DirEntry, error) {
+	files := result.Files
+	if len(files) == 0 {
+		files = result.Contents
+	}
+
+	self := &rdwsFileInfo{
+		name: path.Base(dir),
+		info: types.RDWSFileInfo{
+			Name: result.Name,
+			Type: result.Type,
+			Path: result.Path,
+			Stat: result.Stat,
+		},
+	}
+	if self.info.Type == "" && (len(files) > 0 || result.StorageInfo != nil) {
+		self.info.Type = "dir"
+	}
+	if !isDir(&self.info) {
+		return self, nil, errNotDir
+	}
+
+	entries := make([]fs.DirEntry, 0, len(files))
+	for _, file := range files {
+		entries = append(entries, &rdwsFileInfo{name: file.Name, info: file})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+
+	return self, entries, nil
+}
+
+// File type bits of the Unix mode in an rDWS file stat.
+const (
+	statTypeMask = 0170000
+	statTypeDir  = 0040000
+)
+
+// isDir reports whether an rDWS file entry is a directory. Listings do not always
+// set the type, so without one the stat mode, children and file-only fields are
+// used, and an entry with none of them is taken to be a directory: listing it is
+// the only way to find out.
+func isDir(info *types.RDWSFileInfo) bool {
+	switch {
+	case info.Type != "":
+		return info.Type == "dir"
+	case info.Stat != nil && info.Stat.Mode != 0:
+		return info.Stat.Mode&statTypeMask == statTypeDir
+	case len(info.Children) > 0:
+		return true
+	default:
+		return info.FileSize == 0 && info.Mime == "" && !info.Streamable
+	}
+}
+
+// rdwsFileInfo adapts an rDWS file entry to fs.FileInfo and fs.DirEntry.
+type rdwsFileInfo struct {
+	name string
+	info types.RDWSFileInfo
+}
+
+func (fi *rdwsFileInfo) Name() string { return fi.name }
+
+func (fi *rdwsFileInfo) Size() int64 {
+	if fi.info.Stat != nil {
+		return fi.info.Stat.Size
+	}
+	return fi.info.FileSize
+}
+
+func (fi *rdwsFileInfo) Mode() fs.FileMode {
+	perm := fs.FileMode(0644)
+	if fi.IsDir() {
+		perm = 0755
+	}
+	if fi.info.Stat != nil && fi.info.Stat.Mode != 0 {
+		perm = fs.FileMode(fi.info.Stat.Mode) & fs.ModePerm
+	}
+	if fi.IsDir() {
+		return fs.ModeDir | perm
+	}
+	return perm
+}
+
+func (fi *rdwsFileInfo) ModTime() time.Time {
+	if fi.info.Stat == nil {
+		return time.Time{}
+	}
+	if fi.info.Stat.MtimeMs != 0 {
+		return time.UnixMilli(fi.info.Stat.MtimeMs)
+	}
+	if t, err := time.Parse(time.RFC3339, fi.info.Stat.Mtime); err == nil {
+		return t
+	}
+	return time.Time{}
+}
+
+func (fi *rdwsFileInfo) IsDir() bool { return isDir(&fi.info) }
+
+// Sys returns the underlying *types.RDWSFileInfo.
+func (fi *rdwsFileInfo) Sys() interface{} { return &fi.info }
+
+func (fi *rdwsFileInfo) Type() fs.FileMode { return fi.Mode().Type() }
+
+func (fi *rdwsFileInfo) Info() (fs.FileInfo, error) { return fi, nil }
+
+// PlayerFS exposes a storage device on a player as a read-only io/fs.FS, so that
+// standard library helpers such as fs.Glob, fs.ReadFile, fs.WalkDir and
+// template.ParseFS can read from a remote player.
+//
+// Names are slash-separated and relative to the storage root, e.g. "autorun.brs"
+// for "sd/autorun.brs". Every operation issues rDWS requests with the context
+// supplied to NewPlayerFS.
+type PlayerFS struct {
+	ctx     context.Context
+	service RDWSService
+	serial  string
+	root    string
+}
+
+// NewPlayerFS returns a PlayerFS for the storage device root (e.g. "sd") on the
+// player with the given serial number.
+func NewPlayerFS(ctx context.Context, service RDWSService, serial string, root string) *PlayerFS {
+	return &PlayerFS{
+		ctx:     ctx,
+		service: service,
+		serial:  serial,
+		root:    strings.Trim(root, "/"),
+	}
+}
+
+// fullPath maps an fs.FS name to a path on the player.
+func (p *PlayerFS) fullPath(op, name string) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	return path.Join(p.root, name), nil
+}
+
+// list lists name and splits the result into the entry for name and its children.
+func (p *PlayerFS) list(op, name string) (*rdwsFileInfo, []fs.DirEntry, error) {
+	full, err := p.fullPath(op, name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	response, err := p.service.ListFiles(p.ctx, p.serial, full)
+	if err != nil {
+		return nil, nil, &fs.PathError{Op: op, Path: name, Err: err}
+	}
+
+	self, entries, err := listingEntries(full, &response.Data.Result)
+	if name == "." {
+		self.name = "."
+	}
+	return self, entries, err
+}
+
+// Open opens the named file or directory.
+func (p *PlayerFS) Open(name string) (fs.File, error) {
+	self, entries, err := p.list("open", name)
+	if err == errNotDir {
+		contents, err := p.readFile("open", name)
+		if err != nil {
+			return nil, err
+		}
+		return &playerFile{info: self, Reader: bytes.NewReader(contents)}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &playerDir{info: self, entries: entries}, nil
+}
+
+// Stat returns a FileInfo describing the named file.
+func (p *PlayerFS) Stat(name string) (fs.FileInfo, error) {
+	self, _, err := p.list("stat", name)
+	if err != nil && err != errNotDir {
+		return nil, err
+	}
+	return self, nil
+}
+
+// ReadDir reads the named directory and returns its entries sorted by name.
+func (p *PlayerFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	_, entries, err := p.list("readdir", name)
+	if err == errNotDir {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: err}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// ReadFile reads the named file and returns its contents.
+func (p *PlayerFS) ReadFile(name string) ([]byte, error) {
+	return p.readFile("readfile", name)
+}
+
+func (p *PlayerFS) readFile(op, name string) ([]byte, error) {
+	full, err := p.fullPath(op, name)
+	if err != nil {
+		return nil, err
+	}
+
+	contents, err := p.service.DownloadFile(p.ctx, p.serial, full)
+	if err != nil {
+		return nil, &fs.PathError{Op: op, Path: name, Err: err}
+	}
+	return contents, nil
+}
+
+// playerFile is an open file on a PlayerFS. Its contents are read eagerly on Open.
+type playerFile struct {
+	info *rdwsFileInfo
+	*bytes.Reader
+}
+
+func (f *playerFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+
+func (f *playerFile) Close() error { return nil }
+
+// playerDir is an open directory on a PlayerFS.
+type playerDir struct {
+	info    *rdwsFileInfo
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *playerDir) Stat() (fs.FileInfo, error) { return d.info, nil }
+
+func (d *playerDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: stderrors.New("is a directory")}
+}
+
+func (d *playerDir) Close() error { return nil }
+
+// ReadDir implements fs.ReadDirFile.
+func (d *playerDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	remaining := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return remaining, nil
+	}
+	if len(remaining) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(remaining) {
+		n = len(remaining)
+	}
+	d.offset += n
+	return remaining[:n], nil
+}
+
+// globFS is fs.Glob, except that listing errors other than "not a directory" are
+// returned instead of being ignored, so authentication and network failures surface.
+func globFS(fsys fs.FS, pattern string) ([]string, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+
+	if !hasGlobMeta(pattern) {
+		if _, err := fs.Stat(fsys, pattern); err != nil {
+			return nil, err
+		}
+		return []string{pattern}, nil
+	}
+
+	dir, file := path.Split(pattern)
+	dir = strings.TrimSuffix(dir, "/")
+	if dir == "" {
+		dir = "."
+	}
+
+	if !hasGlobMeta(dir) {
+		return globDir(fsys, dir, file, nil)
+	}
+
+	dirs, err := globFS(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []string
+	for _, d := range dirs {
+		if matches, err = globDir(fsys, d, file, matches); err != nil {
+			return nil, err
+		}
+	}
+	return matches, nil
+}
+
+// globDir appends the entries of dir that match pattern to matches.
+func globDir(fsys fs.FS, dir, pattern string, matches []string) ([]string, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if stderrors.Is(err, errNotDir) {
+		return matches, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		matched, err := path.Match(pattern, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		if matched {
+			matches = append(matches, path.Join(dir, entry.Name()))
+		}
+	}
+	return matches, nil
+}
+
+// hasGlobMeta reports whether p contains any of the magic characters recognized by path.Match.
+func hasGlobMeta(p string) bool {
+	return strings.ContainsAny(p, `*?[\`)
+}
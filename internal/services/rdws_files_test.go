@@ -0,0 +1,391 @@
+package services
+
+import (
+	"context"
+	stderrors "errors"
+	"io/fs"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/brightdevelopers/gopurple/internal/types"
+)
+
+// fakePlayerStorage serves ListFiles and DownloadFile from an in-memory tree.
+type fakePlayerStorage struct {
+	RDWSService
+	files map[string]string // path -> contents; directories are implied by their children
+	fail  map[string]error  // path -> error returned when listing it
+
+	untyped bool // leave the type of listed entries empty
+
+	mu     sync.Mutex
+	listed []string
+}
+
+func newFakePlayerStorage(files map[string]string) *fakePlayerStorage {
+	return &fakePlayerStorage{files: files, fail: map[string]error{}}
+}
+
+func (f *fakePlayerStorage) isDir(p string) bool {
+	for name := range f.files {
+		if strings.HasPrefix(name, p+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *fakePlayerStorage) list(_ context.Context, p string) (*types.RDWSFileListResult, error) {
+	f.mu.Lock()
+	f.listed = append(f.listed, p)
+	f.mu.Unlock()
+
+	if err, ok := f.fail[p]; ok {
+		return nil, err
+	}
+
+	if contents, ok := f.files[p]; ok {
+		return &types.RDWSFileListResult{
+			Name: path.Base(p),
+			Type: "file",
+			Path: p,
+			Stat: &types.RDWSFileStat{Size: int64(len(contents))},
+		}, nil
+	}
+
+	if !f.isDir(p) {
+		return nil, stderrors.New("no such file or directory")
+	}
+
+	seen := map[string]bool{}
+	result := &types.RDWSFileListResult{Name: path.Base(p), Type: "dir", Path: p}
+	for name, contents := range f.files {
+		rest, ok := strings.CutPrefix(name, p+"/")
+		if !ok {
+			continue
+		}
+		child, _, nested := strings.Cut(rest, "/")
+		if seen[child] {
+			continue
+		}
+		seen[child] = true
+		entry := types.RDWSFileInfo{Name: child, Type: "file", Path: path.Join(p, child), FileSize: int64(len(contents))}
+		if nested {
+			entry = types.RDWSFileInfo{Name: child, Type: "dir", Path: path.Join(p, child)}
+		}
+		if f.untyped {
+			entry.Type = ""
+		}
+		result.Files = append(result.Files, entry)
+	}
+	return result, nil
+}
+
+func (f *fakePlayerStorage) ListFiles(ctx context.Context, serial string, p string) (*types.RDWSFileListResponse, error) {
+	result, err := f.list(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	var response types.RDWSFileListResponse
+	response.Data.Result = *result
+	return &response, nil
+}
+
+func (f *fakePlayerStorage) DownloadFile(ctx context.Context, serial string, p string) ([]byte, error) {
+	contents, ok := f.files[p]
+	if !ok {
+		return nil, stderrors.New("no such file")
+	}
+	return []byte(contents), nil
+}
+
+var testPlayerFiles = map[string]string{
+	"sd/autorun.brs":                 "' autorun",
+	"sd/autoplugins/a/manifest.json": `{"name":"a"}`,
+	"sd/autoplugins/b/manifest.json": `{"name":"b"}`,
+	"sd/autoplugins/b/plugin.brs":    "' plugin",
+	"sd/content/index.html":          "<html></html>",
+	"sd/content/video.mp4":           "video",
+}
+
+func TestWalkFiles_LexicalOrder(t *testing.T) {
+	storage := newFakePlayerStorage(testPlayerFiles)
+
+	var visited []string
+	err := walkFiles(context.Background(), storage.list, "sd", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		visited = append(visited, p)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walkFiles returned error: %v", err)
+	}
+
+	expected := []string{
+		"sd",
+		"sd/autoplugins",
+		"sd/autoplugins/a",
+		"sd/autoplugins/a/manifest.json",
+		"sd/autoplugins/b",
+		"sd/autoplugins/b/manifest.json",
+		"sd/autoplugins/b/plugin.brs",
+		"sd/autorun.brs",
+		"sd/content",
+		"sd/content/index.html",
+		"sd/content/video.mp4",
+	}
+	if !reflect.DeepEqual(visited, expected) {
+		t.Errorf("Expected visit order %v, got %v", expected, visited)
+	}
+}
+
+func TestWalkFiles_MatchesWalkDir(t *testing.T) {
+	storage := newFakePlayerStorage(testPlayerFiles)
+
+	collect := func(walk func(fs.WalkDirFunc) error) []string {
+		var visited []string
+		err := walk(func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			visited = append(visited, p+":"+d.Type().String())
+			if d.Name() == "autoplugins" {
+				return fs.SkipDir
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("walk returned error: %v", err)
+		}
+		return visited
+	}
+
+	got := collect(func(fn fs.WalkDirFunc) error {
+		return walkFiles(context.Background(), storage.list, "sd", fn)
+	})
+	want := collect(func(fn fs.WalkDirFunc) error {
+		return fs.WalkDir(NewPlayerFS(context.Background(), storage, "ABC123", ""), "sd", fn)
+	})
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("walkFiles and fs.WalkDir disagree:\n got  %v\n want %v", got, want)
+	}
+}
+
+func TestWalkFiles_SkipDirNotListed(t *testing.T) {
+	storage := newFakePlayerStorage(testPlayerFiles)
+
+	err := walkFiles(context.Background(), storage.list, "sd", func(p string, d fs.DirEntry, err error) error {
+		if p != "sd" && d.IsDir() {
+			return fs.SkipDir
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatalf("walkFiles returned error: %v", err)
+	}
+	if !reflect.DeepEqual(storage.listed, []string{"sd"}) {
+		t.Errorf("Expected only the root to be listed, got %v", storage.listed)
+	}
+}
+
+func TestWalkFiles_UntypedEntries(t *testing.T) {
+	files := map[string]string{"sd/empty.txt": ""}
+	for name, contents := range testPlayerFiles {
+		files[name] = contents
+	}
+	storage := newFakePlayerStorage(files)
+	storage.untyped = true
+
+	var visited []string
+	err := walkFiles(context.Background(), storage.list, "sd", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			visited = append(visited, p+"/")
+		} else {
+			visited = append(visited, p)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walkFiles returned error: %v", err)
+	}
+
+	// The empty file has nothing that marks it as a file until it is listed
+	expected := []string{
+		"sd/",
+		"sd/autoplugins/",
+		"sd/autoplugins/a/",
+		"sd/autoplugins/a/manifest.json",
+		"sd/autoplugins/b/",
+		"sd/autoplugins/b/manifest.json",
+		"sd/autoplugins/b/plugin.brs",
+		"sd/autorun.brs",
+		"sd/content/",
+		"sd/content/index.html",
+		"sd/content/video.mp4",
+		"sd/empty.txt/",
+	}
+	if !reflect.DeepEqual(visited, expected) {
+		t.Errorf("Expected visits %v, got %v", expected, visited)
+	}
+}
+
+func TestWalkFiles_SkipAll(t *testing.T) {
+	storage := newFakePlayerStorage(testPlayerFiles)
+
+	count := 0
+	err := walkFiles(context.Background(), storage.list, "sd", func(p string, d fs.DirEntry, err error) error {
+		count++
+		if p == "sd/autoplugins/a" {
+			return fs.SkipAll
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected SkipAll to stop the walk without error, got %v", err)
+	}
+	if count != 3 {
+		t.Errorf("Expected 3 visits before SkipAll, got %d", count)
+	}
+}
+
+func TestWalkFiles_ListingError(t *testing.T) {
+	storage := newFakePlayerStorage(testPlayerFiles)
+	listErr := stderrors.New("player unreachable")
+	storage.fail["sd/content"] = listErr
+
+	var reported []string
+	err := walkFiles(context.Background(), storage.list, "sd", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if !stderrors.Is(err, listErr) {
+				t.Errorf("Unexpected error for %s: %v", p, err)
+			}
+			reported = append(reported, p)
+			return nil
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walkFiles returned error: %v", err)
+	}
+	if !reflect.DeepEqual(reported, []string{"sd/content"}) {
+		t.Errorf("Expected listing error to be reported once for sd/content, got %v", reported)
+	}
+
+	// Returning the error aborts the walk
+	err = walkFiles(context.Background(), storage.list, "sd", func(p string, d fs.DirEntry, err error) error {
+		return err
+	})
+	if !stderrors.Is(err, listErr) {
+		t.Errorf("Expected walk to return listing error, got %v", err)
+	}
+}
+
+func TestWalkFiles_RootError(t *testing.T) {
+	storage := newFakePlayerStorage(testPlayerFiles)
+
+	called := false
+	err := walkFiles(context.Background(), storage.list, "usb1", func(p string, d fs.DirEntry, err error) error {
+		called = true
+		if d != nil {
+			t.Errorf("Expected nil DirEntry for unreadable root, got %v", d)
+		}
+		return err
+	})
+	if !called || err == nil {
+		t.Error("Expected root listing error to be passed to fn and returned")
+	}
+}
+
+func TestWalkFiles_Validation(t *testing.T) {
+	service := createTestRDWSService()
+	ctx := context.Background()
+	noop := func(string, fs.DirEntry, error) error { return nil }
+
+	if err := service.WalkFiles(ctx, "", "sd", noop); err == nil {
+		t.Error("Expected error when walking with empty serial")
+	}
+	if err := service.WalkFiles(ctx, "ABC123DEF456", "", noop); err == nil {
+		t.Error("Expected error when walking with empty root")
+	}
+	if err := service.WalkFiles(ctx, "ABC123DEF456", "sd", nil); err == nil {
+		t.Error("Expected error when walking with nil function")
+	}
+}
+
+func TestGlob_Validation(t *testing.T) {
+	service := createTestRDWSService()
+	ctx := context.Background()
+
+	if _, err := service.Glob(ctx, "", "sd/*"); err == nil {
+		t.Error("Expected error when globbing with empty serial")
+	}
+	if _, err := service.Glob(ctx, "ABC123DEF456", "*/autorun.brs"); err == nil {
+		t.Error("Expected error when pattern does not start with a storage device")
+	}
+}
+
+func TestPlayerFS(t *testing.T) {
+	storage := newFakePlayerStorage(testPlayerFiles)
+	fsys := NewPlayerFS(context.Background(), storage, "ABC123", "sd")
+
+	if err := fstest.TestFS(fsys,
+		"autorun.brs",
+		"autoplugins/a/manifest.json",
+		"autoplugins/b/plugin.brs",
+		"content/index.html",
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := fs.ReadFile(fsys, "autoplugins/b/manifest.json")
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if string(data) != `{"name":"b"}` {
+		t.Errorf("Unexpected file contents: %q", data)
+	}
+
+	matches, err := fs.Glob(fsys, "autoplugins/*/manifest.json")
+	if err != nil {
+		t.Fatalf("Glob failed: %v", err)
+	}
+	expected := []string{"autoplugins/a/manifest.json", "autoplugins/b/manifest.json"}
+	if !reflect.DeepEqual(matches, expected) {
+		t.Errorf("Expected matches %v, got %v", expected, matches)
+	}
+
+	if _, err := fsys.Open("../etc/passwd"); !stderrors.Is(err, fs.ErrInvalid) {
+		t.Errorf("Expected ErrInvalid for invalid path, got %v", err)
+	}
+}
+
+func TestGlobFS(t *testing.T) {
+	storage := newFakePlayerStorage(testPlayerFiles)
+	fsys := NewPlayerFS(context.Background(), storage, "ABC123", "sd")
+
+	matches, err := globFS(fsys, "*/*/*.brs")
+	if err != nil {
+		t.Fatalf("globFS failed: %v", err)
+	}
+	sort.Strings(matches)
+	if !reflect.DeepEqual(matches, []string{"autoplugins/b/plugin.brs"}) {
+		t.Errorf("Unexpected matches: %v", matches)
+	}
+
+	// Unlike fs.Glob, listing failures are reported
+	storage.fail["sd/content"] = stderrors.New("player unreachable")
+	if _, err := globFS(fsys, "content/*.html"); err == nil {
+		t.Error("Expected listing error to be returned from globFS")
+	}
+}
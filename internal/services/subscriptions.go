@@ -37,7 +37,10 @@ func NewSubscriptionService(cfg *config.Config, httpClient *http.HTTPClient, aut
 }
 
 // List retrieves a list of device subscriptions with optional filtering and pagination.
-func (s *subscriptionService) List(ctx context.Context, opts ...ListOption) (*types.SubscriptionList, error) {
+func (s *subscriptionService) List(ctx context.Context, opts ...ListOption) (_ *types.SubscriptionList, err error) {
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "Subscriptions.List", "")
+	defer func() { traced(err) }()
+
 	// Ensure we have authentication and network context
 	if err := s.authManager.EnsureValid(ctx); err != nil {
 		return nil, err
@@ -82,14 +85,17 @@ func (s *subscriptionService) List(ctx context.Context, opts ...ListOption) (*ty
 	// Make the request
 	var result types.SubscriptionList
 	if err := s.httpClient.GetWithAuth(ctx, token, baseURL, &result); err != nil {
-		return nil, errors.NewAPIError(0, "subscription_list_failed", "Failed to list subscriptions", err.Error())
+		return nil, errors.WrapError("Subscriptions.List", "subscription_list_failed", "Failed to list subscriptions", err)
 	}
 
 	return &result, nil
 }
 
 // GetCount retrieves the number of subscription instances on the network.
-func (s *subscriptionService) GetCount(ctx context.Context) (*types.SubscriptionCount, error) {
+func (s *subscriptionService) GetCount(ctx context.Context) (_ *types.SubscriptionCount, err error) {
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "Subscriptions.GetCount", "")
+	defer func() { traced(err) }()
+
 	// Ensure we have authentication and network context
 	if err := s.authManager.EnsureValid(ctx); err != nil {
 		return nil, err
@@ -111,14 +117,17 @@ func (s *subscriptionService) GetCount(ctx context.Context) (*types.Subscription
 	// Make the request
 	var result types.SubscriptionCount
 	if err := s.httpClient.GetWithAuth(ctx, token, url, &result); err != nil {
-		return nil, errors.NewAPIError(0, "subscription_count_failed", "Failed to get subscription count", err.Error())
+		return nil, errors.WrapError("Subscriptions.GetCount", "subscription_count_failed", "Failed to get subscription count", err)
 	}
 
 	return &result, nil
 }
 
 // GetOperations returns operational permissions granted to roles for subscriptions.
-func (s *subscriptionService) GetOperations(ctx context.Context) (*types.SubscriptionOperations, error) {
+func (s *subscriptionService) GetOperations(ctx context.Context) (_ *types.SubscriptionOperations, err error) {
+	ctx, traced := startOperation(ctx, s.httpClient, s.authManager, "Subscriptions.GetOperations", "")
+	defer func() { traced(err) }()
+
 	// Ensure we have authentication and network context
 	if err := s.authManager.EnsureValid(ctx); err != nil {
 		return nil, err
@@ -140,7 +149,7 @@ func (s *subscriptionService) GetOperations(ctx context.Context) (*types.Subscri
 	// Make the request
 	var result types.SubscriptionOperations
 	if err := s.httpClient.GetWithAuth(ctx, token, url, &result); err != nil {
-		return nil, errors.NewAPIError(0, "subscription_operations_failed", "Failed to get subscription operations", err.Error())
+		return nil, errors.WrapError("Subscriptions.GetOperations", "subscription_operations_failed", "Failed to get subscription operations", err)
 	}
 
 	return &result, nil
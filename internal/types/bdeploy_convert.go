@@ -0,0 +1,209 @@
+package types
+
+import (
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/brightdevelopers/gopurple/internal/errors"
+)
+
+// Setup record layout versions. Version 2 records describe networking only with
+// flat fields (useDHCP, timeServer, contentDataTypeEnabledWired, ...); version 3
+// records add the nested network object, which the v3 setup endpoint uses to
+// configure the player's interfaces. The flat fields are still accepted by v3.
+const (
+	SetupVersion2 = "2.0.0"
+	SetupVersion3 = "3.0.0"
+)
+
+// DefaultTimeServer is the time server BrightSign players use when a setup does not name one.
+const DefaultTimeServer = "http://time.brightsignnetwork.com"
+
+// Interface IDs, names and types used in the v3 network object.
+const (
+	wiredInterfaceID      = "wired_eth0"
+	wiredInterfaceName    = "eth0"
+	wiredInterfaceType    = "Ethernet"
+	wirelessInterfaceID   = "wireless_wlan0"
+	wirelessInterfaceName = "wlan0"
+	wirelessInterfaceType = "WiFi"
+)
+
+// ToV3 returns a copy of a v2 setup record in the v3 layout. The network object is
+// built from the flat fields: the wired interface (and the wireless one if
+// useWireless is set) gets DHCPv4 or Static from useDHCP and the static address
+// fields, content download and health reporting from the interface's data type
+// fields (enabled unless explicitly false), timeServers from timeServer, and the
+// proxy from useProxy, proxyAddress and proxyPort with networkHosts as the bypass
+// list. The flat fields are kept, since v3 still reads them.
+//
+// The copy has no _id, because v2 and v3 records are stored separately. A record
+// that already has a network object keeps it. Records that are already v3 are
+// copied unchanged apart from the ID.
+func (r *BDeploySetupRecord) ToV3() (*BDeploySetupRecord, error) {
+	upgraded, err := r.convertCopy()
+	if err != nil {
+		return nil, err
+	}
+	if r.Version == SetupVersion3 {
+		return upgraded, nil
+	}
+
+	upgraded.Version = SetupVersion3
+	if upgraded.Network == nil {
+		upgraded.Network = upgraded.v2Network()
+	}
+	return upgraded, nil
+}
+
+// ToV2 returns a copy of a v3 setup record in the v2 layout. Settings in the
+// network object are folded back into the flat fields where those are unset, and
+// the network object is removed. Interface settings the flat fields cannot express
+// (additional interfaces, IPv6, extra time servers) are lost. Records that are
+// already v2 are copied unchanged apart from the ID.
+func (r *BDeploySetupRecord) ToV2() (*BDeploySetupRecord, error) {
+	downgraded, err := r.convertCopy()
+	if err != nil {
+		return nil, err
+	}
+	if r.Version == SetupVersion2 {
+		return downgraded, nil
+	}
+
+	downgraded.Version = SetupVersion2
+	if network := downgraded.Network; network != nil {
+		downgraded.foldNetwork(network)
+		downgraded.Network = nil
+	}
+	return downgraded, nil
+}
+
+// convertCopy checks the record's version and returns a deep copy without its ID.
+func (r *BDeploySetupRecord) convertCopy() (*BDeploySetupRecord, error) {
+	if r.Version != "" && r.Version != SetupVersion2 && r.Version != SetupVersion3 {
+		return nil, errors.NewValidationError("version", r.Version, "unsupported setup record version")
+	}
+
+	// A JSON round trip copies slices and pointers and keeps ForceSendFields
+	data, err := MarshalSetupRecord(r)
+	if err != nil {
+		return nil, err
+	}
+	var copied BDeploySetupRecord
+	if err := UnmarshalSetupRecord(data, &copied); err != nil {
+		return nil, err
+	}
+	copied.ID = ""
+	return &copied, nil
+}
+
+// v2Network builds the v3 network object from the record's flat fields.
+func (r *BDeploySetupRecord) v2Network() *NetworkConfig {
+	network := &NetworkConfig{TimeServers: []string{DefaultTimeServer}}
+	if r.TimeServer != "" {
+		network.TimeServers = []string{r.TimeServer}
+	}
+
+	network.Interfaces = append(network.Interfaces, NetworkInterface{
+		ID:                     wiredInterfaceID,
+		Name:                   wiredInterfaceName,
+		Type:                   wiredInterfaceType,
+		Proto:                  r.interfaceProto("", r.UseDHCP, r.StaticIPAddress),
+		ContentDownloadEnabled: r.enabledUnlessFalse("contentDataTypeEnabledWired", r.ContentDataTypeEnabledWired),
+		HealthReportingEnabled: r.enabledUnlessFalse("healthDataTypeEnabledWired", r.HealthDataTypeEnabledWired),
+	})
+	if r.UseWireless {
+		network.Interfaces = append(network.Interfaces, NetworkInterface{
+			ID:                     wirelessInterfaceID,
+			Name:                   wirelessInterfaceName,
+			Type:                   wirelessInterfaceType,
+			Proto:                  r.interfaceProto("_2", r.UseDHCP2, r.StaticIPAddress2),
+			ContentDownloadEnabled: r.enabledUnlessFalse("contentDataTypeEnabledWireless", r.ContentDataTypeEnabledWireless),
+			HealthReportingEnabled: r.enabledUnlessFalse("healthDataTypeEnabledWireless", r.HealthDataTypeEnabledWireless),
+		})
+	}
+
+	if r.UseProxy && r.ProxyAddress != "" {
+		network.Proxy = r.ProxyAddress
+		if r.ProxyPort > 0 {
+			network.Proxy = net.JoinHostPort(r.ProxyAddress, strconv.Itoa(r.ProxyPort))
+		}
+		network.ProxyBypass = strings.Join(r.NetworkHosts, ",")
+	}
+	return network
+}
+
+// interfaceProto follows the same rule as Lint: an interface uses DHCP unless
+// useDHCP is explicitly false or a static address is given without it.
+func (r *BDeploySetupRecord) interfaceProto(suffix string, useDHCP bool, address string) string {
+	if useDHCP || (address == "" && !r.IsForceSent("useDHCP"+suffix)) {
+		return "DHCPv4"
+	}
+	return "Static"
+}
+
+// enabledUnlessFalse returns value if the field is set and true otherwise, since
+// players enable data types that a setup does not mention.
+func (r *BDeploySetupRecord) enabledUnlessFalse(field string, value bool) bool {
+	if r.IsSet(field) {
+		return value
+	}
+	return true
+}
+
+// foldNetwork copies settings from a v3 network object into unset flat fields.
+func (r *BDeploySetupRecord) foldNetwork(network *NetworkConfig) {
+	if r.TimeServer == "" && len(network.TimeServers) > 0 {
+		r.TimeServer = network.TimeServers[0]
+	}
+
+	for _, iface := range network.Interfaces {
+		switch {
+		case iface.Type == wiredInterfaceType || iface.Name == wiredInterfaceName:
+			r.foldBool("useDHCP", &r.UseDHCP, iface.Proto != "Static")
+			r.foldBool("contentDataTypeEnabledWired", &r.ContentDataTypeEnabledWired, iface.ContentDownloadEnabled)
+			r.foldBool("healthDataTypeEnabledWired", &r.HealthDataTypeEnabledWired, iface.HealthReportingEnabled)
+		case iface.Type == wirelessInterfaceType || iface.Name == wirelessInterfaceName:
+			r.UseWireless = true
+			r.foldBool("useDHCP_2", &r.UseDHCP2, iface.Proto != "Static")
+			r.foldBool("contentDataTypeEnabledWireless", &r.ContentDataTypeEnabledWireless, iface.ContentDownloadEnabled)
+			r.foldBool("healthDataTypeEnabledWireless", &r.HealthDataTypeEnabledWireless, iface.HealthReportingEnabled)
+		}
+	}
+
+	if network.Proxy != "" && r.ProxyAddress == "" {
+		r.UseProxy = true
+		r.ProxyAddress = network.Proxy
+		if host, port, err := net.SplitHostPort(network.Proxy); err == nil {
+			if n, err := strconv.Atoi(port); err == nil {
+				r.ProxyAddress, r.ProxyPort = host, n
+			}
+		}
+		if network.ProxyBypass != "" && len(r.NetworkHosts) == 0 {
+			r.NetworkHosts = splitList(network.ProxyBypass)
+		}
+	}
+}
+
+// foldBool sets an unset flat field, sending false explicitly.
+func (r *BDeploySetupRecord) foldBool(field string, target *bool, value bool) {
+	if r.IsSet(field) {
+		return
+	}
+	*target = value
+	if !value {
+		r.ForceSend(field)
+	}
+}
+
+// splitList splits a comma or semicolon separated list, dropping empty entries.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.FieldsFunc(s, func(c rune) bool { return c == ',' || c == ';' }) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
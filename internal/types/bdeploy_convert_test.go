@@ -0,0 +1,113 @@
+package types
+
+import (
+	"reflect"
+	"testing"
+)
+
+func v2SetupRecord(t *testing.T) *BDeploySetupRecord {
+	t.Helper()
+	input := `{
+		"version": "2.0.0",
+		"_id": "v2-setup",
+		"bDeploy": {"username": "u", "networkName": "n", "packageName": "store-0001"},
+		"setupType": "bsn",
+		"timeServer": "http://ntp.example.com",
+		"useDHCP": false,
+		"staticIPAddress": "10.0.0.20",
+		"subnetMask": "255.255.255.0",
+		"contentDataTypeEnabledWired": true,
+		"healthDataTypeEnabledWired": false,
+		"useWireless": true,
+		"ssid": "Store",
+		"passphrase": "correct-horse",
+		"useProxy": true,
+		"proxyAddress": "proxy.example.com",
+		"proxyPort": 3128,
+		"networkHosts": ["cdn.example.com", "api.example.com"]
+	}`
+	var record BDeploySetupRecord
+	if err := UnmarshalSetupRecord([]byte(input), &record); err != nil {
+		t.Fatalf("UnmarshalSetupRecord failed: %v", err)
+	}
+	return &record
+}
+
+func TestToV3(t *testing.T) {
+	record := v2SetupRecord(t)
+	upgraded, err := record.ToV3()
+	if err != nil {
+		t.Fatalf("ToV3 failed: %v", err)
+	}
+
+	if upgraded.Version != SetupVersion3 || upgraded.ID != "" {
+		t.Errorf("Expected a v3 record without an ID, got version %q and ID %q", upgraded.Version, upgraded.ID)
+	}
+	if record.Version != SetupVersion2 || record.Network != nil || record.ID != "v2-setup" {
+		t.Error("Expected the original record to be unchanged")
+	}
+
+	expected := &NetworkConfig{
+		TimeServers: []string{"http://ntp.example.com"},
+		Interfaces: []NetworkInterface{
+			{ID: "wired_eth0", Name: "eth0", Type: "Ethernet", Proto: "Static", ContentDownloadEnabled: true, HealthReportingEnabled: false},
+			{ID: "wireless_wlan0", Name: "wlan0", Type: "WiFi", Proto: "DHCPv4", ContentDownloadEnabled: true, HealthReportingEnabled: true},
+		},
+		Proxy:       "proxy.example.com:3128",
+		ProxyBypass: "cdn.example.com,api.example.com",
+	}
+	if !reflect.DeepEqual(upgraded.Network, expected) {
+		t.Errorf("Unexpected network:\n got  %+v\n want %+v", upgraded.Network, expected)
+	}
+	if upgraded.StaticIPAddress != "10.0.0.20" || !upgraded.IsForceSent("useDHCP") {
+		t.Error("Expected flat fields to be kept")
+	}
+	if err := upgraded.Validate(); err != nil {
+		t.Errorf("Expected the upgraded record to be valid, got %v", err)
+	}
+
+	// A record without networking settings gets a DHCP wired interface
+	minimal := &BDeploySetupRecord{Version: SetupVersion2, SetupType: "bsn"}
+	upgraded, _ = minimal.ToV3()
+	if len(upgraded.Network.Interfaces) != 1 || upgraded.Network.Interfaces[0].Proto != "DHCPv4" ||
+		upgraded.Network.TimeServers[0] != DefaultTimeServer {
+		t.Errorf("Unexpected network for a minimal record: %+v", upgraded.Network)
+	}
+
+	if _, err := (&BDeploySetupRecord{Version: "1.0.0"}).ToV3(); err == nil {
+		t.Error("Expected an error for an unsupported version")
+	}
+}
+
+func TestToV2_RoundTrip(t *testing.T) {
+	upgraded, err := v2SetupRecord(t).ToV3()
+	if err != nil {
+		t.Fatalf("ToV3 failed: %v", err)
+	}
+
+	// Clear the flat fields so they must come from the network object
+	upgraded.TimeServer = ""
+	upgraded.UseProxy, upgraded.ProxyAddress, upgraded.ProxyPort, upgraded.NetworkHosts = false, "", 0, nil
+	upgraded.ForceSendFields = nil
+
+	downgraded, err := upgraded.ToV2()
+	if err != nil {
+		t.Fatalf("ToV2 failed: %v", err)
+	}
+	if downgraded.Version != SetupVersion2 || downgraded.Network != nil {
+		t.Errorf("Expected a v2 record without a network object, got %+v", downgraded)
+	}
+	if downgraded.TimeServer != "http://ntp.example.com" {
+		t.Errorf("Expected the time server to be folded back, got %q", downgraded.TimeServer)
+	}
+	if downgraded.UseDHCP || !downgraded.IsForceSent("useDHCP") || !downgraded.UseDHCP2 {
+		t.Error("Expected useDHCP to be explicitly false and useDHCP_2 true")
+	}
+	if downgraded.HealthDataTypeEnabledWired || !downgraded.IsForceSent("healthDataTypeEnabledWired") {
+		t.Error("Expected healthDataTypeEnabledWired to be explicitly false")
+	}
+	if !downgraded.UseProxy || downgraded.ProxyAddress != "proxy.example.com" || downgraded.ProxyPort != 3128 ||
+		!reflect.DeepEqual(downgraded.NetworkHosts, []string{"cdn.example.com", "api.example.com"}) {
+		t.Errorf("Expected the proxy to be folded back, got %q:%d %v", downgraded.ProxyAddress, downgraded.ProxyPort, downgraded.NetworkHosts)
+	}
+}
@@ -0,0 +1,295 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/brightdevelopers/gopurple/internal/errors"
+)
+
+// Most setup record fields are tagged omitempty, so false, zero and "" are
+// normally left out of the JSON and the server keeps whatever value it had.
+// ForceSendFields lists fields that are sent even when they hold their zero
+// value, which is how a record says "explicitly false" rather than "unset".
+// Records decoded with UnmarshalSetupRecord (including those returned by
+// GetSetupRecord) have every zero-valued field that was present in the JSON
+// added to the list, so a fetched record can be modified and sent back without
+// losing explicit values.
+//
+// BDeploySetupRecord itself has no MarshalJSON or UnmarshalJSON, so a struct
+// that embeds it keeps its own fields when encoded; plain encoding/json ignores
+// ForceSendFields.
+
+// jsonField describes one struct field as seen by encoding/json.
+type jsonField struct {
+	index     int
+	name      string
+	omitEmpty bool
+}
+
+var setupRecordFields = jsonFields(reflect.TypeOf(BDeploySetupRecord{}))
+
+func jsonFields(t reflect.Type) []jsonField {
+	var fields []jsonField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if !field.IsExported() || tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = field.Name
+		}
+		fields = append(fields, jsonField{
+			index:     i,
+			name:      name,
+			omitEmpty: strings.Contains(","+options+",", ",omitempty,"),
+		})
+	}
+	return fields
+}
+
+// isEmptyValue reports whether encoding/json's omitempty would drop v.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Interface, reflect.Pointer:
+		return v.IsZero()
+	}
+	return false
+}
+
+// IsSetupRecordField reports whether name is the JSON name of a top-level setup record field.
+func IsSetupRecordField(name string) bool {
+	for _, field := range setupRecordFields {
+		if field.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// secretSetupFields are the dotted JSON paths of setup record fields that hold
+// passwords or tokens.
+var secretSetupFields = map[string]bool{
+	"bsnDeviceRegistrationTokenEntity.token": true,
+	"dwsPassword":                            true,
+	"lwsPassword":                            true,
+	"passphrase":                             true,
+	"sfnPassword":                            true,
+	"usbUpdatePassword":                      true,
+}
+
+// IsSecretSetupField reports whether the field, by dotted JSON path (e.g.
+// "lwsPassword" or "bsnDeviceRegistrationTokenEntity.token"), holds a password or
+// token that should not be displayed.
+func IsSecretSetupField(path string) bool {
+	return secretSetupFields[path]
+}
+
+// ForceSend marks fields, by JSON name (e.g. "dwsEnabled"), to be sent even when
+// they hold their zero value.
+func (r *BDeploySetupRecord) ForceSend(fields ...string) {
+	for _, field := range fields {
+		if !r.IsForceSent(field) {
+			r.ForceSendFields = append(r.ForceSendFields, field)
+		}
+	}
+}
+
+// IsForceSent reports whether the field is sent even when it holds its zero value.
+func (r *BDeploySetupRecord) IsForceSent(field string) bool {
+	for _, f := range r.ForceSendFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// IsSet reports whether the field, by JSON name, will be sent: it either holds a
+// non-zero value or is listed in ForceSendFields.
+func (r *BDeploySetupRecord) IsSet(field string) bool {
+	v := reflect.ValueOf(r).Elem()
+	for _, f := range setupRecordFields {
+		if f.name == field {
+			return !f.omitEmpty || !isEmptyValue(v.Field(f.index)) || r.IsForceSent(field)
+		}
+	}
+	return false
+}
+
+// MarshalSetupRecord encodes the record, including zero-valued fields listed in
+// ForceSendFields.
+func MarshalSetupRecord(r *BDeploySetupRecord) ([]byte, error) {
+	if len(r.ForceSendFields) == 0 {
+		return json.Marshal(r)
+	}
+
+	// Encode field by field so forced fields keep their place in the output
+	v := reflect.ValueOf(r).Elem()
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	first := true
+	for _, field := range setupRecordFields {
+		value := v.Field(field.index)
+		if field.omitEmpty && isEmptyValue(value) && !r.IsForceSent(field.name) {
+			continue
+		}
+
+		name, err := json.Marshal(field.name)
+		if err != nil {
+			return nil, err
+		}
+		encoded, err := json.Marshal(value.Interface())
+		if err != nil {
+			return nil, err
+		}
+
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(encoded)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// UnmarshalSetupRecord decodes the record and records every zero-valued field
+// present in the JSON in ForceSendFields.
+func UnmarshalSetupRecord(data []byte, r *BDeploySetupRecord) error {
+	var decoded BDeploySetupRecord
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	var present map[string]json.RawMessage
+	if err := json.Unmarshal(data, &present); err != nil {
+		return err
+	}
+
+	*r = decoded
+	r.ForceSendFields = nil
+
+	v := reflect.ValueOf(r).Elem()
+	for _, field := range setupRecordFields {
+		raw, ok := present[field.name]
+		if !ok || !field.omitEmpty || string(raw) == "null" {
+			continue
+		}
+		if isEmptyValue(v.Field(field.index)) {
+			r.ForceSendFields = append(r.ForceSendFields, field.name)
+		}
+	}
+	return nil
+}
+
+// SetupRecordJSON encodes and decodes a setup record with MarshalSetupRecord and
+// UnmarshalSetupRecord, for a record held in a struct that is encoded with
+// encoding/json. A nil Record encodes as null.
+type SetupRecordJSON struct {
+	Record *BDeploySetupRecord
+}
+
+// MarshalJSON implements json.Marshaler.
+func (j SetupRecordJSON) MarshalJSON() ([]byte, error) {
+	if j.Record == nil {
+		return []byte("null"), nil
+	}
+	return MarshalSetupRecord(j.Record)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *SetupRecordJSON) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		j.Record = nil
+		return nil
+	}
+	j.Record = &BDeploySetupRecord{}
+	return UnmarshalSetupRecord(data, j.Record)
+}
+
+// ApplyPatch applies changes to the record as a JSON merge patch (RFC 7386): keys
+// are JSON field names, nested maps such as {"bDeploy": {"packageName": ...}} are
+// merged into the existing object, other values (including typed structs) replace
+// the field, and a nil value resets a field to unset. Zero values such
+// as false and 0 are kept in ForceSendFields so they are sent explicitly.
+func (r *BDeploySetupRecord) ApplyPatch(changes map[string]interface{}) error {
+	for name := range changes {
+		if !IsSetupRecordField(name) {
+			return errors.NewValidationError(name, changes[name], "unknown setup record field")
+		}
+	}
+
+	data, err := MarshalSetupRecord(r)
+	if err != nil {
+		return err
+	}
+	var current map[string]interface{}
+	if err := json.Unmarshal(data, &current); err != nil {
+		return err
+	}
+
+	patched, err := json.Marshal(MergePatch(current, changes))
+	if err != nil {
+		return err
+	}
+
+	var result BDeploySetupRecord
+	if err := UnmarshalSetupRecord(patched, &result); err != nil {
+		return errors.NewValidationError("changes", changes, err.Error())
+	}
+	*r = result
+	return nil
+}
+
+// MergePatch merges patch into target following RFC 7386: nested objects are
+// merged, other values replace the target value and null removes it. target is
+// modified and returned; patch values are not copied.
+func MergePatch(target, patch map[string]interface{}) map[string]interface{} {
+	if target == nil {
+		target = map[string]interface{}{}
+	}
+	for name, value := range patch {
+		if value == nil {
+			delete(target, name)
+			continue
+		}
+		if nested, ok := value.(map[string]interface{}); ok {
+			existing, _ := target[name].(map[string]interface{})
+			target[name] = MergePatch(existing, nested)
+			continue
+		}
+		target[name] = value
+	}
+	return target
+}
+
+// CloneJSON deep-copies a generic JSON value as produced by encoding/json.
+func CloneJSON(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		c := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			c[key] = CloneJSON(item)
+		}
+		return c
+	case []interface{}:
+		c := make([]interface{}, len(v))
+		for i, item := range v {
+			c[i] = CloneJSON(item)
+		}
+		return c
+	default:
+		return v
+	}
+}
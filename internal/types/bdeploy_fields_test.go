@@ -0,0 +1,140 @@
+package types
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSetupRecordJSON_ExplicitZeroValues(t *testing.T) {
+	var record BDeploySetupRecord
+	input := `{"_id":"abc","setupType":"bsn","dwsEnabled":false,"remoteDwsEnabled":true,"uploadLogFilesTime":0,"bDeploy":{"username":"u","networkName":"n","packageName":"p"}}`
+	if err := UnmarshalSetupRecord([]byte(input), &record); err != nil {
+		t.Fatalf("UnmarshalSetupRecord failed: %v", err)
+	}
+
+	expected := []string{"dwsEnabled", "uploadLogFilesTime"}
+	if !reflect.DeepEqual(record.ForceSendFields, expected) {
+		t.Errorf("Expected explicit zero fields %v, got %v", expected, record.ForceSendFields)
+	}
+	if !record.IsSet("dwsEnabled") || record.IsSet("lwsEnabled") || !record.IsSet("remoteDwsEnabled") {
+		t.Error("Expected IsSet to tell explicit false apart from unset")
+	}
+
+	data, err := MarshalSetupRecord(&record)
+	if err != nil {
+		t.Fatalf("MarshalSetupRecord failed: %v", err)
+	}
+	output := string(data)
+	for _, field := range []string{`"dwsEnabled":false`, `"uploadLogFilesTime":0`, `"_id":"abc"`} {
+		if !strings.Contains(output, field) {
+			t.Errorf("Expected %s in %s", field, output)
+		}
+	}
+	if strings.Contains(output, "lwsEnabled") || strings.Contains(output, "ForceSendFields") {
+		t.Errorf("Expected unset fields to be omitted, got %s", output)
+	}
+	if strings.Index(output, `"_id"`) > strings.Index(output, `"dwsEnabled"`) {
+		t.Errorf("Expected fields in declaration order, got %s", output)
+	}
+}
+
+func TestSetupRecordJSON_Unchanged(t *testing.T) {
+	record := BDeploySetupRecord{SetupType: "bsn", DWSEnabled: true}
+	data, err := MarshalSetupRecord(&record)
+	if err != nil {
+		t.Fatalf("MarshalSetupRecord failed: %v", err)
+	}
+	plain, _ := json.Marshal(record)
+	if string(data) != string(plain) {
+		t.Errorf("Expected records without forced fields to encode as before:\n got  %s\n want %s", data, plain)
+	}
+
+	record.ForceSend("useDHCP", "useDHCP")
+	if len(record.ForceSendFields) != 1 {
+		t.Errorf("Expected ForceSend not to duplicate fields, got %v", record.ForceSendFields)
+	}
+	data, _ = MarshalSetupRecord(&record)
+	if !strings.Contains(string(data), `"useDHCP":false`) {
+		t.Errorf("Expected useDHCP to be sent, got %s", data)
+	}
+}
+
+func TestSetupRecordJSON_Embedded(t *testing.T) {
+	// The record has no JSON methods that a struct embedding it would inherit
+	var config struct {
+		BDeploySetupRecord
+		Timeout int `json:"timeout"`
+	}
+	if err := json.Unmarshal([]byte(`{"setupType":"bsn","timeout":60}`), &config); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if config.SetupType != "bsn" || config.Timeout != 60 {
+		t.Errorf("Expected the embedding struct to keep its own fields, got %+v", config)
+	}
+
+	wrapped := struct {
+		Result SetupRecordJSON `json:"result"`
+	}{}
+	if err := json.Unmarshal([]byte(`{"result":{"setupType":"bsn","dwsEnabled":false}}`), &wrapped); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if wrapped.Result.Record == nil || !wrapped.Result.Record.IsForceSent("dwsEnabled") {
+		t.Errorf("Expected SetupRecordJSON to keep explicit values, got %+v", wrapped.Result.Record)
+	}
+	data, _ := json.Marshal(wrapped)
+	if !strings.Contains(string(data), `"dwsEnabled":false`) {
+		t.Errorf("Expected SetupRecordJSON to send explicit values, got %s", data)
+	}
+}
+
+func TestApplyPatch(t *testing.T) {
+	record := validSetupRecord()
+	record.ID = "abc"
+	record.DWSEnabled = true
+	record.TimeZone = "America/New_York"
+
+	err := record.ApplyPatch(map[string]interface{}{
+		"dwsEnabled": false,
+		"bDeploy":    map[string]interface{}{"packageName": "lobby-v2"},
+		"timeZone":   nil,
+		"proxyPort":  8080,
+	})
+	if err != nil {
+		t.Fatalf("ApplyPatch failed: %v", err)
+	}
+
+	if record.DWSEnabled || !record.IsForceSent("dwsEnabled") {
+		t.Error("Expected dwsEnabled to be explicitly false")
+	}
+	if record.BDeploy.PackageName != "lobby-v2" || record.BDeploy.Username != "admin@example.com" {
+		t.Errorf("Expected bDeploy to be merged, got %+v", record.BDeploy)
+	}
+	if record.TimeZone != "" || record.IsSet("timeZone") {
+		t.Error("Expected nil to reset timeZone")
+	}
+	if record.ProxyPort != 8080 || record.ID != "abc" || !record.UseDHCP {
+		t.Errorf("Expected other fields to be kept, got %+v", record)
+	}
+
+	if err := record.ApplyPatch(map[string]interface{}{"dwsEnabeld": false}); err == nil {
+		t.Error("Expected error for unknown field")
+	}
+	if err := record.ApplyPatch(map[string]interface{}{"proxyPort": "eighty"}); err == nil {
+		t.Error("Expected error for a value of the wrong type")
+	}
+}
+
+func TestLint_ExplicitDHCPOff(t *testing.T) {
+	record := validSetupRecord()
+	record.UseDHCP = false
+	if issues := record.Lint(); len(issues) != 0 {
+		t.Errorf("Expected unset useDHCP to be treated as DHCP, got %v", issues)
+	}
+
+	record.ForceSend("useDHCP")
+	if !issueFields(record.Lint(), SetupIssueError)["staticIPAddress"] {
+		t.Error("Expected staticIPAddress to be required when useDHCP is explicitly false")
+	}
+}
@@ -0,0 +1,129 @@
+package types
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/brightdevelopers/gopurple/internal/errors"
+)
+
+// RedactedSecret replaces a password or token in redacted output.
+const RedactedSecret = "********"
+
+// SecretSchemes are the prefixes that mark a password or token field as a
+// reference to a secret kept elsewhere rather than the secret itself:
+//
+//	env:WIFI_PASSPHRASE           environment variable
+//	file:/run/secrets/lws         file contents, without the trailing newline
+//	exec:pass show retail/wifi    standard output of a command
+//	age:secrets/wifi.age          age-encrypted file
+//	sops:secrets.yaml#wifi.psk    value from a sops-encrypted YAML or JSON file
+//
+// References are resolved when the record is sent, so record files and
+// templates can be kept in version control without the secrets.
+var SecretSchemes = []string{"env:", "file:", "exec:", "age:", "sops:"}
+
+// IsSecretReference reports whether value is a secret reference, such as
+// "env:WIFI_PASSPHRASE", rather than a literal secret.
+func IsSecretReference(value string) bool {
+	for _, scheme := range SecretSchemes {
+		if strings.HasPrefix(value, scheme) && len(value) > len(scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// secretValues returns pointers to the record's password and token fields, keyed
+// by their dotted JSON path (see IsSecretSetupField).
+func (r *BDeploySetupRecord) secretValues() map[string]*string {
+	values := map[string]*string{
+		"dwsPassword":       &r.DWSPassword,
+		"lwsPassword":       &r.LWSPassword,
+		"passphrase":        &r.Passphrase,
+		"sfnPassword":       &r.SFNPassword,
+		"usbUpdatePassword": &r.USBUpdatePassword,
+	}
+	if r.BSNDeviceRegistrationTokenEntity != nil {
+		values["bsnDeviceRegistrationTokenEntity.token"] = &r.BSNDeviceRegistrationTokenEntity.Token
+	}
+	return values
+}
+
+// copyForSecrets returns a copy of the record that can have its secrets changed
+// without changing r.
+func (r *BDeploySetupRecord) copyForSecrets() *BDeploySetupRecord {
+	copied := *r
+	if r.BSNDeviceRegistrationTokenEntity != nil {
+		entity := *r.BSNDeviceRegistrationTokenEntity
+		copied.BSNDeviceRegistrationTokenEntity = &entity
+	}
+	return &copied
+}
+
+// SecretReferences returns the dotted JSON paths of the fields that hold secret
+// references, sorted.
+func (r *BDeploySetupRecord) SecretReferences() []string {
+	var paths []string
+	for path, value := range r.secretValues() {
+		if IsSecretReference(*value) {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// unresolvedSecretMessage explains why a secret reference cannot be sent as is.
+const unresolvedSecretMessage = "is a secret reference, but secret references are not resolved; enable them with WithSecretReferences(true) or configure a secret resolver, since B-Deploy would store the reference itself as the secret"
+
+// NewUnresolvedSecretError returns the error for a secret reference in field that
+// would be sent without being resolved.
+func NewUnresolvedSecretError(field string, value string) error {
+	return errors.NewValidationError(field, value, unresolvedSecretMessage)
+}
+
+// CheckSecretReferences returns an error for the first field, by path, that holds
+// a secret reference. It is for records sent without a resolver.
+func (r *BDeploySetupRecord) CheckSecretReferences() error {
+	paths := r.SecretReferences()
+	if len(paths) == 0 {
+		return nil
+	}
+	return NewUnresolvedSecretError(paths[0], *r.secretValues()[paths[0]])
+}
+
+// ResolveSecrets returns a copy of the record with every secret reference
+// replaced by the value resolve returns for it. The record itself is not changed,
+// and is returned as is when it has no references.
+func (r *BDeploySetupRecord) ResolveSecrets(ctx context.Context, resolve func(ctx context.Context, ref string) (string, error)) (*BDeploySetupRecord, error) {
+	paths := r.SecretReferences()
+	if len(paths) == 0 {
+		return r, nil
+	}
+
+	resolved := r.copyForSecrets()
+	values := resolved.secretValues()
+	for _, path := range paths {
+		value, err := resolve(ctx, *values[path])
+		if err != nil {
+			return nil, errors.NewValidationError(path, *values[path], err.Error())
+		}
+		*values[path] = value
+	}
+	return resolved, nil
+}
+
+// Redacted returns a copy of the record with its passwords and tokens replaced by
+// RedactedSecret, for display and logs. Secret references are kept, since they
+// say where a secret comes from without revealing it.
+func (r *BDeploySetupRecord) Redacted() *BDeploySetupRecord {
+	redacted := r.copyForSecrets()
+	for _, value := range redacted.secretValues() {
+		if *value != "" && !IsSecretReference(*value) {
+			*value = RedactedSecret
+		}
+	}
+	return redacted
+}
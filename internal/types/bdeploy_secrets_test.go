@@ -0,0 +1,89 @@
+package types
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func secretSetupRecord() *BDeploySetupRecord {
+	record := validSetupRecord()
+	record.UseWireless = true
+	record.SSID = "store-wifi"
+	record.Passphrase = "env:WIFI"
+	record.LWSPassword = "literal-secret"
+	record.DWSPassword = "file:dws.txt"
+	record.BSNDeviceRegistrationTokenEntity = &BSNTokenEntity{Token: "token-value", Scope: "cert"}
+	return record
+}
+
+func TestIsSecretReference(t *testing.T) {
+	for value, expected := range map[string]bool{
+		"env:WIFI":              true,
+		"sops:secrets.yaml#psk": true,
+		"exec:pass show wifi":   true,
+		"env:":                  false,
+		"plain-password":        false,
+		"Env:WIFI":              false,
+		"":                      false,
+	} {
+		if got := IsSecretReference(value); got != expected {
+			t.Errorf("IsSecretReference(%q) = %v, expected %v", value, got, expected)
+		}
+	}
+}
+
+func TestResolveSecrets(t *testing.T) {
+	record := secretSetupRecord()
+	if refs := record.SecretReferences(); len(refs) != 2 || refs[0] != "dwsPassword" || refs[1] != "passphrase" {
+		t.Fatalf("Expected dwsPassword and passphrase to be references, got %v", refs)
+	}
+
+	resolved, err := record.ResolveSecrets(context.Background(), func(ctx context.Context, ref string) (string, error) {
+		return "resolved(" + ref + ")", nil
+	})
+	if err != nil {
+		t.Fatalf("ResolveSecrets failed: %v", err)
+	}
+	if resolved.Passphrase != "resolved(env:WIFI)" || resolved.DWSPassword != "resolved(file:dws.txt)" || resolved.LWSPassword != "literal-secret" {
+		t.Errorf("Unexpected resolved record %+v", resolved)
+	}
+	if record.Passphrase != "env:WIFI" {
+		t.Errorf("Expected the original record to keep its references, got %q", record.Passphrase)
+	}
+
+	_, err = record.ResolveSecrets(context.Background(), func(ctx context.Context, ref string) (string, error) {
+		return "", fmt.Errorf("not available")
+	})
+	if err == nil {
+		t.Error("Expected an error when a reference cannot be resolved")
+	}
+
+	plain := validSetupRecord()
+	if same, _ := plain.ResolveSecrets(context.Background(), nil); same != plain {
+		t.Error("Expected a record without references to be returned as is")
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	record := secretSetupRecord()
+	redacted := record.Redacted()
+
+	if redacted.LWSPassword != RedactedSecret || redacted.BSNDeviceRegistrationTokenEntity.Token != RedactedSecret {
+		t.Errorf("Expected literal secrets to be redacted, got %+v", redacted)
+	}
+	if redacted.Passphrase != "env:WIFI" || redacted.SFNPassword != "" {
+		t.Errorf("Expected references and empty values to be kept, got %+v", redacted)
+	}
+	if record.LWSPassword != "literal-secret" || record.BSNDeviceRegistrationTokenEntity.Token != "token-value" {
+		t.Error("Expected the original record to be unchanged")
+	}
+}
+
+func TestLint_SecretReferencePassphrase(t *testing.T) {
+	record := secretSetupRecord()
+	record.LWSEnabled = true
+	if errs := issueFields(record.Lint(), SetupIssueError); errs["passphrase"] {
+		t.Errorf("Expected a passphrase reference not to be length checked, got %v", errs)
+	}
+}
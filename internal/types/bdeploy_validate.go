@@ -0,0 +1,424 @@
+package types
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// SetupIssueSeverity distinguishes problems the API will reject or misapply from
+// settings that are merely ignored.
+type SetupIssueSeverity string
+
+const (
+	SetupIssueError   SetupIssueSeverity = "error"   // The record is inconsistent and should not be saved
+	SetupIssueWarning SetupIssueSeverity = "warning" // The setting has no effect as configured
+)
+
+// SetupIssue is a single problem found in a setup record.
+type SetupIssue struct {
+	Field    string             `json:"field"` // JSON path of the field, e.g. "network.interfaces[0].proto"
+	Severity SetupIssueSeverity `json:"severity"`
+	Message  string             `json:"message"`
+}
+
+func (i SetupIssue) String() string {
+	return fmt.Sprintf("%s: %s: %s", i.Severity, i.Field, i.Message)
+}
+
+// SetupValidationError is returned by BDeploySetupRecord.Validate and lists every
+// error-level issue in the record.
+type SetupValidationError struct {
+	Issues []SetupIssue
+}
+
+func (e *SetupValidationError) Error() string {
+	if len(e.Issues) == 1 {
+		return fmt.Sprintf("invalid setup record: %s: %s", e.Issues[0].Field, e.Issues[0].Message)
+	}
+	parts := make([]string, len(e.Issues))
+	for i, issue := range e.Issues {
+		parts[i] = issue.Field + ": " + issue.Message
+	}
+	return fmt.Sprintf("invalid setup record (%d problems): %s", len(e.Issues), strings.Join(parts, "; "))
+}
+
+// Allowed values for enumerated setup record fields.
+var (
+	setupTypes           = []string{"bsn", "lfn", "standalone", "sfn", "partnerApplication"}
+	setupVersions        = []string{"2.0.0", "3.0.0"}
+	firmwareUpdateTypes  = []string{"standard", "latest", "specific"}
+	lwsConfigs           = []string{"status", "content", "diagnostic"}
+	orientations         = []string{"Landscape", "Portrait"}
+	rateLimitModes       = []string{"default", "unlimited", "limited"}
+	connectionPriorities = []string{"wired", "wireless"}
+	interfaceProtos      = []string{"DHCPv4", "DHCPv6", "Static"}
+)
+
+const minutesPerDay = 24 * 60
+
+// setupLinter accumulates issues while a record is checked.
+type setupLinter struct {
+	issues []SetupIssue
+}
+
+func (l *setupLinter) errorf(field, format string, args ...interface{}) {
+	l.issues = append(l.issues, SetupIssue{Field: field, Severity: SetupIssueError, Message: fmt.Sprintf(format, args...)})
+}
+
+func (l *setupLinter) warnf(field, format string, args ...interface{}) {
+	l.issues = append(l.issues, SetupIssue{Field: field, Severity: SetupIssueWarning, Message: fmt.Sprintf(format, args...)})
+}
+
+func (l *setupLinter) required(field, value string) {
+	if strings.TrimSpace(value) == "" {
+		l.errorf(field, "is required")
+	}
+}
+
+func (l *setupLinter) oneOf(field, value string, allowed []string) {
+	if value == "" {
+		return
+	}
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+	l.errorf(field, "%q is not one of %s", value, strings.Join(allowed, ", "))
+}
+
+func (l *setupLinter) inRange(field string, value, min, max int) {
+	if value < min || value > max {
+		l.errorf(field, "%d is outside the range %d-%d", value, min, max)
+	}
+}
+
+// optionalRange checks a range for fields where zero means "not set".
+func (l *setupLinter) optionalRange(field string, value, min, max int) {
+	if value != 0 {
+		l.inRange(field, value, min, max)
+	}
+}
+
+func (l *setupLinter) ipv4(field, value string) {
+	if value == "" {
+		return
+	}
+	if ip := net.ParseIP(value); ip == nil || ip.To4() == nil {
+		l.errorf(field, "%q is not an IPv4 address", value)
+	}
+}
+
+func (l *setupLinter) url(field, value string, schemes ...string) {
+	if value == "" {
+		return
+	}
+	u, err := url.Parse(value)
+	if err != nil || u.Host == "" {
+		l.errorf(field, "%q is not an absolute URL", value)
+		return
+	}
+	for _, scheme := range schemes {
+		if u.Scheme == scheme {
+			return
+		}
+	}
+	l.errorf(field, "URL scheme %q is not one of %s", u.Scheme, strings.Join(schemes, ", "))
+}
+
+// ignored warns about fields that are set but have no effect.
+func (l *setupLinter) ignored(reason string, fields map[string]bool) {
+	for _, field := range sortedSetFields(fields) {
+		l.warnf(field, "is ignored %s", reason)
+	}
+}
+
+// sortedSetFields returns the names of the fields that are set, in a stable order.
+func sortedSetFields(fields map[string]bool) []string {
+	var names []string
+	for name, set := range fields {
+		if set {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Lint checks the record for missing required fields, out-of-range values and
+// settings that contradict each other, and returns every issue found. Warnings
+// describe fields that are set but have no effect; errors describe records the
+// player would not apply as intended.
+//
+// A record that leaves useDHCP unset and has no static address fields is treated
+// as using DHCP; one that explicitly sets it to false (see ForceSend) must supply
+// a static address.
+func (r *BDeploySetupRecord) Lint() []SetupIssue {
+	l := &setupLinter{}
+
+	// Required fields
+	l.required("bDeploy.username", r.BDeploy.Username)
+	l.required("bDeploy.networkName", r.BDeploy.NetworkName)
+	l.required("bDeploy.packageName", r.BDeploy.PackageName)
+	l.required("setupType", r.SetupType)
+
+	// Enumerations
+	l.oneOf("setupType", r.SetupType, setupTypes)
+	l.oneOf("version", r.Version, setupVersions)
+	l.oneOf("firmwareUpdateType", r.FirmwareUpdateType, firmwareUpdateTypes)
+	l.oneOf("lwsConfig", r.LWSConfig, lwsConfigs)
+	l.oneOf("remoteSnapshotScreenOrientation", r.RemoteSnapshotScreenOrientation, orientations)
+	l.oneOf("deviceScreenShotsOrientation", r.DeviceScreenShotsOrientation, orientations)
+	l.oneOf("networkConnectionPriority", r.NetworkConnectionPriority, connectionPriorities)
+
+	r.lintNetwork(l)
+	r.lintWireless(l)
+	r.lintStaticIP(l, "", r.UseDHCP, r.StaticIPAddress, r.SubnetMask, r.Gateway, r.DNS1, r.DNS2, r.DNS3)
+	if r.UseWireless {
+		r.lintStaticIP(l, "_2", r.UseDHCP2, r.StaticIPAddress2, r.SubnetMask2, r.Gateway2, r.DNS1_2, r.DNS2_2, r.DNS3_2)
+	}
+	r.lintRateLimits(l)
+	r.lintWindows(l)
+	r.lintReporting(l)
+	r.lintServices(l)
+
+	if c := r.IdleScreenColor; c != nil {
+		l.inRange("idleScreenColor.r", c.R, 0, 255)
+		l.inRange("idleScreenColor.g", c.G, 0, 255)
+		l.inRange("idleScreenColor.b", c.B, 0, 255)
+		l.inRange("idleScreenColor.a", c.A, 0, 1)
+	}
+
+	return l.issues
+}
+
+// Validate returns a *SetupValidationError listing every error-level issue reported
+// by Lint, or nil if there are none. Warnings do not cause validation to fail.
+func (r *BDeploySetupRecord) Validate() error {
+	var errs []SetupIssue
+	for _, issue := range r.Lint() {
+		if issue.Severity == SetupIssueError {
+			errs = append(errs, issue)
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return &SetupValidationError{Issues: errs}
+}
+
+func (r *BDeploySetupRecord) lintNetwork(l *setupLinter) {
+	if r.Network == nil {
+		return
+	}
+	for i, iface := range r.Network.Interfaces {
+		field := fmt.Sprintf("network.interfaces[%d]", i)
+		if iface.Proto == "" {
+			l.errorf(field+".proto", "is required")
+		}
+		l.oneOf(field+".proto", iface.Proto, interfaceProtos)
+	}
+}
+
+func (r *BDeploySetupRecord) lintWireless(l *setupLinter) {
+	if !r.UseWireless {
+		l.ignored("unless useWireless is set", map[string]bool{
+			"ssid":       r.SSID != "",
+			"passphrase": r.Passphrase != "",
+		})
+		if r.NetworkConnectionPriority == "wireless" {
+			l.errorf("networkConnectionPriority", "is wireless but useWireless is not set")
+		}
+		return
+	}
+
+	l.required("ssid", r.SSID)
+	if r.Passphrase != "" && !IsSecretReference(r.Passphrase) && (len(r.Passphrase) < 8 || len(r.Passphrase) > 63) {
+		l.errorf("passphrase", "must be 8-63 characters for WPA, got %d", len(r.Passphrase))
+	}
+}
+
+// lintStaticIP checks the static address settings of one interface; suffix is ""
+// for the wired interface and "_2" for the wireless one.
+func (r *BDeploySetupRecord) lintStaticIP(l *setupLinter, suffix string, useDHCP bool, address, mask, gateway, dns1, dns2, dns3 string) {
+	fields := map[string]bool{
+		"staticIPAddress" + suffix: address != "",
+		"subnetMask" + suffix:      mask != "",
+		"gateway" + suffix:         gateway != "",
+		"dns1" + suffix:            dns1 != "",
+		"dns2" + suffix:            dns2 != "",
+		"dns3" + suffix:            dns3 != "",
+	}
+
+	if useDHCP {
+		l.ignored("while useDHCP"+suffix+" is set", fields)
+		return
+	}
+	if len(sortedSetFields(fields)) == 0 && !r.IsForceSent("useDHCP"+suffix) {
+		return
+	}
+
+	l.required("staticIPAddress"+suffix, address)
+	l.required("subnetMask"+suffix, mask)
+	l.ipv4("staticIPAddress"+suffix, address)
+	l.ipv4("gateway"+suffix, gateway)
+	l.ipv4("dns1"+suffix, dns1)
+	l.ipv4("dns2"+suffix, dns2)
+	l.ipv4("dns3"+suffix, dns3)
+
+	if mask == "" {
+		return
+	}
+	maskIP := net.ParseIP(mask).To4()
+	if maskIP == nil {
+		l.errorf("subnetMask"+suffix, "%q is not an IPv4 subnet mask", mask)
+		return
+	}
+	if ones, bits := net.IPMask(maskIP).Size(); ones == 0 && bits == 0 {
+		l.errorf("subnetMask"+suffix, "%q is not a contiguous subnet mask", mask)
+		return
+	}
+
+	ip, gw := net.ParseIP(address).To4(), net.ParseIP(gateway).To4()
+	if ip != nil && gw != nil && !ip.Mask(net.IPMask(maskIP)).Equal(gw.Mask(net.IPMask(maskIP))) {
+		l.warnf("gateway"+suffix, "%s is not on the same subnet as %s/%s", gateway, address, mask)
+	}
+}
+
+func (r *BDeploySetupRecord) lintRateLimits(l *setupLinter) {
+	limits := []struct {
+		suffix string
+		mode   string
+		rate   int
+		window string
+	}{
+		{"", r.RateLimitModeOutsideWindow, r.RateLimitRateOutsideWindow, "OutsideWindow"},
+		{"", r.RateLimitModeInWindow, r.RateLimitRateInWindow, "InWindow"},
+		{"", r.RateLimitModeInitialDownloads, r.RateLimitRateInitialDownloads, "InitialDownloads"},
+		{"_2", r.RateLimitModeOutsideWindow2, r.RateLimitRateOutsideWindow2, "OutsideWindow"},
+		{"_2", r.RateLimitModeInWindow2, r.RateLimitRateInWindow2, "InWindow"},
+		{"_2", r.RateLimitModeInitialDownloads2, r.RateLimitRateInitialDownloads2, "InitialDownloads"},
+	}
+	for _, limit := range limits {
+		modeField := "rateLimitMode" + limit.window + limit.suffix
+		rateField := "rateLimitRate" + limit.window + limit.suffix
+
+		l.oneOf(modeField, limit.mode, rateLimitModes)
+		switch {
+		case limit.rate < 0:
+			l.errorf(rateField, "cannot be negative")
+		case limit.mode == "limited" && limit.rate == 0:
+			l.errorf(rateField, "is required when %s is limited", modeField)
+		case limit.mode != "limited" && limit.rate != 0:
+			l.warnf(rateField, "is ignored unless %s is limited", modeField)
+		}
+	}
+}
+
+func (r *BDeploySetupRecord) lintWindows(l *setupLinter) {
+	windows := []struct {
+		restrictedField string
+		rangeField      string
+		restricted      bool
+		start, end      int
+	}{
+		{"contentDownloadsRestricted", "contentDownloadRange", r.ContentDownloadsRestricted, r.ContentDownloadRangeStart, r.ContentDownloadRangeEnd},
+		{"heartbeatsRestricted", "heartbeatsRange", r.HeartbeatsRestricted, r.HeartbeatsRangeStart, r.HeartbeatsRangeEnd},
+	}
+	for _, w := range windows {
+		startField, endField, restrictedField := w.rangeField+"Start", w.rangeField+"End", w.restrictedField
+
+		l.inRange(startField, w.start, 0, minutesPerDay-1)
+		l.inRange(endField, w.end, 0, minutesPerDay-1)
+
+		if !w.restricted {
+			l.ignored("unless "+restrictedField+" is set", map[string]bool{
+				startField: w.start != 0,
+				endField:   w.end != 0,
+			})
+			continue
+		}
+		if w.start == w.end {
+			l.errorf(endField, "equals %s, so the window is empty", startField)
+		}
+	}
+}
+
+func (r *BDeploySetupRecord) lintReporting(l *setupLinter) {
+	l.inRange("uploadLogFilesTime", r.UploadLogFilesTime, 0, 23)
+	if !r.UploadLogFilesAtSpecificTime && r.UploadLogFilesTime != 0 {
+		l.warnf("uploadLogFilesTime", "is ignored unless uploadLogFilesAtSpecificTime is set")
+	}
+	l.url("logHandlerUrl", r.LogHandlerURL, "http", "https")
+
+	if r.EnableRemoteSnapshot {
+		l.optionalRange("remoteSnapshotInterval", r.RemoteSnapshotInterval, 1, minutesPerDay)
+	} else {
+		l.ignored("unless enableRemoteSnapshot is set", map[string]bool{
+			"remoteSnapshotInterval":         r.RemoteSnapshotInterval != 0,
+			"remoteSnapshotMaxImages":        r.RemoteSnapshotMaxImages != 0,
+			"remoteSnapshotJpegQualityLevel": r.RemoteSnapshotJPEGQualityLevel != 0,
+			"remoteSnapshotHandlerUrl":       r.RemoteSnapshotHandlerURL != "",
+		})
+	}
+	l.optionalRange("remoteSnapshotJpegQualityLevel", r.RemoteSnapshotJPEGQualityLevel, 1, 100)
+	l.optionalRange("deviceScreenShotsQuality", r.DeviceScreenShotsQuality, 1, 100)
+	l.url("remoteSnapshotHandlerUrl", r.RemoteSnapshotHandlerURL, "http", "https")
+
+	counts := []struct {
+		field string
+		value int
+	}{
+		{"remoteSnapshotMaxImages", r.RemoteSnapshotMaxImages},
+		{"deviceScreenShotsInterval", r.DeviceScreenShotsInterval},
+		{"deviceScreenShotsCountLimit", r.DeviceScreenShotsCountLimit},
+		{"timeBetweenNetConnects", r.TimeBetweenNetConnects},
+		{"timeBetweenHeartbeats", r.TimeBetweenHeartbeats},
+	}
+	for _, count := range counts {
+		if count.value < 0 {
+			l.errorf(count.field, "cannot be negative")
+		}
+	}
+}
+
+func (r *BDeploySetupRecord) lintServices(l *setupLinter) {
+	if r.SpecifyHostname {
+		l.required("hostname", r.Hostname)
+	} else if r.Hostname != "" {
+		l.warnf("hostname", "is ignored unless specifyHostname is set")
+	}
+
+	if r.UseProxy {
+		l.required("proxyAddress", r.ProxyAddress)
+		l.inRange("proxyPort", r.ProxyPort, 1, 65535)
+	} else {
+		l.ignored("unless useProxy is set", map[string]bool{
+			"proxyAddress": r.ProxyAddress != "",
+			"proxyPort":    r.ProxyPort != 0,
+		})
+	}
+
+	if r.SetupType == "sfn" {
+		l.required("sfnWebFolderUrl", r.SFNWebFolderURL)
+	}
+	l.url("sfnWebFolderUrl", r.SFNWebFolderURL, "http", "https")
+	if r.SFNEnableBasicAuthentication {
+		l.required("sfnUserName", r.SFNUserName)
+	}
+
+	if !r.LWSEnabled {
+		l.ignored("unless lwsEnabled is set", map[string]bool{
+			"lwsConfig":   r.LWSConfig != "",
+			"lwsUserName": r.LWSUserName != "",
+			"lwsPassword": r.LWSPassword != "",
+		})
+	}
+
+	if r.BrightWallScreenNumber != "" && r.BrightWallName == "" {
+		l.errorf("BrightWallName", "is required when BrightWallScreenNumber is set")
+	}
+}
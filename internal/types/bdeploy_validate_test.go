@@ -0,0 +1,141 @@
+package types
+
+import (
+	stderrors "errors"
+	"testing"
+)
+
+func validSetupRecord() *BDeploySetupRecord {
+	return &BDeploySetupRecord{
+		Version:   "3.0.0",
+		BDeploy:   BDeployInfo{Username: "admin@example.com", NetworkName: "Lobby", PackageName: "lobby-v1"},
+		SetupType: "bsn",
+		UseDHCP:   true,
+	}
+}
+
+// issueFields returns the field paths of the issues with the given severity.
+func issueFields(issues []SetupIssue, severity SetupIssueSeverity) map[string]bool {
+	fields := map[string]bool{}
+	for _, issue := range issues {
+		if issue.Severity == severity {
+			fields[issue.Field] = true
+		}
+	}
+	return fields
+}
+
+func TestLint_Valid(t *testing.T) {
+	if issues := validSetupRecord().Lint(); len(issues) != 0 {
+		t.Errorf("Expected no issues, got %v", issues)
+	}
+	if err := validSetupRecord().Validate(); err != nil {
+		t.Errorf("Expected valid record, got %v", err)
+	}
+}
+
+func TestLint_ReportsEveryError(t *testing.T) {
+	record := &BDeploySetupRecord{
+		SetupType:                      "cloud",
+		RemoteSnapshotJPEGQualityLevel: 150,
+		EnableRemoteSnapshot:           true,
+		ContentDownloadsRestricted:     true,
+		ContentDownloadRangeStart:      60,
+		ContentDownloadRangeEnd:        1500,
+		IdleScreenColor:                &IdleScreenColor{R: 300, A: 2},
+		Network:                        &NetworkConfig{Interfaces: []NetworkInterface{{Name: "eth0", Proto: "DHCPv4"}, {Name: "wlan0", Proto: "dhcp"}}},
+		RateLimitModeInWindow:          "limited",
+	}
+
+	errs := issueFields(record.Lint(), SetupIssueError)
+	for _, field := range []string{
+		"bDeploy.username", "bDeploy.networkName", "bDeploy.packageName", "setupType",
+		"remoteSnapshotJpegQualityLevel", "contentDownloadRangeEnd",
+		"idleScreenColor.r", "idleScreenColor.a",
+		"network.interfaces[1].proto", "rateLimitRateInWindow",
+	} {
+		if !errs[field] {
+			t.Errorf("Expected an error for %s, got %v", field, errs)
+		}
+	}
+	if errs["network.interfaces[0].proto"] {
+		t.Error("Expected DHCPv4 to be accepted")
+	}
+
+	var validationErr *SetupValidationError
+	if err := record.Validate(); !stderrors.As(err, &validationErr) || len(validationErr.Issues) != len(errs) {
+		t.Errorf("Expected Validate to return all %d errors, got %v", len(errs), err)
+	}
+}
+
+func TestLint_StaticIP(t *testing.T) {
+	record := validSetupRecord()
+	record.UseDHCP = false
+	record.StaticIPAddress = "192.168.1.20"
+	record.Gateway = "10.0.0.1"
+	record.DNS1 = "not-an-ip"
+
+	issues := record.Lint()
+	errs := issueFields(issues, SetupIssueError)
+	if !errs["subnetMask"] || !errs["dns1"] {
+		t.Errorf("Expected errors for subnetMask and dns1, got %v", issues)
+	}
+
+	record.SubnetMask = "255.255.255.0"
+	record.DNS1 = "8.8.8.8"
+	issues = record.Lint()
+	if len(issueFields(issues, SetupIssueError)) != 0 {
+		t.Errorf("Expected no errors once the mask is set, got %v", issues)
+	}
+	if !issueFields(issues, SetupIssueWarning)["gateway"] {
+		t.Errorf("Expected a warning for a gateway outside the subnet, got %v", issues)
+	}
+
+	record.SubnetMask = "255.0.255.0"
+	if !issueFields(record.Lint(), SetupIssueError)["subnetMask"] {
+		t.Error("Expected an error for a non-contiguous subnet mask")
+	}
+
+	// Static settings are ignored, not wrong, while DHCP is on
+	record.UseDHCP = true
+	issues = record.Lint()
+	if len(issueFields(issues, SetupIssueError)) != 0 || !issueFields(issues, SetupIssueWarning)["staticIPAddress"] {
+		t.Errorf("Expected only ignored-field warnings with DHCP enabled, got %v", issues)
+	}
+}
+
+func TestLint_Coupling(t *testing.T) {
+	record := validSetupRecord()
+	record.SSID = "Lobby"
+	record.NetworkConnectionPriority = "wireless"
+	record.UseProxy = true
+	record.SpecifyHostname = true
+	record.UploadLogFilesTime = 3
+
+	issues := record.Lint()
+	errs := issueFields(issues, SetupIssueError)
+	warnings := issueFields(issues, SetupIssueWarning)
+	if !warnings["ssid"] || !warnings["uploadLogFilesTime"] {
+		t.Errorf("Expected warnings for ssid and uploadLogFilesTime, got %v", issues)
+	}
+	for _, field := range []string{"networkConnectionPriority", "proxyAddress", "proxyPort", "hostname"} {
+		if !errs[field] {
+			t.Errorf("Expected an error for %s, got %v", field, issues)
+		}
+	}
+
+	record.UseWireless = true
+	record.SSID = ""
+	record.Passphrase = "short"
+	errs = issueFields(record.Lint(), SetupIssueError)
+	if !errs["ssid"] || !errs["passphrase"] || errs["networkConnectionPriority"] {
+		t.Errorf("Expected ssid and passphrase errors once wireless is enabled, got %v", errs)
+	}
+}
+
+func TestSetupValidationError(t *testing.T) {
+	err := &SetupValidationError{Issues: []SetupIssue{{Field: "ssid", Severity: SetupIssueError, Message: "is required"}}}
+	if err.Error() != "invalid setup record: ssid: is required" {
+		t.Errorf("Unexpected error message %q", err.Error())
+	}
+}
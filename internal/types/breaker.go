@@ -0,0 +1,23 @@
+package types
+
+import "time"
+
+// BreakerState is the state of a player's circuit breaker.
+type BreakerState string
+
+const (
+	// BreakerClosed lets rDWS calls through; the player is taken to be reachable.
+	BreakerClosed BreakerState = "closed"
+	// BreakerOpen fails rDWS calls at once; the player was unreachable.
+	BreakerOpen BreakerState = "open"
+	// BreakerHalfOpen lets one trial call through after the cooldown.
+	BreakerHalfOpen BreakerState = "half-open"
+)
+
+// PlayerBreakerEvent reports that a player's circuit breaker changed state.
+type PlayerBreakerEvent struct {
+	Serial   string
+	State    BreakerState
+	Failures int       // Consecutive unreachable results
+	Until    time.Time // When an open breaker half-opens (zero otherwise)
+}
@@ -0,0 +1,32 @@
+package types
+
+import "net/http"
+
+// Doer sends an HTTP request, like http.RoundTripper. The SDK sends every request
+// attempt through a chain of Doers.
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// DoerFunc adapts a function to a Doer.
+type DoerFunc func(req *http.Request) (*http.Response, error)
+
+// Do calls f(req).
+func (f DoerFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Middleware wraps the Doer that sends a request, to change the request, the
+// response, or whether the request is sent at all.
+type Middleware func(next Doer) Doer
+
+// RecorderMode selects whether the HTTP recorder captures exchanges to a cassette
+// or serves them back from one.
+type RecorderMode string
+
+const (
+	// RecorderRecord sends requests and writes every exchange to the cassette.
+	RecorderRecord RecorderMode = "record"
+	// RecorderReplay answers requests from the cassette without sending them.
+	RecorderReplay RecorderMode = "replay"
+)
@@ -0,0 +1,10 @@
+package types
+
+import "time"
+
+// TokenRefresh describes a newly obtained access token, for the OnTokenRefreshed hook.
+type TokenRefresh struct {
+	NetworkName string    // Network the new session selected again, if any
+	ExpiresAt   time.Time // When the new token expires
+	Background  bool      // Obtained by the background refresher rather than on demand
+}
@@ -579,6 +579,10 @@ type BDeploySetupRecord struct {
 
 	// USB updates
 	USBUpdatePassword string `json:"usbUpdatePassword,omitempty"` // Password for USB updates
+
+	// ForceSendFields lists JSON field names sent even when they hold their zero
+	// value, so false and 0 can be told apart from unset (see ForceSend)
+	ForceSendFields []string `json:"-"`
 }
 
 // BDeployCreateResponse represents the response from creating a B-Deploy record.
@@ -667,6 +671,7 @@ type BDeployDeviceRequest struct {
 	Model       string `json:"model,omitempty"`     // Device model
 	Desc        string `json:"desc,omitempty"`      // Device description
 	SetupID     string `json:"setupId,omitempty"`   // Setup ID to associate with
+	Dissociate  bool   `json:"-"`                   // UpdateDevice: send a null setupId to clear the association
 	URL         string `json:"url,omitempty"`       // Direct presentation URL (alternative to setupId)
 	UserData    string `json:"userdata,omitempty"`  // Additional header information
 }